@@ -0,0 +1,21 @@
+-- Exportação de dados pessoais (LGPD/GDPR)
+CREATE TABLE IF NOT EXISTS data_export_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'expired')),
+    file_path TEXT,
+    download_token VARCHAR(64),
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+-- Índices para data_export_jobs
+CREATE INDEX IF NOT EXISTS idx_data_export_jobs_user_id ON data_export_jobs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_data_export_jobs_status ON data_export_jobs(status);
+
+CREATE TRIGGER update_data_export_jobs_updated_at BEFORE UPDATE ON data_export_jobs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,40 @@
+-- Exclusão de conta orquestrada entre serviços (saga)
+-- Sem FK para users: o pedido precisa sobreviver à exclusão definitiva do usuário
+CREATE TABLE IF NOT EXISTS account_deletion_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled'
+        CHECK (status IN ('scheduled', 'cancelled', 'in_progress', 'completed')),
+    participants TEXT[] NOT NULL,
+    requested_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    scheduled_for TIMESTAMP NOT NULL,
+    cancelled_at TIMESTAMP,
+    started_at TIMESTAMP,
+    last_published_at TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+-- Apenas um pedido ativo por usuário
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_deletion_requests_active
+    ON account_deletion_requests(user_id) WHERE status IN ('scheduled', 'in_progress');
+CREATE INDEX IF NOT EXISTS idx_account_deletion_requests_due
+    ON account_deletion_requests(status, scheduled_for);
+
+-- Confirmações de cada serviço participante
+CREATE TABLE IF NOT EXISTS account_deletion_acks (
+    request_id UUID NOT NULL REFERENCES account_deletion_requests(id) ON DELETE CASCADE,
+    service VARCHAR(100) NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    acknowledged_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (request_id, service)
+);
+
+-- Registro mínimo mantido para auditoria após a exclusão definitiva
+CREATE TABLE IF NOT EXISTS user_tombstones (
+    user_id UUID PRIMARY KEY,
+    deletion_request_id UUID NOT NULL REFERENCES account_deletion_requests(id),
+    email_hash VARCHAR(64) NOT NULL,
+    requested_at TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    acknowledgements JSONB NOT NULL DEFAULT '[]'
+);
@@ -0,0 +1,16 @@
+-- Verificação de email
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+-- Tokens de verificação: apenas o hash SHA-256 é armazenado
+-- O email é gravado junto para que um token antigo não verifique um email trocado depois
+CREATE TABLE IF NOT EXISTS email_verifications (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Índices para email_verifications
+CREATE INDEX IF NOT EXISTS idx_email_verifications_user_id ON email_verifications(user_id);
@@ -0,0 +1,59 @@
+-- Suporte a múltiplas moedas
+
+-- Moeda ISO-4217 de cada transação (transações existentes ficam em BRL)
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'BRL'
+    CHECK (currency ~ '^[A-Z]{3}$');
+
+-- Preferências do usuário mantidas pelo transaction-service
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    base_currency CHAR(3) NOT NULL DEFAULT 'BRL' CHECK (base_currency ~ '^[A-Z]{3}$'),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_user_settings_updated_at BEFORE UPDATE ON user_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Cotações diárias: 1 unidade de currency vale rate unidades de quote_currency
+-- Importadas de arquivos locais (ex.: PTAX do Banco Central, cotadas em BRL)
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL DEFAULT 'BRL',
+    rate_date DATE NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    source VARCHAR(50) NOT NULL,
+    imported_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (currency, quote_currency, rate_date)
+);
+
+-- Cotação direta do par na data (última disponível até ela)
+CREATE OR REPLACE FUNCTION fx_pair_rate(from_currency CHAR(3), to_currency CHAR(3), on_date DATE)
+RETURNS NUMERIC AS $$
+    SELECT rate FROM exchange_rates
+    WHERE currency = from_currency AND quote_currency = to_currency AND rate_date <= on_date
+    ORDER BY rate_date DESC
+    LIMIT 1
+$$ LANGUAGE SQL STABLE;
+
+-- Cotação de uma moeda em BRL na data, usando o último dia útil disponível
+CREATE OR REPLACE FUNCTION fx_rate_to_brl(from_currency CHAR(3), on_date DATE)
+RETURNS NUMERIC AS $$
+    SELECT CASE WHEN from_currency = 'BRL' THEN 1::NUMERIC
+        ELSE fx_pair_rate(from_currency, 'BRL', on_date)
+    END
+$$ LANGUAGE SQL STABLE;
+
+-- Fator de conversão entre duas moedas na data: par direto, par inverso
+-- ou cruzamento via BRL; NULL se faltar cotação
+CREATE OR REPLACE FUNCTION fx_rate(from_currency CHAR(3), to_currency CHAR(3), on_date DATE)
+RETURNS NUMERIC AS $$
+    SELECT CASE WHEN from_currency = to_currency THEN 1::NUMERIC ELSE COALESCE(
+        fx_pair_rate(from_currency, to_currency, on_date),
+        1 / fx_pair_rate(to_currency, from_currency, on_date),
+        fx_rate_to_brl(from_currency, on_date) / NULLIF(fx_rate_to_brl(to_currency, on_date), 0)
+    ) END
+$$ LANGUAGE SQL STABLE;
+
+-- Índices para transactions
+CREATE INDEX IF NOT EXISTS idx_transactions_user_currency ON transactions(user_id, currency);
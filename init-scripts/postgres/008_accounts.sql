@@ -0,0 +1,44 @@
+-- Contas financeiras (carteiras): onde o dinheiro de cada transação está
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('checking', 'savings', 'credit_card', 'cash', 'investment')),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    opening_balance DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_user_id ON accounts(user_id);
+
+CREATE TRIGGER update_accounts_updated_at BEFORE UPDATE ON accounts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS account_id UUID REFERENCES accounts(id) ON DELETE RESTRICT;
+
+-- Transações existentes vão para uma conta "Carteira" por usuário e moeda
+INSERT INTO accounts (user_id, name, type, currency)
+SELECT DISTINCT
+    user_id,
+    CASE WHEN currency = 'BRL' THEN 'Carteira' ELSE 'Carteira ' || currency END,
+    'cash',
+    currency
+FROM transactions
+WHERE account_id IS NULL
+ON CONFLICT (user_id, name) DO NOTHING;
+
+UPDATE transactions t
+SET account_id = a.id
+FROM accounts a
+WHERE t.account_id IS NULL
+    AND a.user_id = t.user_id
+    AND a.currency = t.currency
+    AND a.name = CASE WHEN t.currency = 'BRL' THEN 'Carteira' ELSE 'Carteira ' || t.currency END;
+
+ALTER TABLE transactions ALTER COLUMN account_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_account_date ON transactions(account_id, date);
@@ -0,0 +1,33 @@
+-- Transferências entre contas: duas pernas ligadas que não contam como receita ou despesa
+
+CREATE TABLE IF NOT EXISTS transfers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE RESTRICT,
+    to_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE RESTRICT,
+    description TEXT NOT NULL,
+    date TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (from_account_id <> to_account_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfers_user_id ON transfers(user_id);
+
+CREATE TRIGGER update_transfers_updated_at BEFORE UPDATE ON transfers
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Pernas: type = 'transfer', saída (out) debita a conta de origem e entrada (in) credita a de destino
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('income', 'expense', 'transfer'));
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS transfer_id UUID REFERENCES transfers(id) ON DELETE CASCADE;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS transfer_side VARCHAR(3) CHECK (transfer_side IN ('out', 'in'));
+
+ALTER TABLE transactions ADD CONSTRAINT transactions_transfer_leg_check CHECK (
+    (type = 'transfer') = (transfer_id IS NOT NULL AND transfer_side IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_transfer_side ON transactions(transfer_id, transfer_side)
+    WHERE transfer_id IS NOT NULL;
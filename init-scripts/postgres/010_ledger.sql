@@ -0,0 +1,165 @@
+-- Razão de partidas dobradas: lançamentos imutáveis cujas partidas somam zero por moeda.
+-- A API REST continua gravando em transactions/transfers/accounts; cada alteração gera
+-- lançamentos na mesma transação do banco. Correções são estornos, nunca alterações.
+--
+-- Convenção de sinais: débito positivo, crédito negativo. O saldo de uma conta do
+-- usuário é a soma das suas partidas; receitas ficam negativas e despesas positivas.
+
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- wallet:<account_id>, income:<moeda>:<categoria>, expense:<moeda>:<categoria>,
+    -- equity:opening:<moeda>, equity:conversion:<moeda>
+    key TEXT NOT NULL,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('asset', 'liability', 'income', 'expense', 'equity')),
+    account_id UUID REFERENCES accounts(id) ON DELETE RESTRICT,
+    category VARCHAR(100),
+    currency CHAR(3) NOT NULL,
+    name VARCHAR(150) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_accounts_account_id ON ledger_accounts(account_id);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('transaction', 'transfer', 'opening_balance', 'reversal')),
+    -- Registro da API que originou o lançamento (transaction, transfer ou account)
+    source_type VARCHAR(20) NOT NULL CHECK (source_type IN ('transaction', 'transfer', 'account')),
+    source_id UUID NOT NULL,
+    reverses_entry_id UUID UNIQUE REFERENCES journal_entries(id),
+    description TEXT NOT NULL,
+    -- Data de competência; estornos herdam a data do lançamento estornado
+    date TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_journal_entries_user_date ON journal_entries(user_id, date);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_source ON journal_entries(source_type, source_id);
+
+CREATE TABLE IF NOT EXISTS postings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    entry_id UUID NOT NULL REFERENCES journal_entries(id),
+    ledger_account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount <> 0),
+    currency CHAR(3) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_postings_entry_id ON postings(entry_id);
+CREATE INDEX IF NOT EXISTS idx_postings_ledger_account_id ON postings(ledger_account_id);
+
+-- Lançamentos e partidas são imutáveis. A exclusão só é permitida para apagar os
+-- dados de um usuário (LGPD), com SET LOCAL ledger.allow_erasure = 'on'.
+CREATE OR REPLACE FUNCTION ledger_immutable()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('ledger.allow_erasure', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'ledger % are immutable; post a reversing entry instead', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER journal_entries_immutable BEFORE UPDATE OR DELETE ON journal_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
+
+CREATE TRIGGER postings_immutable BEFORE UPDATE OR DELETE ON postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
+
+-- Todo lançamento fecha em zero em cada moeda; verificado no commit
+CREATE OR REPLACE FUNCTION ledger_entry_balanced()
+RETURNS TRIGGER AS $$
+DECLARE
+    unbalanced RECORD;
+BEGIN
+    SELECT currency, SUM(amount) AS total INTO unbalanced
+    FROM postings
+    WHERE entry_id = NEW.entry_id
+    GROUP BY currency
+    HAVING SUM(amount) <> 0
+    LIMIT 1;
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'journal entry % does not balance: % %', NEW.entry_id, unbalanced.total, unbalanced.currency;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER postings_balanced AFTER INSERT ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_entry_balanced();
+
+-- Carga inicial a partir dos dados existentes
+
+INSERT INTO ledger_accounts (user_id, key, kind, account_id, currency, name)
+SELECT user_id, 'wallet:' || id, CASE WHEN type = 'credit_card' THEN 'liability' ELSE 'asset' END, id, currency, name
+FROM accounts
+ON CONFLICT (user_id, key) DO NOTHING;
+
+INSERT INTO ledger_accounts (user_id, key, kind, category, currency, name)
+SELECT DISTINCT user_id, type || ':' || currency || ':' || category, type, category, currency, category
+FROM transactions
+WHERE type IN ('income', 'expense')
+ON CONFLICT (user_id, key) DO NOTHING;
+
+INSERT INTO ledger_accounts (user_id, key, kind, currency, name)
+SELECT DISTINCT user_id, 'equity:opening:' || currency, 'equity', currency, 'Saldos iniciais'
+FROM accounts
+WHERE opening_balance <> 0
+ON CONFLICT (user_id, key) DO NOTHING;
+
+INSERT INTO ledger_accounts (user_id, key, kind, currency, name)
+SELECT DISTINCT t.user_id, 'equity:conversion:' || t.currency, 'equity', t.currency, 'Conversão de moedas'
+FROM transactions t
+JOIN transactions o ON o.transfer_id = t.transfer_id AND o.id <> t.id AND o.currency <> t.currency
+ON CONFLICT (user_id, key) DO NOTHING;
+
+-- Saldos iniciais
+INSERT INTO journal_entries (user_id, kind, source_type, source_id, description, date)
+SELECT user_id, 'opening_balance', 'account', id, 'Saldo inicial: ' || name, created_at
+FROM accounts
+WHERE opening_balance <> 0;
+
+INSERT INTO postings (entry_id, ledger_account_id, amount, currency)
+SELECT e.id, la.id, side.amount, a.currency
+FROM accounts a
+JOIN journal_entries e ON e.source_type = 'account' AND e.source_id = a.id
+CROSS JOIN LATERAL (VALUES ('wallet:' || a.id, a.opening_balance),
+                           ('equity:opening:' || a.currency, -a.opening_balance)) AS side(key, amount)
+JOIN ledger_accounts la ON la.user_id = a.user_id AND la.key = side.key;
+
+-- Receitas e despesas
+INSERT INTO journal_entries (user_id, kind, source_type, source_id, description, date, created_at)
+SELECT user_id, 'transaction', 'transaction', id, description, date, created_at
+FROM transactions
+WHERE type IN ('income', 'expense');
+
+INSERT INTO postings (entry_id, ledger_account_id, amount, currency)
+SELECT e.id, la.id, side.amount, t.currency
+FROM transactions t
+JOIN journal_entries e ON e.source_type = 'transaction' AND e.source_id = t.id
+CROSS JOIN LATERAL (VALUES
+    ('wallet:' || t.account_id, CASE WHEN t.type = 'income' THEN t.amount ELSE -t.amount END),
+    (t.type || ':' || t.currency || ':' || t.category, CASE WHEN t.type = 'income' THEN -t.amount ELSE t.amount END)
+) AS side(key, amount)
+JOIN ledger_accounts la ON la.user_id = t.user_id AND la.key = side.key;
+
+-- Transferências (entre moedas diferentes, passando pelas contas de conversão)
+INSERT INTO journal_entries (user_id, kind, source_type, source_id, description, date, created_at)
+SELECT user_id, 'transfer', 'transfer', id, description, date, created_at
+FROM transfers;
+
+INSERT INTO postings (entry_id, ledger_account_id, amount, currency)
+SELECT e.id, la.id, side.amount, t.currency
+FROM transactions t
+JOIN transactions o ON o.transfer_id = t.transfer_id AND o.id <> t.id
+JOIN journal_entries e ON e.source_type = 'transfer' AND e.source_id = t.transfer_id
+CROSS JOIN LATERAL (VALUES
+    ('wallet:' || t.account_id, CASE WHEN t.transfer_side = 'in' THEN t.amount ELSE -t.amount END),
+    ('equity:conversion:' || t.currency, CASE WHEN t.transfer_side = 'in' THEN -t.amount ELSE t.amount END)
+) AS side(key, amount)
+JOIN ledger_accounts la ON la.user_id = t.user_id AND la.key = side.key
+WHERE o.currency <> t.currency OR side.key LIKE 'wallet:%';
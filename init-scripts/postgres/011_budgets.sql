@@ -0,0 +1,32 @@
+-- Orçamentos mensais por categoria
+
+CREATE TABLE IF NOT EXISTS budgets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category VARCHAR(100) NOT NULL,
+    -- Limite mensal, na moeda do orçamento (despesas em outras moedas são convertidas)
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    -- Sobra de um mês é somada ao limite do mês seguinte
+    rollover BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Primeiro dia do mês a partir do qual o orçamento vale
+    start_month DATE NOT NULL CHECK (EXTRACT(DAY FROM start_month) = 1),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, category)
+);
+
+CREATE TRIGGER update_budgets_updated_at BEFORE UPDATE ON budgets
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Alertas já publicados por mês; apagados se o gasto voltar abaixo do limiar,
+-- para que um novo cruzamento publique de novo
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    month DATE NOT NULL,
+    threshold SMALLINT NOT NULL CHECK (threshold IN (80, 100)),
+    triggered_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (budget_id, month, threshold)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_category_date ON transactions(user_id, category, date);
@@ -0,0 +1,26 @@
+-- Metas de economia ligadas a uma conta ou a uma categoria
+
+CREATE TABLE IF NOT EXISTS goals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    target_amount DECIMAL(15, 2) NOT NULL CHECK (target_amount > 0),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    deadline DATE,
+    -- Contribuições: movimento líquido da conta ou lançamentos da categoria a partir de start_date
+    account_id UUID REFERENCES accounts(id) ON DELETE CASCADE,
+    category VARCHAR(100),
+    start_date DATE NOT NULL,
+    -- Preenchido uma única vez, quando o goal.achieved é publicado
+    achieved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT goals_single_link CHECK ((account_id IS NULL) <> (category IS NULL)),
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_goals_user_account ON goals(user_id, account_id) WHERE achieved_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_goals_user_category ON goals(user_id, category) WHERE achieved_at IS NULL;
+
+CREATE TRIGGER update_goals_updated_at BEFORE UPDATE ON goals
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
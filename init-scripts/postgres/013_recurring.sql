@@ -0,0 +1,48 @@
+-- Transações recorrentes: regras, exceções por ocorrência e vínculo das transações geradas
+
+CREATE TABLE IF NOT EXISTS recurring_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE RESTRICT,
+    description TEXT NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    category VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('income', 'expense')),
+    frequency VARCHAR(20) NOT NULL CHECK (frequency IN ('daily', 'weekly', 'monthly', 'last_business_day', 'yearly')),
+    interval INTEGER NOT NULL DEFAULT 1 CHECK (interval BETWEEN 1 AND 366),
+    day_of_month SMALLINT CHECK (day_of_month BETWEEN 1 AND 31),
+    weekday SMALLINT CHECK (weekday BETWEEN 0 AND 6),
+    start_date DATE NOT NULL,
+    end_date DATE CHECK (end_date >= start_date),
+    -- Próxima data ainda não materializada; NULL quando a série terminou
+    next_occurrence DATE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_rules_user ON recurring_rules(user_id);
+CREATE INDEX IF NOT EXISTS idx_recurring_rules_due ON recurring_rules(next_occurrence) WHERE active;
+
+CREATE TRIGGER update_recurring_rules_updated_at BEFORE UPDATE ON recurring_rules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS recurring_exceptions (
+    rule_id UUID NOT NULL REFERENCES recurring_rules(id) ON DELETE CASCADE,
+    occurrence_date DATE NOT NULL,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('skip', 'modify')),
+    description TEXT,
+    amount DECIMAL(15, 2) CHECK (amount > 0),
+    category VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (rule_id, occurrence_date)
+);
+
+-- Cada ocorrência vira no máximo uma transação, mesmo com o worker em várias réplicas
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS recurring_rule_id UUID REFERENCES recurring_rules(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS recurring_date DATE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_recurring_occurrence
+    ON transactions(recurring_rule_id, recurring_date)
+    WHERE recurring_rule_id IS NOT NULL;
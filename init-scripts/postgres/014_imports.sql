@@ -0,0 +1,48 @@
+-- Importação de extratos em lotes com pré-visualização, commit atômico e rollback
+
+CREATE TABLE IF NOT EXISTS import_batches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    format VARCHAR(10) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'committed', 'rolled_back')),
+    total_entries INTEGER NOT NULL DEFAULT 0,
+    error_count INTEGER NOT NULL DEFAULT 0,
+    imported_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    committed_at TIMESTAMP,
+    rolled_back_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_batches_user ON import_batches(user_id, created_at DESC);
+
+-- Lançamentos lidos do arquivo, classificados contra as transações existentes
+CREATE TABLE IF NOT EXISTS import_entries (
+    batch_id UUID NOT NULL REFERENCES import_batches(id) ON DELETE CASCADE,
+    line INTEGER NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    date DATE NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    type VARCHAR(20) NOT NULL CHECK (type IN ('income', 'expense')),
+    description TEXT NOT NULL,
+    category VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'new'
+        CHECK (status IN ('new', 'duplicate', 'possible_duplicate')),
+    duplicate_of UUID,
+    transaction_id UUID,
+    PRIMARY KEY (batch_id, line)
+);
+
+-- FITID do banco (ou hash do conteúdo) e lote de origem de cada transação importada
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS external_id VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS import_batch_id UUID REFERENCES import_batches(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_account_external_id
+    ON transactions(account_id, external_id)
+    WHERE external_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_import_batch ON transactions(import_batch_id)
+    WHERE import_batch_id IS NOT NULL;
@@ -0,0 +1,32 @@
+-- Perfis de mapeamento de colunas para importação de CSV, reutilizáveis entre importações
+
+CREATE TABLE IF NOT EXISTS import_profiles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    delimiter VARCHAR(1) NOT NULL DEFAULT ',',
+    encoding VARCHAR(10) NOT NULL DEFAULT 'utf-8' CHECK (encoding IN ('utf-8', 'latin1')),
+    has_header BOOLEAN NOT NULL DEFAULT TRUE,
+    skip_rows INTEGER NOT NULL DEFAULT 0 CHECK (skip_rows >= 0),
+    date_column VARCHAR(100) NOT NULL,
+    date_format VARCHAR(30) NOT NULL,
+    description_columns TEXT[] NOT NULL,
+    amount_column VARCHAR(100),
+    debit_column VARCHAR(100),
+    credit_column VARCHAR(100),
+    category_column VARCHAR(100),
+    external_id_column VARCHAR(100),
+    decimal_separator VARCHAR(5) NOT NULL DEFAULT 'comma' CHECK (decimal_separator IN ('comma', 'dot')),
+    sign_convention VARCHAR(20) NOT NULL CHECK (sign_convention IN ('signed', 'inverted', 'debit_credit')),
+    default_category VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE TRIGGER update_import_profiles_updated_at BEFORE UPDATE ON import_profiles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Perfil usado por cada lote importado de CSV
+ALTER TABLE import_batches
+    ADD COLUMN IF NOT EXISTS profile_id UUID REFERENCES import_profiles(id) ON DELETE SET NULL;
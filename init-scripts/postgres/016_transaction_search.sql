@@ -0,0 +1,22 @@
+-- Busca textual nas descrições das transações, sem diferenciar acentos
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- Português com os acentos removidos antes do stemming ("farmácia" = "farmacia")
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'portuguese_unaccent') THEN
+        CREATE TEXT SEARCH CONFIGURATION public.portuguese_unaccent (COPY = pg_catalog.portuguese);
+        ALTER TEXT SEARCH CONFIGURATION public.portuguese_unaccent
+            ALTER MAPPING FOR hword, hword_part, word WITH unaccent, portuguese_stem;
+    END IF;
+END
+$$;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS description_search tsvector
+    GENERATED ALWAYS AS (to_tsvector('public.portuguese_unaccent', description)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_description_search ON transactions USING GIN (description_search);
+
+-- Filtros e ordenação por valor
+CREATE INDEX IF NOT EXISTS idx_transactions_user_amount ON transactions(user_id, amount);
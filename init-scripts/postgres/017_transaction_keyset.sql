@@ -0,0 +1,4 @@
+-- Paginação por cursor da listagem de transações: a chave (date, created_at, id)
+-- é percorrida pelo índice nos dois sentidos
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_keyset ON transactions(user_id, date, created_at, id);
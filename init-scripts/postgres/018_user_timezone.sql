@@ -0,0 +1,4 @@
+-- Fuso horário do usuário, usado para agrupar as transações por dia, semana,
+-- mês ou ano nas análises. NULL usa o padrão do serviço.
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS timezone VARCHAR(64);
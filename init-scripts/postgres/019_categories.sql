@@ -0,0 +1,87 @@
+-- Categorias do usuário, hierárquicas, no lugar do texto livre das transações.
+-- transactions.category continua com o nome da categoria, mantido pelo serviço,
+-- porque orçamentos, recorrências, importações e o razão agrupam pelo nome.
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- Chave de comparação dos nomes: "Alimentação", "alimentacao" e " ALIMENTACAO "
+-- são a mesma categoria. unaccent() com o dicionário explícito é imutável na
+-- prática, o que permite usá-la no índice.
+CREATE OR REPLACE FUNCTION category_key(name TEXT) RETURNS TEXT AS $$
+    SELECT lower(public.unaccent('public.unaccent'::regdictionary, btrim(name)))
+$$ LANGUAGE SQL IMMUTABLE PARALLEL SAFE;
+
+CREATE TABLE IF NOT EXISTS categories (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- A subcategoria tem o mesmo tipo da mãe
+    parent_id UUID REFERENCES categories(id),
+    name VARCHAR(100) NOT NULL CHECK (btrim(name) <> ''),
+    type VARCHAR(20) NOT NULL CHECK (type IN ('income', 'expense')),
+    color VARCHAR(7) CHECK (color ~ '^#[0-9a-fA-F]{6}$'),
+    icon VARCHAR(50),
+    -- Categorias arquivadas não recebem novas transações, mas continuam nas existentes
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (parent_id IS NULL OR parent_id <> id)
+);
+
+-- Nomes únicos por usuário e tipo, em qualquer nível da árvore, para que o nome
+-- continue identificando a categoria nas APIs que recebem texto
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_user_type_name ON categories(user_id, type, category_key(name));
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_id);
+
+CREATE TRIGGER update_categories_updated_at BEFORE UPDATE ON categories
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- NULL apenas nas transferências
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS category_id UUID REFERENCES categories(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_category_id ON transactions(category_id);
+
+-- Migração do texto livre: uma categoria por nome normalizado, com a grafia mais
+-- usada. Os lançamentos já gravados no razão mantêm as contas de categoria com a
+-- grafia original.
+INSERT INTO categories (user_id, name, type)
+SELECT DISTINCT ON (user_id, type, category_key(category)) user_id, btrim(category), type
+FROM transactions
+WHERE type IN ('income', 'expense') AND btrim(category) <> ''
+GROUP BY user_id, type, category
+ORDER BY user_id, type, category_key(category), COUNT(*) DESC, category
+ON CONFLICT DO NOTHING;
+
+UPDATE transactions t
+SET category_id = c.id, category = c.name
+FROM categories c
+WHERE t.category_id IS NULL
+    AND t.type IN ('income', 'expense')
+    AND c.user_id = t.user_id
+    AND c.type = t.type
+    AND category_key(c.name) = category_key(t.category);
+
+-- Orçamentos e recorrências casam com as transações pelo nome: passam a usar a
+-- grafia da categoria. Entre orçamentos com grafias equivalentes só o mais
+-- antigo é renomeado, para não violar UNIQUE (user_id, category).
+UPDATE budgets b
+SET category = m.name
+FROM (
+    SELECT b.id, c.name,
+        ROW_NUMBER() OVER (PARTITION BY c.id ORDER BY b.category = c.name DESC, b.created_at) AS rank
+    FROM budgets b
+    JOIN categories c ON c.user_id = b.user_id AND c.type = 'expense' AND category_key(c.name) = category_key(b.category)
+) m
+WHERE m.id = b.id AND m.rank = 1 AND b.category <> m.name;
+
+UPDATE recurring_rules r
+SET category = c.name
+FROM categories c
+WHERE c.user_id = r.user_id AND c.type = r.type
+    AND category_key(c.name) = category_key(r.category) AND r.category <> c.name;
+
+UPDATE recurring_exceptions e
+SET category = c.name
+FROM recurring_rules r, categories c
+WHERE r.id = e.rule_id AND e.category IS NOT NULL
+    AND c.user_id = r.user_id AND c.type = r.type
+    AND category_key(c.name) = category_key(e.category) AND e.category <> c.name;
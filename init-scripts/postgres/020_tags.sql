@@ -0,0 +1,24 @@
+-- Tags livres nas transações ("viagem-2026", "reembolsável"), independentes da categoria
+
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL CHECK (btrim(name) <> ''),
+    color VARCHAR(7) CHECK (color ~ '^#[0-9a-fA-F]{6}$'),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Mesma comparação dos nomes de categoria: sem diferenciar maiúsculas e acentos
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name ON tags(user_id, category_key(name));
+
+CREATE TRIGGER update_tags_updated_at BEFORE UPDATE ON tags
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS transaction_tags (
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (transaction_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_tags_tag ON transaction_tags(tag_id);
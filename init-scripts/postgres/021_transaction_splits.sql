@@ -0,0 +1,70 @@
+-- Divisão de uma transação entre várias categorias (ex.: um recibo de
+-- supermercado com mercearia, limpeza e farmácia)
+
+CREATE TABLE IF NOT EXISTS transaction_splits (
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    line SMALLINT NOT NULL CHECK (line > 0),
+    category VARCHAR(100) NOT NULL,
+    category_id UUID REFERENCES categories(id),
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    note VARCHAR(255),
+    PRIMARY KEY (transaction_id, line)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_category ON transaction_splits(category_id);
+
+-- As linhas somam o valor da transação; verificado no commit, para que a
+-- transação e as suas linhas possam mudar juntas
+CREATE OR REPLACE FUNCTION transaction_splits_balanced()
+RETURNS TRIGGER AS $$
+DECLARE
+    split_transaction_id UUID;
+    transaction_amount DECIMAL(15, 2);
+    lines_total DECIMAL(15, 2);
+BEGIN
+    IF TG_TABLE_NAME = 'transactions' THEN
+        split_transaction_id := NEW.id;
+    ELSIF TG_OP = 'DELETE' THEN
+        split_transaction_id := OLD.transaction_id;
+    ELSE
+        split_transaction_id := NEW.transaction_id;
+    END IF;
+
+    SELECT t.amount, SUM(s.amount) INTO transaction_amount, lines_total
+    FROM transactions t
+    JOIN transaction_splits s ON s.transaction_id = t.id
+    WHERE t.id = split_transaction_id
+    GROUP BY t.amount;
+
+    -- Sem linhas a transação não está dividida
+    IF FOUND AND lines_total <> transaction_amount THEN
+        RAISE EXCEPTION 'splits of transaction % sum to %, expected %', split_transaction_id, lines_total, transaction_amount;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER transaction_splits_balanced AFTER INSERT OR UPDATE OR DELETE ON transaction_splits
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION transaction_splits_balanced();
+
+CREATE CONSTRAINT TRIGGER transactions_splits_balanced AFTER UPDATE OF amount ON transactions
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION transaction_splits_balanced();
+
+-- Linhas de categoria: uma por linha das transações divididas e a própria
+-- transação nas demais. Totais por categoria (estatísticas, orçamentos e
+-- metas) somam por aqui.
+CREATE OR REPLACE VIEW transaction_lines AS
+SELECT
+    t.id AS transaction_id,
+    t.user_id,
+    t.account_id,
+    t.currency,
+    t.type,
+    t.date,
+    CASE WHEN s.transaction_id IS NULL THEN t.category ELSE s.category END AS category,
+    CASE WHEN s.transaction_id IS NULL THEN t.category_id ELSE s.category_id END AS category_id,
+    COALESCE(s.amount, t.amount) AS amount
+FROM transactions t
+LEFT JOIN transaction_splits s ON s.transaction_id = t.id;
@@ -0,0 +1,4 @@
+-- O download da exportação passa a exigir o header Authorization do dono: o
+-- token na query string ia parar em logs de proxies e no histórico do navegador
+
+ALTER TABLE data_export_jobs DROP COLUMN IF EXISTS download_token;
@@ -0,0 +1,4 @@
+-- Lançamentos importados já divididos entre categorias (diário Beancount com
+-- mais de uma conta de categoria): as linhas viram a divisão da transação no commit
+
+ALTER TABLE import_entries ADD COLUMN IF NOT EXISTS splits JSONB;
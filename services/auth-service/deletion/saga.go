@@ -0,0 +1,289 @@
+package deletion
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"auth-service/messaging"
+	"auth-service/metrics"
+	"auth-service/models"
+	"auth-service/repository"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// LocalParticipant é a etapa executada pelo próprio auth-service (sessões no Redis)
+const LocalParticipant = "auth-sessions"
+
+// Saga orquestra a exclusão de uma conta: período de carência, pedido aos serviços,
+// coleta das confirmações e exclusão definitiva com tombstone
+type Saga struct {
+	deletionRepo  *repository.DeletionRepository
+	userRepo      *repository.UserRepository
+	sessionRepo   *repository.SessionRepository
+	exportRepo    *repository.ExportRepository
+	publisher     *messaging.EventPublisher
+	participants  []string
+	gracePeriod   time.Duration
+	retryInterval time.Duration
+	logger        *zap.Logger
+}
+
+func NewSaga(
+	deletionRepo *repository.DeletionRepository,
+	userRepo *repository.UserRepository,
+	sessionRepo *repository.SessionRepository,
+	exportRepo *repository.ExportRepository,
+	publisher *messaging.EventPublisher,
+	participants []string,
+	gracePeriod time.Duration,
+	retryInterval time.Duration,
+	logger *zap.Logger,
+) *Saga {
+	return &Saga{
+		deletionRepo:  deletionRepo,
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		exportRepo:    exportRepo,
+		publisher:     publisher,
+		participants:  append([]string{LocalParticipant}, participants...),
+		gracePeriod:   gracePeriod,
+		retryInterval: retryInterval,
+		logger:        logger,
+	}
+}
+
+// Request agenda a exclusão da conta ao fim do período de carência
+func (s *Saga) Request(ctx context.Context, userID string) (*models.AccountDeletionRequest, error) {
+	now := time.Now()
+	req := &models.AccountDeletionRequest{
+		ID:               uuid.New().String(),
+		UserID:           userID,
+		Status:           models.DeletionStatusScheduled,
+		Participants:     s.participants,
+		RequestedAt:      now,
+		ScheduledFor:     now.Add(s.gracePeriod),
+		Acknowledgements: []models.DeletionAcknowledgement{},
+	}
+
+	if err := s.deletionRepo.Create(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// O login fica bloqueado durante a carência; as sessões abertas são encerradas
+	// para que nenhum refresh token continue emitindo acessos
+	if revoked, err := s.sessionRepo.DeleteAllForUser(ctx, userID); err == nil {
+		metrics.ActiveSessions.Sub(float64(revoked))
+	} else {
+		s.logger.Error("failed to revoke sessions for deletion",
+			zap.Error(err),
+			zap.String("request_id", req.ID),
+		)
+	}
+
+	metrics.AccountDeletionsTotal.WithLabelValues(models.DeletionStatusScheduled).Inc()
+	s.logger.Info("account deletion scheduled",
+		zap.String("request_id", req.ID),
+		zap.String("user_id", userID),
+		zap.Time("scheduled_for", req.ScheduledFor),
+	)
+
+	return req, nil
+}
+
+// Cancel cancela a exclusão enquanto ainda estiver no período de carência
+func (s *Saga) Cancel(ctx context.Context, userID string) error {
+	if err := s.deletionRepo.Cancel(ctx, userID); err != nil {
+		return err
+	}
+
+	metrics.AccountDeletionsTotal.WithLabelValues(models.DeletionStatusCancelled).Inc()
+	s.logger.Info("account deletion cancelled", zap.String("user_id", userID))
+
+	return nil
+}
+
+// Status retorna o pedido ativo do usuário
+func (s *Saga) Status(ctx context.Context, userID string) (*models.AccountDeletionRequest, error) {
+	return s.deletionRepo.FindActiveByUser(ctx, userID)
+}
+
+// Start executa a varredura periódica e consome as confirmações dos participantes
+func (s *Saga) Start(ctx context.Context, rabbitmq *messaging.RabbitMQ) error {
+	err := rabbitmq.Consume(ctx, "auth_deletion_acks", []string{messaging.UserDeletionAcknowledged}, s.handleAck)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		s.tick(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.tick(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *Saga) tick(ctx context.Context) {
+	due, err := s.deletionRepo.StartDue(ctx, time.Now())
+	if err == nil {
+		for _, req := range due {
+			s.logger.Info("account deletion started",
+				zap.String("request_id", req.ID),
+				zap.String("user_id", req.UserID),
+			)
+		}
+	}
+
+	// Pedidos recém iniciados e pedidos sem todas as confirmações são (re)publicados.
+	// Os participantes devem tratar o evento de forma idempotente.
+	stalled, err := s.deletionRepo.ListStalled(ctx, time.Now().Add(-s.retryInterval))
+	if err != nil {
+		return
+	}
+
+	for _, req := range stalled {
+		remote := []string{}
+		for _, participant := range req.Pending() {
+			if participant == LocalParticipant {
+				s.runLocal(ctx, req)
+				continue
+			}
+			remote = append(remote, participant)
+		}
+
+		if len(remote) == 0 {
+			s.finalize(ctx, req.ID)
+			continue
+		}
+
+		event := messaging.UserDeletionRequestedEvent{
+			RequestID:    req.ID,
+			UserID:       req.UserID,
+			Participants: remote,
+		}
+		if err := s.publisher.PublishUserDeletionRequested(ctx, event); err != nil {
+			continue
+		}
+		s.deletionRepo.MarkPublished(ctx, req.ID, time.Now())
+	}
+}
+
+// runLocal revoga as sessões do usuário no Redis e registra a confirmação local
+func (s *Saga) runLocal(ctx context.Context, req *models.AccountDeletionRequest) {
+	revoked, err := s.sessionRepo.DeleteAllForUser(ctx, req.UserID)
+	if err != nil {
+		s.logger.Error("failed to revoke sessions for deletion",
+			zap.Error(err),
+			zap.String("request_id", req.ID),
+		)
+		return
+	}
+	metrics.ActiveSessions.Sub(float64(revoked))
+
+	s.deletionRepo.RecordAck(ctx, req.ID, LocalParticipant, map[string]interface{}{
+		"sessions_revoked": revoked,
+	})
+}
+
+func (s *Saga) handleAck(ctx context.Context, delivery amqp.Delivery) error {
+	var event messaging.UserDeletionAcknowledgedEvent
+	if err := json.Unmarshal(delivery.Body, &event); err != nil {
+		// Mensagem inválida não deve voltar para a fila
+		s.logger.Error("invalid deletion ack", zap.Error(err))
+		return nil
+	}
+
+	req, err := s.deletionRepo.FindByID(ctx, event.RequestID)
+	if err == repository.ErrDeletionNotFound {
+		s.logger.Warn("deletion ack for unknown request", zap.String("request_id", event.RequestID))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if req.UserID != event.UserID {
+		s.logger.Warn("deletion ack user mismatch",
+			zap.String("request_id", event.RequestID),
+			zap.String("service", event.Service),
+		)
+		return nil
+	}
+
+	if err := s.deletionRepo.RecordAck(ctx, event.RequestID, event.Service, event.Details); err != nil {
+		return err
+	}
+
+	s.logger.Info("account deletion acknowledged",
+		zap.String("request_id", event.RequestID),
+		zap.String("service", event.Service),
+	)
+
+	s.finalize(ctx, event.RequestID)
+	return nil
+}
+
+// finalize apaga o usuário definitivamente quando todos os participantes confirmaram
+func (s *Saga) finalize(ctx context.Context, requestID string) {
+	req, err := s.deletionRepo.FindByID(ctx, requestID)
+	if err != nil || req.Status != models.DeletionStatusInProgress || len(req.Pending()) > 0 {
+		return
+	}
+
+	email := ""
+	if user, err := s.userRepo.FindByID(ctx, req.UserID); err == nil {
+		email = user.Email
+	}
+
+	// Sessões criadas depois da confirmação local também são removidas
+	if revoked, err := s.sessionRepo.DeleteAllForUser(ctx, req.UserID); err == nil {
+		metrics.ActiveSessions.Sub(float64(revoked))
+	}
+
+	// Os registros de exportação são apagados em cascata, mas os arquivos ficam em disco
+	if paths, err := s.exportRepo.ListFilePathsByUser(ctx, req.UserID); err == nil {
+		for _, path := range paths {
+			os.Remove(path)
+		}
+	}
+
+	if err := s.deletionRepo.Finalize(ctx, req, email); err != nil {
+		if err != repository.ErrDeletionNotFound {
+			s.logger.Error("failed to finalize account deletion",
+				zap.Error(err),
+				zap.String("request_id", req.ID),
+			)
+		}
+		return
+	}
+
+	metrics.AccountDeletionsTotal.WithLabelValues(models.DeletionStatusCompleted).Inc()
+
+	event := messaging.UserDeletedEvent{UserID: req.UserID, DeletionRequestID: req.ID}
+	if err := s.publisher.PublishUserDeleted(ctx, event); err != nil {
+		s.logger.Error("failed to publish user deleted event",
+			zap.Error(err),
+			zap.String("request_id", req.ID),
+		)
+	}
+
+	s.logger.Info("account deleted",
+		zap.String("request_id", req.ID),
+		zap.String("user_id", req.UserID),
+		zap.Duration("duration", time.Since(req.RequestedAt)),
+	)
+}
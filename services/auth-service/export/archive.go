@@ -0,0 +1,142 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+type manifestEntry struct {
+	Service  string `json:"service"`
+	Dataset  string `json:"dataset"`
+	Records  int    `json:"records"`
+	JSONFile string `json:"json_file"`
+	CSVFile  string `json:"csv_file"`
+}
+
+type serviceSlice struct {
+	service  string
+	datasets []Dataset
+}
+
+// writeArchive empacota as fatias de cada serviço em um ZIP com um JSON e um CSV por dataset
+func writeArchive(path, userID string, slices []serviceSlice) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+
+	manifest := []manifestEntry{}
+	for _, slice := range slices {
+		for _, dataset := range slice.datasets {
+			entry := manifestEntry{
+				Service:  slice.service,
+				Dataset:  dataset.Name,
+				Records:  len(dataset.Records),
+				JSONFile: fmt.Sprintf("%s/%s.json", slice.service, dataset.Name),
+				CSVFile:  fmt.Sprintf("%s/%s.csv", slice.service, dataset.Name),
+			}
+
+			if err := writeJSON(zw, entry.JSONFile, dataset.Records); err != nil {
+				return err
+			}
+			if err := writeCSV(zw, entry.CSVFile, dataset.Records); err != nil {
+				return err
+			}
+
+			manifest = append(manifest, entry)
+		}
+	}
+
+	err = writeJSON(zw, "manifest.json", map[string]interface{}{
+		"user_id":      userID,
+		"generated_at": time.Now().UTC(),
+		"files":        manifest,
+	})
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeCSV(zw *zip.Writer, name string, records []map[string]interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	return encodeCSV(w, records)
+}
+
+// encodeCSV usa a união das chaves dos registros como cabeçalho, em ordem alfabética
+func encodeCSV(w io.Writer, records []map[string]interface{}) error {
+	columnSet := map[string]struct{}{}
+	for _, record := range records {
+		for key := range record {
+			columnSet[key] = struct{}{}
+		}
+	}
+
+	columns := make([]string, 0, len(columnSet))
+	for key := range columnSet {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns))
+	for _, record := range records {
+		for i, column := range columns {
+			row[i] = formatCSVValue(record[column])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case float64, bool, int, int64, json.Number:
+		return fmt.Sprint(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
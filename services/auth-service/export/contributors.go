@@ -0,0 +1,164 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"auth-service/models"
+	"auth-service/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Dataset é um conjunto de registros exportado por um serviço (ex: "transactions")
+type Dataset struct {
+	Name    string                   `json:"name"`
+	Records []map[string]interface{} `json:"records"`
+}
+
+// Contributor fornece a fatia dos dados pessoais mantida por um serviço
+type Contributor interface {
+	Name() string
+	Collect(ctx context.Context, user *models.User) ([]Dataset, error)
+}
+
+// UserContributor exporta os dados mantidos pelo próprio auth-service: o cadastro,
+// os dispositivos conhecidos e o histórico de tentativas de login
+type UserContributor struct {
+	securityRepo *repository.LoginSecurityRepository
+}
+
+func NewUserContributor(securityRepo *repository.LoginSecurityRepository) *UserContributor {
+	return &UserContributor{securityRepo: securityRepo}
+}
+
+func (u *UserContributor) Name() string {
+	return "auth-service"
+}
+
+func (u *UserContributor) Collect(ctx context.Context, user *models.User) ([]Dataset, error) {
+	profile := map[string]interface{}{
+		"id":         user.ID,
+		"email":      user.Email,
+		"name":       user.Name,
+		"created_at": user.CreatedAt,
+		"updated_at": user.UpdatedAt,
+	}
+
+	devices, err := u.securityRepo.ListDevices(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list known devices: %w", err)
+	}
+
+	// A impressão digital fica fora do JSON da API, mas é dado do usuário e entra na exportação
+	knownDevices := make([]map[string]interface{}, 0, len(devices))
+	for _, device := range devices {
+		knownDevices = append(knownDevices, map[string]interface{}{
+			"id":            device.ID,
+			"fingerprint":   device.Fingerprint,
+			"user_agent":    device.UserAgent,
+			"ip_prefix":     device.IPPrefix,
+			"device_id":     device.DeviceID,
+			"first_seen_at": device.FirstSeenAt,
+			"last_seen_at":  device.LastSeenAt,
+		})
+	}
+
+	attempts, err := u.securityRepo.ListAttempts(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login attempts: %w", err)
+	}
+
+	loginAttempts := make([]map[string]interface{}, 0, len(attempts))
+	for _, attempt := range attempts {
+		loginAttempts = append(loginAttempts, map[string]interface{}{
+			"id":          attempt.ID,
+			"success":     attempt.Success,
+			"ip":          attempt.IP,
+			"country":     attempt.Country,
+			"latitude":    attempt.Latitude,
+			"longitude":   attempt.Longitude,
+			"fingerprint": attempt.Fingerprint,
+			"created_at":  attempt.CreatedAt,
+		})
+	}
+
+	return []Dataset{
+		{Name: "profile", Records: []map[string]interface{}{profile}},
+		{Name: "known_devices", Records: knownDevices},
+		{Name: "login_attempts", Records: loginAttempts},
+	}, nil
+}
+
+// ServiceContributor busca a fatia de dados de outro serviço via HTTP.
+// A chamada é autenticada com um token de curta duração emitido em nome do usuário.
+type ServiceContributor struct {
+	name      string
+	url       string
+	jwtSecret string
+	client    *http.Client
+}
+
+func NewServiceContributor(name, url, jwtSecret string) *ServiceContributor {
+	return &ServiceContributor{
+		name:      name,
+		url:       url,
+		jwtSecret: jwtSecret,
+		client:    &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+func (s *ServiceContributor) Name() string {
+	return s.name
+}
+
+func (s *ServiceContributor) Collect(ctx context.Context, user *models.User) ([]Dataset, error) {
+	token, err := s.serviceToken(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign service token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", s.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", s.name, resp.StatusCode)
+	}
+
+	var payload struct {
+		Datasets []Dataset `json:"datasets"`
+	}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber() // preserva valores monetários exatamente como enviados
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode %s response: %w", s.name, err)
+	}
+
+	return payload.Datasets, nil
+}
+
+func (s *ServiceContributor) serviceToken(user *models.User) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"purpose": "data_export",
+		"exp":     time.Now().Add(5 * time.Minute).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.jwtSecret))
+}
@@ -0,0 +1,199 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"auth-service/metrics"
+	"auth-service/models"
+	"auth-service/repository"
+
+	"go.uber.org/zap"
+)
+
+// Worker processa pedidos de exportação de forma assíncrona
+type Worker struct {
+	exportRepo   *repository.ExportRepository
+	userRepo     *repository.UserRepository
+	contributors []Contributor
+	dir          string
+	linkTTL      time.Duration
+	queue        chan string
+	logger       *zap.Logger
+}
+
+func NewWorker(
+	exportRepo *repository.ExportRepository,
+	userRepo *repository.UserRepository,
+	contributors []Contributor,
+	dir string,
+	linkTTL time.Duration,
+	logger *zap.Logger,
+) *Worker {
+	return &Worker{
+		exportRepo:   exportRepo,
+		userRepo:     userRepo,
+		contributors: contributors,
+		dir:          dir,
+		linkTTL:      linkTTL,
+		queue:        make(chan string, 100),
+		logger:       logger,
+	}
+}
+
+// Enqueue agenda um pedido para processamento.
+// Se a fila estiver cheia o pedido continua pendente e é recuperado na próxima varredura.
+func (w *Worker) Enqueue(jobID string) {
+	select {
+	case w.queue <- jobID:
+	default:
+		w.logger.Warn("export queue full, job will be picked up later", zap.String("export_id", jobID))
+	}
+}
+
+// Start inicia os workers e a varredura periódica até o contexto ser cancelado
+func (w *Worker) Start(ctx context.Context, concurrency int) error {
+	if err := os.MkdirAll(w.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create export dir: %w", err)
+	}
+
+	if n, err := w.exportRepo.ResetProcessing(ctx); err == nil && n > 0 {
+		w.logger.Info("requeued interrupted export jobs", zap.Int64("count", n))
+	}
+
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case jobID := <-w.queue:
+					w.process(ctx, jobID)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		w.sweep(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.sweep(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// staleClaimAfter é quanto um pedido pode ficar em processamento antes de
+// voltar para a fila (um worker que caiu sem conseguir marcar a falha)
+const staleClaimAfter = time.Hour
+
+// sweep reenfileira pedidos pendentes e presos em processamento e remove
+// arquivos com link expirado
+func (w *Worker) sweep(ctx context.Context) {
+	if n, err := w.exportRepo.RequeueStale(ctx, time.Now().Add(-staleClaimAfter)); err == nil && n > 0 {
+		w.logger.Warn("requeued stale export jobs", zap.Int64("count", n))
+	}
+
+	pending, err := w.exportRepo.ListPending(ctx)
+	if err == nil {
+		for _, job := range pending {
+			w.Enqueue(job.ID)
+		}
+	}
+
+	expired, err := w.exportRepo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return
+	}
+
+	for _, job := range expired {
+		if job.FilePath != "" {
+			if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+				w.logger.Error("failed to remove expired export", zap.Error(err), zap.String("export_id", job.ID))
+				continue
+			}
+		}
+		if err := w.exportRepo.MarkExpired(ctx, job.ID); err == nil {
+			metrics.DataExportsTotal.WithLabelValues(models.ExportStatusExpired).Inc()
+		}
+	}
+}
+
+func (w *Worker) process(ctx context.Context, jobID string) {
+	claimed, err := w.exportRepo.Claim(ctx, jobID)
+	if err != nil || !claimed {
+		return
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.DataExportDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	// Depois do Claim, toda falha marca o pedido; senão ele ficaria em
+	// processamento e bloquearia novos pedidos do usuário
+	job, err := w.exportRepo.FindByID(ctx, jobID)
+	if err == nil {
+		err = w.build(ctx, job)
+	}
+
+	if err != nil {
+		w.logger.Error("failed to build data export",
+			zap.Error(err),
+			zap.String("export_id", jobID),
+		)
+		metrics.DataExportsTotal.WithLabelValues(models.ExportStatusFailed).Inc()
+		if err := w.exportRepo.MarkFailed(ctx, jobID, err.Error()); err != nil {
+			w.logger.Error("failed to mark export as failed", zap.Error(err), zap.String("export_id", jobID))
+		}
+		return
+	}
+
+	metrics.DataExportsTotal.WithLabelValues(models.ExportStatusCompleted).Inc()
+	w.logger.Info("data export completed",
+		zap.String("export_id", job.ID),
+		zap.String("user_id", job.UserID),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
+
+func (w *Worker) build(ctx context.Context, job *models.DataExportJob) error {
+	user, err := w.userRepo.FindByID(ctx, job.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+
+	slices := make([]serviceSlice, 0, len(w.contributors))
+	for _, contributor := range w.contributors {
+		datasets, err := contributor.Collect(ctx, user)
+		if err != nil {
+			return fmt.Errorf("%s: %w", contributor.Name(), err)
+		}
+		slices = append(slices, serviceSlice{service: contributor.Name(), datasets: datasets})
+	}
+
+	path := filepath.Join(w.dir, job.ID+".zip")
+	if err := writeArchive(path, user.ID, slices); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	if err := w.exportRepo.MarkCompleted(ctx, job.ID, path, time.Now().Add(w.linkTTL)); err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"auth-service/config"
+	"auth-service/metrics"
+	"auth-service/models"
+	"auth-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type AdminHandler struct {
+	userRepo    *repository.UserRepository
+	sessionRepo *repository.SessionRepository
+	auditRepo   *repository.AuditRepository
+	config      *config.Config
+	logger      *zap.Logger
+}
+
+func NewAdminHandler(
+	userRepo *repository.UserRepository,
+	sessionRepo *repository.SessionRepository,
+	auditRepo *repository.AuditRepository,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *AdminHandler {
+	return &AdminHandler{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		auditRepo:   auditRepo,
+		config:      cfg,
+		logger:      logger,
+	}
+}
+
+type AdminActionRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ImpersonateRequest struct {
+	Reason     string `json:"reason" binding:"required"`
+	AllowWrite bool   `json:"allow_write"`
+}
+
+// audit grava a ação na trilha de auditoria. Falhas são logadas mas não interrompem a requisição.
+func (h *AdminHandler) audit(c *gin.Context, action, targetUserID string, metadata map[string]interface{}) {
+	actorID, _ := c.Get("user_id")
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	traceID := ""
+	if span := trace.SpanFromContext(c.Request.Context()); span.SpanContext().HasTraceID() {
+		traceID = span.SpanContext().TraceID().String()
+	}
+
+	entry := &models.AuditLog{
+		ID:           uuid.New().String(),
+		ActorID:      actorID.(string),
+		Action:       action,
+		TargetUserID: targetUserID,
+		Metadata:     metadata,
+		IP:           c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		TraceID:      traceID,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := h.auditRepo.Create(c.Request.Context(), entry); err != nil {
+		h.logger.Error("failed to audit admin action",
+			zap.Error(err),
+			zap.String("action", action),
+			zap.String("target_user_id", targetUserID),
+		)
+	}
+}
+
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	return page, pageSize
+}
+
+// loadTarget busca o usuário alvo da rota e responde 404 se não existir
+func (h *AdminHandler) loadTarget(c *gin.Context) (*models.User, bool) {
+	if _, err := uuid.Parse(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return nil, false
+	}
+
+	user, err := h.userRepo.FindByID(c.Request.Context(), c.Param("id"))
+	if err == repository.ErrUserNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		return nil, false
+	}
+
+	return user, true
+}
+
+// ListUsers busca usuários por email ou nome
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	page, pageSize := pagination(c)
+	term := c.Query("q")
+
+	users, total, err := h.userRepo.Search(c.Request.Context(), term, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list users"})
+		return
+	}
+
+	h.audit(c, "admin.users.search", "", map[string]interface{}{"query": term, "page": page})
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      users,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetUser retorna os detalhes de um usuário com a contagem de sessões ativas
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	user, ok := h.loadTarget(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.sessionRepo.CountForUser(c.Request.Context(), user.ID)
+	if err != nil {
+		h.logger.Error("failed to count sessions", zap.Error(err), zap.String("user_id", user.ID))
+		sessions = -1
+	}
+
+	h.audit(c, "admin.users.view", user.ID, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":            user,
+		"active_sessions": sessions,
+	})
+}
+
+// DisableUser desativa a conta e revoga todas as sessões
+func (h *AdminHandler) DisableUser(c *gin.Context) {
+	var req AdminActionRequest
+	c.ShouldBindJSON(&req)
+
+	user, ok := h.loadTarget(c)
+	if !ok {
+		return
+	}
+
+	if actorID, _ := c.Get("user_id"); actorID == user.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot disable your own account"})
+		return
+	}
+
+	if err := h.userRepo.SetDisabled(c.Request.Context(), user.ID, true); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable user"})
+		return
+	}
+
+	revoked := h.revokeSessions(c, user.ID)
+	h.audit(c, "admin.users.disable", user.ID, map[string]interface{}{
+		"reason":           req.Reason,
+		"sessions_revoked": revoked,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "user disabled", "sessions_revoked": revoked})
+}
+
+// EnableUser reativa uma conta desativada
+func (h *AdminHandler) EnableUser(c *gin.Context) {
+	var req AdminActionRequest
+	c.ShouldBindJSON(&req)
+
+	user, ok := h.loadTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.SetDisabled(c.Request.Context(), user.ID, false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable user"})
+		return
+	}
+
+	h.audit(c, "admin.users.enable", user.ID, map[string]interface{}{"reason": req.Reason})
+
+	c.JSON(http.StatusOK, gin.H{"message": "user enabled"})
+}
+
+// ForcePasswordReset obriga a troca de senha no próximo login e encerra as sessões
+func (h *AdminHandler) ForcePasswordReset(c *gin.Context) {
+	var req AdminActionRequest
+	c.ShouldBindJSON(&req)
+
+	user, ok := h.loadTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.RequirePasswordReset(c.Request.Context(), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to force password reset"})
+		return
+	}
+
+	revoked := h.revokeSessions(c, user.ID)
+	h.audit(c, "admin.users.force_password_reset", user.ID, map[string]interface{}{
+		"reason":           req.Reason,
+		"sessions_revoked": revoked,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset required", "sessions_revoked": revoked})
+}
+
+// RevokeSessions encerra todas as sessões do usuário
+func (h *AdminHandler) RevokeSessions(c *gin.Context) {
+	var req AdminActionRequest
+	c.ShouldBindJSON(&req)
+
+	user, ok := h.loadTarget(c)
+	if !ok {
+		return
+	}
+
+	revoked := h.revokeSessions(c, user.ID)
+	if revoked < 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+		return
+	}
+
+	h.audit(c, "admin.users.revoke_sessions", user.ID, map[string]interface{}{
+		"reason":           req.Reason,
+		"sessions_revoked": revoked,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "sessions revoked", "sessions_revoked": revoked})
+}
+
+func (h *AdminHandler) revokeSessions(c *gin.Context, userID string) int {
+	revoked, err := h.sessionRepo.DeleteAllForUser(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to revoke sessions", zap.Error(err), zap.String("user_id", userID))
+		return -1
+	}
+
+	metrics.ActiveSessions.Sub(float64(revoked))
+	return revoked
+}
+
+// Impersonate emite um token de suporte para agir em nome do usuário.
+// O token é curto, não tem refresh e carrega a claim "act" com o agente; por padrão só permite leitura.
+func (h *AdminHandler) Impersonate(c *gin.Context) {
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := h.loadTarget(c)
+	if !ok {
+		return
+	}
+
+	actorID, _ := c.Get("user_id")
+	actorEmail, _ := c.Get("email")
+
+	if actorID == user.ID || user.Role == models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "cannot impersonate this user"})
+		return
+	}
+
+	if user.IsDisabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "user is disabled"})
+		return
+	}
+
+	scope := "read"
+	if req.AllowWrite {
+		scope = "read write"
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(h.config.ImpersonationTokenTTL) * time.Second)
+	tokenID := uuid.New().String()
+
+	claims := jwt.MapClaims{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"name":    user.Name,
+		"role":    user.Role,
+		"act": map[string]interface{}{
+			"sub":   actorID,
+			"email": actorEmail,
+		},
+		"scope": scope,
+		"jti":   tokenID,
+		"exp":   expiresAt.Unix(),
+		"iat":   now.Unix(),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(h.config.JWTSecret))
+	if err != nil {
+		h.logger.Error("failed to sign impersonation token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	h.audit(c, "admin.users.impersonate", user.ID, map[string]interface{}{
+		"reason":     req.Reason,
+		"scope":      scope,
+		"token_id":   tokenID,
+		"expires_at": expiresAt,
+	})
+
+	h.logger.Warn("impersonation token issued",
+		zap.Any("impersonator_id", actorID),
+		zap.String("user_id", user.ID),
+		zap.String("scope", scope),
+		zap.String("token_id", tokenID),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  token,
+		"expires_in":    h.config.ImpersonationTokenTTL,
+		"scope":         scope,
+		"impersonating": user.ID,
+	})
+}
+
+// ListAuditLogs consulta a trilha de auditoria
+func (h *AdminHandler) ListAuditLogs(c *gin.Context) {
+	page, pageSize := pagination(c)
+	filters := repository.AuditFilters{
+		ActorID:      c.Query("actor_id"),
+		TargetUserID: c.Query("target_user_id"),
+		Action:       c.Query("action"),
+	}
+
+	for _, id := range []string{filters.ActorID, filters.TargetUserID} {
+		if _, err := uuid.Parse(id); id != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+	}
+
+	entries, total, err := h.auditRepo.List(c.Request.Context(), filters, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      entries,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
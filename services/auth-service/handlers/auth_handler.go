@@ -1,16 +1,20 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
+	"strings"
 	"time"
 
 	"auth-service/config"
+	"auth-service/messaging"
 	"auth-service/metrics"
 	"auth-service/models"
 	"auth-service/repository"
+	"auth-service/security"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -18,18 +22,45 @@ import (
 )
 
 type AuthHandler struct {
-	userRepo    *repository.UserRepository
-	redisClient *redis.Client
-	config      *config.Config
-	logger      *zap.Logger
+	userRepo         *repository.UserRepository
+	sessionRepo      *repository.SessionRepository
+	verificationRepo *repository.VerificationRepository
+	deletionRepo     *repository.DeletionRepository
+	publisher        *messaging.EventPublisher
+	redisHealth      *repository.RedisHealth
+	detector         *security.Detector
+	credentials      *credentialChecker
+	config           *config.Config
+	logger           *zap.Logger
 }
 
-func NewAuthHandler(userRepo *repository.UserRepository, redisClient *redis.Client, cfg *config.Config, logger *zap.Logger) *AuthHandler {
+func NewAuthHandler(
+	userRepo *repository.UserRepository,
+	sessionRepo *repository.SessionRepository,
+	verificationRepo *repository.VerificationRepository,
+	deletionRepo *repository.DeletionRepository,
+	publisher *messaging.EventPublisher,
+	redisHealth *repository.RedisHealth,
+	detector *security.Detector,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *AuthHandler {
 	return &AuthHandler{
-		userRepo:    userRepo,
-		redisClient: redisClient,
-		config:      cfg,
-		logger:      logger,
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		verificationRepo: verificationRepo,
+		deletionRepo:     deletionRepo,
+		publisher:        publisher,
+		redisHealth:      redisHealth,
+		detector:         detector,
+		config:           cfg,
+		logger:           logger,
+		credentials: &credentialChecker{
+			userRepo:  userRepo,
+			detector:  detector,
+			publisher: publisher,
+			logger:    logger,
+		},
 	}
 }
 
@@ -42,12 +73,37 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	DeviceID string `json:"device_id"`
 }
 
+type VerifyLoginRequest struct {
+	ChallengeID string `json:"challenge_id" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	Email           string `json:"email" binding:"required,email"`
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+	StepUpRequest
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// TokenResponse é a resposta de login e renovação.
+// Em modo degradado (Redis indisponível) não há refresh token e o access token dura menos.
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 	ExpiresIn    int64  `json:"expires_in"`
+	Degraded     bool   `json:"degraded,omitempty"`
 }
 
 // Register cria um novo usuário
@@ -73,12 +129,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// Todo cadastro nasce com papel de usuário: emails de ADMIN_EMAILS só são
+	// promovidos depois de verificados (ver VerifyEmail)
 	// Cria usuário
 	user := &models.User{
 		ID:           uuid.New().String(),
 		Email:        req.Email,
 		Name:         req.Name,
 		PasswordHash: string(hashedPassword),
+		Role:         models.RoleUser,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
@@ -92,6 +151,21 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	// Atualiza métricas
 	metrics.RegistrationsTotal.Inc()
 
+	// Emite o token de verificação e anuncia o cadastro. Falhas aqui não desfazem o
+	// cadastro: o usuário pode pedir um novo link de verificação depois.
+	token, err := h.issueVerification(c, user)
+	if err == nil {
+		event := messaging.UserRegisteredEvent{
+			UserID:            user.ID,
+			Email:             user.Email,
+			Name:              user.Name,
+			VerificationToken: token,
+		}
+		if err := h.publisher.PublishUserRegistered(c.Request.Context(), event); err != nil {
+			h.logger.Error("failed to publish user registered event", zap.Error(err), zap.String("user_id", user.ID))
+		}
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"id":    user.ID,
 		"email": user.Email,
@@ -116,15 +190,136 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	deviceID := req.DeviceID
+	if deviceID == "" {
+		deviceID = c.GetHeader("X-Device-ID")
+	}
+	device := security.NewDevice(c.Request.UserAgent(), c.ClientIP(), deviceID)
+
 	// Verifica senha
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		h.detector.RecordFailure(c.Request.Context(), user.ID, device)
 		metrics.LoginAttemptsTotal.WithLabelValues("invalid_password").Inc()
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
 
+	// Contas desativadas por um administrador não podem autenticar
+	if user.IsDisabled() {
+		metrics.LoginAttemptsTotal.WithLabelValues("disabled").Inc()
+		c.JSON(http.StatusForbidden, gin.H{"error": "account disabled"})
+		return
+	}
+
+	// Conta com exclusão agendada: a exclusão só pode ser cancelada via /auth/deletion/cancel
+	if h.rejectPendingDeletion(c, user) {
+		metrics.LoginAttemptsTotal.WithLabelValues("deletion_pending").Inc()
+		return
+	}
+
+	// Troca de senha forçada: o usuário precisa definir uma nova senha antes de entrar
+	if user.PasswordResetRequired {
+		metrics.LoginAttemptsTotal.WithLabelValues("password_reset_required").Inc()
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "password reset required",
+			"code":  "password_reset_required",
+		})
+		return
+	}
+
+	// Dispositivo novo, viagem impossível ou rajada de falhas exigem verificação adicional
+	assessment := h.detector.Assess(c.Request.Context(), user.ID, device)
+	if assessment.Suspicious() {
+		h.requireStepUp(c, user, assessment)
+		return
+	}
+
+	h.detector.RecordSuccess(c.Request.Context(), user.ID, device, assessment.Location)
+	h.issueTokens(c, user)
+}
+
+// VerifyLogin conclui um login suspeito com o código enviado ao usuário
+func (h *AuthHandler) VerifyLogin(c *gin.Context) {
+	var req VerifyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	challenge, err := h.detector.VerifyChallenge(c.Request.Context(), req.ChallengeID, req.Code)
+	switch err {
+	case nil:
+	case security.ErrChallengeInvalid, security.ErrChallengeExhausted:
+		metrics.StepUpVerificationsTotal.WithLabelValues("invalid").Inc()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify login"})
+		return
+	}
+
+	user, err := h.userRepo.FindByID(c.Request.Context(), challenge.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	// O estado da conta pode ter mudado enquanto o desafio estava aberto
+	if user.IsDisabled() || user.PasswordResetRequired {
+		c.JSON(http.StatusForbidden, gin.H{"error": "login no longer allowed"})
+		return
+	}
+
+	if h.rejectPendingDeletion(c, user) {
+		return
+	}
+
+	metrics.StepUpVerificationsTotal.WithLabelValues("success").Inc()
+	h.logger.Info("suspicious login verified",
+		zap.String("user_id", user.ID),
+		zap.String("challenge_id", challenge.ID),
+	)
+
+	h.issueTokens(c, user)
+}
+
+// rejectPendingDeletion impede o login enquanto houver um pedido de exclusão da
+// conta: tokens novos permitiriam gravar dados depois que os serviços já apagaram os seus
+func (h *AuthHandler) rejectPendingDeletion(c *gin.Context, user *models.User) bool {
+	_, err := h.deletionRepo.FindActiveByUser(c.Request.Context(), user.ID)
+	if err == repository.ErrDeletionNotFound {
+		return false
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to login"})
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": "account deletion pending",
+		"code":  "account_deletion_pending",
+	})
+	return true
+}
+
+// requireStepUp abre o desafio, avisa o usuário e interrompe o login
+func (h *AuthHandler) requireStepUp(c *gin.Context, user *models.User, assessment *security.Assessment) {
+	if requireStepUp(c, h.detector, h.publisher, h.logger, user, assessment) {
+		metrics.LoginAttemptsTotal.WithLabelValues("step_up_required").Inc()
+	}
+}
+
+// issueTokens emite o par de tokens e abre a sessão no Redis
+func (h *AuthHandler) issueTokens(c *gin.Context, user *models.User) {
+	// Sem Redis não há onde guardar a sessão: emite só um access token curto
+	if !h.redisHealth.Healthy() {
+		h.degradedLogin(c, user)
+		return
+	}
+
 	// Gera tokens
-	accessToken, err := h.generateAccessToken(user)
+	accessToken, err := h.generateAccessToken(user, h.config.JWTExpiration)
 	if err != nil {
 		h.logger.Error("failed to generate access token", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
@@ -134,9 +329,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	refreshToken := uuid.New().String()
 
 	// Armazena refresh token no Redis
-	if err := h.redisClient.Set(c.Request.Context(), "refresh:"+refreshToken, user.ID, 7*24*time.Hour).Err(); err != nil {
+	if err := h.sessionRepo.Create(c.Request.Context(), refreshToken, user.ID, 7*24*time.Hour); err != nil {
 		h.logger.Error("failed to store refresh token", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store session"})
+		h.redisHealth.ReportFailure(err)
+		h.degradedLogin(c, user)
 		return
 	}
 
@@ -150,6 +346,25 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
+// degradedLogin conclui o login sem sessão no Redis
+func (h *AuthHandler) degradedLogin(c *gin.Context, user *models.User) {
+	accessToken, err := h.generateAccessToken(user, h.config.DegradedJWTExpiration)
+	if err != nil {
+		h.logger.Error("failed to generate access token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	metrics.LoginAttemptsTotal.WithLabelValues("degraded").Inc()
+	h.logger.Warn("login in degraded mode", zap.String("user_id", user.ID))
+
+	c.JSON(http.StatusOK, TokenResponse{
+		AccessToken: accessToken,
+		ExpiresIn:   h.config.DegradedJWTExpiration,
+		Degraded:    true,
+	})
+}
+
 // RefreshToken renova o access token
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	var req struct {
@@ -162,8 +377,28 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
+	// A renovação depende do Redis e fica desativada em modo degradado
+	if !h.redisHealth.Healthy() {
+		metrics.TokenRefreshTotal.WithLabelValues("degraded").Inc()
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "token refresh temporarily unavailable",
+			"code":  "degraded_mode",
+		})
+		return
+	}
+
 	// Busca user_id no Redis
-	userID, err := h.redisClient.Get(c.Request.Context(), "refresh:"+req.RefreshToken).Result()
+	userID, err := h.sessionRepo.Get(c.Request.Context(), req.RefreshToken)
+	if err != nil && err != repository.ErrSessionNotFound {
+		h.redisHealth.ReportFailure(err)
+		metrics.TokenRefreshTotal.WithLabelValues("degraded").Inc()
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "token refresh temporarily unavailable",
+			"code":  "degraded_mode",
+		})
+		return
+	}
+
 	if err != nil {
 		metrics.TokenRefreshTotal.WithLabelValues("invalid_token").Inc()
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
@@ -178,8 +413,15 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
+	if user.IsDisabled() || user.PasswordResetRequired {
+		h.sessionRepo.Delete(c.Request.Context(), req.RefreshToken)
+		metrics.TokenRefreshTotal.WithLabelValues("revoked").Inc()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+		return
+	}
+
 	// Gera novo access token
-	accessToken, err := h.generateAccessToken(user)
+	accessToken, err := h.generateAccessToken(user, h.config.JWTExpiration)
 	if err != nil {
 		h.logger.Error("failed to generate access token", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
@@ -195,6 +437,203 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	})
 }
 
+// ChangePassword troca a senha a partir da senha atual e encerra as sessões existentes.
+// É pública para atender usuários com troca de senha forçada, que não conseguem fazer login.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Falhas contam para a rajada do login e acessos suspeitos exigem o código de verificação
+	user, ok := h.credentials.check(c, req.Email, req.CurrentPassword, req.StepUpRequest)
+	if !ok {
+		return
+	}
+
+	if user.IsDisabled() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "account disabled"})
+		return
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new password must be different from the current one"})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		h.logger.Error("failed to hash password", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	if err := h.userRepo.UpdatePassword(c.Request.Context(), user.ID, string(hashedPassword)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+		return
+	}
+
+	revoked, err := h.sessionRepo.DeleteAllForUser(c.Request.Context(), user.ID)
+	if err != nil {
+		h.logger.Error("failed to revoke sessions after password change", zap.Error(err), zap.String("user_id", user.ID))
+	}
+	metrics.ActiveSessions.Sub(float64(revoked))
+
+	c.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
+}
+
+// VerifyEmail confirma o email a partir do token enviado por email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, email, err := h.verificationRepo.Consume(c.Request.Context(), req.Token)
+	if err == repository.ErrVerificationNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification token"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+		return
+	}
+
+	// Quem controla um email de ADMIN_EMAILS só vira administrador após provar isso
+	if _, err := h.userRepo.PromoteAdmins(c.Request.Context(), h.config.AdminEmails); err != nil {
+		h.logger.Error("failed to promote configured admins", zap.Error(err), zap.String("user_id", userID))
+	}
+
+	event := messaging.UserVerifiedEvent{UserID: userID, Email: email}
+	if err := h.publisher.PublishUserVerified(c.Request.Context(), event); err != nil {
+		h.logger.Error("failed to publish user verified event", zap.Error(err), zap.String("user_id", userID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified successfully"})
+}
+
+// ResendVerification emite um novo link de verificação para o email atual
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	user, err := h.userRepo.FindByID(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if user.IsVerified() {
+		c.JSON(http.StatusConflict, gin.H{"error": "email already verified"})
+		return
+	}
+
+	token, err := h.issueVerification(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue verification"})
+		return
+	}
+
+	// Evento próprio: reenviar user.registered repetiria o cadastro para quem o consome
+	event := messaging.UserVerificationRequestedEvent{
+		UserID:            user.ID,
+		Email:             user.Email,
+		Name:              user.Name,
+		VerificationToken: token,
+	}
+	if err := h.publisher.PublishUserVerificationRequested(c.Request.Context(), event); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to send verification"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification email sent"})
+}
+
+// ChangeEmail troca o email do usuário autenticado e exige nova verificação
+func (h *AuthHandler) ChangeEmail(c *gin.Context) {
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	user, err := h.userRepo.FindByID(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	if strings.EqualFold(user.Email, req.NewEmail) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new email must be different from the current one"})
+		return
+	}
+
+	oldEmail := user.Email
+	if err := h.userRepo.ChangeEmail(c.Request.Context(), user.ID, req.NewEmail); err != nil {
+		if err == repository.ErrUserAlreadyExists {
+			c.JSON(http.StatusConflict, gin.H{"error": "email already in use"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change email"})
+		return
+	}
+	user.Email = req.NewEmail
+
+	// ChangeEmail retira o papel de administrador; as sessões abertas carregariam o
+	// papel antigo nos tokens renovados
+	if user.Role == models.RoleAdmin {
+		if revoked, err := h.sessionRepo.DeleteAllForUser(c.Request.Context(), user.ID); err == nil {
+			metrics.ActiveSessions.Sub(float64(revoked))
+		}
+		h.logger.Warn("admin role removed after email change", zap.String("user_id", user.ID))
+		user.Role = models.RoleUser
+	}
+
+	token, _ := h.issueVerification(c, user)
+	event := messaging.UserEmailChangedEvent{
+		UserID:            user.ID,
+		OldEmail:          oldEmail,
+		NewEmail:          user.Email,
+		Name:              user.Name,
+		VerificationToken: token,
+	}
+	if err := h.publisher.PublishUserEmailChanged(c.Request.Context(), event); err != nil {
+		h.logger.Error("failed to publish email changed event", zap.Error(err), zap.String("user_id", user.ID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":             user.ID,
+		"email":          user.Email,
+		"email_verified": false,
+	})
+}
+
+// issueVerification gera e persiste um token de verificação para o email atual do usuário
+func (h *AuthHandler) issueVerification(c *gin.Context, user *models.User) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		h.logger.Error("failed to generate verification token", zap.Error(err))
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	ttl := time.Duration(h.config.EmailVerificationTTL) * time.Second
+	if err := h.verificationRepo.Create(c.Request.Context(), user.ID, user.Email, token, ttl); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
 // Me retorna informações do usuário autenticado
 func (h *AuthHandler) Me(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -210,10 +649,12 @@ func (h *AuthHandler) Me(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"id":         user.ID,
-		"email":      user.Email,
-		"name":       user.Name,
-		"created_at": user.CreatedAt,
+		"id":             user.ID,
+		"email":          user.Email,
+		"name":           user.Name,
+		"role":           user.Role,
+		"email_verified": user.IsVerified(),
+		"created_at":     user.CreatedAt,
 	})
 }
 
@@ -229,7 +670,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	}
 
 	// Remove refresh token do Redis
-	if err := h.redisClient.Del(c.Request.Context(), "refresh:"+req.RefreshToken).Err(); err != nil {
+	if err := h.sessionRepo.Delete(c.Request.Context(), req.RefreshToken); err != nil && err != repository.ErrSessionNotFound {
 		h.logger.Error("failed to delete refresh token", zap.Error(err))
 	}
 
@@ -238,12 +679,13 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
 }
 
-func (h *AuthHandler) generateAccessToken(user *models.User) (string, error) {
+func (h *AuthHandler) generateAccessToken(user *models.User, expiration int64) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
 		"name":    user.Name,
-		"exp":     time.Now().Add(time.Duration(h.config.JWTExpiration) * time.Second).Unix(),
+		"role":    user.Role,
+		"exp":     time.Now().Add(time.Duration(expiration) * time.Second).Unix(),
 		"iat":     time.Now().Unix(),
 	}
 
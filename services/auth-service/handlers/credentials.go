@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"auth-service/messaging"
+	"auth-service/models"
+	"auth-service/repository"
+	"auth-service/security"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// StepUpRequest são os campos opcionais das rotas públicas que aceitam email e senha.
+// Quando a operação exige verificação adicional, o cliente a repete com o desafio e o código recebidos.
+type StepUpRequest struct {
+	DeviceID    string `json:"device_id"`
+	ChallengeID string `json:"challenge_id"`
+	Code        string `json:"code"`
+}
+
+// credentialChecker confere email e senha fora do login com as mesmas proteções dele:
+// senhas incorretas contam para a rajada de falhas e acessos suspeitos exigem o código
+// de verificação adicional. Sem isso essas rotas serviriam para testar senhas à vontade.
+type credentialChecker struct {
+	userRepo  *repository.UserRepository
+	detector  *security.Detector
+	publisher *messaging.EventPublisher
+	logger    *zap.Logger
+}
+
+// check retorna o usuário quando as credenciais são aceitas. Caso contrário a resposta já foi enviada.
+func (v *credentialChecker) check(c *gin.Context, email, password string, stepUp StepUpRequest) (*models.User, bool) {
+	user, err := v.userRepo.FindByEmail(c.Request.Context(), email)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return nil, false
+	}
+
+	deviceID := stepUp.DeviceID
+	if deviceID == "" {
+		deviceID = c.GetHeader("X-Device-ID")
+	}
+	device := security.NewDevice(c.Request.UserAgent(), c.ClientIP(), deviceID)
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		v.detector.RecordFailure(c.Request.Context(), user.ID, device)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return nil, false
+	}
+
+	// Segunda etapa: o código do desafio aberto na primeira chamada
+	if stepUp.ChallengeID != "" {
+		challenge, err := v.detector.VerifyChallenge(c.Request.Context(), stepUp.ChallengeID, stepUp.Code)
+		switch {
+		case err == security.ErrChallengeInvalid || err == security.ErrChallengeExhausted:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return nil, false
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify code"})
+			return nil, false
+		case challenge.UserID != user.ID:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": security.ErrChallengeInvalid.Error()})
+			return nil, false
+		}
+		return user, true
+	}
+
+	assessment := v.detector.Assess(c.Request.Context(), user.ID, device)
+	if assessment.Suspicious() {
+		requireStepUp(c, v.detector, v.publisher, v.logger, user, assessment)
+		return nil, false
+	}
+
+	v.detector.RecordSuccess(c.Request.Context(), user.ID, device, assessment.Location)
+	return user, true
+}
+
+// requireStepUp abre o desafio, envia o código ao usuário e responde 401 step_up_required.
+// Retorna false se o desafio não pôde ser aberto ou o código não pôde ser enviado.
+func requireStepUp(c *gin.Context, detector *security.Detector, publisher *messaging.EventPublisher, logger *zap.Logger, user *models.User, assessment *security.Assessment) bool {
+	challenge, code, err := detector.CreateChallenge(c.Request.Context(), user.ID, assessment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start verification"})
+		return false
+	}
+
+	event := messaging.SuspiciousLoginEvent{
+		UserID:      user.ID,
+		Email:       user.Email,
+		Name:        user.Name,
+		ChallengeID: challenge.ID,
+		Code:        code,
+		Reasons:     challenge.Reasons,
+		IP:          challenge.IP,
+		Country:     challenge.Country,
+		UserAgent:   challenge.UserAgent,
+		ExpiresAt:   challenge.ExpiresAt,
+	}
+
+	// Sem o evento o usuário não recebe o código e não conseguiria concluir a operação
+	if err := publisher.PublishSuspiciousLogin(c.Request.Context(), event); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to send verification code"})
+		return false
+	}
+
+	logger.Warn("suspicious login",
+		zap.String("user_id", user.ID),
+		zap.Strings("reasons", challenge.Reasons),
+		zap.String("ip", challenge.IP),
+		zap.String("path", c.FullPath()),
+		zap.String("challenge_id", challenge.ID),
+	)
+
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"error":        "additional verification required",
+		"code":         "step_up_required",
+		"challenge_id": challenge.ID,
+		"expires_in":   int64(time.Until(challenge.ExpiresAt).Seconds()),
+	})
+	return true
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-service/deletion"
+	"auth-service/messaging"
+	"auth-service/repository"
+	"auth-service/security"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type DeletionHandler struct {
+	saga        *deletion.Saga
+	userRepo    *repository.UserRepository
+	credentials *credentialChecker
+	logger      *zap.Logger
+}
+
+func NewDeletionHandler(
+	saga *deletion.Saga,
+	userRepo *repository.UserRepository,
+	detector *security.Detector,
+	publisher *messaging.EventPublisher,
+	logger *zap.Logger,
+) *DeletionHandler {
+	return &DeletionHandler{
+		saga:     saga,
+		userRepo: userRepo,
+		credentials: &credentialChecker{
+			userRepo:  userRepo,
+			detector:  detector,
+			publisher: publisher,
+			logger:    logger,
+		},
+		logger: logger,
+	}
+}
+
+type DeletionRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type CancelDeletionRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+	StepUpRequest
+}
+
+// RequestDeletion agenda a exclusão da conta do usuário autenticado
+func (h *DeletionHandler) RequestDeletion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req DeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userRepo.FindByID(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	// Confirma a identidade antes de uma operação irreversível
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	deletionReq, err := h.saga.Request(c.Request.Context(), user.ID)
+	if err == repository.ErrDeletionAlreadyRequested {
+		c.JSON(http.StatusConflict, gin.H{"error": "account deletion already requested"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to request account deletion"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, deletionReq)
+}
+
+// GetDeletion retorna o andamento da exclusão da conta
+func (h *DeletionHandler) GetDeletion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	deletionReq, err := h.saga.Status(c.Request.Context(), userID.(string))
+	if err == repository.ErrDeletionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no pending account deletion"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account deletion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"request": deletionReq,
+		"pending": deletionReq.Pending(),
+	})
+}
+
+// CancelDeletion cancela a exclusão durante o período de carência
+func (h *DeletionHandler) CancelDeletion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	err := h.saga.Cancel(c.Request.Context(), userID.(string))
+	if err == repository.ErrDeletionNotFound {
+		c.JSON(http.StatusConflict, gin.H{"error": "no account deletion in grace period"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel account deletion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account deletion cancelled"})
+}
+
+// CancelDeletionWithCredentials cancela a exclusão a partir de email e senha.
+// É pública porque o login fica bloqueado enquanto a exclusão estiver agendada, mas passa
+// pelas mesmas proteções do login: falhas contam para a rajada e acessos suspeitos exigem código.
+func (h *DeletionHandler) CancelDeletionWithCredentials(c *gin.Context) {
+	var req CancelDeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := h.credentials.check(c, req.Email, req.Password, req.StepUpRequest)
+	if !ok {
+		return
+	}
+
+	err := h.saga.Cancel(c.Request.Context(), user.ID)
+	if err == repository.ErrDeletionNotFound {
+		c.JSON(http.StatusConflict, gin.H{"error": "no account deletion in grace period"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel account deletion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account deletion cancelled"})
+}
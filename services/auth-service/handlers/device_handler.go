@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"auth-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type DeviceHandler struct {
+	securityRepo *repository.LoginSecurityRepository
+	logger       *zap.Logger
+}
+
+func NewDeviceHandler(securityRepo *repository.LoginSecurityRepository, logger *zap.Logger) *DeviceHandler {
+	return &DeviceHandler{
+		securityRepo: securityRepo,
+		logger:       logger,
+	}
+}
+
+// ListDevices lista os dispositivos conhecidos do usuário autenticado
+func (h *DeviceHandler) ListDevices(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	devices, err := h.securityRepo.ListDevices(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list devices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": devices})
+}
+
+// DeleteDevice esquece um dispositivo conhecido
+func (h *DeviceHandler) DeleteDevice(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "device not found"})
+		return
+	}
+
+	err := h.securityRepo.DeleteDevice(c.Request.Context(), userID.(string), id)
+	if err == repository.ErrDeviceNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "device not found"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete device"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "device removed"})
+}
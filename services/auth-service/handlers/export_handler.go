@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"auth-service/config"
+	"auth-service/export"
+	"auth-service/models"
+	"auth-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ExportHandler struct {
+	exportRepo *repository.ExportRepository
+	worker     *export.Worker
+	config     *config.Config
+	logger     *zap.Logger
+}
+
+func NewExportHandler(exportRepo *repository.ExportRepository, worker *export.Worker, cfg *config.Config, logger *zap.Logger) *ExportHandler {
+	return &ExportHandler{
+		exportRepo: exportRepo,
+		worker:     worker,
+		config:     cfg,
+		logger:     logger,
+	}
+}
+
+type ExportResponse struct {
+	*models.DataExportJob
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+func (h *ExportHandler) toResponse(job *models.DataExportJob) ExportResponse {
+	resp := ExportResponse{DataExportJob: job}
+	if job.Status == models.ExportStatusCompleted {
+		resp.DownloadURL = "/api/v1/me/exports/" + job.ID + "/download"
+	}
+	return resp
+}
+
+// RequestExport cria um pedido assíncrono de exportação dos dados do usuário
+func (h *ExportHandler) RequestExport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// Reaproveita um pedido ainda em andamento
+	active, err := h.exportRepo.FindActiveByUser(c.Request.Context(), userID.(string))
+	if err == nil {
+		c.JSON(http.StatusAccepted, h.toResponse(active))
+		return
+	}
+	if err != repository.ErrExportNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to request export"})
+		return
+	}
+
+	job := &models.DataExportJob{
+		ID:        uuid.New().String(),
+		UserID:    userID.(string),
+		Status:    models.ExportStatusPending,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := h.exportRepo.Create(c.Request.Context(), job); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to request export"})
+		return
+	}
+
+	h.worker.Enqueue(job.ID)
+
+	h.logger.Info("data export requested",
+		zap.String("export_id", job.ID),
+		zap.String("user_id", job.UserID),
+	)
+
+	c.JSON(http.StatusAccepted, h.toResponse(job))
+}
+
+// ListExports lista os pedidos de exportação do usuário
+func (h *ExportHandler) ListExports(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	jobs, err := h.exportRepo.ListByUser(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list exports"})
+		return
+	}
+
+	data := make([]ExportResponse, 0, len(jobs))
+	for _, job := range jobs {
+		data = append(data, h.toResponse(job))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// GetExport retorna o status de um pedido de exportação
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	job, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toResponse(job))
+}
+
+// Download entrega o arquivo ZIP ao dono da exportação enquanto ela não expirar.
+// Exige o header Authorization: nenhum segredo vai na URL, que acaba em logs de
+// proxies e no histórico do navegador.
+func (h *ExportHandler) Download(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	job, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	if job.Status != models.ExportStatusCompleted || job.ExpiresAt == nil || time.Now().After(*job.ExpiresAt) {
+		c.JSON(http.StatusGone, gin.H{"error": "download link expired"})
+		return
+	}
+
+	h.logger.Info("data export downloaded",
+		zap.String("export_id", job.ID),
+		zap.String("user_id", job.UserID),
+		zap.String("ip", c.ClientIP()),
+	)
+
+	c.FileAttachment(job.FilePath, "personal-data-"+job.ID+".zip")
+}
+
+// find busca a exportação do usuário; ids malformados e exportações de outros
+// usuários respondem 404. Em caso de erro, a resposta já foi enviada.
+func (h *ExportHandler) find(c *gin.Context, userID string) (*models.DataExportJob, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "export not found"})
+		return nil, false
+	}
+
+	job, err := h.exportRepo.FindByID(c.Request.Context(), id)
+	if err == repository.ErrExportNotFound || (err == nil && job.UserID != userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "export not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get export", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get export"})
+		return nil, false
+	}
+
+	return job, true
+}
@@ -18,9 +18,14 @@ import (
 	"go.uber.org/zap"
 
 	"auth-service/config"
+	"auth-service/deletion"
+	"auth-service/export"
 	"auth-service/handlers"
+	"auth-service/messaging"
 	"auth-service/middleware"
+	"auth-service/models"
 	"auth-service/repository"
+	"auth-service/security"
 )
 
 var (
@@ -54,18 +59,114 @@ func main() {
 	}
 	defer db.Close()
 
-	// Conecta ao Redis
-	redisClient := repository.NewRedisClient(cfg.RedisURL)
+	// Conecta ao Redis (instância única, Sentinel ou Cluster conforme REDIS_URL)
+	redisClient, err := repository.NewRedisClient(cfg.RedisURL)
+	if err != nil {
+		logger.Fatal("invalid redis configuration", zap.Error(err))
+	}
 	defer redisClient.Close()
 
+	// Sem Redis o serviço sobe em modo degradado em vez de falhar
+	err = repository.ConnectRedis(context.Background(), redisClient, int(cfg.RedisConnectRetries), logger)
+	if err != nil {
+		logger.Warn("redis unavailable, starting in degraded mode", zap.Error(err))
+	}
+	redisHealth := repository.NewRedisHealth(redisClient, err == nil, logger)
+
+	// Inicializa RabbitMQ
+	rabbitmq, err := messaging.NewRabbitMQ(cfg.RabbitMQURL, logger)
+	if err != nil {
+		logger.Fatal("failed to connect to rabbitmq", zap.Error(err))
+	}
+	defer rabbitmq.Close()
+
+	// Inicializa publisher
+	publisher := messaging.NewEventPublisher(rabbitmq, logger)
+
 	// Inicializa repositórios
 	userRepo := repository.NewUserRepository(db, logger)
+	sessionRepo := repository.NewSessionRepository(redisClient, logger)
+	exportRepo := repository.NewExportRepository(db, logger)
+	deletionRepo := repository.NewDeletionRepository(db, logger)
+	auditRepo := repository.NewAuditRepository(db, logger)
+	verificationRepo := repository.NewVerificationRepository(db, logger)
+	loginSecurityRepo := repository.NewLoginSecurityRepository(db, logger)
+
+	// Garante o papel de administrador para os emails configurados
+	if promoted, err := userRepo.PromoteAdmins(context.Background(), cfg.AdminEmails); err != nil {
+		logger.Error("failed to promote configured admins", zap.Error(err))
+	} else if promoted > 0 {
+		logger.Info("configured admins promoted", zap.Int64("count", promoted))
+	}
+
+	// Inicializa worker de exportação de dados pessoais
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	redisHealth.Start(workerCtx, time.Duration(cfg.RedisHealthInterval)*time.Second)
+
+	exportWorker := export.NewWorker(
+		exportRepo,
+		userRepo,
+		[]export.Contributor{
+			export.NewUserContributor(loginSecurityRepo),
+			export.NewServiceContributor("transaction-service", cfg.TransactionServiceURL+"/api/v1/privacy/export", cfg.JWTSecret),
+		},
+		cfg.ExportDir,
+		time.Duration(cfg.ExportLinkTTL)*time.Second,
+		logger,
+	)
+	if err := exportWorker.Start(workerCtx, int(cfg.ExportWorkers)); err != nil {
+		logger.Fatal("failed to start export worker", zap.Error(err))
+	}
+
+	// Inicializa a saga de exclusão de contas
+	deletionSaga := deletion.NewSaga(
+		deletionRepo,
+		userRepo,
+		sessionRepo,
+		exportRepo,
+		publisher,
+		cfg.DeletionParticipants,
+		time.Duration(cfg.DeletionGracePeriod)*time.Second,
+		time.Duration(cfg.DeletionRetryInterval)*time.Second,
+		logger,
+	)
+	if err := deletionSaga.Start(workerCtx, rabbitmq); err != nil {
+		logger.Fatal("failed to start account deletion saga", zap.Error(err))
+	}
+
+	// Banco GeoIP offline para detectar viagens impossíveis (opcional)
+	var geoip *security.GeoIP
+	if cfg.GeoIPDatabasePath != "" {
+		if geoip, err = security.OpenGeoIP(cfg.GeoIPDatabasePath); err != nil {
+			logger.Warn("failed to open geoip database, impossible travel detection disabled",
+				zap.Error(err),
+				zap.String("path", cfg.GeoIPDatabasePath),
+			)
+		}
+	}
+	defer geoip.Close()
+
+	// Inicializa a detecção de logins suspeitos
+	detector := security.NewDetector(loginSecurityRepo, geoip, security.Thresholds{
+		FailureBurst:         int(cfg.LoginFailureBurst),
+		FailureBurstWindow:   time.Duration(cfg.LoginFailureWindow) * time.Second,
+		MaxTravelSpeedKmh:    float64(cfg.MaxTravelSpeedKmh),
+		MinTravelDistanceKm:  float64(cfg.MinTravelDistanceKm),
+		ChallengeTTL:         time.Duration(cfg.StepUpCodeTTL) * time.Second,
+		ChallengeMaxAttempts: int(cfg.StepUpMaxAttempts),
+	}, logger)
 
 	// Inicializa handlers
-	authHandler := handlers.NewAuthHandler(userRepo, redisClient, cfg, logger)
+	authHandler := handlers.NewAuthHandler(userRepo, sessionRepo, verificationRepo, deletionRepo, publisher, redisHealth, detector, cfg, logger)
+	exportHandler := handlers.NewExportHandler(exportRepo, exportWorker, cfg, logger)
+	deletionHandler := handlers.NewDeletionHandler(deletionSaga, userRepo, detector, publisher, logger)
+	adminHandler := handlers.NewAdminHandler(userRepo, sessionRepo, auditRepo, cfg, logger)
+	deviceHandler := handlers.NewDeviceHandler(loginSecurityRepo, logger)
 
 	// Configura o router
-	router := setupRouter(authHandler)
+	router := setupRouter(redisHealth, authHandler, exportHandler, deletionHandler, adminHandler, deviceHandler)
 
 	// Configura servidor HTTP
 	srv := &http.Server{
@@ -93,6 +194,7 @@ func main() {
 	<-quit
 
 	logger.Info("shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -104,7 +206,14 @@ func main() {
 	logger.Info("server exited successfully")
 }
 
-func setupRouter(authHandler *handlers.AuthHandler) *gin.Engine {
+func setupRouter(
+	redisHealth *repository.RedisHealth,
+	authHandler *handlers.AuthHandler,
+	exportHandler *handlers.ExportHandler,
+	deletionHandler *handlers.DeletionHandler,
+	adminHandler *handlers.AdminHandler,
+	deviceHandler *handlers.DeviceHandler,
+) *gin.Engine {
 	// Modo release em produção
 	if os.Getenv("ENVIRONMENT") == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -121,9 +230,15 @@ func setupRouter(authHandler *handlers.AuthHandler) *gin.Engine {
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
+		status, redisStatus := "healthy", "up"
+		if !redisHealth.Healthy() {
+			status, redisStatus = "degraded", "down"
+		}
+
 		c.JSON(http.StatusOK, gin.H{
-			"status":  "healthy",
+			"status":  status,
 			"service": "auth-service",
+			"redis":   redisStatus,
 			"time":    time.Now().Unix(),
 		})
 	})
@@ -139,15 +254,60 @@ func setupRouter(authHandler *handlers.AuthHandler) *gin.Engine {
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/verify", authHandler.VerifyLogin)
 			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/password/change", authHandler.ChangePassword)
+			auth.POST("/verify-email", authHandler.VerifyEmail)
+			auth.POST("/deletion/cancel", deletionHandler.CancelDeletionWithCredentials)
 		}
 
 		// Rotas protegidas
 		protected := v1.Group("/")
-		protected.Use(middleware.AuthMiddleware(logger))
+		protected.Use(middleware.AuthMiddleware(logger), middleware.ImpersonationGuard(logger))
 		{
 			protected.GET("/me", authHandler.Me)
-			protected.POST("/logout", authHandler.Logout)
+			protected.GET("/me/devices", deviceHandler.ListDevices)
+			protected.GET("/me/exports", exportHandler.ListExports)
+			protected.GET("/me/exports/:id", exportHandler.GetExport)
+			protected.GET("/me/deletion", deletionHandler.GetDeletion)
+		}
+
+		// Ações sobre a própria conta: nunca disponíveis para tokens de suporte
+		account := v1.Group("/")
+		account.Use(middleware.AuthMiddleware(logger), middleware.DenyImpersonation(logger))
+		{
+			account.POST("/logout", authHandler.Logout)
+			account.PUT("/me/email", authHandler.ChangeEmail)
+			account.POST("/me/email/verification", authHandler.ResendVerification)
+
+			// Dispositivos conhecidos
+			account.DELETE("/me/devices/:id", deviceHandler.DeleteDevice)
+
+			// Exportação de dados pessoais (LGPD/GDPR)
+			account.POST("/me/exports", exportHandler.RequestExport)
+			account.GET("/me/exports/:id/download", exportHandler.Download)
+
+			// Exclusão de conta com período de carência
+			account.POST("/me/deletion", deletionHandler.RequestDeletion)
+			account.DELETE("/me/deletion", deletionHandler.CancelDeletion)
+		}
+
+		// Rotas administrativas
+		admin := v1.Group("/admin")
+		admin.Use(
+			middleware.AuthMiddleware(logger),
+			middleware.DenyImpersonation(logger),
+			middleware.RequireRole(models.RoleAdmin, logger),
+		)
+		{
+			admin.GET("/users", adminHandler.ListUsers)
+			admin.GET("/users/:id", adminHandler.GetUser)
+			admin.POST("/users/:id/disable", adminHandler.DisableUser)
+			admin.POST("/users/:id/enable", adminHandler.EnableUser)
+			admin.POST("/users/:id/force-password-reset", adminHandler.ForcePasswordReset)
+			admin.POST("/users/:id/revoke-sessions", adminHandler.RevokeSessions)
+			admin.POST("/users/:id/impersonate", adminHandler.Impersonate)
+			admin.GET("/audit-logs", adminHandler.ListAuditLogs)
 		}
 	}
 
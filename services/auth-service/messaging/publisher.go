@@ -0,0 +1,354 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"auth-service/metrics"
+)
+
+const (
+	ExchangeName              = "users_exchange"
+	ExchangeType              = "topic"
+	UserRegistered            = "user.registered"
+	UserVerified              = "user.verified"
+	UserVerificationRequested = "user.verification_requested"
+	UserEmailChanged          = "user.email_changed"
+	UserDeleted               = "user.deleted"
+	UserDeletionRequested     = "user.deletion_requested"
+	UserDeletionAcknowledged  = "user.deletion_acknowledged"
+	SecuritySuspiciousLogin   = "security.suspicious_login"
+)
+
+type RabbitMQ struct {
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	logger  *zap.Logger
+}
+
+type EventPublisher struct {
+	rabbitmq *RabbitMQ
+	logger   *zap.Logger
+	tracer   trace.Tracer
+}
+
+// UserRegisteredEvent anuncia um novo cadastro. O token de verificação permite que o
+// notification-service envie o link de confirmação junto com o email de boas-vindas.
+type UserRegisteredEvent struct {
+	UserID            string    `json:"user_id"`
+	Email             string    `json:"email"`
+	Name              string    `json:"name"`
+	VerificationToken string    `json:"verification_token"`
+	TraceID           string    `json:"trace_id"`
+	SpanID            string    `json:"span_id"`
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+// UserVerificationRequestedEvent pede o reenvio do link de verificação do email atual
+type UserVerificationRequestedEvent struct {
+	UserID            string    `json:"user_id"`
+	Email             string    `json:"email"`
+	Name              string    `json:"name"`
+	VerificationToken string    `json:"verification_token"`
+	TraceID           string    `json:"trace_id"`
+	SpanID            string    `json:"span_id"`
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+// UserVerifiedEvent anuncia que o usuário confirmou o email
+type UserVerifiedEvent struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	TraceID   string    `json:"trace_id"`
+	SpanID    string    `json:"span_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// UserEmailChangedEvent anuncia a troca de email; o novo endereço precisa ser verificado
+type UserEmailChangedEvent struct {
+	UserID            string    `json:"user_id"`
+	OldEmail          string    `json:"old_email"`
+	NewEmail          string    `json:"new_email"`
+	Name              string    `json:"name"`
+	VerificationToken string    `json:"verification_token"`
+	TraceID           string    `json:"trace_id"`
+	SpanID            string    `json:"span_id"`
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+// UserDeletedEvent anuncia que a conta foi removida definitivamente
+type UserDeletedEvent struct {
+	UserID            string    `json:"user_id"`
+	DeletionRequestID string    `json:"deletion_request_id"`
+	TraceID           string    `json:"trace_id"`
+	SpanID            string    `json:"span_id"`
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+// SuspiciousLoginEvent avisa o usuário de um login suspeito e leva o código de verificação adicional
+type SuspiciousLoginEvent struct {
+	UserID      string    `json:"user_id"`
+	Email       string    `json:"email"`
+	Name        string    `json:"name"`
+	ChallengeID string    `json:"challenge_id"`
+	Code        string    `json:"code"`
+	Reasons     []string  `json:"reasons"`
+	IP          string    `json:"ip"`
+	Country     string    `json:"country,omitempty"`
+	UserAgent   string    `json:"user_agent"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	TraceID     string    `json:"trace_id"`
+	SpanID      string    `json:"span_id"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// UserDeletionRequestedEvent pede que cada serviço apague os dados do usuário
+type UserDeletionRequestedEvent struct {
+	RequestID    string    `json:"request_id"`
+	UserID       string    `json:"user_id"`
+	Participants []string  `json:"participants"`
+	TraceID      string    `json:"trace_id"`
+	SpanID       string    `json:"span_id"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// UserDeletionAcknowledgedEvent é a confirmação enviada por um serviço participante
+type UserDeletionAcknowledgedEvent struct {
+	RequestID string                 `json:"request_id"`
+	UserID    string                 `json:"user_id"`
+	Service   string                 `json:"service"`
+	Details   map[string]interface{} `json:"details"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// NewRabbitMQ cria uma nova conexão com RabbitMQ
+func NewRabbitMQ(url string, logger *zap.Logger) (*RabbitMQ, error) {
+	// Conecta ao RabbitMQ
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to rabbitmq: %w", err)
+	}
+
+	// Cria um canal
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+
+	// Declara o exchange
+	err = channel.ExchangeDeclare(
+		ExchangeName, // name
+		ExchangeType, // type
+		true,         // durable
+		false,        // auto-deleted
+		false,        // internal
+		false,        // no-wait
+		nil,          // arguments
+	)
+	if err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
+	logger.Info("connected to rabbitmq",
+		zap.String("exchange", ExchangeName),
+		zap.String("type", ExchangeType),
+	)
+
+	return &RabbitMQ{
+		conn:    conn,
+		channel: channel,
+		logger:  logger,
+	}, nil
+}
+
+// Close fecha a conexão com RabbitMQ
+func (r *RabbitMQ) Close() error {
+	if r.channel != nil {
+		if err := r.channel.Close(); err != nil {
+			r.logger.Error("error closing channel", zap.Error(err))
+		}
+	}
+	if r.conn != nil {
+		if err := r.conn.Close(); err != nil {
+			r.logger.Error("error closing connection", zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// NewEventPublisher cria um novo publisher de eventos
+func NewEventPublisher(rabbitmq *RabbitMQ, logger *zap.Logger) *EventPublisher {
+	return &EventPublisher{
+		rabbitmq: rabbitmq,
+		logger:   logger,
+		tracer:   otel.Tracer("auth-service"),
+	}
+}
+
+// PublishUserRegistered publica o cadastro de um novo usuário
+func (p *EventPublisher) PublishUserRegistered(ctx context.Context, event UserRegisteredEvent) error {
+	ctx, span := p.tracer.Start(ctx, "PublishUserRegistered")
+	defer span.End()
+
+	event.TraceID = span.SpanContext().TraceID().String()
+	event.SpanID = span.SpanContext().SpanID().String()
+	event.Timestamp = time.Now()
+
+	span.SetAttributes(attribute.String("user.id", event.UserID))
+
+	return p.publish(ctx, UserRegistered, event, event.TraceID, event.SpanID)
+}
+
+// PublishUserVerificationRequested publica o pedido de reenvio do link de verificação
+func (p *EventPublisher) PublishUserVerificationRequested(ctx context.Context, event UserVerificationRequestedEvent) error {
+	ctx, span := p.tracer.Start(ctx, "PublishUserVerificationRequested")
+	defer span.End()
+
+	event.TraceID = span.SpanContext().TraceID().String()
+	event.SpanID = span.SpanContext().SpanID().String()
+	event.Timestamp = time.Now()
+
+	span.SetAttributes(attribute.String("user.id", event.UserID))
+
+	return p.publish(ctx, UserVerificationRequested, event, event.TraceID, event.SpanID)
+}
+
+// PublishUserVerified publica a confirmação de email
+func (p *EventPublisher) PublishUserVerified(ctx context.Context, event UserVerifiedEvent) error {
+	ctx, span := p.tracer.Start(ctx, "PublishUserVerified")
+	defer span.End()
+
+	event.TraceID = span.SpanContext().TraceID().String()
+	event.SpanID = span.SpanContext().SpanID().String()
+	event.Timestamp = time.Now()
+
+	span.SetAttributes(attribute.String("user.id", event.UserID))
+
+	return p.publish(ctx, UserVerified, event, event.TraceID, event.SpanID)
+}
+
+// PublishUserEmailChanged publica a troca de email de um usuário
+func (p *EventPublisher) PublishUserEmailChanged(ctx context.Context, event UserEmailChangedEvent) error {
+	ctx, span := p.tracer.Start(ctx, "PublishUserEmailChanged")
+	defer span.End()
+
+	event.TraceID = span.SpanContext().TraceID().String()
+	event.SpanID = span.SpanContext().SpanID().String()
+	event.Timestamp = time.Now()
+
+	span.SetAttributes(attribute.String("user.id", event.UserID))
+
+	return p.publish(ctx, UserEmailChanged, event, event.TraceID, event.SpanID)
+}
+
+// PublishUserDeleted publica a remoção definitiva de uma conta
+func (p *EventPublisher) PublishUserDeleted(ctx context.Context, event UserDeletedEvent) error {
+	ctx, span := p.tracer.Start(ctx, "PublishUserDeleted")
+	defer span.End()
+
+	event.TraceID = span.SpanContext().TraceID().String()
+	event.SpanID = span.SpanContext().SpanID().String()
+	event.Timestamp = time.Now()
+
+	span.SetAttributes(
+		attribute.String("user.id", event.UserID),
+		attribute.String("deletion.request_id", event.DeletionRequestID),
+	)
+
+	return p.publish(ctx, UserDeleted, event, event.TraceID, event.SpanID)
+}
+
+// PublishSuspiciousLogin publica a detecção de um login suspeito
+func (p *EventPublisher) PublishSuspiciousLogin(ctx context.Context, event SuspiciousLoginEvent) error {
+	ctx, span := p.tracer.Start(ctx, "PublishSuspiciousLogin")
+	defer span.End()
+
+	event.TraceID = span.SpanContext().TraceID().String()
+	event.SpanID = span.SpanContext().SpanID().String()
+	event.Timestamp = time.Now()
+
+	span.SetAttributes(
+		attribute.String("user.id", event.UserID),
+		attribute.StringSlice("security.reasons", event.Reasons),
+	)
+
+	return p.publish(ctx, SecuritySuspiciousLogin, event, event.TraceID, event.SpanID)
+}
+
+// PublishUserDeletionRequested publica o início da exclusão de uma conta
+func (p *EventPublisher) PublishUserDeletionRequested(ctx context.Context, event UserDeletionRequestedEvent) error {
+	ctx, span := p.tracer.Start(ctx, "PublishUserDeletionRequested")
+	defer span.End()
+
+	event.TraceID = span.SpanContext().TraceID().String()
+	event.SpanID = span.SpanContext().SpanID().String()
+	event.Timestamp = time.Now()
+
+	span.SetAttributes(
+		attribute.String("deletion.request_id", event.RequestID),
+		attribute.String("user.id", event.UserID),
+	)
+
+	return p.publish(ctx, UserDeletionRequested, event, event.TraceID, event.SpanID)
+}
+
+// publish serializa e envia uma mensagem persistente para o exchange de usuários
+func (p *EventPublisher) publish(ctx context.Context, routingKey string, event interface{}, traceID, spanID string) error {
+	start := time.Now()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = p.rabbitmq.channel.PublishWithContext(
+		ctx,
+		ExchangeName, // exchange
+		routingKey,   // routing key
+		false,        // mandatory
+		false,        // immediate
+		amqp.Publishing{
+			ContentType:  "application/json",
+			Body:         body,
+			DeliveryMode: amqp.Persistent, // Torna a mensagem persistente
+			Timestamp:    time.Now(),
+			Headers: amqp.Table{
+				"trace_id": traceID,
+				"span_id":  spanID,
+			},
+		},
+	)
+
+	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		metrics.MessagesPublishedTotal.WithLabelValues(routingKey, "error").Inc()
+		p.logger.Error("failed to publish user event",
+			zap.Error(err),
+			zap.String("routing_key", routingKey),
+			zap.String("trace_id", traceID),
+		)
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	metrics.MessagesPublishedTotal.WithLabelValues(routingKey, "success").Inc()
+
+	p.logger.Info("user event published",
+		zap.String("routing_key", routingKey),
+		zap.String("trace_id", traceID),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	return nil
+}
@@ -55,6 +55,49 @@ var (
 		},
 	)
 
+	// Data export metrics
+	DataExportsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_data_exports_total",
+			Help: "Total number of personal data export jobs by final status",
+		},
+		[]string{"status"},
+	)
+
+	DataExportDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "auth_data_export_duration_seconds",
+			Help:    "Time spent building personal data export archives",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+
+	// Account deletion metrics
+	AccountDeletionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_account_deletions_total",
+			Help: "Total number of account deletion requests by outcome",
+		},
+		[]string{"status"},
+	)
+
+	// Login security metrics
+	SuspiciousLoginsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_suspicious_logins_total",
+			Help: "Total number of suspicious logins by reason",
+		},
+		[]string{"reason"},
+	)
+
+	StepUpVerificationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_step_up_verifications_total",
+			Help: "Total number of step-up verification attempts by status",
+		},
+		[]string{"status"},
+	)
+
 	// Database metrics
 	DatabaseConnectionsActive = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -88,4 +131,13 @@ var (
 			Help: "Redis connection status (1 = connected, 0 = disconnected)",
 		},
 	)
+
+	// RabbitMQ metrics
+	MessagesPublishedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rabbitmq_messages_published_total",
+			Help: "Total number of messages published to RabbitMQ",
+		},
+		[]string{"routing_key", "status"},
+	)
 )
@@ -51,6 +51,14 @@ func AuthMiddleware(logger *zap.Logger) gin.HandlerFunc {
 
 		// Extrai claims
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			// Tokens internos com "purpose" (ex.: data_export, emitido pelo worker de
+			// exportação para o transaction-service) não valem nas rotas daqui
+			if purpose, _ := claims["purpose"].(string); purpose != "" {
+				logger.Warn("purpose token rejected", zap.String("purpose", purpose))
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token not valid for this route"})
+				return
+			}
+
 			// Adiciona user_id ao contexto
 			if userID, exists := claims["user_id"]; exists {
 				c.Set("user_id", userID)
@@ -60,6 +68,38 @@ func AuthMiddleware(logger *zap.Logger) gin.HandlerFunc {
 			if email, exists := claims["email"]; exists {
 				c.Set("email", email)
 			}
+
+			// Token de suporte: "act" identifica o agente que age em nome do usuário
+			if act, ok := claims["act"].(map[string]interface{}); ok {
+				if actorID, ok := act["sub"].(string); ok && actorID != "" {
+					c.Set("impersonator_id", actorID)
+					scope, _ := claims["scope"].(string)
+					c.Set("impersonation_scope", scope)
+				}
+			}
+
+			// Adiciona papel ao contexto
+			if role, exists := claims["role"]; exists {
+				c.Set("role", role)
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole restringe a rota a usuários com o papel informado no token
+func RequireRole(role string, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if current, _ := c.Get("role"); current != role {
+			userID, _ := c.Get("user_id")
+			logger.Warn("forbidden: missing role",
+				zap.Any("user_id", userID),
+				zap.String("required_role", role),
+				zap.String("path", c.Request.URL.Path),
+			)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
 		}
 
 		c.Next()
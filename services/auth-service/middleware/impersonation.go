@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// ImpersonationGuard aplica as restrições de tokens de suporte emitidos pelo auth-service.
+// Sem o escopo "write" o token é somente leitura e qualquer escrita é rejeitada.
+func ImpersonationGuard(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		impersonatorID, exists := c.Get("impersonator_id")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		scope := c.GetString("impersonation_scope")
+
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(
+			attribute.String("impersonation.actor_id", impersonatorID.(string)),
+			attribute.String("impersonation.scope", scope),
+		)
+
+		if !isReadOnlyMethod(c.Request.Method) && !hasScope(scope, "write") {
+			logger.Warn("write rejected for read-only impersonation",
+				zap.Any("user_id", userID),
+				zap.Any("impersonator_id", impersonatorID),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+			)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "impersonation session is read-only"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func hasScope(scope, wanted string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == wanted {
+			return true
+		}
+	}
+	return false
+}
+
+// DenyImpersonation bloqueia a rota para tokens de suporte, independente do escopo.
+// Usado em ações sobre a própria conta, que só o titular pode executar.
+func DenyImpersonation(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if impersonatorID, exists := c.Get("impersonator_id"); exists {
+			userID, _ := c.Get("user_id")
+			logger.Warn("account action rejected for impersonation",
+				zap.Any("user_id", userID),
+				zap.Any("impersonator_id", impersonatorID),
+				zap.String("path", c.Request.URL.Path),
+			)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not allowed while impersonating"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -49,6 +49,11 @@ func RequestLogger(logger *zap.Logger) gin.HandlerFunc {
 			fields = append(fields, zap.String("user_id", userID.(string)))
 		}
 
+		// Requisições sob impersonação registram também o agente de suporte
+		if impersonatorID, exists := c.Get("impersonator_id"); exists {
+			fields = append(fields, zap.String("impersonator_id", impersonatorID.(string)))
+		}
+
 		// Log com nível apropriado
 		if len(c.Errors) > 0 {
 			fields = append(fields, zap.String("error", c.Errors.String()))
@@ -81,7 +86,6 @@ func TracingMiddleware(tracer trace.Tracer) gin.HandlerFunc {
 		c.Request = c.Request.WithContext(ctx)
 
 		// Adiciona trace_id ao response header
-		span.SpanContext().TraceID().String()
 		c.Header("X-Trace-ID", span.SpanContext().TraceID().String())
 
 		// Processa a requisição
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AuditLog registra uma ação administrativa
+type AuditLog struct {
+	ID           string                 `json:"id" db:"id"`
+	ActorID      string                 `json:"actor_id" db:"actor_id"`
+	Action       string                 `json:"action" db:"action"`
+	TargetUserID string                 `json:"target_user_id,omitempty" db:"target_user_id"`
+	Metadata     map[string]interface{} `json:"metadata" db:"metadata"`
+	IP           string                 `json:"ip,omitempty" db:"ip"`
+	UserAgent    string                 `json:"user_agent,omitempty" db:"user_agent"`
+	TraceID      string                 `json:"trace_id,omitempty" db:"trace_id"`
+	CreatedAt    time.Time              `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	DeletionStatusScheduled  = "scheduled"
+	DeletionStatusCancelled  = "cancelled"
+	DeletionStatusInProgress = "in_progress"
+	DeletionStatusCompleted  = "completed"
+)
+
+// AccountDeletionRequest acompanha a exclusão de uma conta em todos os serviços
+type AccountDeletionRequest struct {
+	ID               string                    `json:"id" db:"id"`
+	UserID           string                    `json:"user_id" db:"user_id"`
+	Status           string                    `json:"status" db:"status"`
+	Participants     []string                  `json:"participants" db:"participants"`
+	RequestedAt      time.Time                 `json:"requested_at" db:"requested_at"`
+	ScheduledFor     time.Time                 `json:"scheduled_for" db:"scheduled_for"`
+	CancelledAt      *time.Time                `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	StartedAt        *time.Time                `json:"started_at,omitempty" db:"started_at"`
+	LastPublishedAt  *time.Time                `json:"-" db:"last_published_at"`
+	CompletedAt      *time.Time                `json:"completed_at,omitempty" db:"completed_at"`
+	Acknowledgements []DeletionAcknowledgement `json:"acknowledgements"`
+}
+
+// DeletionAcknowledgement é a confirmação de um serviço de que apagou sua fatia de dados
+type DeletionAcknowledgement struct {
+	Service        string    `json:"service"`
+	AcknowledgedAt time.Time `json:"acknowledged_at"`
+}
+
+// Pending retorna os participantes que ainda não confirmaram a exclusão
+func (r *AccountDeletionRequest) Pending() []string {
+	acked := make(map[string]bool, len(r.Acknowledgements))
+	for _, ack := range r.Acknowledgements {
+		acked[ack.Service] = true
+	}
+
+	pending := []string{}
+	for _, participant := range r.Participants {
+		if !acked[participant] {
+			pending = append(pending, participant)
+		}
+	}
+	return pending
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// KnownDevice é um dispositivo já usado pelo usuário em um login bem-sucedido
+type KnownDevice struct {
+	ID          string    `json:"id" db:"id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	Fingerprint string    `json:"-" db:"fingerprint"`
+	UserAgent   string    `json:"user_agent" db:"user_agent"`
+	IPPrefix    string    `json:"ip_prefix" db:"ip_prefix"`
+	DeviceID    string    `json:"device_id,omitempty" db:"device_id"`
+	FirstSeenAt time.Time `json:"first_seen_at" db:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at" db:"last_seen_at"`
+}
+
+// LoginAttempt registra uma tentativa de login com a localização estimada do IP
+type LoginAttempt struct {
+	ID          string    `json:"id" db:"id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	Success     bool      `json:"success" db:"success"`
+	IP          string    `json:"ip" db:"ip"`
+	Country     string    `json:"country,omitempty" db:"country"`
+	Latitude    *float64  `json:"latitude,omitempty" db:"latitude"`
+	Longitude   *float64  `json:"longitude,omitempty" db:"longitude"`
+	Fingerprint string    `json:"-" db:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// LoginChallenge é a verificação adicional exigida após um login suspeito
+type LoginChallenge struct {
+	ID          string     `json:"id" db:"id"`
+	UserID      string     `json:"user_id" db:"user_id"`
+	CodeHash    string     `json:"-" db:"code_hash"`
+	Fingerprint string     `json:"-" db:"fingerprint"`
+	UserAgent   string     `json:"user_agent" db:"user_agent"`
+	IP          string     `json:"ip" db:"ip"`
+	IPPrefix    string     `json:"ip_prefix" db:"ip_prefix"`
+	DeviceID    string     `json:"device_id,omitempty" db:"device_id"`
+	Country     string     `json:"country,omitempty" db:"country"`
+	Latitude    *float64   `json:"latitude,omitempty" db:"latitude"`
+	Longitude   *float64   `json:"longitude,omitempty" db:"longitude"`
+	Reasons     []string   `json:"reasons" db:"reasons"`
+	Attempts    int        `json:"attempts" db:"attempts"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
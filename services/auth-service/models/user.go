@@ -2,11 +2,30 @@ package models
 
 import "time"
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID           string    `json:"id" db:"id"`
-	Email        string    `json:"email" db:"email"`
-	Name         string    `json:"name" db:"name"`
-	PasswordHash string    `json:"-" db:"password_hash"` // Never expose in JSON
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID                    string     `json:"id" db:"id"`
+	Email                 string     `json:"email" db:"email"`
+	Name                  string     `json:"name" db:"name"`
+	PasswordHash          string     `json:"-" db:"password_hash"` // Never expose in JSON
+	Role                  string     `json:"role" db:"role"`
+	DisabledAt            *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	PasswordResetRequired bool       `json:"password_reset_required" db:"password_reset_required"`
+	EmailVerifiedAt       *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	CreatedAt             time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsDisabled indica se a conta foi desativada por um administrador
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// IsVerified indica se o email atual já foi confirmado
+func (u *User) IsVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
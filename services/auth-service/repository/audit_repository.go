@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"auth-service/models"
+
+	"go.uber.org/zap"
+)
+
+type AuditRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+type AuditFilters struct {
+	ActorID      string
+	TargetUserID string
+	Action       string
+}
+
+func NewAuditRepository(db *sql.DB, logger *zap.Logger) *AuditRepository {
+	return &AuditRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create grava uma entrada na trilha de auditoria
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	metadata, err := json.Marshal(entry.Metadata)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO audit_logs (id, actor_id, action, target_user_id, metadata, ip, user_agent, trace_id, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		entry.ID,
+		entry.ActorID,
+		entry.Action,
+		entry.TargetUserID,
+		metadata,
+		entry.IP,
+		entry.UserAgent,
+		entry.TraceID,
+		entry.CreatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("failed to write audit log",
+			zap.Error(err),
+			zap.String("actor_id", entry.ActorID),
+			zap.String("action", entry.Action),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// List lista entradas da trilha de auditoria com filtros e paginação
+func (r *AuditRepository) List(ctx context.Context, filters AuditFilters, page, pageSize int) ([]*models.AuditLog, int, error) {
+	where := ` WHERE 1=1`
+	args := []interface{}{}
+
+	if filters.ActorID != "" {
+		args = append(args, filters.ActorID)
+		where += fmt.Sprintf(` AND actor_id = $%d`, len(args))
+	}
+
+	if filters.TargetUserID != "" {
+		args = append(args, filters.TargetUserID)
+		where += fmt.Sprintf(` AND target_user_id = $%d`, len(args))
+	}
+
+	if filters.Action != "" {
+		args = append(args, filters.Action)
+		where += fmt.Sprintf(` AND action = $%d`, len(args))
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_logs`+where, args...).Scan(&total); err != nil {
+		r.logger.Error("failed to count audit logs", zap.Error(err))
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, actor_id, action, COALESCE(target_user_id::text, ''), metadata,
+			COALESCE(ip, ''), COALESCE(user_agent, ''), COALESCE(trace_id, ''), created_at
+		FROM audit_logs` + where +
+		fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, pageSize, (page-1)*pageSize)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to list audit logs", zap.Error(err))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditLog{}
+	for rows.Next() {
+		entry := &models.AuditLog{}
+		var metadata []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.Action,
+			&entry.TargetUserID,
+			&metadata,
+			&entry.IP,
+			&entry.UserAgent,
+			&entry.TraceID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan audit log", zap.Error(err))
+			continue
+		}
+		json.Unmarshal(metadata, &entry.Metadata)
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"auth-service/models"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrDeletionNotFound         = errors.New("deletion request not found")
+	ErrDeletionAlreadyRequested = errors.New("deletion already requested")
+)
+
+type DeletionRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewDeletionRepository(db *sql.DB, logger *zap.Logger) *DeletionRepository {
+	return &DeletionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const deletionColumns = `
+	id, user_id, status, participants, requested_at, scheduled_for,
+	cancelled_at, started_at, last_published_at, completed_at
+`
+
+func scanDeletionRequest(row rowScanner) (*models.AccountDeletionRequest, error) {
+	req := &models.AccountDeletionRequest{}
+	var cancelledAt, startedAt, lastPublishedAt, completedAt sql.NullTime
+
+	err := row.Scan(
+		&req.ID,
+		&req.UserID,
+		&req.Status,
+		pq.Array(&req.Participants),
+		&req.RequestedAt,
+		&req.ScheduledFor,
+		&cancelledAt,
+		&startedAt,
+		&lastPublishedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	req.CancelledAt = nullTimePtr(cancelledAt)
+	req.StartedAt = nullTimePtr(startedAt)
+	req.LastPublishedAt = nullTimePtr(lastPublishedAt)
+	req.CompletedAt = nullTimePtr(completedAt)
+
+	return req, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// Create agenda a exclusão de uma conta
+func (r *DeletionRepository) Create(ctx context.Context, req *models.AccountDeletionRequest) error {
+	query := `
+		INSERT INTO account_deletion_requests (id, user_id, status, participants, requested_at, scheduled_for)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		req.ID,
+		req.UserID,
+		req.Status,
+		pq.Array(req.Participants),
+		req.RequestedAt,
+		req.ScheduledFor,
+	)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrDeletionAlreadyRequested
+	}
+
+	if err != nil {
+		r.logger.Error("failed to create deletion request",
+			zap.Error(err),
+			zap.String("user_id", req.UserID),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// FindByID busca um pedido de exclusão com as confirmações recebidas
+func (r *DeletionRepository) FindByID(ctx context.Context, id string) (*models.AccountDeletionRequest, error) {
+	query := `SELECT ` + deletionColumns + ` FROM account_deletion_requests WHERE id = $1`
+
+	return r.findOne(ctx, query, id)
+}
+
+// FindActiveByUser busca o pedido agendado ou em andamento do usuário
+func (r *DeletionRepository) FindActiveByUser(ctx context.Context, userID string) (*models.AccountDeletionRequest, error) {
+	query := `SELECT ` + deletionColumns + `
+		FROM account_deletion_requests
+		WHERE user_id = $1 AND status IN ('scheduled', 'in_progress')
+	`
+
+	return r.findOne(ctx, query, userID)
+}
+
+func (r *DeletionRepository) findOne(ctx context.Context, query string, arg string) (*models.AccountDeletionRequest, error) {
+	req, err := scanDeletionRequest(r.db.QueryRowContext(ctx, query, arg))
+	if err == sql.ErrNoRows {
+		return nil, ErrDeletionNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find deletion request", zap.Error(err))
+		return nil, err
+	}
+
+	req.Acknowledgements, err = r.listAcks(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// Cancel cancela um pedido ainda dentro do período de carência
+func (r *DeletionRepository) Cancel(ctx context.Context, userID string) error {
+	query := `
+		UPDATE account_deletion_requests
+		SET status = 'cancelled', cancelled_at = NOW()
+		WHERE user_id = $1 AND status = 'scheduled'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to cancel deletion request",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrDeletionNotFound
+	}
+
+	return nil
+}
+
+// StartDue move para "in_progress" os pedidos cujo período de carência terminou
+func (r *DeletionRepository) StartDue(ctx context.Context, now time.Time) ([]*models.AccountDeletionRequest, error) {
+	query := `
+		UPDATE account_deletion_requests
+		SET status = 'in_progress', started_at = $1
+		WHERE status = 'scheduled' AND scheduled_for <= $1
+		RETURNING ` + deletionColumns
+
+	return r.list(ctx, query, now)
+}
+
+// ListStalled lista pedidos em andamento cuja última publicação é anterior a "before"
+func (r *DeletionRepository) ListStalled(ctx context.Context, before time.Time) ([]*models.AccountDeletionRequest, error) {
+	query := `SELECT ` + deletionColumns + `
+		FROM account_deletion_requests
+		WHERE status = 'in_progress' AND (last_published_at IS NULL OR last_published_at < $1)
+	`
+
+	return r.list(ctx, query, before)
+}
+
+func (r *DeletionRepository) list(ctx context.Context, query string, args ...interface{}) ([]*models.AccountDeletionRequest, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to list deletion requests", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*models.AccountDeletionRequest{}
+	for rows.Next() {
+		req, err := scanDeletionRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, req := range requests {
+		if req.Acknowledgements, err = r.listAcks(ctx, req.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return requests, nil
+}
+
+// MarkPublished registra a (re)publicação do evento de exclusão
+func (r *DeletionRepository) MarkPublished(ctx context.Context, id string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE account_deletion_requests SET last_published_at = $1 WHERE id = $2`, at, id)
+	return err
+}
+
+// RecordAck registra a confirmação de um participante. Confirmações repetidas são ignoradas.
+func (r *DeletionRepository) RecordAck(ctx context.Context, requestID, service string, details map[string]interface{}) error {
+	payload, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO account_deletion_acks (request_id, service, details)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (request_id, service) DO NOTHING
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, requestID, service, payload); err != nil {
+		r.logger.Error("failed to record deletion ack",
+			zap.Error(err),
+			zap.String("request_id", requestID),
+			zap.String("service", service),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (r *DeletionRepository) listAcks(ctx context.Context, requestID string) ([]models.DeletionAcknowledgement, error) {
+	query := `
+		SELECT service, acknowledged_at
+		FROM account_deletion_acks
+		WHERE request_id = $1
+		ORDER BY acknowledged_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	acks := []models.DeletionAcknowledgement{}
+	for rows.Next() {
+		var ack models.DeletionAcknowledgement
+		if err := rows.Scan(&ack.Service, &ack.AcknowledgedAt); err != nil {
+			return nil, err
+		}
+		acks = append(acks, ack)
+	}
+
+	return acks, rows.Err()
+}
+
+// Finalize grava o tombstone, apaga o usuário e conclui o pedido em uma única transação
+func (r *DeletionRepository) Finalize(ctx context.Context, req *models.AccountDeletionRequest, email string) error {
+	acks, err := json.Marshal(req.Acknowledgements)
+	if err != nil {
+		return err
+	}
+
+	emailHash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_tombstones (user_id, deletion_request_id, email_hash, requested_at, acknowledgements)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO NOTHING
+	`, req.UserID, req.ID, hex.EncodeToString(emailHash[:]), req.RequestedAt, acks)
+	if err != nil {
+		r.logger.Error("failed to write user tombstone", zap.Error(err), zap.String("user_id", req.UserID))
+		return err
+	}
+
+	// Lançamentos gravados depois da confirmação do transaction-service ainda podem
+	// existir; sem apagá-los aqui o DELETE em cascata esbarraria na imutabilidade do razão
+	if err := eraseLedger(ctx, tx, req.UserID); err != nil {
+		r.logger.Error("failed to erase user ledger", zap.Error(err), zap.String("user_id", req.UserID))
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, req.UserID); err != nil {
+		r.logger.Error("failed to hard delete user", zap.Error(err), zap.String("user_id", req.UserID))
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE account_deletion_requests
+		SET status = 'completed', completed_at = NOW()
+		WHERE id = $1 AND status = 'in_progress'
+	`, req.ID)
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return ErrDeletionNotFound
+	}
+
+	return tx.Commit()
+}
+
+// eraseLedger apaga o razão do usuário, a única exclusão que os gatilhos de
+// imutabilidade permitem (ledger.allow_erasure vale só até o fim da transação)
+func eraseLedger(ctx context.Context, tx *sql.Tx, userID string) error {
+	if _, err := tx.ExecContext(ctx, `SET LOCAL ledger.allow_erasure = 'on'`); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM postings
+		WHERE entry_id IN (SELECT id FROM journal_entries WHERE user_id = $1)
+	`, userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM journal_entries WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM ledger_accounts WHERE user_id = $1`, userID)
+	return err
+}
@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth-service/models"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrExportNotFound = errors.New("export not found")
+)
+
+type ExportRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewExportRepository(db *sql.DB, logger *zap.Logger) *ExportRepository {
+	return &ExportRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const exportColumns = `
+	id, user_id, status, COALESCE(file_path, ''), COALESCE(error, ''),
+	created_at, updated_at, completed_at, expires_at
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanExportJob(row rowScanner) (*models.DataExportJob, error) {
+	job := &models.DataExportJob{}
+	var completedAt, expiresAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Status,
+		&job.FilePath,
+		&job.Error,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&completedAt,
+		&expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		job.ExpiresAt = &expiresAt.Time
+	}
+
+	return job, nil
+}
+
+// Create registra um novo pedido de exportação
+func (r *ExportRepository) Create(ctx context.Context, job *models.DataExportJob) error {
+	query := `
+		INSERT INTO data_export_jobs (id, user_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		job.ID,
+		job.UserID,
+		job.Status,
+		job.CreatedAt,
+		job.UpdatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("failed to create export job",
+			zap.Error(err),
+			zap.String("export_id", job.ID),
+			zap.String("user_id", job.UserID),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// FindByID busca um pedido de exportação por ID
+func (r *ExportRepository) FindByID(ctx context.Context, id string) (*models.DataExportJob, error) {
+	query := `SELECT ` + exportColumns + ` FROM data_export_jobs WHERE id = $1`
+
+	job, err := scanExportJob(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrExportNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find export job",
+			zap.Error(err),
+			zap.String("export_id", id),
+		)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// FindActiveByUser retorna o pedido pendente ou em processamento do usuário, se existir
+func (r *ExportRepository) FindActiveByUser(ctx context.Context, userID string) (*models.DataExportJob, error) {
+	query := `SELECT ` + exportColumns + `
+		FROM data_export_jobs
+		WHERE user_id = $1 AND status IN ('pending', 'processing')
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	job, err := scanExportJob(r.db.QueryRowContext(ctx, query, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrExportNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find active export job",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// ListByUser lista os pedidos de exportação do usuário, do mais recente para o mais antigo
+func (r *ExportRepository) ListByUser(ctx context.Context, userID string) ([]*models.DataExportJob, error) {
+	query := `SELECT ` + exportColumns + `
+		FROM data_export_jobs
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 20
+	`
+
+	return r.list(ctx, query, userID)
+}
+
+// ListFilePathsByUser lista os arquivos de todas as exportações do usuário, sem paginação
+func (r *ExportRepository) ListFilePathsByUser(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT file_path FROM data_export_jobs
+		WHERE user_id = $1 AND file_path IS NOT NULL AND file_path <> ''
+	`, userID)
+	if err != nil {
+		r.logger.Error("failed to list export files", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	defer rows.Close()
+
+	paths := []string{}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, rows.Err()
+}
+
+// ListPending lista pedidos aguardando processamento
+func (r *ExportRepository) ListPending(ctx context.Context) ([]*models.DataExportJob, error) {
+	query := `SELECT ` + exportColumns + `
+		FROM data_export_jobs
+		WHERE status = 'pending'
+		ORDER BY created_at
+	`
+
+	return r.list(ctx, query)
+}
+
+// ListExpired lista exportações concluídas cujo link de download já expirou
+func (r *ExportRepository) ListExpired(ctx context.Context, now time.Time) ([]*models.DataExportJob, error) {
+	query := `SELECT ` + exportColumns + `
+		FROM data_export_jobs
+		WHERE status = 'completed' AND expires_at < $1
+	`
+
+	return r.list(ctx, query, now)
+}
+
+func (r *ExportRepository) list(ctx context.Context, query string, args ...interface{}) ([]*models.DataExportJob, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to list export jobs", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*models.DataExportJob{}
+	for rows.Next() {
+		job, err := scanExportJob(rows)
+		if err != nil {
+			r.logger.Error("failed to scan export job", zap.Error(err))
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Claim marca um pedido pendente como em processamento.
+// Retorna false se outro worker já assumiu o pedido.
+func (r *ExportRepository) Claim(ctx context.Context, id string) (bool, error) {
+	query := `
+		UPDATE data_export_jobs
+		SET status = 'processing', updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	err := r.exec(ctx, query, id, id)
+	if err == ErrExportNotFound {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// RequeueStale devolve para a fila pedidos em processamento desde antes de before
+func (r *ExportRepository) RequeueStale(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		UPDATE data_export_jobs SET status = 'pending', updated_at = NOW()
+		WHERE status = 'processing' AND updated_at < $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		r.logger.Error("failed to requeue stale export jobs", zap.Error(err))
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// ResetProcessing devolve para a fila pedidos interrompidos por um restart do serviço
+func (r *ExportRepository) ResetProcessing(ctx context.Context) (int64, error) {
+	query := `UPDATE data_export_jobs SET status = 'pending', updated_at = NOW() WHERE status = 'processing'`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		r.logger.Error("failed to reset processing export jobs", zap.Error(err))
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// MarkCompleted registra o arquivo gerado e até quando ele pode ser baixado
+func (r *ExportRepository) MarkCompleted(ctx context.Context, id, filePath string, expiresAt time.Time) error {
+	query := `
+		UPDATE data_export_jobs
+		SET status = 'completed', file_path = $1, expires_at = $2,
+			error = NULL, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $3 AND status = 'processing'
+	`
+
+	return r.exec(ctx, query, id, filePath, expiresAt, id)
+}
+
+// MarkFailed registra a falha de um pedido de exportação
+func (r *ExportRepository) MarkFailed(ctx context.Context, id, reason string) error {
+	query := `
+		UPDATE data_export_jobs
+		SET status = 'failed', error = $1, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $2
+	`
+
+	return r.exec(ctx, query, id, reason, id)
+}
+
+// MarkExpired invalida o link de download de um pedido
+func (r *ExportRepository) MarkExpired(ctx context.Context, id string) error {
+	query := `
+		UPDATE data_export_jobs
+		SET status = 'expired', file_path = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	return r.exec(ctx, query, id, id)
+}
+
+func (r *ExportRepository) exec(ctx context.Context, query, id string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to update export job",
+			zap.Error(err),
+			zap.String("export_id", id),
+		)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrExportNotFound
+	}
+
+	return nil
+}
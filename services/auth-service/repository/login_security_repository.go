@@ -0,0 +1,399 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"auth-service/models"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrDeviceNotFound    = errors.New("device not found")
+	ErrChallengeNotFound = errors.New("login challenge not found")
+)
+
+// LoginSecurityRepository guarda dispositivos conhecidos, o histórico de tentativas
+// de login e os desafios de verificação adicional
+type LoginSecurityRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewLoginSecurityRepository(db *sql.DB, logger *zap.Logger) *LoginSecurityRepository {
+	return &LoginSecurityRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func nullFloatPtr(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
+
+// RecordAttempt grava uma tentativa de login
+func (r *LoginSecurityRepository) RecordAttempt(ctx context.Context, attempt *models.LoginAttempt) error {
+	query := `
+		INSERT INTO login_attempts (id, user_id, success, ip, country, latitude, longitude, fingerprint, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		attempt.ID,
+		attempt.UserID,
+		attempt.Success,
+		attempt.IP,
+		attempt.Country,
+		attempt.Latitude,
+		attempt.Longitude,
+		attempt.Fingerprint,
+		attempt.CreatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("failed to record login attempt", zap.Error(err), zap.String("user_id", attempt.UserID))
+		return err
+	}
+
+	return nil
+}
+
+// CountFailuresSince conta as falhas desde o último sucesso, limitadas à janela informada
+func (r *LoginSecurityRepository) CountFailuresSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM login_attempts
+		WHERE user_id = $1
+			AND success = FALSE
+			AND created_at > $2
+			AND created_at > COALESCE(
+				(SELECT MAX(created_at) FROM login_attempts WHERE user_id = $1 AND success = TRUE),
+				'-infinity'::timestamp
+			)
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID, since).Scan(&count); err != nil {
+		r.logger.Error("failed to count login failures", zap.Error(err), zap.String("user_id", userID))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// LastLocatedSuccess retorna o último login bem-sucedido com localização conhecida
+func (r *LoginSecurityRepository) LastLocatedSuccess(ctx context.Context, userID string) (*models.LoginAttempt, error) {
+	query := `
+		SELECT id, user_id, success, COALESCE(ip, ''), COALESCE(country, ''), latitude, longitude,
+			COALESCE(fingerprint, ''), created_at
+		FROM login_attempts
+		WHERE user_id = $1 AND success = TRUE AND latitude IS NOT NULL AND longitude IS NOT NULL
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	attempt := &models.LoginAttempt{}
+	var latitude, longitude sql.NullFloat64
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&attempt.ID,
+		&attempt.UserID,
+		&attempt.Success,
+		&attempt.IP,
+		&attempt.Country,
+		&latitude,
+		&longitude,
+		&attempt.Fingerprint,
+		&attempt.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		r.logger.Error("failed to get last login location", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+
+	attempt.Latitude = nullFloatPtr(latitude)
+	attempt.Longitude = nullFloatPtr(longitude)
+	return attempt, nil
+}
+
+// ListAttempts lista o histórico de tentativas de login do usuário, da mais recente para a mais antiga
+func (r *LoginSecurityRepository) ListAttempts(ctx context.Context, userID string) ([]*models.LoginAttempt, error) {
+	query := `
+		SELECT id, user_id, success, COALESCE(ip, ''), COALESCE(country, ''), latitude, longitude,
+			COALESCE(fingerprint, ''), created_at
+		FROM login_attempts
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to list login attempts", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []*models.LoginAttempt{}
+	for rows.Next() {
+		attempt := &models.LoginAttempt{}
+		var latitude, longitude sql.NullFloat64
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.UserID,
+			&attempt.Success,
+			&attempt.IP,
+			&attempt.Country,
+			&latitude,
+			&longitude,
+			&attempt.Fingerprint,
+			&attempt.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan login attempt", zap.Error(err))
+			return nil, err
+		}
+		attempt.Latitude = nullFloatPtr(latitude)
+		attempt.Longitude = nullFloatPtr(longitude)
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, rows.Err()
+}
+
+// DeviceStatus informa se o dispositivo é conhecido e se o usuário já tem algum dispositivo registrado
+func (r *LoginSecurityRepository) DeviceStatus(ctx context.Context, userID, fingerprint string) (known bool, hasDevices bool, err error) {
+	query := `
+		SELECT COUNT(*) FILTER (WHERE fingerprint = $2) > 0, COUNT(*) > 0
+		FROM known_devices
+		WHERE user_id = $1
+	`
+
+	if err = r.db.QueryRowContext(ctx, query, userID, fingerprint).Scan(&known, &hasDevices); err != nil {
+		r.logger.Error("failed to check known device", zap.Error(err), zap.String("user_id", userID))
+	}
+
+	return known, hasDevices, err
+}
+
+// TouchDevice registra o dispositivo como conhecido ou atualiza o último acesso
+func (r *LoginSecurityRepository) TouchDevice(ctx context.Context, device *models.KnownDevice) error {
+	query := `
+		INSERT INTO known_devices (id, user_id, fingerprint, user_agent, ip_prefix, device_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		ON CONFLICT (user_id, fingerprint) DO UPDATE SET last_seen_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		device.ID,
+		device.UserID,
+		device.Fingerprint,
+		device.UserAgent,
+		device.IPPrefix,
+		device.DeviceID,
+	)
+
+	if err != nil {
+		r.logger.Error("failed to save known device", zap.Error(err), zap.String("user_id", device.UserID))
+		return err
+	}
+
+	return nil
+}
+
+// ListDevices lista os dispositivos conhecidos do usuário
+func (r *LoginSecurityRepository) ListDevices(ctx context.Context, userID string) ([]*models.KnownDevice, error) {
+	query := `
+		SELECT id, user_id, fingerprint, COALESCE(user_agent, ''), COALESCE(ip_prefix, ''),
+			COALESCE(device_id, ''), first_seen_at, last_seen_at
+		FROM known_devices
+		WHERE user_id = $1
+		ORDER BY last_seen_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to list known devices", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []*models.KnownDevice{}
+	for rows.Next() {
+		device := &models.KnownDevice{}
+		err := rows.Scan(
+			&device.ID,
+			&device.UserID,
+			&device.Fingerprint,
+			&device.UserAgent,
+			&device.IPPrefix,
+			&device.DeviceID,
+			&device.FirstSeenAt,
+			&device.LastSeenAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan known device", zap.Error(err))
+			continue
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, rows.Err()
+}
+
+// DeleteDevice esquece um dispositivo; o próximo login a partir dele exigirá verificação
+func (r *LoginSecurityRepository) DeleteDevice(ctx context.Context, userID, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM known_devices WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		r.logger.Error("failed to delete known device", zap.Error(err), zap.String("id", id))
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrDeviceNotFound
+	}
+
+	return nil
+}
+
+const challengeColumns = `
+	id, user_id, code_hash, fingerprint, COALESCE(user_agent, ''), COALESCE(ip, ''),
+	COALESCE(ip_prefix, ''), COALESCE(device_id, ''), COALESCE(country, ''), latitude, longitude,
+	reasons, attempts, expires_at, completed_at, created_at
+`
+
+// CreateChallenge grava um desafio de verificação adicional
+func (r *LoginSecurityRepository) CreateChallenge(ctx context.Context, challenge *models.LoginChallenge) error {
+	query := `
+		INSERT INTO login_challenges (
+			id, user_id, code_hash, fingerprint, user_agent, ip, ip_prefix, device_id,
+			country, latitude, longitude, reasons, expires_at, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12, $13, $14)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		challenge.ID,
+		challenge.UserID,
+		challenge.CodeHash,
+		challenge.Fingerprint,
+		challenge.UserAgent,
+		challenge.IP,
+		challenge.IPPrefix,
+		challenge.DeviceID,
+		challenge.Country,
+		challenge.Latitude,
+		challenge.Longitude,
+		pq.Array(challenge.Reasons),
+		challenge.ExpiresAt,
+		challenge.CreatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("failed to create login challenge", zap.Error(err), zap.String("user_id", challenge.UserID))
+		return err
+	}
+
+	return nil
+}
+
+// FindChallenge busca um desafio ainda em aberto
+func (r *LoginSecurityRepository) FindChallenge(ctx context.Context, id string) (*models.LoginChallenge, error) {
+	query := `SELECT ` + challengeColumns + ` FROM login_challenges WHERE id = $1 AND completed_at IS NULL`
+
+	challenge := &models.LoginChallenge{}
+	var latitude, longitude sql.NullFloat64
+	var completedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&challenge.ID,
+		&challenge.UserID,
+		&challenge.CodeHash,
+		&challenge.Fingerprint,
+		&challenge.UserAgent,
+		&challenge.IP,
+		&challenge.IPPrefix,
+		&challenge.DeviceID,
+		&challenge.Country,
+		&latitude,
+		&longitude,
+		pq.Array(&challenge.Reasons),
+		&challenge.Attempts,
+		&challenge.ExpiresAt,
+		&completedAt,
+		&challenge.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrChallengeNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to get login challenge", zap.Error(err), zap.String("id", id))
+		return nil, err
+	}
+
+	challenge.Latitude = nullFloatPtr(latitude)
+	challenge.Longitude = nullFloatPtr(longitude)
+	challenge.CompletedAt = nullTimePtr(completedAt)
+	return challenge, nil
+}
+
+// ConsumeChallengeAttempt conta uma tentativa e devolve o hash do código para a
+// comparação. O incremento e o limite ficam no mesmo UPDATE para que tentativas
+// paralelas não passem do máximo; sem tentativas restantes retorna ErrChallengeNotFound.
+func (r *LoginSecurityRepository) ConsumeChallengeAttempt(ctx context.Context, id string, maxAttempts int) (string, error) {
+	query := `
+		UPDATE login_challenges SET attempts = attempts + 1
+		WHERE id = $1 AND attempts < $2 AND completed_at IS NULL
+		RETURNING code_hash
+	`
+
+	var codeHash string
+	err := r.db.QueryRowContext(ctx, query, id, maxAttempts).Scan(&codeHash)
+	if err == sql.ErrNoRows {
+		return "", ErrChallengeNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to count login challenge attempt", zap.Error(err), zap.String("id", id))
+		return "", err
+	}
+
+	return codeHash, nil
+}
+
+// CompleteChallenge encerra o desafio. Retorna ErrChallengeNotFound se ele já tiver sido usado.
+func (r *LoginSecurityRepository) CompleteChallenge(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE login_challenges SET completed_at = NOW() WHERE id = $1 AND completed_at IS NULL`, id)
+	if err != nil {
+		r.logger.Error("failed to complete login challenge", zap.Error(err), zap.String("id", id))
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrChallengeNotFound
+	}
+
+	return nil
+}
@@ -2,23 +2,191 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"auth-service/metrics"
 
 	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// Esquemas aceitos em REDIS_URL:
+//
+//	redis://[:senha@]host:6379/0                         instância única (também rediss://)
+//	redis-sentinel://[:senha@]h1:26379,h2:26379/mestre?db=0 Sentinel, com o nome do mestre no caminho
+//	redis-cluster://[:senha@]h1:6379,h2:6379             Cluster
+const (
+	redisSentinelScheme = "redis-sentinel"
+	redisClusterScheme  = "redis-cluster"
 )
 
-// NewRedisClient cria uma nova conexão com o Redis
-func NewRedisClient(redisURL string) *redis.Client {
-	opt, err := redis.ParseURL(redisURL)
+// NewRedisClient cria o cliente Redis de acordo com o esquema da URL.
+// Não testa a conexão: use ConnectRedis para aguardar o Redis na inicialização.
+func NewRedisClient(redisURL string) (redis.UniversalClient, error) {
+	u, err := url.Parse(redisURL)
 	if err != nil {
-		panic("failed to parse redis URL: " + err.Error())
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "redis", "rediss":
+		opt, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+		}
+		return redis.NewClient(opt), nil
+
+	case redisSentinelScheme:
+		masterName := strings.Trim(u.Path, "/")
+		if masterName == "" {
+			return nil, fmt.Errorf("redis sentinel URL must include the master name")
+		}
+
+		db := 0
+		if value := u.Query().Get("db"); value != "" {
+			if db, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("invalid redis db %q", value)
+			}
+		}
+
+		password, _ := u.User.Password()
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       masterName,
+			SentinelAddrs:    strings.Split(u.Host, ","),
+			SentinelPassword: u.Query().Get("sentinel_password"),
+			Username:         u.User.Username(),
+			Password:         password,
+			DB:               db,
+		}), nil
+
+	case redisClusterScheme:
+		password, _ := u.User.Password()
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    strings.Split(u.Host, ","),
+			Username: u.User.Username(),
+			Password: password,
+		}), nil
+	}
+
+	return nil, fmt.Errorf("unsupported redis URL scheme %q", u.Scheme)
+}
+
+// ConnectRedis tenta alcançar o Redis com backoff exponencial.
+// Retorna o último erro se todas as tentativas falharem; o serviço segue em modo degradado.
+func ConnectRedis(ctx context.Context, client redis.UniversalClient, attempts int, logger *zap.Logger) error {
+	backoff := 500 * time.Millisecond
+	var err error
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = client.Ping(ctx).Err(); err == nil {
+			metrics.RedisConnectionStatus.Set(1)
+			return nil
+		}
+
+		logger.Warn("redis not reachable, retrying",
+			zap.Error(err),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+		)
+
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > 10*time.Second {
+			backoff = 10 * time.Second
+		}
+	}
+
+	metrics.RedisConnectionStatus.Set(0)
+	return err
+}
+
+// RedisHealth acompanha a disponibilidade do Redis.
+// Enquanto o Redis estiver fora o serviço opera em modo degradado: o login emite
+// apenas access tokens de curta duração e a renovação por refresh token fica desativada.
+type RedisHealth struct {
+	client  redis.UniversalClient
+	healthy atomic.Bool
+	logger  *zap.Logger
+}
+
+func NewRedisHealth(client redis.UniversalClient, healthy bool, logger *zap.Logger) *RedisHealth {
+	h := &RedisHealth{
+		client: client,
+		logger: logger,
 	}
+	h.set(healthy)
+	return h
+}
 
-	client := redis.NewClient(opt)
+// Healthy indica se o Redis respondeu na última verificação
+func (h *RedisHealth) Healthy() bool {
+	return h.healthy.Load()
+}
 
-	// Testa a conexão
-	if err := client.Ping(context.Background()).Err(); err != nil {
-		panic("failed to connect to redis: " + err.Error())
+// ReportFailure entra em modo degradado após uma falha de operação, sem esperar a próxima verificação
+func (h *RedisHealth) ReportFailure(err error) {
+	if err == nil || err == redis.Nil {
+		return
+	}
+	if h.healthy.Load() {
+		h.logger.Warn("redis operation failed, entering degraded mode", zap.Error(err))
 	}
+	h.set(false)
+}
+
+// Start verifica o Redis periodicamente até o contexto ser cancelado
+func (h *RedisHealth) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
 
-	return client
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.check(ctx)
+			}
+		}
+	}()
+}
+
+func (h *RedisHealth) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	err := h.client.Ping(ctx).Err()
+	healthy := err == nil
+
+	if healthy != h.healthy.Load() {
+		if healthy {
+			h.logger.Info("redis reachable again, leaving degraded mode")
+		} else {
+			h.logger.Warn("redis unreachable, entering degraded mode", zap.Error(err))
+		}
+	}
+
+	h.set(healthy)
+}
+
+func (h *RedisHealth) set(healthy bool) {
+	h.healthy.Store(healthy)
+	if healthy {
+		metrics.RedisConnectionStatus.Set(1)
+	} else {
+		metrics.RedisConnectionStatus.Set(0)
+	}
 }
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// SessionRepository guarda os refresh tokens no Redis.
+// Além da chave "refresh:<token>" mantém o índice "user_sessions:<user_id>"
+// para permitir listar e revogar todas as sessões de um usuário.
+// As chaves podem cair em slots diferentes no modo Cluster, por isso nenhum
+// comando envolve mais de uma chave.
+type SessionRepository struct {
+	client redis.UniversalClient
+	logger *zap.Logger
+}
+
+func NewSessionRepository(client redis.UniversalClient, logger *zap.Logger) *SessionRepository {
+	return &SessionRepository{
+		client: client,
+		logger: logger,
+	}
+}
+
+func refreshKey(token string) string {
+	return "refresh:" + token
+}
+
+func userSessionsKey(userID string) string {
+	return "user_sessions:" + userID
+}
+
+// Create armazena um refresh token para o usuário
+func (r *SessionRepository) Create(ctx context.Context, token, userID string, ttl time.Duration) error {
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, refreshKey(token), userID, ttl)
+	pipe.SAdd(ctx, userSessionsKey(userID), token)
+	pipe.Expire(ctx, userSessionsKey(userID), ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Error("failed to store session", zap.Error(err), zap.String("user_id", userID))
+		return err
+	}
+
+	return nil
+}
+
+// Get retorna o usuário dono do refresh token
+func (r *SessionRepository) Get(ctx context.Context, token string) (string, error) {
+	userID, err := r.client.Get(ctx, refreshKey(token)).Result()
+	if err == redis.Nil {
+		return "", ErrSessionNotFound
+	}
+
+	return userID, err
+}
+
+// Delete revoga um refresh token
+func (r *SessionRepository) Delete(ctx context.Context, token string) error {
+	userID, err := r.Get(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, refreshKey(token))
+	pipe.SRem(ctx, userSessionsKey(userID), token)
+
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// DeleteAllForUser revoga todas as sessões do usuário e retorna quantas estavam ativas
+func (r *SessionRepository) DeleteAllForUser(ctx context.Context, userID string) (int, error) {
+	tokens, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	pipe := r.client.Pipeline()
+	results := make([]*redis.IntCmd, 0, len(tokens))
+	for _, token := range tokens {
+		results = append(results, pipe.Del(ctx, refreshKey(token)))
+	}
+
+	deleted := int64(0)
+	if len(results) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return 0, err
+		}
+		for _, result := range results {
+			deleted += result.Val()
+		}
+	}
+
+	if err := r.client.Del(ctx, userSessionsKey(userID)).Err(); err != nil {
+		return int(deleted), err
+	}
+
+	return int(deleted), nil
+}
+
+// CountForUser conta as sessões ainda válidas do usuário, limpando tokens expirados do índice
+func (r *SessionRepository) CountForUser(ctx context.Context, userID string) (int, error) {
+	tokens, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	active := 0
+	for _, token := range tokens {
+		exists, err := r.client.Exists(ctx, refreshKey(token)).Result()
+		if err != nil {
+			return 0, err
+		}
+		if exists == 1 {
+			active++
+			continue
+		}
+		r.client.SRem(ctx, userSessionsKey(userID), token)
+	}
+
+	return active, nil
+}
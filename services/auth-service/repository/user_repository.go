@@ -4,11 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"auth-service/models"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -41,6 +43,36 @@ func NewPostgresConnection(databaseURL string) (*sql.DB, error) {
 	return db, nil
 }
 
+const userColumns = `
+	id, email, name, password_hash, role, disabled_at, password_reset_required, email_verified_at,
+	created_at, updated_at
+`
+
+func scanUser(row rowScanner) (*models.User, error) {
+	user := &models.User{}
+	var disabledAt, emailVerifiedAt sql.NullTime
+
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.PasswordHash,
+		&user.Role,
+		&disabledAt,
+		&user.PasswordResetRequired,
+		&emailVerifiedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	user.DisabledAt = nullTimePtr(disabledAt)
+	user.EmailVerifiedAt = nullTimePtr(emailVerifiedAt)
+	return user, nil
+}
+
 func NewUserRepository(db *sql.DB, logger *zap.Logger) *UserRepository {
 	return &UserRepository{
 		db:     db,
@@ -51,8 +83,8 @@ func NewUserRepository(db *sql.DB, logger *zap.Logger) *UserRepository {
 // Create cria um novo usuário
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (id, email, name, password_hash, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO users (id, email, name, password_hash, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -60,6 +92,7 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 		user.Email,
 		user.Name,
 		user.PasswordHash,
+		user.Role,
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
@@ -78,21 +111,9 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 
 // FindByID busca um usuário por ID
 func (r *UserRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
-	query := `
-		SELECT id, email, name, password_hash, created_at, updated_at
-		FROM users
-		WHERE id = $1
-	`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 
-	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Name,
-		&user.PasswordHash,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, ErrUserNotFound
@@ -111,21 +132,9 @@ func (r *UserRepository) FindByID(ctx context.Context, id string) (*models.User,
 
 // FindByEmail busca um usuário por email
 func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
-	query := `
-		SELECT id, email, name, password_hash, created_at, updated_at
-		FROM users
-		WHERE email = $1
-	`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 
-	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Name,
-		&user.PasswordHash,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, email))
 
 	if err == sql.ErrNoRows {
 		return nil, ErrUserNotFound
@@ -142,6 +151,49 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models
 	return user, nil
 }
 
+// Search busca usuários por email ou nome com paginação
+func (r *UserRepository) Search(ctx context.Context, term string, page, pageSize int) ([]*models.User, int, error) {
+	where := ``
+	args := []interface{}{}
+	if term != "" {
+		where = ` WHERE lower(email) LIKE $1 OR lower(name) LIKE $1`
+		args = append(args, "%"+escapeLike(strings.ToLower(term))+"%")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`+where, args...).Scan(&total); err != nil {
+		r.logger.Error("failed to count users", zap.Error(err))
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users` + where +
+		fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, pageSize, (page-1)*pageSize)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to search users", zap.Error(err))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			r.logger.Error("failed to scan user", zap.Error(err))
+			continue
+		}
+		users = append(users, user)
+	}
+
+	return users, total, rows.Err()
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
 // Update atualiza um usuário
 func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
@@ -177,6 +229,117 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	return nil
 }
 
+// SetDisabled ativa ou desativa uma conta
+func (r *UserRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	query := `UPDATE users SET disabled_at = NULL, updated_at = NOW() WHERE id = $1`
+	if disabled {
+		query = `UPDATE users SET disabled_at = COALESCE(disabled_at, NOW()), updated_at = NOW() WHERE id = $1`
+	}
+
+	return r.execByID(ctx, "failed to set user disabled", query, id)
+}
+
+// RequirePasswordReset obriga o usuário a trocar a senha no próximo login
+func (r *UserRepository) RequirePasswordReset(ctx context.Context, id string) error {
+	query := `UPDATE users SET password_reset_required = TRUE, updated_at = NOW() WHERE id = $1`
+
+	return r.execByID(ctx, "failed to require password reset", query, id)
+}
+
+// ChangeEmail troca o email, que volta a ficar não verificado. O papel de
+// administrador é removido: ele vale para o email de ADMIN_EMAILS, não para a conta.
+func (r *UserRepository) ChangeEmail(ctx context.Context, id, email string) error {
+	query := `
+		UPDATE users
+		SET email = $1, email_verified_at = NULL, role = 'user', updated_at = NOW()
+		WHERE id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, email, id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrUserAlreadyExists
+		}
+		r.logger.Error("failed to change email", zap.Error(err), zap.String("id", id))
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdatePassword troca a senha e limpa a obrigação de redefinição
+func (r *UserRepository) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	query := `
+		UPDATE users
+		SET password_hash = $1, password_reset_required = FALSE, updated_at = NOW()
+		WHERE id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, passwordHash, id)
+	if err != nil {
+		r.logger.Error("failed to update password", zap.Error(err), zap.String("id", id))
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// PromoteAdmins concede o papel de administrador aos emails configurados, desde que
+// verificados: sem isso qualquer um poderia se cadastrar com um desses emails
+func (r *UserRepository) PromoteAdmins(ctx context.Context, emails []string) (int64, error) {
+	if len(emails) == 0 {
+		return 0, nil
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users SET role = 'admin', updated_at = NOW() WHERE email = ANY($1) AND email_verified_at IS NOT NULL AND role <> 'admin'`,
+		pq.Array(emails),
+	)
+	if err != nil {
+		r.logger.Error("failed to promote admins", zap.Error(err))
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *UserRepository) execByID(ctx context.Context, message, query, id string) error {
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		r.logger.Error(message, zap.Error(err), zap.String("id", id))
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
 // Delete deleta um usuário
 func (r *UserRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM users WHERE id = $1`
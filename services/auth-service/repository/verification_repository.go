@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var ErrVerificationNotFound = errors.New("verification token not found")
+
+type VerificationRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewVerificationRepository(db *sql.DB, logger *zap.Logger) *VerificationRepository {
+	return &VerificationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create registra um token de verificação para o email informado
+func (r *VerificationRepository) Create(ctx context.Context, userID, email, token string, ttl time.Duration) error {
+	query := `
+		INSERT INTO email_verifications (token_hash, user_id, email, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, hashVerificationToken(token), userID, email, time.Now().Add(ttl))
+	if err != nil {
+		r.logger.Error("failed to create email verification",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// Consume usa o token e marca o email como verificado. Retorna o usuário e o email confirmados.
+// O token só vale se o email do usuário ainda for o mesmo de quando ele foi emitido.
+func (r *VerificationRepository) Consume(ctx context.Context, token string) (string, string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback()
+
+	var userID, email string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE email_verifications
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id, email
+	`, hashVerificationToken(token)).Scan(&userID, &email)
+
+	if err == sql.ErrNoRows {
+		return "", "", ErrVerificationNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to consume email verification", zap.Error(err))
+		return "", "", err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+		WHERE id = $1 AND email = $2
+	`, userID, email)
+	if err != nil {
+		r.logger.Error("failed to mark email as verified", zap.Error(err), zap.String("user_id", userID))
+		return "", "", err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return "", "", ErrVerificationNotFound
+	}
+
+	// Tokens pendentes para o mesmo email deixam de valer
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE email_verifications SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID); err != nil {
+		return "", "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", "", err
+	}
+
+	return userID, email, nil
+}
@@ -0,0 +1,295 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"auth-service/metrics"
+	"auth-service/models"
+	"auth-service/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Motivos que tornam um login suspeito
+const (
+	ReasonNewDevice        = "new_device"
+	ReasonImpossibleTravel = "impossible_travel"
+	ReasonFailureBurst     = "failure_burst"
+)
+
+var (
+	ErrChallengeInvalid   = errors.New("invalid or expired challenge")
+	ErrChallengeExhausted = errors.New("too many invalid codes")
+)
+
+// Thresholds são os limites configuráveis da detecção
+type Thresholds struct {
+	// Falhas consecutivas dentro da janela que tornam o próximo sucesso suspeito
+	FailureBurst       int
+	FailureBurstWindow time.Duration
+	// Velocidade máxima plausível entre dois logins e distância mínima para avaliá-la
+	MaxTravelSpeedKmh   float64
+	MinTravelDistanceKm float64
+	// Validade e número de tentativas do código de verificação adicional
+	ChallengeTTL         time.Duration
+	ChallengeMaxAttempts int
+}
+
+// Assessment é o resultado da avaliação de um login
+type Assessment struct {
+	Device   Device
+	Location *Location
+	Reasons  []string
+}
+
+// Suspicious indica se o login exige verificação adicional
+func (a *Assessment) Suspicious() bool {
+	return len(a.Reasons) > 0
+}
+
+// store é a parte do LoginSecurityRepository usada pelo Detector
+type store interface {
+	RecordAttempt(ctx context.Context, attempt *models.LoginAttempt) error
+	CountFailuresSince(ctx context.Context, userID string, since time.Time) (int, error)
+	LastLocatedSuccess(ctx context.Context, userID string) (*models.LoginAttempt, error)
+	DeviceStatus(ctx context.Context, userID, fingerprint string) (known bool, hasDevices bool, err error)
+	TouchDevice(ctx context.Context, device *models.KnownDevice) error
+	CreateChallenge(ctx context.Context, challenge *models.LoginChallenge) error
+	FindChallenge(ctx context.Context, id string) (*models.LoginChallenge, error)
+	ConsumeChallengeAttempt(ctx context.Context, id string, maxAttempts int) (string, error)
+	CompleteChallenge(ctx context.Context, id string) error
+}
+
+// Detector avalia logins e mantém o histórico de dispositivos e tentativas
+type Detector struct {
+	repo       store
+	geoip      *GeoIP
+	thresholds Thresholds
+	logger     *zap.Logger
+}
+
+func NewDetector(repo *repository.LoginSecurityRepository, geoip *GeoIP, thresholds Thresholds, logger *zap.Logger) *Detector {
+	return &Detector{
+		repo:       repo,
+		geoip:      geoip,
+		thresholds: thresholds,
+		logger:     logger,
+	}
+}
+
+// Locate estima a localização do IP do dispositivo
+func (d *Detector) Locate(device Device) *Location {
+	location, _ := d.geoip.Lookup(device.IP)
+	return location
+}
+
+// RecordFailure registra uma senha incorreta
+func (d *Detector) RecordFailure(ctx context.Context, userID string, device Device) {
+	d.recordAttempt(ctx, userID, device, d.Locate(device), false)
+}
+
+// Assess avalia um login com senha correta. Erros de consulta não bloqueiam o login.
+func (d *Detector) Assess(ctx context.Context, userID string, device Device) *Assessment {
+	assessment := &Assessment{Device: device, Location: d.Locate(device), Reasons: []string{}}
+
+	// Dispositivo desconhecido; o primeiro dispositivo de uma conta é registrado sem verificação
+	known, hasDevices, err := d.repo.DeviceStatus(ctx, userID, device.Fingerprint)
+	if err == nil && hasDevices && !known {
+		assessment.Reasons = append(assessment.Reasons, ReasonNewDevice)
+	}
+
+	// Viagem impossível em relação ao último login localizado
+	if assessment.Location != nil {
+		last, err := d.repo.LastLocatedSuccess(ctx, userID)
+		if err == nil && last != nil && d.impossibleTravel(last, assessment.Location) {
+			assessment.Reasons = append(assessment.Reasons, ReasonImpossibleTravel)
+		}
+	}
+
+	// Rajada de falhas seguida de sucesso
+	if d.thresholds.FailureBurst > 0 {
+		since := time.Now().Add(-d.thresholds.FailureBurstWindow)
+		failures, err := d.repo.CountFailuresSince(ctx, userID, since)
+		if err == nil && failures >= d.thresholds.FailureBurst {
+			assessment.Reasons = append(assessment.Reasons, ReasonFailureBurst)
+		}
+	}
+
+	for _, reason := range assessment.Reasons {
+		metrics.SuspiciousLoginsTotal.WithLabelValues(reason).Inc()
+	}
+
+	return assessment
+}
+
+func (d *Detector) impossibleTravel(last *models.LoginAttempt, current *Location) bool {
+	distance := haversineKm(*last.Latitude, *last.Longitude, current.Latitude, current.Longitude)
+	if distance < d.thresholds.MinTravelDistanceKm {
+		return false
+	}
+
+	hours := time.Since(last.CreatedAt).Hours()
+	if hours <= 0 {
+		return true
+	}
+
+	return distance/hours > d.thresholds.MaxTravelSpeedKmh
+}
+
+// haversineKm calcula a distância em km entre duas coordenadas
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// RecordSuccess registra o login aceito e marca o dispositivo como conhecido
+func (d *Detector) RecordSuccess(ctx context.Context, userID string, device Device, location *Location) {
+	d.recordAttempt(ctx, userID, device, location, true)
+
+	d.repo.TouchDevice(ctx, &models.KnownDevice{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Fingerprint: device.Fingerprint,
+		UserAgent:   device.UserAgent,
+		IPPrefix:    device.IPPrefix,
+		DeviceID:    device.DeviceID,
+	})
+}
+
+func (d *Detector) recordAttempt(ctx context.Context, userID string, device Device, location *Location, success bool) {
+	attempt := &models.LoginAttempt{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Success:     success,
+		IP:          device.IP,
+		Fingerprint: device.Fingerprint,
+		CreatedAt:   time.Now(),
+	}
+
+	if location != nil {
+		attempt.Country = location.Country
+		attempt.Latitude = &location.Latitude
+		attempt.Longitude = &location.Longitude
+	}
+
+	d.repo.RecordAttempt(ctx, attempt)
+}
+
+// CreateChallenge abre um desafio de verificação adicional e retorna o código a ser enviado ao usuário
+func (d *Detector) CreateChallenge(ctx context.Context, userID string, assessment *Assessment) (*models.LoginChallenge, string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return nil, "", err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	device := assessment.Device
+	challenge := &models.LoginChallenge{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		CodeHash:    hashCode(code),
+		Fingerprint: device.Fingerprint,
+		UserAgent:   device.UserAgent,
+		IP:          device.IP,
+		IPPrefix:    device.IPPrefix,
+		DeviceID:    device.DeviceID,
+		Reasons:     assessment.Reasons,
+		ExpiresAt:   time.Now().Add(d.thresholds.ChallengeTTL),
+		CreatedAt:   time.Now(),
+	}
+
+	if location := assessment.Location; location != nil {
+		challenge.Country = location.Country
+		challenge.Latitude = &location.Latitude
+		challenge.Longitude = &location.Longitude
+	}
+
+	if err := d.repo.CreateChallenge(ctx, challenge); err != nil {
+		return nil, "", err
+	}
+
+	return challenge, code, nil
+}
+
+// VerifyChallenge confere o código e, se correto, encerra o desafio e registra o login
+func (d *Detector) VerifyChallenge(ctx context.Context, challengeID, code string) (*models.LoginChallenge, error) {
+	if _, err := uuid.Parse(challengeID); err != nil {
+		return nil, ErrChallengeInvalid
+	}
+
+	challenge, err := d.repo.FindChallenge(ctx, challengeID)
+	if err == repository.ErrChallengeNotFound {
+		return nil, ErrChallengeInvalid
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(challenge.ExpiresAt) {
+		return nil, ErrChallengeInvalid
+	}
+
+	// A tentativa é contada antes da comparação, atomicamente com o limite
+	codeHash, err := d.repo.ConsumeChallengeAttempt(ctx, challenge.ID, d.thresholds.ChallengeMaxAttempts)
+	if err == repository.ErrChallengeNotFound {
+		return nil, ErrChallengeExhausted
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashCode(code)), []byte(codeHash)) != 1 {
+		return nil, ErrChallengeInvalid
+	}
+
+	if err := d.repo.CompleteChallenge(ctx, challenge.ID); err != nil {
+		if err == repository.ErrChallengeNotFound {
+			return nil, ErrChallengeInvalid
+		}
+		return nil, err
+	}
+
+	device := Device{
+		UserAgent:   challenge.UserAgent,
+		IP:          challenge.IP,
+		IPPrefix:    challenge.IPPrefix,
+		DeviceID:    challenge.DeviceID,
+		Fingerprint: challenge.Fingerprint,
+	}
+
+	var location *Location
+	if challenge.Latitude != nil && challenge.Longitude != nil {
+		location = &Location{
+			Country:   challenge.Country,
+			Latitude:  *challenge.Latitude,
+			Longitude: *challenge.Longitude,
+		}
+	}
+
+	d.RecordSuccess(ctx, challenge.UserID, device, location)
+	return challenge, nil
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
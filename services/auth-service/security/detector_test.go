@@ -0,0 +1,303 @@
+package security
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"auth-service/models"
+	"auth-service/repository"
+)
+
+// fakeStore reproduz em memória as regras das consultas do LoginSecurityRepository
+type fakeStore struct {
+	attempts   []*models.LoginAttempt
+	devices    map[string]bool
+	challenges map[string]*models.LoginChallenge
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{devices: map[string]bool{}, challenges: map[string]*models.LoginChallenge{}}
+}
+
+func (f *fakeStore) RecordAttempt(ctx context.Context, attempt *models.LoginAttempt) error {
+	f.attempts = append(f.attempts, attempt)
+	return nil
+}
+
+func (f *fakeStore) CountFailuresSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	lastSuccess := time.Time{}
+	for _, attempt := range f.attempts {
+		if attempt.UserID == userID && attempt.Success && attempt.CreatedAt.After(lastSuccess) {
+			lastSuccess = attempt.CreatedAt
+		}
+	}
+
+	count := 0
+	for _, attempt := range f.attempts {
+		if attempt.UserID == userID && !attempt.Success && attempt.CreatedAt.After(since) && attempt.CreatedAt.After(lastSuccess) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeStore) LastLocatedSuccess(ctx context.Context, userID string) (*models.LoginAttempt, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) DeviceStatus(ctx context.Context, userID, fingerprint string) (bool, bool, error) {
+	return f.devices[userID+"|"+fingerprint], len(f.devices) > 0, nil
+}
+
+func (f *fakeStore) TouchDevice(ctx context.Context, device *models.KnownDevice) error {
+	f.devices[device.UserID+"|"+device.Fingerprint] = true
+	return nil
+}
+
+func (f *fakeStore) CreateChallenge(ctx context.Context, challenge *models.LoginChallenge) error {
+	f.challenges[challenge.ID] = challenge
+	return nil
+}
+
+func (f *fakeStore) FindChallenge(ctx context.Context, id string) (*models.LoginChallenge, error) {
+	challenge, ok := f.challenges[id]
+	if !ok || challenge.CompletedAt != nil {
+		return nil, repository.ErrChallengeNotFound
+	}
+	copied := *challenge
+	return &copied, nil
+}
+
+func (f *fakeStore) ConsumeChallengeAttempt(ctx context.Context, id string, maxAttempts int) (string, error) {
+	challenge, ok := f.challenges[id]
+	if !ok || challenge.Attempts >= maxAttempts || challenge.CompletedAt != nil {
+		return "", repository.ErrChallengeNotFound
+	}
+	challenge.Attempts++
+	return challenge.CodeHash, nil
+}
+
+func (f *fakeStore) CompleteChallenge(ctx context.Context, id string) error {
+	challenge, ok := f.challenges[id]
+	if !ok || challenge.CompletedAt != nil {
+		return repository.ErrChallengeNotFound
+	}
+	now := time.Now()
+	challenge.CompletedAt = &now
+	return nil
+}
+
+func TestHaversineKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"mesmo ponto", -23.5505, -46.6333, -23.5505, -46.6333, 0},
+		{"um grau no equador", 0, 0, 0, 1, 111.19},
+		{"polo a polo", 90, 0, -90, 0, 20015.09},
+		{"antípodas no equador", 0, 0, 0, 180, 20015.09},
+		{"São Paulo a Rio de Janeiro", -23.5505, -46.6333, -22.9068, -43.1729, 360.75},
+		{"São Paulo a Lisboa", -23.5505, -46.6333, 38.7223, -9.1393, 7949.03},
+		{"atravessa o antimeridiano", 0, 179.5, 0, -179.5, 111.19},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := haversineKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("haversineKm() = %.2f, want %.2f", got, tt.want)
+			}
+
+			// A distância não depende do sentido
+			if back := haversineKm(tt.lat2, tt.lon2, tt.lat1, tt.lon1); math.Abs(back-got) > 1e-9 {
+				t.Errorf("haversineKm() invertido = %.2f, want %.2f", back, got)
+			}
+		})
+	}
+}
+
+func TestImpossibleTravel(t *testing.T) {
+	detector := &Detector{thresholds: Thresholds{MaxTravelSpeedKmh: 900, MinTravelDistanceKm: 500}}
+
+	saoPaulo := Location{Latitude: -23.5505, Longitude: -46.6333}
+	rio := Location{Latitude: -22.9068, Longitude: -43.1729}
+	lisboa := Location{Latitude: 38.7223, Longitude: -9.1393}
+
+	tests := []struct {
+		name    string
+		last    Location
+		current Location
+		ago     time.Duration
+		want    bool
+	}{
+		{"mesmo lugar", saoPaulo, saoPaulo, time.Minute, false},
+		{"abaixo da distância mínima no mesmo instante", saoPaulo, rio, 0, false},
+		{"abaixo da distância mínima no futuro", saoPaulo, rio, -time.Hour, false},
+		{"oceano em duas horas", saoPaulo, lisboa, 2 * time.Hour, true},
+		{"oceano em doze horas", saoPaulo, lisboa, 12 * time.Hour, false},
+		{"no limite da velocidade", saoPaulo, lisboa, 8*time.Hour + 49*time.Minute, true},
+		{"logo acima do tempo mínimo", saoPaulo, lisboa, 8*time.Hour + 50*time.Minute, false},
+		{"login anterior no futuro (relógio adiantado)", saoPaulo, lisboa, -time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			last := &models.LoginAttempt{
+				Latitude:  &tt.last.Latitude,
+				Longitude: &tt.last.Longitude,
+				CreatedAt: time.Now().Add(-tt.ago),
+			}
+
+			current := tt.current
+			if got := detector.impossibleTravel(last, &current); got != tt.want {
+				t.Errorf("impossibleTravel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailureBurst(t *testing.T) {
+	device := NewDevice("Mozilla/5.0", "10.0.0.1", "")
+
+	tests := []struct {
+		name      string
+		threshold int
+		// Sequência de tentativas: F para senha incorreta, S para login aceito
+		events string
+		// Quanto tempo atrás a sequência aconteceu
+		age  time.Duration
+		want bool
+	}{
+		{name: "sem falhas", threshold: 3, events: "", want: false},
+		{name: "abaixo do limite", threshold: 3, events: "FF", want: false},
+		{name: "no limite", threshold: 3, events: "FFF", want: true},
+		{name: "acima do limite", threshold: 3, events: "FFFFF", want: true},
+		{name: "sucesso zera a contagem", threshold: 3, events: "FFFS", want: false},
+		{name: "sucesso no meio da rajada", threshold: 3, events: "FFSFF", want: false},
+		{name: "nova rajada depois do sucesso", threshold: 3, events: "FFFSFFF", want: true},
+		{name: "rajada fora da janela", threshold: 3, events: "FFFF", age: 20 * time.Minute, want: false},
+		{name: "rajada dentro da janela", threshold: 3, events: "FFFF", age: 10 * time.Minute, want: true},
+		{name: "detecção desativada", threshold: 0, events: "FFFFF", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeStore()
+			detector := &Detector{repo: repo, thresholds: Thresholds{
+				FailureBurst:       tt.threshold,
+				FailureBurstWindow: 15 * time.Minute,
+			}}
+
+			ctx := context.Background()
+			for i, event := range tt.events {
+				switch event {
+				case 'F':
+					detector.RecordFailure(ctx, "user-1", device)
+				case 'S':
+					detector.RecordSuccess(ctx, "user-1", device, nil)
+				}
+				// Mantém a ordem das tentativas mesmo com o relógio de baixa resolução
+				repo.attempts[len(repo.attempts)-1].CreatedAt = time.Now().Add(-tt.age - time.Duration(len(tt.events)-i)*time.Millisecond)
+			}
+
+			assessment := detector.Assess(ctx, "user-1", device)
+			if got := hasReason(assessment, ReasonFailureBurst); got != tt.want {
+				t.Errorf("Assess() failure_burst = %v, want %v (reasons %v)", got, tt.want, assessment.Reasons)
+			}
+		})
+	}
+}
+
+func TestVerifyChallenge(t *testing.T) {
+	const right, wrong = "right", "wrong"
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		// Códigos enviados em sequência e o erro esperado de cada um
+		codes []string
+		want  []error
+	}{
+		{name: "código correto", ttl: time.Minute, codes: []string{right}, want: []error{nil}},
+		{name: "código errado", ttl: time.Minute, codes: []string{wrong}, want: []error{ErrChallengeInvalid}},
+		{name: "erra e acerta", ttl: time.Minute, codes: []string{wrong, right}, want: []error{ErrChallengeInvalid, nil}},
+		{
+			name:  "acerta na última tentativa",
+			ttl:   time.Minute,
+			codes: []string{wrong, wrong, right},
+			want:  []error{ErrChallengeInvalid, ErrChallengeInvalid, nil},
+		},
+		{
+			name:  "tentativas esgotadas",
+			ttl:   time.Minute,
+			codes: []string{wrong, wrong, wrong, right},
+			want:  []error{ErrChallengeInvalid, ErrChallengeInvalid, ErrChallengeInvalid, ErrChallengeExhausted},
+		},
+		{name: "desafio já usado", ttl: time.Minute, codes: []string{right, right}, want: []error{nil, ErrChallengeInvalid}},
+		{name: "desafio expirado", ttl: -time.Second, codes: []string{right}, want: []error{ErrChallengeInvalid}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeStore()
+			detector := &Detector{repo: repo, thresholds: Thresholds{ChallengeTTL: tt.ttl, ChallengeMaxAttempts: 3}}
+
+			ctx := context.Background()
+			device := NewDevice("Mozilla/5.0", "10.0.0.1", "")
+			assessment := &Assessment{Device: device, Reasons: []string{ReasonNewDevice}}
+			challenge, code, err := detector.CreateChallenge(ctx, "user-1", assessment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, attempt := range tt.codes {
+				sent := code
+				if attempt == wrong {
+					sent = "x" + code
+				}
+
+				verified, err := detector.VerifyChallenge(ctx, challenge.ID, sent)
+				if err != tt.want[i] {
+					t.Fatalf("tentativa %d: VerifyChallenge() error = %v, want %v", i+1, err, tt.want[i])
+				}
+
+				if err == nil && verified.UserID != "user-1" {
+					t.Errorf("tentativa %d: VerifyChallenge() user = %s, want user-1", i+1, verified.UserID)
+				}
+			}
+
+			// Só o código aceito registra o login e torna o dispositivo conhecido
+			accepted := false
+			for _, err := range tt.want {
+				accepted = accepted || err == nil
+			}
+
+			known, _, _ := repo.DeviceStatus(ctx, "user-1", device.Fingerprint)
+			if known != accepted {
+				t.Errorf("dispositivo conhecido = %v, want %v", known, accepted)
+			}
+		})
+	}
+}
+
+func TestVerifyChallengeMalformedID(t *testing.T) {
+	detector := &Detector{repo: newFakeStore(), thresholds: Thresholds{ChallengeTTL: time.Minute, ChallengeMaxAttempts: 3}}
+
+	for _, id := range []string{"", "abc", "../../etc"} {
+		if _, err := detector.VerifyChallenge(context.Background(), id, "123456"); err != ErrChallengeInvalid {
+			t.Errorf("VerifyChallenge(%q) error = %v, want %v", id, err, ErrChallengeInvalid)
+		}
+	}
+}
+
+func hasReason(assessment *Assessment, reason string) bool {
+	for _, r := range assessment.Reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strings"
+)
+
+// Device identifica o dispositivo de origem de um login.
+// A impressão digital combina user agent, prefixo do IP (/24 no IPv4, /48 no IPv6)
+// e o identificador opcional enviado pelo cliente, tolerando trocas de IP dentro da mesma rede.
+type Device struct {
+	UserAgent   string
+	IP          string
+	IPPrefix    string
+	DeviceID    string
+	Fingerprint string
+}
+
+// NewDevice monta o dispositivo a partir dos dados da requisição
+func NewDevice(userAgent, ip, deviceID string) Device {
+	device := Device{
+		UserAgent: strings.TrimSpace(userAgent),
+		IP:        ip,
+		IPPrefix:  ipPrefix(ip),
+		DeviceID:  strings.TrimSpace(deviceID),
+	}
+
+	sum := sha256.Sum256([]byte(device.UserAgent + "|" + device.IPPrefix + "|" + device.DeviceID))
+	device.Fingerprint = hex.EncodeToString(sum[:])
+	return device
+}
+
+func ipPrefix(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
@@ -0,0 +1,61 @@
+package security
+
+import (
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Location é a posição estimada de um IP
+type Location struct {
+	Country   string
+	Latitude  float64
+	Longitude float64
+}
+
+// GeoIP consulta um banco GeoLite2/GeoIP2 City local (formato .mmdb).
+// Um GeoIP nil é válido e nunca encontra localização, desativando a checagem de viagem impossível.
+type GeoIP struct {
+	reader *geoip2.Reader
+}
+
+// OpenGeoIP abre o banco de geolocalização informado
+func OpenGeoIP(path string) (*GeoIP, error) {
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeoIP{reader: reader}, nil
+}
+
+// Lookup retorna a localização do IP, se conhecida
+func (g *GeoIP) Lookup(ip string) (*Location, bool) {
+	if g == nil {
+		return nil, false
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsLoopback() || parsed.IsPrivate() {
+		return nil, false
+	}
+
+	record, err := g.reader.City(parsed)
+	if err != nil || (record.Location.Latitude == 0 && record.Location.Longitude == 0) {
+		return nil, false
+	}
+
+	return &Location{
+		Country:   record.Country.IsoCode,
+		Latitude:  record.Location.Latitude,
+		Longitude: record.Location.Longitude,
+	}, true
+}
+
+// Close libera o banco de geolocalização
+func (g *GeoIP) Close() error {
+	if g == nil {
+		return nil
+	}
+	return g.reader.Close()
+}
@@ -24,7 +24,8 @@ const config = {
     smtpPort: parseInt(process.env.SMTP_PORT || '587'),
     smtpUser: process.env.SMTP_USER || '',
     smtpPass: process.env.SMTP_PASS || '',
-    emailFrom: process.env.EMAIL_FROM || 'noreply@financeapp.com'
+    emailFrom: process.env.EMAIL_FROM || 'noreply@financeapp.com',
+    appUrl: process.env.APP_URL || 'http://localhost:3000'
 };
 
 // ============================================
@@ -151,7 +152,7 @@ class EmailService {
                     <p>Uma transação de alto valor foi detectada em sua conta:</p>
                     <ul>
                         <li><strong>Descrição:</strong> ${transaction.description}</li>
-                        <li><strong>Valor:</strong> R$ ${transaction.amount.toFixed(2)}</li>
+                        <li><strong>Valor:</strong> R$ ${Number(transaction.amount).toFixed(2)}</li>
                         <li><strong>Categoria:</strong> ${transaction.category}</li>
                         <li><strong>Data:</strong> ${new Date(transaction.date).toLocaleString('pt-BR')}</li>
                     </ul>
@@ -180,6 +181,218 @@ class EmailService {
         }
     }
 
+    async sendWelcome(user) {
+        if (!this.transporter) {
+            return { success: false, reason: 'not_configured' };
+        }
+
+        try {
+            const verifyUrl = `${config.appUrl}/verify-email?token=${encodeURIComponent(user.verification_token)}`;
+            const mailOptions = {
+                from: config.emailFrom,
+                to: user.email,
+                subject: 'Bem-vindo! Confirme seu email',
+                html: `
+                    <h2>Olá, ${user.name}!</h2>
+                    <p>Sua conta foi criada com sucesso.</p>
+                    <p>Para confirmar seu email, acesse o link abaixo:</p>
+                    <p><a href="${verifyUrl}">${verifyUrl}</a></p>
+                `
+            };
+
+            const info = await this.transporter.sendMail(mailOptions);
+
+            logger.info('Welcome email sent', {
+                user_id: user.user_id,
+                message_id: info.messageId
+            });
+
+            return { success: true, messageId: info.messageId };
+
+        } catch (error) {
+            logger.error('Error sending welcome email', {
+                error: error.message,
+                user_id: user.user_id
+            });
+
+            return { success: false, error: error.message };
+        }
+    }
+
+    async sendVerification(user) {
+        if (!this.transporter) {
+            return { success: false, reason: 'not_configured' };
+        }
+
+        try {
+            const verifyUrl = `${config.appUrl}/verify-email?token=${encodeURIComponent(user.verification_token)}`;
+            const info = await this.transporter.sendMail({
+                from: config.emailFrom,
+                to: user.email,
+                subject: 'Confirme seu email',
+                html: `
+                    <h2>Olá, ${user.name}!</h2>
+                    <p>Para confirmar seu email, acesse o link abaixo:</p>
+                    <p><a href="${verifyUrl}">${verifyUrl}</a></p>
+                `
+            });
+
+            logger.info('Verification email sent', {
+                user_id: user.user_id,
+                message_id: info.messageId
+            });
+
+            return { success: true, messageId: info.messageId };
+
+        } catch (error) {
+            logger.error('Error sending verification email', {
+                error: error.message,
+                user_id: user.user_id
+            });
+
+            return { success: false, error: error.message };
+        }
+    }
+
+    async sendEmailChanged(data) {
+        if (!this.transporter) {
+            return { success: false, reason: 'not_configured' };
+        }
+
+        try {
+            const verifyUrl = `${config.appUrl}/verify-email?token=${encodeURIComponent(data.verification_token)}`;
+
+            // Confirmação para o novo endereço
+            const info = await this.transporter.sendMail({
+                from: config.emailFrom,
+                to: data.new_email,
+                subject: 'Confirme seu novo email',
+                html: `
+                    <h2>Olá, ${data.name}!</h2>
+                    <p>Para confirmar seu novo email, acesse o link abaixo:</p>
+                    <p><a href="${verifyUrl}">${verifyUrl}</a></p>
+                `
+            });
+
+            // Aviso para o endereço antigo
+            await this.transporter.sendMail({
+                from: config.emailFrom,
+                to: data.old_email,
+                subject: 'Seu email foi alterado',
+                html: `
+                    <h2>Email alterado</h2>
+                    <p>O email da sua conta foi alterado para ${data.new_email}.</p>
+                    <p>Se você não reconhece esta alteração, entre em contato conosco imediatamente.</p>
+                `
+            });
+
+            logger.info('Email changed notifications sent', {
+                user_id: data.user_id,
+                message_id: info.messageId
+            });
+
+            return { success: true, messageId: info.messageId };
+
+        } catch (error) {
+            logger.error('Error sending email changed notifications', {
+                error: error.message,
+                user_id: data.user_id
+            });
+
+            return { success: false, error: error.message };
+        }
+    }
+
+    async sendSuspiciousLogin(data) {
+        if (!this.transporter) {
+            return { success: false, reason: 'not_configured' };
+        }
+
+        const reasonLabels = {
+            new_device: 'Novo dispositivo',
+            impossible_travel: 'Localização incompatível com o último acesso',
+            failure_burst: 'Várias tentativas de senha incorretas'
+        };
+
+        try {
+            const reasons = (data.reasons || []).map((reason) => `<li>${reasonLabels[reason] || reason}</li>`).join('');
+            const mailOptions = {
+                from: config.emailFrom,
+                to: data.email,
+                subject: '🔐 Código de verificação de acesso',
+                html: `
+                    <h2>Confirme que é você</h2>
+                    <p>Detectamos um acesso incomum à sua conta:</p>
+                    <ul>${reasons}</ul>
+                    <ul>
+                        <li><strong>IP:</strong> ${data.ip}${data.country ? ` (${data.country})` : ''}</li>
+                        <li><strong>Navegador:</strong> ${data.user_agent}</li>
+                    </ul>
+                    <p>Seu código de verificação é <strong>${data.code}</strong>.
+                    Ele expira em ${new Date(data.expires_at).toLocaleString('pt-BR')}.</p>
+                    <p>Se não foi você, troque sua senha imediatamente.</p>
+                `
+            };
+
+            const info = await this.transporter.sendMail(mailOptions);
+
+            logger.info('Suspicious login email sent', {
+                user_id: data.user_id,
+                challenge_id: data.challenge_id,
+                message_id: info.messageId
+            });
+
+            return { success: true, messageId: info.messageId };
+
+        } catch (error) {
+            logger.error('Error sending suspicious login email', {
+                error: error.message,
+                user_id: data.user_id
+            });
+
+            return { success: false, error: error.message };
+        }
+    }
+
+    async sendGoalAchieved(userEmail, data) {
+        if (!this.transporter) {
+            return { success: false, reason: 'not_configured' };
+        }
+
+        try {
+            const mailOptions = {
+                from: config.emailFrom,
+                to: userEmail,
+                subject: '🎯 Meta atingida!',
+                html: `
+                    <h2>Parabéns!</h2>
+                    <p>Você atingiu a meta <strong>${data.goal_name}</strong>:</p>
+                    <ul>
+                        <li><strong>Meta:</strong> ${data.currency} ${Number(data.target_amount).toFixed(2)}</li>
+                        <li><strong>Economizado:</strong> ${data.currency} ${Number(data.saved_amount).toFixed(2)}</li>
+                    </ul>
+                `
+            };
+
+            const info = await this.transporter.sendMail(mailOptions);
+
+            logger.info('Goal achieved email sent', {
+                user_email: userEmail,
+                message_id: info.messageId
+            });
+
+            return { success: true, messageId: info.messageId };
+
+        } catch (error) {
+            logger.error('Error sending goal achieved email', {
+                error: error.message,
+                user_email: userEmail
+            });
+
+            return { success: false, error: error.message };
+        }
+    }
+
     async sendBudgetAlert(userEmail, data) {
         if (!this.transporter) {
             return { success: false, reason: 'not_configured' };
@@ -189,14 +402,16 @@ class EmailService {
             const mailOptions = {
                 from: config.emailFrom,
                 to: userEmail,
-                subject: '⚠️ Alerta de Orçamento',
+                subject: data.event_type === 'budget.warning'
+                    ? '⚠️ Orçamento quase no limite'
+                    : '⚠️ Orçamento estourado',
                 html: `
                     <h2>Alerta de Orçamento</h2>
-                    <p>Você atingiu ${data.percentage}% do seu orçamento mensal:</p>
+                    <p>Você atingiu ${data.percentage}% do seu orçamento de ${data.month}:</p>
                     <ul>
                         <li><strong>Categoria:</strong> ${data.category}</li>
-                        <li><strong>Gasto Atual:</strong> R$ ${data.current_amount.toFixed(2)}</li>
-                        <li><strong>Orçamento:</strong> R$ ${data.budget_limit.toFixed(2)}</li>
+                        <li><strong>Gasto Atual:</strong> ${data.currency || 'BRL'} ${Number(data.current_amount).toFixed(2)}</li>
+                        <li><strong>Orçamento:</strong> ${data.currency || 'BRL'} ${Number(data.budget_limit).toFixed(2)}</li>
                     </ul>
                 `
             };
@@ -243,6 +458,11 @@ class NotificationService {
                 durable: true
             });
 
+            // Exchange de eventos de usuários (auth-service)
+            await this.channel.assertExchange('users_exchange', 'topic', {
+                durable: true
+            });
+
             // Declara fila para notificações
             const queueName = 'notification_queue';
             await this.channel.assertQueue(queueName, {
@@ -255,9 +475,17 @@ class NotificationService {
 
             // Bind para eventos de transações
             await this.channel.bindQueue(queueName, 'transactions_exchange', 'transaction.created');
+            await this.channel.bindQueue(queueName, 'transactions_exchange', 'budget.warning');
             await this.channel.bindQueue(queueName, 'transactions_exchange', 'budget.exceeded');
             await this.channel.bindQueue(queueName, 'transactions_exchange', 'goal.achieved');
 
+            // Bind para eventos de usuários
+            await this.channel.bindQueue(queueName, 'users_exchange', 'user.registered');
+            await this.channel.bindQueue(queueName, 'users_exchange', 'user.verification_requested');
+            await this.channel.bindQueue(queueName, 'users_exchange', 'user.email_changed');
+            await this.channel.bindQueue(queueName, 'users_exchange', 'user.deletion_requested');
+            await this.channel.bindQueue(queueName, 'users_exchange', 'security.suspicious_login');
+
             // Configura prefetch
             await this.channel.prefetch(1);
 
@@ -310,12 +538,28 @@ class NotificationService {
                 case 'transaction.created':
                     result = await this.handleTransactionCreated(message);
                     break;
+                case 'budget.warning':
                 case 'budget.exceeded':
-                    result = await this.handleBudgetExceeded(message);
+                    result = await this.handleBudgetAlert(message);
                     break;
                 case 'goal.achieved':
                     result = await this.handleGoalAchieved(message);
                     break;
+                case 'user.registered':
+                    result = await this.handleUserRegistered(message, traceId);
+                    break;
+                case 'user.verification_requested':
+                    result = await this.handleVerificationRequested(message, traceId);
+                    break;
+                case 'user.email_changed':
+                    result = await this.handleUserEmailChanged(message, traceId);
+                    break;
+                case 'security.suspicious_login':
+                    result = await this.handleSuspiciousLogin(message, traceId);
+                    break;
+                case 'user.deletion_requested':
+                    result = await this.handleUserDeletionRequested(message, traceId);
+                    break;
                 default:
                     logger.warn('Unknown routing key', { routing_key: msg.fields.routingKey });
                     result = { success: false, reason: 'unknown_routing_key' };
@@ -357,7 +601,10 @@ class NotificationService {
     }
 
     async handleTransactionCreated(message) {
-        const { amount, description, user_email } = message;
+        const { description, user_email } = message;
+
+        // O valor chega como string decimal exata ("1234.50")
+        const amount = Number(message.amount);
 
         // Verifica se é transação de alto valor
         if (amount > config.highAmountThreshold) {
@@ -384,14 +631,23 @@ class NotificationService {
         return { success: true, notified: false };
     }
 
-    async handleBudgetExceeded(message) {
-        logger.info('Budget exceeded event received', {
+    async handleBudgetAlert(message) {
+        logger.info('Budget alert event received', {
+            event_type: message.event_type,
             user_id: message.user_id,
-            category: message.category
+            category: message.category,
+            percentage: message.percentage
         });
 
+        // Os valores chegam como string decimal exata ("1234.50")
         if (message.user_email) {
-            return await this.emailService.sendBudgetAlert(message.user_email, message);
+            const result = await this.emailService.sendBudgetAlert(message.user_email, message);
+
+            if (result.reason === 'not_configured') {
+                return { success: true, notified: false };
+            }
+
+            return result;
         }
 
         return { success: true, notified: false };
@@ -400,10 +656,114 @@ class NotificationService {
     async handleGoalAchieved(message) {
         logger.info('Goal achieved event received', {
             user_id: message.user_id,
+            goal_id: message.goal_id,
             goal: message.goal_name
         });
 
-        // Implementar notificação de meta atingida
+        if (message.user_email) {
+            const result = await this.emailService.sendGoalAchieved(message.user_email, message);
+
+            if (result.reason === 'not_configured') {
+                return { success: true, notified: false };
+            }
+
+            return result;
+        }
+
+        return { success: true, notified: false };
+    }
+
+    async handleUserRegistered(message, traceId) {
+        logger.info('User registered event received', {
+            user_id: message.user_id,
+            trace_id: traceId
+        });
+
+        const result = await this.emailService.sendWelcome(message);
+
+        // Sem SMTP configurado não há o que reenviar; evita requeue infinito
+        if (result.reason === 'not_configured') {
+            return { success: true, notified: false };
+        }
+
+        return result;
+    }
+
+    async handleVerificationRequested(message, traceId) {
+        logger.info('Verification requested event received', {
+            user_id: message.user_id,
+            trace_id: traceId
+        });
+
+        const result = await this.emailService.sendVerification(message);
+
+        if (result.reason === 'not_configured') {
+            return { success: true, notified: false };
+        }
+
+        return result;
+    }
+
+    async handleUserEmailChanged(message, traceId) {
+        logger.info('User email changed event received', {
+            user_id: message.user_id,
+            trace_id: traceId
+        });
+
+        const result = await this.emailService.sendEmailChanged(message);
+
+        if (result.reason === 'not_configured') {
+            return { success: true, notified: false };
+        }
+
+        return result;
+    }
+
+    async handleSuspiciousLogin(message, traceId) {
+        logger.warn('Suspicious login event received', {
+            user_id: message.user_id,
+            challenge_id: message.challenge_id,
+            reasons: message.reasons,
+            trace_id: traceId
+        });
+
+        const result = await this.emailService.sendSuspiciousLogin(message);
+
+        if (result.reason === 'not_configured') {
+            return { success: true, notified: false };
+        }
+
+        return result;
+    }
+
+    async handleUserDeletionRequested(message, traceId) {
+        const participants = message.participants || [];
+        if (participants.length > 0 && !participants.includes('notification-service')) {
+            return { success: true, notified: false };
+        }
+
+        // O serviço ainda não persiste preferências por usuário, então não há
+        // dados a apagar; a confirmação mantém a saga de exclusão avançando.
+        logger.info('User deletion requested', {
+            request_id: message.request_id,
+            user_id: message.user_id,
+            trace_id: traceId
+        });
+
+        const ack = {
+            request_id: message.request_id,
+            user_id: message.user_id,
+            service: 'notification-service',
+            details: { preferences_deleted: 0 },
+            timestamp: new Date().toISOString()
+        };
+
+        this.channel.publish('users_exchange', 'user.deletion_acknowledged', Buffer.from(JSON.stringify(ack)), {
+            contentType: 'application/json',
+            persistent: true,
+            headers: { trace_id: traceId || '' }
+        });
+
         return { success: true, notified: false };
     }
 
@@ -0,0 +1,133 @@
+// Package export escreve transações em formatos de arquivo (CSV, JSON Lines,
+// OFX e diários Beancount/ledger-cli) à medida que elas são lidas, sem
+// acumular o resultado.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"transaction-service/models"
+	"transaction-service/money"
+)
+
+// Formatos de exportação
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatOFX   = "ofx"
+)
+
+// ContentTypes associa cada formato ao tipo de mídia da resposta
+var ContentTypes = map[string]string{
+	FormatCSV:   "text/csv; charset=utf-8",
+	FormatJSONL: "application/x-ndjson",
+	FormatOFX:   "application/x-ofx",
+	// Os diários não têm tipo de mídia próprio; só são escolhidos por ?format=
+	FormatBeancount: "text/plain; charset=utf-8",
+	FormatLedger:    "text/plain; charset=utf-8",
+}
+
+// Writer recebe as transações uma a uma. Flush envia o que estiver em buffer;
+// Close completa o arquivo e deve ser chamado mesmo sem nenhuma transação.
+type Writer interface {
+	Write(t *models.Transaction) error
+	Flush() error
+	Close() error
+}
+
+// Signed é o valor com o sinal do efeito no saldo da conta
+func Signed(t *models.Transaction) money.Amount {
+	if t.Type == "income" || (t.TransferSide != nil && *t.TransferSide == models.TransferSideIn) {
+		return t.Amount
+	}
+	return -t.Amount
+}
+
+var csvHeader = []string{
+	"id", "date", "account_id", "type", "category", "description", "amount", "signed_amount",
+	"currency", "transfer_id", "external_id", "created_at", "tags",
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter escreve o cabeçalho e uma linha por transação. As tags vão numa
+// só coluna, separadas por ";".
+func NewCSVWriter(w io.Writer) (Writer, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw}, nil
+}
+
+func (c *csvWriter) Write(t *models.Transaction) error {
+	return c.w.Write([]string{
+		t.ID,
+		t.Date.Format("2006-01-02"),
+		t.AccountID,
+		t.Type,
+		spreadsheetSafe(t.Category),
+		spreadsheetSafe(t.Description),
+		t.Amount.String(),
+		Signed(t).String(),
+		t.Currency,
+		optional(t.TransferID),
+		optional(t.ExternalID),
+		t.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		spreadsheetSafe(strings.Join(t.Tags, ";")),
+	})
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+// spreadsheetSafe evita que textos livres sejam interpretados como fórmula
+// ao abrir o CSV numa planilha
+func spreadsheetSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func optional(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLWriter escreve um objeto JSON por linha, no mesmo formato da API
+func NewJSONLWriter(w io.Writer) (Writer, error) {
+	buffered := bufio.NewWriter(w)
+	return &jsonlWriter{w: buffered, enc: json.NewEncoder(buffered)}, nil
+}
+
+func (j *jsonlWriter) Write(t *models.Transaction) error {
+	return j.enc.Encode(t)
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.w.Flush()
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.Flush()
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"transaction-service/models"
+)
+
+func TestCSVWriterTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{"sem tags", nil, ""},
+		{"uma tag", []string{"viagem"}, "viagem"},
+		{"várias tags", []string{"viagem", "trabalho"}, "viagem;trabalho"},
+		{"tag parecida com fórmula", []string{"=SOMA(A1)", "viagem"}, "'=SOMA(A1);viagem"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewCSVWriter(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			transaction := &models.Transaction{ID: "t1", Type: "expense", Amount: 1000, Currency: "BRL", Date: day("2024-01-05"), Tags: tt.tags}
+			if err := writer.Write(transaction); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 || !strings.HasSuffix(lines[0], ",tags") {
+				t.Fatalf("CSV = %q, want header ending in tags and one row", buf.String())
+			}
+
+			fields := strings.Split(lines[1], ",")
+			if got := fields[len(fields)-1]; got != tt.want {
+				t.Errorf("tags = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONLWriterTags(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewJSONLWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transaction := &models.Transaction{ID: "t1", Type: "expense", Amount: 1000, Currency: "BRL", Date: day("2024-01-05"), Tags: []string{"viagem", "trabalho"}}
+	if err := writer.Write(transaction); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(got.Tags, ",") != "viagem,trabalho" {
+		t.Errorf("tags = %v, want [viagem trabalho]", got.Tags)
+	}
+}
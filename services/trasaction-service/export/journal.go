@@ -0,0 +1,368 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"transaction-service/models"
+	"transaction-service/money"
+)
+
+// Diários de contabilidade em texto
+const (
+	FormatBeancount = "beancount"
+	FormatLedger    = "ledger"
+)
+
+// Contas de patrimônio usadas pelo diário
+const (
+	openingBalancesAccount = "Equity:Opening-Balances"
+	transfersAccount       = "Equity:Transfers"
+)
+
+// JournalOptions descreve o que o diário declara antes das transações
+type JournalOptions struct {
+	BaseCurrency string
+	Since        time.Time         // data da transação mais antiga exportada
+	Accounts     []*models.Account // contas da carteira, com o saldo de abertura
+	// PairTransfers junta as duas pernas de uma transferência num lançamento.
+	// Sem ele (exportação de uma única conta) cada perna vai contra Equity:Transfers.
+	PairTransfers bool
+}
+
+// journalWriter escreve um diário Beancount ou ledger-cli. Cada conta da
+// carteira vira Assets:<nome> (cartões em Liabilities:) e cada categoria
+// Income:<nome> ou Expenses:<nome>; todos os lançamentos têm duas partidas
+// que somam zero pelo peso na moeda.
+type journalWriter struct {
+	w        *bufio.Writer
+	format   string
+	openDate time.Time
+	pair     bool
+
+	accounts   map[string]string // ID da conta → nome no diário
+	currencies map[string]string // ID da conta → moeda
+	categories map[string]string // "Expenses|Mercado" → nome no diário
+	names      map[string]bool   // nomes já usados
+
+	pending      map[string]*models.Transaction // pernas aguardando o par, por transfer_id
+	pendingOrder []string
+}
+
+// NewJournalWriter declara as contas e os saldos de abertura e depois escreve
+// um lançamento por transação; as categorias são declaradas no primeiro uso
+func NewJournalWriter(w io.Writer, format string, opts JournalOptions) (Writer, error) {
+	j := &journalWriter{
+		w:          bufio.NewWriter(w),
+		format:     format,
+		openDate:   opts.Since,
+		pair:       opts.PairTransfers,
+		accounts:   make(map[string]string),
+		currencies: make(map[string]string),
+		categories: make(map[string]string),
+		names:      map[string]bool{openingBalancesAccount: true, transfersAccount: true},
+		pending:    make(map[string]*models.Transaction),
+	}
+
+	for _, account := range opts.Accounts {
+		if j.openDate.IsZero() || account.CreatedAt.Before(j.openDate) {
+			j.openDate = account.CreatedAt
+		}
+	}
+	if j.openDate.IsZero() {
+		j.openDate = time.Now()
+	}
+
+	fmt.Fprintf(j.w, "; Exportado em %s\n", time.Now().UTC().Format("2006-01-02 15:04:05Z"))
+	if format == FormatBeancount {
+		fmt.Fprintf(j.w, "option \"title\" \"Transações\"\n")
+		fmt.Fprintf(j.w, "option \"operating_currency\" %s\n\n", quote(opts.BaseCurrency))
+	} else {
+		currencies := map[string]bool{opts.BaseCurrency: true}
+		fmt.Fprintf(j.w, "commodity %s\n", opts.BaseCurrency)
+		for _, account := range opts.Accounts {
+			if !currencies[account.Currency] {
+				currencies[account.Currency] = true
+				fmt.Fprintf(j.w, "commodity %s\n", account.Currency)
+			}
+		}
+		j.w.WriteString("\n")
+	}
+
+	j.open(openingBalancesAccount, "", nil)
+	j.open(transfersAccount, "", nil)
+	for _, account := range opts.Accounts {
+		root := "Assets"
+		if account.Type == models.AccountTypeCreditCard {
+			root = "Liabilities"
+		}
+
+		name := root + ":" + accountComponent(account.Name, "Conta")
+		if j.names[name] {
+			name += "-" + account.ID[:8]
+		}
+		j.names[name] = true
+		j.accounts[account.ID] = name
+		j.currencies[account.ID] = account.Currency
+		j.open(name, account.Currency, []string{"account_id", account.ID})
+	}
+	j.w.WriteString("\n")
+
+	for _, account := range opts.Accounts {
+		if account.OpeningBalance == 0 {
+			continue
+		}
+		j.entry(account.CreatedAt, "Saldo inicial: "+account.Name, "", []journalPosting{
+			{account: j.accounts[account.ID], amount: account.OpeningBalance, currency: account.Currency},
+			{account: openingBalancesAccount, amount: -account.OpeningBalance, currency: account.Currency},
+		})
+	}
+
+	return j, j.w.Flush()
+}
+
+// journalPosting é uma partida; price, quando presente, é o custo total na
+// moeda da outra partida (conversão entre moedas)
+type journalPosting struct {
+	account  string
+	amount   money.Amount
+	currency string
+	price    *money.Amount
+	priceCur string
+}
+
+func (j *journalWriter) Write(t *models.Transaction) error {
+	if t.TransferID != nil && t.TransferSide != nil {
+		return j.writeTransfer(t)
+	}
+
+	root := "Expenses"
+	if t.Type == "income" {
+		root = "Income"
+	}
+
+	account, err := j.account(t.AccountID)
+	if err != nil {
+		return err
+	}
+
+	// Uma transação dividida tem uma partida por linha
+	postings := []journalPosting{{account: account, amount: Signed(t), currency: t.Currency}}
+	for _, line := range t.Lines() {
+		amount := line.Amount
+		if t.Type == "income" {
+			amount = -amount
+		}
+		postings = append(postings, journalPosting{account: j.category(root, line.Category), amount: amount, currency: t.Currency})
+	}
+
+	j.entry(t.Date, t.Description, t.ID, postings)
+	return nil
+}
+
+// writeTransfer escreve a transferência quando as duas pernas chegam. Entre
+// moedas diferentes, a entrada leva o valor da saída como custo total (@@).
+func (j *journalWriter) writeTransfer(t *models.Transaction) error {
+	if !j.pair {
+		return j.writeLeg(t)
+	}
+
+	other, ok := j.pending[*t.TransferID]
+	if !ok {
+		j.pending[*t.TransferID] = t
+		j.pendingOrder = append(j.pendingOrder, *t.TransferID)
+		return nil
+	}
+	delete(j.pending, *t.TransferID)
+
+	out, in := other, t
+	if *t.TransferSide == models.TransferSideOut {
+		out, in = t, other
+	}
+
+	from, err := j.account(out.AccountID)
+	if err != nil {
+		return err
+	}
+	to, err := j.account(in.AccountID)
+	if err != nil {
+		return err
+	}
+
+	inPosting := journalPosting{account: to, amount: in.Amount, currency: in.Currency}
+	if in.Currency != out.Currency {
+		cost := out.Amount
+		inPosting.price, inPosting.priceCur = &cost, out.Currency
+	}
+
+	j.entry(out.Date, out.Description, *out.TransferID, []journalPosting{
+		inPosting,
+		{account: from, amount: -out.Amount, currency: out.Currency},
+	})
+	return nil
+}
+
+// writeLeg lança uma perna sem o par contra Equity:Transfers
+func (j *journalWriter) writeLeg(t *models.Transaction) error {
+	account, err := j.account(t.AccountID)
+	if err != nil {
+		return err
+	}
+
+	j.entry(t.Date, t.Description, *t.TransferID, []journalPosting{
+		{account: account, amount: Signed(t), currency: t.Currency},
+		{account: transfersAccount, amount: -Signed(t), currency: t.Currency},
+	})
+	return nil
+}
+
+func (j *journalWriter) Flush() error {
+	return j.w.Flush()
+}
+
+// Close escreve as pernas cujo par não apareceu na exportação
+func (j *journalWriter) Close() error {
+	for _, transferID := range j.pendingOrder {
+		if leg, ok := j.pending[transferID]; ok {
+			if err := j.writeLeg(leg); err != nil {
+				return err
+			}
+		}
+	}
+	return j.w.Flush()
+}
+
+func (j *journalWriter) account(accountID string) (string, error) {
+	name, ok := j.accounts[accountID]
+	if !ok {
+		return "", fmt.Errorf("account %s is not in the journal", accountID)
+	}
+	return name, nil
+}
+
+// category devolve a conta da categoria, declarando-a no primeiro uso
+func (j *journalWriter) category(root, category string) string {
+	key := root + "|" + category
+	if name, ok := j.categories[key]; ok {
+		return name
+	}
+
+	base := root + ":" + accountComponent(category, "Sem-Categoria")
+	name := base
+	for n := 2; j.names[name]; n++ {
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	j.names[name] = true
+	j.categories[key] = name
+
+	j.open(name, "", []string{"category", category})
+	return name
+}
+
+// open declara uma conta. No Beancount os metadados ficam na diretiva; o
+// ledger-cli só recebe a declaração, exigida pelo modo --pedantic.
+func (j *journalWriter) open(name, currency string, meta []string) {
+	if j.format != FormatBeancount {
+		fmt.Fprintf(j.w, "account %s\n", name)
+		return
+	}
+
+	fmt.Fprintf(j.w, "%s open %s", j.openDate.Format("2006-01-02"), name)
+	if currency != "" {
+		fmt.Fprintf(j.w, " %s", currency)
+	}
+	j.w.WriteString("\n")
+	for i := 0; i+1 < len(meta); i += 2 {
+		fmt.Fprintf(j.w, "  %s: %s\n", meta[i], quote(meta[i+1]))
+	}
+}
+
+// entry escreve um lançamento marcado como conferido (*)
+func (j *journalWriter) entry(date time.Time, description, id string, postings []journalPosting) {
+	description = strings.Join(strings.Fields(description), " ")
+	if description == "" {
+		description = "Sem descrição"
+	}
+
+	indent := "  "
+	if j.format == FormatBeancount {
+		fmt.Fprintf(j.w, "%s * %s\n", date.Format("2006-01-02"), quote(description))
+		if id != "" {
+			fmt.Fprintf(j.w, "%sid: %s\n", indent, quote(id))
+		}
+	} else {
+		indent = "    "
+		fmt.Fprintf(j.w, "%s * %s\n", date.Format("2006/01/02"), description)
+		if id != "" {
+			fmt.Fprintf(j.w, "%s; id: %s\n", indent, id)
+		}
+	}
+
+	for _, p := range postings {
+		// Pelo menos dois espaços entre conta e valor, como o ledger-cli exige
+		fmt.Fprintf(j.w, "%s%-44s  %12s %s", indent, p.account, p.amount.String(), p.currency)
+		if p.price != nil {
+			fmt.Fprintf(j.w, " @@ %s %s", p.price.String(), p.priceCur)
+		}
+		j.w.WriteString("\n")
+	}
+	j.w.WriteString("\n")
+}
+
+// quote escreve uma string entre aspas no formato do Beancount
+func quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// accountComponent converte um nome livre num componente de conta válido nos
+// dois formatos: letras sem acento, dígitos e hífens, começando em maiúscula
+func accountComponent(name, fallback string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		r = foldAccent(r)
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+
+	component := b.String()
+	if component == "" {
+		return fallback
+	}
+	return strings.ToUpper(component[:1]) + component[1:]
+}
+
+// accentFolds cobre as letras acentuadas do português e do espanhol
+var accentFolds = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+	'Á': 'A', 'À': 'A', 'Â': 'A', 'Ã': 'A', 'Ä': 'A',
+	'É': 'E', 'È': 'E', 'Ê': 'E', 'Ë': 'E',
+	'Í': 'I', 'Ì': 'I', 'Î': 'I', 'Ï': 'I',
+	'Ó': 'O', 'Ò': 'O', 'Ô': 'O', 'Õ': 'O', 'Ö': 'O',
+	'Ú': 'U', 'Ù': 'U', 'Û': 'U', 'Ü': 'U',
+	'Ç': 'C', 'Ñ': 'N',
+}
+
+func foldAccent(r rune) rune {
+	if folded, ok := accentFolds[r]; ok {
+		return folded
+	}
+	return r
+}
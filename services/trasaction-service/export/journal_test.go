@@ -0,0 +1,209 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"transaction-service/models"
+	"transaction-service/money"
+	"transaction-service/statement"
+)
+
+func TestAccountComponent(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Nubank", "Nubank"},
+		{"conta corrente", "Conta-corrente"},
+		{"Alimentação & Bebidas", "Alimentacao-Bebidas"},
+		{"  Cartão  Itaú ", "Cartao-Itau"},
+		{"Poupança (2024)", "Poupanca-2024"},
+		{"123 Investimentos", "123-Investimentos"},
+		{"Café:Padaria", "Cafe-Padaria"},
+		{"Ñandú", "Nandu"},
+		{"日本", "Sem-Categoria"},
+		{"", "Sem-Categoria"},
+		{"---", "Sem-Categoria"},
+	}
+
+	for _, tt := range tests {
+		if got := accountComponent(tt.name, "Sem-Categoria"); got != tt.want {
+			t.Errorf("accountComponent(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func strPtr(v string) *string {
+	return &v
+}
+
+var journalAccounts = []*models.Account{
+	{ID: "11111111-aaaa", Name: "Conta Corrente", Type: models.AccountTypeChecking, Currency: "BRL", OpeningBalance: 100000, CreatedAt: day("2024-01-01")},
+	{ID: "22222222-bbbb", Name: "Cartão", Type: models.AccountTypeCreditCard, Currency: "BRL", CreatedAt: day("2024-01-02")},
+	{ID: "33333333-cccc", Name: "Wise", Type: models.AccountTypeChecking, Currency: "USD", CreatedAt: day("2024-01-03")},
+}
+
+func journalTransactions() []*models.Transaction {
+	return []*models.Transaction{
+		{ID: "t1", AccountID: "11111111-aaaa", Type: "expense", Category: "Alimentação", Description: "Mercado  do \"Zé\"", Amount: 15025, Currency: "BRL", Date: day("2024-01-05")},
+		{ID: "t2", AccountID: "11111111-aaaa", Type: "income", Category: "Salário", Description: "Salário", Amount: 500000, Currency: "BRL", Date: day("2024-01-06")},
+		{ID: "t3", AccountID: "22222222-bbbb", Type: "expense", Category: "alimentação", Description: "", Amount: 990, Currency: "BRL", Date: day("2024-01-07")},
+		{ID: "t4", AccountID: "11111111-aaaa", Type: "transfer", Description: "Câmbio", Amount: 55000, Currency: "BRL", Date: day("2024-01-08"),
+			TransferID: strPtr("x1"), TransferSide: strPtr(models.TransferSideOut)},
+		{ID: "t5", AccountID: "33333333-cccc", Type: "transfer", Description: "Câmbio", Amount: 10000, Currency: "USD", Date: day("2024-01-08"),
+			TransferID: strPtr("x1"), TransferSide: strPtr(models.TransferSideIn)},
+		{ID: "t6", AccountID: "11111111-aaaa", Type: "transfer", Description: "Pagamento fatura", Amount: 2000, Currency: "BRL", Date: day("2024-01-09"),
+			TransferID: strPtr("x2"), TransferSide: strPtr(models.TransferSideOut)},
+	}
+}
+
+func writeJournal(t *testing.T, format string, pair bool, transactions []*models.Transaction) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewJournalWriter(&buf, format, JournalOptions{
+		BaseCurrency:  "BRL",
+		Since:         day("2024-01-05"),
+		Accounts:      journalAccounts,
+		PairTransfers: pair,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, transaction := range transactions {
+		if err := w.Write(transaction); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// normalize junta os espaços de alinhamento para comparar linhas
+func normalize(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+		lines[i] = indent + strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestJournalWriterBeancount(t *testing.T) {
+	out := normalize(writeJournal(t, FormatBeancount, true, journalTransactions()))
+
+	for _, want := range []string{
+		`option "operating_currency" "BRL"`,
+		"2024-01-01 open Equity:Opening-Balances\n",
+		"2024-01-01 open Assets:Conta-Corrente BRL\n  account_id: \"11111111-aaaa\"\n",
+		"2024-01-01 open Liabilities:Cartao BRL\n",
+		"2024-01-01 open Assets:Wise USD\n",
+		"2024-01-01 * \"Saldo inicial: Conta Corrente\"\n  Assets:Conta-Corrente 1000.00 BRL\n  Equity:Opening-Balances -1000.00 BRL\n",
+		"2024-01-01 open Expenses:Alimentacao\n  category: \"Alimentação\"\n",
+		"2024-01-05 * \"Mercado do \\\"Zé\\\"\"\n  id: \"t1\"\n  Assets:Conta-Corrente -150.25 BRL\n  Expenses:Alimentacao 150.25 BRL\n",
+		"2024-01-06 * \"Salário\"\n  id: \"t2\"\n  Assets:Conta-Corrente 5000.00 BRL\n  Income:Salario -5000.00 BRL\n",
+		// Categoria que só difere na caixa ganha outro nome de conta
+		"2024-01-01 open Expenses:Alimentacao-2\n  category: \"alimentação\"\n",
+		"2024-01-07 * \"Sem descrição\"\n  id: \"t3\"\n  Liabilities:Cartao -9.90 BRL\n  Expenses:Alimentacao-2 9.90 BRL\n",
+		"2024-01-08 * \"Câmbio\"\n  id: \"x1\"\n  Assets:Wise 100.00 USD @@ 550.00 BRL\n  Assets:Conta-Corrente -550.00 BRL\n",
+		"2024-01-09 * \"Pagamento fatura\"\n  id: \"x2\"\n  Assets:Conta-Corrente -20.00 BRL\n  Equity:Transfers 20.00 BRL\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("journal is missing:\n%s\n--- journal:\n%s", want, out)
+		}
+	}
+}
+
+func TestJournalWriterLedger(t *testing.T) {
+	out := normalize(writeJournal(t, FormatLedger, false, journalTransactions()))
+
+	for _, want := range []string{
+		"commodity BRL\ncommodity USD\n",
+		"account Assets:Conta-Corrente\n",
+		"2024/01/05 * Mercado do \"Zé\"\n    ; id: t1\n    Assets:Conta-Corrente -150.25 BRL\n    Expenses:Alimentacao 150.25 BRL\n",
+		// Sem PairTransfers cada perna vai contra Equity:Transfers
+		"2024/01/08 * Câmbio\n    ; id: x1\n    Assets:Conta-Corrente -550.00 BRL\n    Equity:Transfers 550.00 BRL\n",
+		"2024/01/08 * Câmbio\n    ; id: x1\n    Assets:Wise 100.00 USD\n    Equity:Transfers -100.00 USD\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("journal is missing:\n%s\n--- journal:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, " open ") || strings.Contains(out, "@@") {
+		t.Errorf("ledger journal should not have Beancount directives:\n%s", out)
+	}
+}
+
+func TestJournalWriterUnknownAccount(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewJournalWriter(&buf, FormatBeancount, JournalOptions{BaseCurrency: "BRL", Accounts: journalAccounts})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = w.Write(&models.Transaction{ID: "t9", AccountID: "missing", Type: "expense", Amount: 100, Currency: "BRL"})
+	if err == nil {
+		t.Error("Write should fail for an account outside the journal")
+	}
+}
+
+// A exportação Beancount de uma conta volta pela importação com os mesmos
+// valores, inclusive as transações divididas
+func TestJournalBeancountRoundTrip(t *testing.T) {
+	split := &models.Transaction{ID: "t7", AccountID: "11111111-aaaa", Type: "expense", Category: "Alimentação", Description: "Supermercado",
+		Amount: 10000, Currency: "BRL", Date: day("2024-01-10"), Splits: []models.TransactionSplit{
+			{Category: "Alimentação", Amount: 7550},
+			{Category: "Limpeza", Amount: 2450},
+		}}
+	out := writeJournal(t, FormatBeancount, true, append(journalTransactions()[:3], split))
+
+	st, err := statement.ParseBeancount([]byte(out), statement.BeancountTarget{AccountID: "11111111-aaaa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st.Currency != "BRL" {
+		t.Errorf("currency = %q, want BRL", st.Currency)
+	}
+
+	want := []struct {
+		id       string
+		amount   money.Amount
+		category string
+		splits   []statement.EntrySplit
+	}{
+		{"beancount:t1", -15025, "Alimentação", nil},
+		{"beancount:t2", 500000, "Salário", nil},
+		{"beancount:t7", -10000, "Alimentação", []statement.EntrySplit{
+			{Category: "Alimentação", Amount: -7550},
+			{Category: "Limpeza", Amount: -2450},
+		}},
+	}
+
+	// O saldo inicial vai contra Equity e entra como erro de linha
+	if len(st.Entries) != len(want) || len(st.Errors) != 1 {
+		t.Fatalf("entries = %+v, errors = %+v", st.Entries, st.Errors)
+	}
+	for i, w := range want {
+		entry := st.Entries[i]
+		if entry.ExternalID != w.id || entry.Amount != w.amount || entry.Category != w.category || !reflect.DeepEqual(entry.Splits, w.splits) {
+			t.Errorf("entry %d = %+v, want %+v", i, entry, w)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"transaction-service/models"
+	"transaction-service/money"
+)
+
+// OFXAccount traz os dados do extrato de uma conta que não vêm das transações
+type OFXAccount struct {
+	ID       string
+	Type     string
+	Currency string
+	Start    time.Time    // primeira transação exportada
+	End      time.Time    // última transação exportada
+	Balance  money.Amount // saldo ao final de End
+}
+
+// ofxAccountTypes converte os tipos de conta para ACCTTYPE
+var ofxAccountTypes = map[string]string{
+	models.AccountTypeChecking:   "CHECKING",
+	models.AccountTypeSavings:    "SAVINGS",
+	models.AccountTypeCreditCard: "CREDITLINE",
+	models.AccountTypeCash:       "CHECKING",
+	models.AccountTypeInvestment: "MONEYMRKT",
+}
+
+const ofxHeader = "OFXHEADER:100\r\nDATA:OFXSGML\r\nVERSION:102\r\nSECURITY:NONE\r\nENCODING:UTF-8\r\n" +
+	"CHARSET:NONE\r\nCOMPRESSION:NONE\r\nOLDFILEUID:NONE\r\nNEWFILEUID:NONE\r\n\r\n"
+
+type ofxWriter struct {
+	w       *bufio.Writer
+	account func(accountID string) (*OFXAccount, error)
+	current *OFXAccount
+	trnUID  int
+}
+
+// NewOFXWriter escreve um OFX 1.0.2 (SGML) com um extrato por conta. As
+// transações precisam chegar agrupadas por conta; account é consultada no
+// início de cada grupo.
+func NewOFXWriter(w io.Writer, account func(accountID string) (*OFXAccount, error)) (Writer, error) {
+	o := &ofxWriter{w: bufio.NewWriter(w), account: account}
+
+	now := ofxDate(time.Now())
+	o.printf("%s<OFX>\r\n", ofxHeader)
+	o.printf("<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS>")
+	o.printf("<DTSERVER>%s<LANGUAGE>POR</SONRS></SIGNONMSGSRSV1>\r\n", now)
+	o.printf("<BANKMSGSRSV1>\r\n")
+
+	return o, nil
+}
+
+func (o *ofxWriter) Write(t *models.Transaction) error {
+	if o.current == nil || o.current.ID != t.AccountID {
+		o.closeStatement()
+
+		account, err := o.account(t.AccountID)
+		if err != nil {
+			return err
+		}
+		o.openStatement(account)
+	}
+
+	trnType := "DEBIT"
+	switch {
+	case t.Type == models.TransactionTypeTransfer:
+		trnType = "XFER"
+	case t.Type == "income":
+		trnType = "CREDIT"
+	}
+
+	// NAME tem no máximo 32 caracteres; a descrição completa vai em MEMO
+	name := t.Description
+	if utf8.RuneCountInString(name) > 32 {
+		name = string([]rune(name)[:32])
+	}
+
+	o.printf("<STMTTRN><TRNTYPE>%s<DTPOSTED>%s<TRNAMT>%s<FITID>%s<NAME>%s<MEMO>%s</STMTTRN>\r\n",
+		trnType,
+		ofxDate(t.Date),
+		Signed(t).String(),
+		t.ID,
+		html.EscapeString(name),
+		html.EscapeString(t.Description),
+	)
+	return nil
+}
+
+func (o *ofxWriter) openStatement(account *OFXAccount) {
+	o.current = account
+	o.trnUID++
+
+	accountType, ok := ofxAccountTypes[account.Type]
+	if !ok {
+		accountType = "CHECKING"
+	}
+
+	o.printf("<STMTTRNRS><TRNUID>%d<STATUS><CODE>0<SEVERITY>INFO</STATUS>\r\n", o.trnUID)
+	o.printf("<STMTRS><CURDEF>%s<BANKACCTFROM><BANKID>0000<ACCTID>%s<ACCTTYPE>%s</BANKACCTFROM>\r\n",
+		account.Currency, account.ID, accountType)
+	o.printf("<BANKTRANLIST><DTSTART>%s<DTEND>%s\r\n", ofxDate(account.Start), ofxDate(account.End))
+}
+
+func (o *ofxWriter) closeStatement() {
+	if o.current == nil {
+		return
+	}
+	o.printf("</BANKTRANLIST><LEDGERBAL><BALAMT>%s<DTASOF>%s</LEDGERBAL></STMTRS></STMTTRNRS>\r\n",
+		o.current.Balance.String(), ofxDate(o.current.End))
+	o.current = nil
+}
+
+func (o *ofxWriter) Flush() error {
+	return o.w.Flush()
+}
+
+func (o *ofxWriter) Close() error {
+	o.closeStatement()
+	o.printf("</BANKMSGSRSV1>\r\n</OFX>\r\n")
+	return o.w.Flush()
+}
+
+// printf escreve no buffer; erros de escrita aparecem no Flush
+func (o *ofxWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(o.w, format, args...)
+}
+
+func ofxDate(t time.Time) string {
+	return t.UTC().Format("20060102150405")
+}
@@ -0,0 +1,82 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"transaction-service/repository"
+
+	"go.uber.org/zap"
+)
+
+// Importer carrega arquivos de cotações na tabela exchange_rates
+type Importer struct {
+	repo   *repository.ExchangeRateRepository
+	logger *zap.Logger
+}
+
+func NewImporter(repo *repository.ExchangeRateRepository, logger *zap.Logger) *Importer {
+	return &Importer{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ImportFile importa um arquivo PTAX ou CSV; o arquivo inteiro entra ou nada entra
+func (i *Importer) ImportFile(ctx context.Context, path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	rates, err := Parse(file)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+
+	imported, err := i.repo.Upsert(ctx, rates)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+
+	i.logger.Info("exchange rates imported",
+		zap.String("file", path),
+		zap.Int("rates", imported),
+	)
+
+	return imported, nil
+}
+
+// ImportDir importa todos os arquivos .csv do diretório em ordem alfabética.
+// Um arquivo inválido é registrado e não impede a importação dos demais.
+func (i *Importer) ImportDir(ctx context.Context, dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	files := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".csv") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	total := 0
+	for _, path := range files {
+		imported, err := i.ImportFile(ctx, path)
+		if err != nil {
+			i.logger.Error("failed to import exchange rates", zap.Error(err))
+			continue
+		}
+		total += imported
+	}
+
+	return total, nil
+}
@@ -0,0 +1,158 @@
+package fx
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"transaction-service/models"
+)
+
+// Fontes das cotações importadas
+const (
+	SourcePTAX = "bcb_ptax"
+	SourceCSV  = "csv"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unrecognized exchange rate file format")
+
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+	ratePattern     = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+// Parse detecta o formato do arquivo e devolve as cotações.
+//
+// Formatos aceitos:
+//   - PTAX do Banco Central (fechamento diário, separado por ";"):
+//     02012024;220;A;USD;4,8910;4,8916;1,0000;1,0000
+//     usa a taxa de venda, cotada em BRL;
+//   - CSV genérico com cabeçalho: date,currency,rate[,quote_currency]
+//     com datas ISO (2024-01-02) e ponto decimal; quote_currency padrão BRL.
+func Parse(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := bufio.NewReader(r)
+	first, err := reader.Peek(256)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	line := string(first)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	switch {
+	case strings.Contains(line, ";"):
+		return parsePTAX(reader)
+	case strings.HasPrefix(strings.ToLower(strings.TrimPrefix(line, "\ufeff")), "date,"):
+		return parseCSV(reader)
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+func parsePTAX(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+
+	rates := []models.ExchangeRate{}
+	for lineNumber := 1; ; lineNumber++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if len(record) < 6 {
+			return nil, fmt.Errorf("line %d: expected at least 6 fields, got %d", lineNumber, len(record))
+		}
+
+		date, err := time.Parse("02012006", strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q", lineNumber, record[0])
+		}
+
+		currency := strings.ToUpper(strings.TrimSpace(record[3]))
+		rate := strings.ReplaceAll(strings.TrimSpace(record[5]), ",", ".")
+		if err := validate(currency, "BRL", rate); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		rates = append(rates, models.ExchangeRate{
+			Currency:      currency,
+			QuoteCurrency: "BRL",
+			Date:          date,
+			Rate:          rate,
+			Source:        SourcePTAX,
+		})
+	}
+
+	return rates, nil
+}
+
+func parseCSV(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	// Cabeçalho
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+
+	rates := []models.ExchangeRate{}
+	for lineNumber := 2; ; lineNumber++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: expected date,currency,rate", lineNumber)
+		}
+
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q", lineNumber, record[0])
+		}
+
+		quote := "BRL"
+		if len(record) > 3 && strings.TrimSpace(record[3]) != "" {
+			quote = strings.ToUpper(strings.TrimSpace(record[3]))
+		}
+
+		currency := strings.ToUpper(strings.TrimSpace(record[1]))
+		rate := strings.TrimSpace(record[2])
+		if err := validate(currency, quote, rate); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		rates = append(rates, models.ExchangeRate{
+			Currency:      currency,
+			QuoteCurrency: quote,
+			Date:          date,
+			Rate:          rate,
+			Source:        SourceCSV,
+		})
+	}
+
+	return rates, nil
+}
+
+func validate(currency, quote, rate string) error {
+	if !currencyPattern.MatchString(currency) || !currencyPattern.MatchString(quote) {
+		return fmt.Errorf("invalid currency pair %s/%s", currency, quote)
+	}
+	if !ratePattern.MatchString(rate) || strings.Trim(rate, "0.") == "" {
+		return fmt.Errorf("invalid rate %q", rate)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"transaction-service/models"
+	"transaction-service/money"
+	"transaction-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type AccountHandler struct {
+	repo     *repository.AccountRepository
+	settings *repository.SettingsRepository
+	logger   *zap.Logger
+}
+
+func NewAccountHandler(
+	repo *repository.AccountRepository,
+	settings *repository.SettingsRepository,
+	logger *zap.Logger,
+) *AccountHandler {
+	return &AccountHandler{
+		repo:     repo,
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+// OpeningBalance pode ser negativo (ex.: fatura de cartão em aberto)
+type CreateAccountRequest struct {
+	Name           string       `json:"name" binding:"required,max=100"`
+	Type           string       `json:"type" binding:"required,oneof=checking savings credit_card cash investment"`
+	Currency       string       `json:"currency" binding:"omitempty,iso4217"` // padrão: moeda base do usuário
+	OpeningBalance money.Amount `json:"opening_balance"`
+}
+
+type UpdateAccountRequest struct {
+	Name           string       `json:"name" binding:"required,max=100"`
+	Type           string       `json:"type" binding:"required,oneof=checking savings credit_card cash investment"`
+	OpeningBalance money.Amount `json:"opening_balance"`
+	Archived       bool         `json:"archived"`
+}
+
+// Create cria uma nova conta
+func (h *AccountHandler) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CreateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		base, err := h.settings.BaseCurrency(c.Request.Context(), userID.(string))
+		if err != nil {
+			h.logger.Error("failed to get base currency", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create account"})
+			return
+		}
+		currency = base
+	}
+
+	account := &models.Account{
+		ID:             uuid.New().String(),
+		UserID:         userID.(string),
+		Name:           req.Name,
+		Type:           req.Type,
+		Currency:       currency,
+		OpeningBalance: req.OpeningBalance,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	err := h.repo.Create(c.Request.Context(), account)
+	if err == repository.ErrAccountAlreadyExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to create account", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create account"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// List lista as contas do usuário com o saldo atual
+func (h *AccountHandler) List(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	includeArchived := c.Query("include_archived") == "true"
+
+	accounts, err := h.repo.List(c.Request.Context(), userID.(string), includeArchived)
+	if err != nil {
+		h.logger.Error("failed to list accounts", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": accounts})
+}
+
+// GetByID busca uma conta com o saldo atual
+func (h *AccountHandler) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	account, err := h.repo.FindByID(c.Request.Context(), c.Param("id"), userID.(string))
+	if err == repository.ErrAccountNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get account", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// Update altera a conta; a moeda é fixa desde a criação
+func (h *AccountHandler) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req UpdateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.repo.FindByID(c.Request.Context(), c.Param("id"), userID.(string))
+	if err == repository.ErrAccountNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get account", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account"})
+		return
+	}
+
+	account.Balance += req.OpeningBalance - account.OpeningBalance
+	account.Name = req.Name
+	account.Type = req.Type
+	account.OpeningBalance = req.OpeningBalance
+	account.Archived = req.Archived
+	account.UpdatedAt = time.Now()
+
+	err = h.repo.Update(c.Request.Context(), account)
+	if err == repository.ErrAccountAlreadyExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to update account", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// Delete remove uma conta sem transações
+func (h *AccountHandler) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	err := h.repo.Delete(c.Request.Context(), c.Param("id"), userID.(string))
+	if err == repository.ErrAccountNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		return
+	}
+
+	if err == repository.ErrAccountInUse {
+		c.JSON(http.StatusConflict, gin.H{"error": "account has transactions, archive it instead"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to delete account", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account deleted successfully"})
+}
+
+// Balance retorna o saldo da conta ao final de uma data (padrão: hoje)
+func (h *AccountHandler) Balance(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	date := time.Now().UTC()
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	balance, err := h.repo.BalanceAt(c.Request.Context(), c.Param("id"), userID.(string), date)
+	if err == repository.ErrAccountNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get account balance", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account balance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+
+	"transaction-service/models"
+	"transaction-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxAnalyticsDays limita o período por granularidade, para que a série não
+// passe de algumas centenas de intervalos
+var maxAnalyticsDays = map[string]int{
+	models.GranularityDay:   731,
+	models.GranularityWeek:  3653,
+	models.GranularityMonth: 7305,
+	models.GranularityYear:  36525,
+}
+
+type AnalyticsHandler struct {
+	repo     *repository.AnalyticsRepository
+	settings *repository.SettingsRepository
+	logger   *zap.Logger
+}
+
+func NewAnalyticsHandler(repo *repository.AnalyticsRepository, settings *repository.SettingsRepository, logger *zap.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		repo:     repo,
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+// Analytics retorna receitas, despesas e saldo por intervalo entre start_date e
+// end_date (YYYY-MM-DD, obrigatórios), agrupados por granularity (day, week,
+// month ou year; padrão month) pelas datas do calendário no fuso do usuário.
+// Traz também as categorias por tipo, as médias e a comparação com o período
+// anterior e com o mesmo período do ano anterior. account_id é opcional.
+func (h *AnalyticsHandler) Analytics(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	accountID := c.Query("account_id")
+	if accountID != "" {
+		if _, err := uuid.Parse(accountID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+			return
+		}
+	}
+
+	granularity := c.DefaultQuery("granularity", models.GranularityMonth)
+	maxDays, ok := maxAnalyticsDays[granularity]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "granularity must be day, week, month or year"})
+		return
+	}
+
+	start, ok := queryDate(c, "start_date")
+	if !ok {
+		return
+	}
+	end, ok := queryDate(c, "end_date")
+	if !ok {
+		return
+	}
+	if start == nil || end == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
+		return
+	}
+	if end.Before(*start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
+	}
+	if days := int(end.Sub(*start).Hours()/24) + 1; days > maxDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period is too long for this granularity"})
+		return
+	}
+
+	settings, err := h.settings.Get(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to get settings", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get analytics"})
+		return
+	}
+
+	analytics, err := h.repo.Analytics(c.Request.Context(), repository.AnalyticsFilters{
+		UserID:       userID.(string),
+		AccountID:    accountID,
+		BaseCurrency: settings.BaseCurrency,
+		Timezone:     settings.Timezone,
+		Granularity:  granularity,
+		Start:        *start,
+		End:          *end,
+	})
+	if err != nil {
+		h.logger.Error("failed to get analytics", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get analytics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
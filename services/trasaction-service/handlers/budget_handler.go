@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"transaction-service/models"
+	"transaction-service/money"
+	"transaction-service/repository"
+	"transaction-service/tracking"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type BudgetHandler struct {
+	repo     *repository.BudgetRepository
+	settings *repository.SettingsRepository
+	tracker  *tracking.BudgetTracker
+	logger   *zap.Logger
+}
+
+func NewBudgetHandler(
+	repo *repository.BudgetRepository,
+	settings *repository.SettingsRepository,
+	tracker *tracking.BudgetTracker,
+	logger *zap.Logger,
+) *BudgetHandler {
+	return &BudgetHandler{
+		repo:     repo,
+		settings: settings,
+		tracker:  tracker,
+		logger:   logger,
+	}
+}
+
+// Amount é o limite mensal. StartMonth (2006-01) marca o primeiro mês em que o
+// orçamento vale e de onde o rollover começa a acumular (padrão: mês atual).
+type CreateBudgetRequest struct {
+	Category   string       `json:"category" binding:"required,max=100"`
+	Amount     money.Amount `json:"amount" binding:"required,gt=0"`
+	Currency   string       `json:"currency" binding:"omitempty,iso4217"` // padrão: moeda base do usuário
+	Rollover   bool         `json:"rollover"`
+	StartMonth string       `json:"start_month" binding:"omitempty,datetime=2006-01"`
+}
+
+type UpdateBudgetRequest struct {
+	Amount     money.Amount `json:"amount" binding:"required,gt=0"`
+	Rollover   bool         `json:"rollover"`
+	StartMonth string       `json:"start_month" binding:"omitempty,datetime=2006-01"` // vazio mantém o atual
+}
+
+// Create cria o orçamento de uma categoria
+func (h *BudgetHandler) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CreateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		base, err := h.settings.BaseCurrency(c.Request.Context(), userID.(string))
+		if err != nil {
+			h.logger.Error("failed to get base currency", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create budget"})
+			return
+		}
+		currency = base
+	}
+
+	startMonth := req.StartMonth
+	if startMonth == "" {
+		startMonth = time.Now().UTC().Format("2006-01")
+	}
+
+	budget := &models.Budget{
+		ID:         uuid.New().String(),
+		UserID:     userID.(string),
+		Category:   req.Category,
+		Amount:     req.Amount,
+		Currency:   currency,
+		Rollover:   req.Rollover,
+		StartMonth: startMonth,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	err := h.repo.Create(c.Request.Context(), budget)
+	if err == repository.ErrBudgetAlreadyExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to create budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create budget"})
+		return
+	}
+
+	// Despesas já lançadas no mês podem ter cruzado um limiar
+	h.tracker.Evaluate(c.Request.Context(), budget, time.Now(), c.GetString("email"))
+
+	c.JSON(http.StatusCreated, budget)
+}
+
+// List lista os orçamentos do usuário
+func (h *BudgetHandler) List(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	budgets, err := h.repo.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to list budgets", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list budgets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": budgets})
+}
+
+// GetByID busca um orçamento com a situação do mês (padrão: mês atual)
+func (h *BudgetHandler) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	month, ok := parseMonth(c)
+	if !ok {
+		return
+	}
+
+	budget, err := h.repo.FindByID(c.Request.Context(), c.Param("id"), userID.(string))
+	if err == repository.ErrBudgetNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "budget not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get budget"})
+		return
+	}
+
+	status, err := h.repo.Status(c.Request.Context(), budget, month)
+	if err != nil {
+		h.logger.Error("failed to get budget status", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get budget"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"budget": budget, "status": status})
+}
+
+// Update altera limite, rollover e mês inicial; categoria e moeda são fixas
+func (h *BudgetHandler) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req UpdateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budget, err := h.repo.FindByID(c.Request.Context(), c.Param("id"), userID.(string))
+	if err == repository.ErrBudgetNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "budget not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get budget"})
+		return
+	}
+
+	budget.Amount = req.Amount
+	budget.Rollover = req.Rollover
+	if req.StartMonth != "" {
+		budget.StartMonth = req.StartMonth
+	}
+	budget.UpdatedAt = time.Now()
+
+	err = h.repo.Update(c.Request.Context(), budget)
+	if err == repository.ErrBudgetNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "budget not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to update budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update budget"})
+		return
+	}
+
+	// Um limite menor pode cruzar um limiar; um maior rearma os alertas
+	h.tracker.Evaluate(c.Request.Context(), budget, time.Now(), c.GetString("email"))
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// Delete remove um orçamento
+func (h *BudgetHandler) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	err := h.repo.Delete(c.Request.Context(), c.Param("id"), userID.(string))
+	if err == repository.ErrBudgetNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "budget not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to delete budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete budget"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "budget deleted successfully"})
+}
+
+// Report retorna o orçado x realizado do mês (?month=2006-01, padrão: mês atual)
+func (h *BudgetHandler) Report(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	month, ok := parseMonth(c)
+	if !ok {
+		return
+	}
+
+	baseCurrency, err := h.settings.BaseCurrency(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to get base currency", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get budget report"})
+		return
+	}
+
+	report, err := h.repo.Report(c.Request.Context(), userID.(string), baseCurrency, month)
+	if err != nil {
+		h.logger.Error("failed to get budget report", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get budget report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// parseMonth lê ?month=2006-01 (padrão: mês atual). Em caso de erro, a resposta já foi enviada.
+func parseMonth(c *gin.Context) (time.Time, bool) {
+	value := c.Query("month")
+	if value == "" {
+		return models.BudgetMonth(time.Now()), true
+	}
+
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid month format, use YYYY-MM"})
+		return time.Time{}, false
+	}
+
+	return month, true
+}
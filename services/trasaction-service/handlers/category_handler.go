@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"transaction-service/models"
+	"transaction-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type CategoryHandler struct {
+	repo   *repository.CategoryRepository
+	logger *zap.Logger
+}
+
+func NewCategoryHandler(repo *repository.CategoryRepository, logger *zap.Logger) *CategoryHandler {
+	return &CategoryHandler{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ParentID vazio cria a categoria na raiz; a mãe precisa ser do mesmo tipo
+type CreateCategoryRequest struct {
+	Name     string  `json:"name" binding:"required,max=100"`
+	Type     string  `json:"type" binding:"required,oneof=income expense"`
+	ParentID *string `json:"parent_id" binding:"omitempty,uuid"`
+	Color    *string `json:"color" binding:"omitempty,hexcolor,len=7"` // formato: #RRGGBB
+	Icon     *string `json:"icon" binding:"omitempty,max=50"`
+}
+
+// O tipo é fixo desde a criação. Arquivar arquiva também as subcategorias.
+type UpdateCategoryRequest struct {
+	Name     string  `json:"name" binding:"required,max=100"`
+	ParentID *string `json:"parent_id" binding:"omitempty,uuid"`
+	Color    *string `json:"color" binding:"omitempty,hexcolor,len=7"`
+	Icon     *string `json:"icon" binding:"omitempty,max=50"`
+	Archived bool    `json:"archived"`
+}
+
+type MergeCategoryRequest struct {
+	TargetID string `json:"target_id" binding:"required,uuid"`
+}
+
+// Create cria uma categoria
+func (h *CategoryHandler) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be blank"})
+		return
+	}
+
+	category := &models.Category{
+		ID:        uuid.New().String(),
+		UserID:    userID.(string),
+		ParentID:  req.ParentID,
+		Name:      name,
+		Type:      req.Type,
+		Color:     req.Color,
+		Icon:      req.Icon,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err := h.repo.Create(c.Request.Context(), category)
+	if h.categoryError(c, err) {
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to create category", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create category"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// List lista as categorias do usuário; na primeira vez cria o conjunto inicial
+func (h *CategoryHandler) List(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	includeArchived := c.Query("include_archived") == "true"
+
+	categories, err := h.repo.List(c.Request.Context(), userID.(string), includeArchived)
+	if err != nil {
+		h.logger.Error("failed to list categories", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": categories})
+}
+
+// GetByID busca uma categoria
+func (h *CategoryHandler) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	category, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// Update renomeia, move, arquiva ou altera cor e ícone da categoria. O novo nome
+// passa para as transações, recorrências e orçamento da categoria.
+func (h *CategoryHandler) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be blank"})
+		return
+	}
+
+	category, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	category.ParentID = req.ParentID
+	category.Name = name
+	category.Color = req.Color
+	category.Icon = req.Icon
+	category.Archived = req.Archived
+	category.UpdatedAt = time.Now()
+
+	err := h.repo.Update(c.Request.Context(), category)
+	if h.categoryError(c, err) {
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to update category", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// Merge junta a categoria na de destino: as transações e subcategorias passam
+// para o destino e a categoria é apagada. Recusa (409) se as duas tiverem orçamento.
+func (h *CategoryHandler) Merge(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req MergeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	source, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	if req.TargetID == source.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot merge a category into itself"})
+		return
+	}
+
+	target, moved, err := h.repo.Merge(c.Request.Context(), source.ID, req.TargetID, userID.(string))
+	if h.categoryError(c, err) {
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to merge categories", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to merge categories"})
+		return
+	}
+
+	h.logger.Info("categories merged",
+		zap.String("user_id", userID.(string)),
+		zap.String("source_id", source.ID),
+		zap.String("target_id", target.ID),
+		zap.Int64("transactions_moved", moved),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"category":           target,
+		"transactions_moved": moved,
+	})
+}
+
+func (h *CategoryHandler) find(c *gin.Context, userID string) (*models.Category, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return nil, false
+	}
+
+	category, err := h.repo.FindByID(c.Request.Context(), id, userID)
+	if err == repository.ErrCategoryNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get category", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get category"})
+		return nil, false
+	}
+
+	return category, true
+}
+
+// categoryError responde aos erros de validação das categorias; retorna false
+// se err não for um deles
+func (h *CategoryHandler) categoryError(c *gin.Context, err error) bool {
+	switch err {
+	case repository.ErrCategoryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case repository.ErrCategoryAlreadyExists, repository.ErrCategoryBudgetConflict:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case repository.ErrCategoryParentNotFound,
+		repository.ErrCategoryTargetNotFound,
+		repository.ErrCategoryTypeMismatch,
+		repository.ErrCategoryCycle,
+		repository.ErrCategoryArchived:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
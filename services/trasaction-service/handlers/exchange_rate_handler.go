@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"transaction-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ExchangeRateHandler struct {
+	repo     *repository.ExchangeRateRepository
+	settings *repository.SettingsRepository
+	logger   *zap.Logger
+}
+
+func NewExchangeRateHandler(
+	repo *repository.ExchangeRateRepository,
+	settings *repository.SettingsRepository,
+	logger *zap.Logger,
+) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		repo:     repo,
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+type exchangeRateQuery struct {
+	From string `form:"from" binding:"required,iso4217"`
+	To   string `form:"to" binding:"omitempty,iso4217"` // padrão: moeda base do usuário
+	Date string `form:"date" binding:"omitempty"`       // formato: 2006-01-02; padrão: hoje
+}
+
+// Get retorna a cotação vigente numa data (última disponível até ela)
+func (h *ExchangeRateHandler) Get(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var query exchangeRateQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	date := time.Now().UTC()
+	if query.Date != "" {
+		parsed, err := time.Parse("2006-01-02", query.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	to := query.To
+	if to == "" {
+		base, err := h.settings.BaseCurrency(c.Request.Context(), userID.(string))
+		if err != nil {
+			h.logger.Error("failed to get base currency", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get exchange rate"})
+			return
+		}
+		to = base
+	}
+
+	rate, err := h.repo.Convert(c.Request.Context(), query.From, to, date)
+	if err == repository.ErrExchangeRateNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "exchange rate not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get exchange rate", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get exchange rate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": query.From,
+		"to":   to,
+		"date": date.Format("2006-01-02"),
+		"rate": rate,
+	})
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"transaction-service/export"
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// exportFlushEvery é a cada quantas transações a resposta é enviada ao cliente
+const exportFlushEvery = 500
+
+// streamTimeout substitui o WriteTimeout/ReadTimeout do servidor nas rotas que
+// transferem arquivos grandes em streaming
+const streamTimeout = 30 * time.Minute
+
+// exportMediaTypes associa os tipos aceitos no Accept aos formatos de exportação
+var exportMediaTypes = map[string]string{
+	"text/csv":             export.FormatCSV,
+	"application/x-ndjson": export.FormatJSONL,
+	"application/jsonl":    export.FormatJSONL,
+	"application/x-ofx":    export.FormatOFX,
+	"application/ofx":      export.FormatOFX,
+}
+
+type ExportHandler struct {
+	repo     *repository.TransactionRepository
+	accounts *repository.AccountRepository
+	settings *repository.SettingsRepository
+	logger   *zap.Logger
+}
+
+func NewExportHandler(
+	repo *repository.TransactionRepository,
+	accounts *repository.AccountRepository,
+	settings *repository.SettingsRepository,
+	logger *zap.Logger,
+) *ExportHandler {
+	return &ExportHandler{
+		repo:     repo,
+		accounts: accounts,
+		settings: settings,
+		logger:   logger,
+	}
+}
+
+// Export envia todas as transações dos mesmos filtros da listagem, em ordem
+// cronológica, sem paginação. O formato vem de ?format= (csv, jsonl, ofx,
+// beancount ou ledger) ou do Accept; o padrão é CSV. No OFX há um extrato por
+// conta; os diários declaram as contas e os saldos de abertura antes das transações.
+func (h *ExportHandler) Export(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	filters, ok := transactionFilters(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	var ofxAccount func(string) (*export.OFXAccount, error)
+	if format == export.FormatOFX {
+		ranges, err := h.repo.ExportRanges(c.Request.Context(), filters)
+		if err != nil {
+			h.logger.Error("failed to get export ranges", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export transactions"})
+			return
+		}
+		ofxAccount = h.ofxAccount(c, userID.(string), ranges)
+	}
+
+	var journal export.JournalOptions
+	if format == export.FormatBeancount || format == export.FormatLedger {
+		if !h.journalOptions(c, filters, &journal) {
+			return
+		}
+	}
+
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(streamTimeout)); err != nil {
+		h.logger.Warn("failed to extend export write deadline", zap.Error(err))
+	}
+
+	// O arquivo só começa quando a primeira transação chega, para que uma falha
+	// ao abrir o cursor ainda possa ser respondida com erro
+	var writer export.Writer
+	start := func() error {
+		c.Header("Content-Type", export.ContentTypes[format])
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="transactions-%s.%s"`, time.Now().UTC().Format("2006-01-02"), format))
+		c.Status(http.StatusOK)
+
+		var err error
+		switch format {
+		case export.FormatJSONL:
+			writer, err = export.NewJSONLWriter(c.Writer)
+		case export.FormatOFX:
+			writer, err = export.NewOFXWriter(c.Writer, ofxAccount)
+		case export.FormatBeancount, export.FormatLedger:
+			writer, err = export.NewJournalWriter(c.Writer, format, journal)
+		default:
+			writer, err = export.NewCSVWriter(c.Writer)
+		}
+		return err
+	}
+
+	count := 0
+	err := h.repo.Export(c.Request.Context(), filters, format == export.FormatOFX, func(t *models.Transaction) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.Write(t); err != nil {
+			return err
+		}
+
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+
+	if err != nil && writer == nil {
+		h.logger.Error("failed to export transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export transactions"})
+		return
+	}
+
+	// Depois que o arquivo começou, o status já foi enviado: resta interromper
+	if err != nil {
+		h.logger.Error("transaction export interrupted",
+			zap.Error(err),
+			zap.String("format", format),
+			zap.Int("transactions", count),
+		)
+		c.Abort()
+		return
+	}
+
+	if writer == nil {
+		if err := start(); err != nil {
+			h.logger.Error("failed to export transactions", zap.Error(err))
+			return
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		h.logger.Error("failed to finish transaction export", zap.Error(err))
+		return
+	}
+
+	metrics.TransactionExportsTotal.WithLabelValues(format).Inc()
+}
+
+// exportFormat escolhe o formato por ?format= ou pelo Accept.
+// Em caso de erro, a resposta já foi enviada.
+func exportFormat(c *gin.Context) (string, bool) {
+	if format := c.Query("format"); format != "" {
+		if _, ok := export.ContentTypes[format]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, jsonl, ofx, beancount or ledger"})
+			return "", false
+		}
+		return format, true
+	}
+
+	if c.GetHeader("Accept") == "" {
+		return export.FormatCSV, true
+	}
+
+	offered := []string{"text/csv", "application/x-ndjson", "application/jsonl", "application/x-ofx", "application/ofx"}
+	mediaType := c.NegotiateFormat(offered...)
+	if mediaType == "" {
+		c.JSON(http.StatusNotAcceptable, gin.H{"error": "supported formats are text/csv, application/x-ndjson and application/x-ofx"})
+		return "", false
+	}
+
+	return exportMediaTypes[mediaType], true
+}
+
+// ofxAccount monta os dados do extrato OFX de cada conta: período exportado e
+// saldo ao final dele
+func (h *ExportHandler) ofxAccount(c *gin.Context, userID string, ranges map[string][2]time.Time) func(string) (*export.OFXAccount, error) {
+	return func(accountID string) (*export.OFXAccount, error) {
+		account, err := h.accounts.FindByID(c.Request.Context(), accountID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		period := ranges[accountID]
+		balance, err := h.accounts.BalanceAt(c.Request.Context(), accountID, userID, period[1])
+		if err != nil {
+			return nil, err
+		}
+
+		return &export.OFXAccount{
+			ID:       account.ID,
+			Type:     account.Type,
+			Currency: account.Currency,
+			Start:    period[0],
+			End:      period[1],
+			Balance:  balance.Balance,
+		}, nil
+	}
+}
+
+// journalOptions reúne as contas, a moeda base e a data inicial do diário.
+// Em caso de erro, a resposta já foi enviada.
+func (h *ExportHandler) journalOptions(c *gin.Context, filters repository.TransactionFilters, opts *export.JournalOptions) bool {
+	ctx := c.Request.Context()
+
+	accounts, err := h.accounts.ListAllByUser(ctx, filters.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export transactions"})
+		return false
+	}
+
+	// Exportando uma só conta, as demais ficam de fora e as transferências
+	// não têm o par
+	if filters.AccountID != "" {
+		selected := []*models.Account{}
+		for _, account := range accounts {
+			if account.ID == filters.AccountID {
+				selected = append(selected, account)
+			}
+		}
+		accounts = selected
+	}
+
+	baseCurrency, err := h.settings.BaseCurrency(ctx, filters.UserID)
+	if err != nil {
+		h.logger.Error("failed to get base currency", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export transactions"})
+		return false
+	}
+
+	ranges, err := h.repo.ExportRanges(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to get export ranges", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export transactions"})
+		return false
+	}
+
+	*opts = export.JournalOptions{
+		BaseCurrency:  baseCurrency,
+		Accounts:      accounts,
+		PairTransfers: filters.AccountID == "",
+	}
+	for _, period := range ranges {
+		if opts.Since.IsZero() || period[0].Before(opts.Since) {
+			opts.Since = period[0]
+		}
+	}
+
+	return true
+}
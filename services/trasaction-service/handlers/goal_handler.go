@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"transaction-service/models"
+	"transaction-service/money"
+	"transaction-service/repository"
+	"transaction-service/tracking"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type GoalHandler struct {
+	repo     *repository.GoalRepository
+	accounts *repository.AccountRepository
+	settings *repository.SettingsRepository
+	tracker  *tracking.GoalTracker
+	logger   *zap.Logger
+}
+
+func NewGoalHandler(
+	repo *repository.GoalRepository,
+	accounts *repository.AccountRepository,
+	settings *repository.SettingsRepository,
+	tracker *tracking.GoalTracker,
+	logger *zap.Logger,
+) *GoalHandler {
+	return &GoalHandler{
+		repo:     repo,
+		accounts: accounts,
+		settings: settings,
+		tracker:  tracker,
+		logger:   logger,
+	}
+}
+
+// A meta é ligada a uma conta (account_id) ou a uma categoria (category), nunca aos dois.
+// StartDate marca de quando as contribuições passam a contar (padrão: hoje).
+type CreateGoalRequest struct {
+	Name         string       `json:"name" binding:"required,max=100"`
+	TargetAmount money.Amount `json:"target_amount" binding:"required,gt=0"`
+	Currency     string       `json:"currency" binding:"omitempty,iso4217"` // padrão: moeda da conta ou moeda base
+	Deadline     *string      `json:"deadline" binding:"omitempty,datetime=2006-01-02"`
+	AccountID    *string      `json:"account_id" binding:"omitempty,uuid"`
+	Category     *string      `json:"category" binding:"omitempty,min=1,max=100"`
+	StartDate    string       `json:"start_date" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type UpdateGoalRequest struct {
+	Name         string       `json:"name" binding:"required,max=100"`
+	TargetAmount money.Amount `json:"target_amount" binding:"required,gt=0"`
+	Deadline     *string      `json:"deadline" binding:"omitempty,datetime=2006-01-02"` // null remove o prazo
+}
+
+// Create cria uma meta de economia
+func (h *GoalHandler) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CreateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if (req.AccountID == nil) == (req.Category == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of account_id or category is required"})
+		return
+	}
+
+	currency := req.Currency
+	if req.AccountID != nil {
+		account, err := h.accounts.FindByID(c.Request.Context(), *req.AccountID, userID.(string))
+		if err == repository.ErrAccountNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account not found"})
+			return
+		}
+		if err != nil {
+			h.logger.Error("failed to get account", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create goal"})
+			return
+		}
+		if currency == "" {
+			currency = account.Currency
+		}
+	}
+
+	if currency == "" {
+		base, err := h.settings.BaseCurrency(c.Request.Context(), userID.(string))
+		if err != nil {
+			h.logger.Error("failed to get base currency", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create goal"})
+			return
+		}
+		currency = base
+	}
+
+	startDate := req.StartDate
+	if startDate == "" {
+		startDate = time.Now().UTC().Format("2006-01-02")
+	}
+
+	goal := &models.Goal{
+		ID:           uuid.New().String(),
+		UserID:       userID.(string),
+		Name:         req.Name,
+		TargetAmount: req.TargetAmount,
+		Currency:     currency,
+		Deadline:     req.Deadline,
+		AccountID:    req.AccountID,
+		Category:     req.Category,
+		StartDate:    startDate,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	err := h.repo.Create(c.Request.Context(), goal)
+	if err == repository.ErrGoalAlreadyExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to create goal", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create goal"})
+		return
+	}
+
+	// Contribuições desde start_date podem já cobrir o alvo
+	h.tracker.Evaluate(c.Request.Context(), goal, c.GetString("email"))
+	h.attachProgress(c, goal)
+
+	c.JSON(http.StatusCreated, goal)
+}
+
+// List lista as metas do usuário com o andamento de cada uma
+func (h *GoalHandler) List(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	goals, err := h.repo.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to list goals", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list goals"})
+		return
+	}
+
+	for _, goal := range goals {
+		h.attachProgress(c, goal)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": goals})
+}
+
+// GetByID busca uma meta com o andamento e a projeção de conclusão
+func (h *GoalHandler) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	goal, err := h.repo.FindByID(c.Request.Context(), c.Param("id"), userID.(string))
+	if err == repository.ErrGoalNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "goal not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get goal", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get goal"})
+		return
+	}
+
+	h.attachProgress(c, goal)
+
+	c.JSON(http.StatusOK, goal)
+}
+
+// Update altera nome, valor alvo e prazo. Uma meta já atingida não volta a
+// publicar goal.achieved, mesmo que o alvo aumente.
+func (h *GoalHandler) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req UpdateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	goal, err := h.repo.FindByID(c.Request.Context(), c.Param("id"), userID.(string))
+	if err == repository.ErrGoalNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "goal not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get goal", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get goal"})
+		return
+	}
+
+	goal.Name = req.Name
+	goal.TargetAmount = req.TargetAmount
+	goal.Deadline = req.Deadline
+	goal.UpdatedAt = time.Now()
+
+	err = h.repo.Update(c.Request.Context(), goal)
+	if err == repository.ErrGoalNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "goal not found"})
+		return
+	}
+
+	if err == repository.ErrGoalAlreadyExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to update goal", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update goal"})
+		return
+	}
+
+	h.tracker.Evaluate(c.Request.Context(), goal, c.GetString("email"))
+	h.attachProgress(c, goal)
+
+	c.JSON(http.StatusOK, goal)
+}
+
+// Delete remove uma meta
+func (h *GoalHandler) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	err := h.repo.Delete(c.Request.Context(), c.Param("id"), userID.(string))
+	if err == repository.ErrGoalNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "goal not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to delete goal", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete goal"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "goal deleted successfully"})
+}
+
+// attachProgress calcula o andamento da meta; falhas só omitem o campo
+func (h *GoalHandler) attachProgress(c *gin.Context, goal *models.Goal) {
+	progress, err := h.repo.Progress(c.Request.Context(), goal, time.Now())
+	if err != nil {
+		h.logger.Error("failed to get goal progress", zap.Error(err), zap.String("goal_id", goal.ID))
+		return
+	}
+	goal.Progress = progress
+}
@@ -0,0 +1,785 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"transaction-service/messaging"
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/money"
+	"transaction-service/repository"
+	"transaction-service/statement"
+	"transaction-service/tracking"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxStatementSize limita o tamanho do arquivo de extrato enviado
+const maxStatementSize = 10 << 20
+
+// maxCSVSize limita o CSV, que é lido em streaming e pode ser bem maior que um extrato
+const maxCSVSize = 100 << 20
+
+// maxReportedErrors limita os erros por linha devolvidos na resposta; error_count traz o total
+const maxReportedErrors = 500
+
+// defaultImportCategory é usada quando o arquivo não traz categoria e o usuário não informa outra
+const defaultImportCategory = "Outros"
+
+type ImportHandler struct {
+	repo      *repository.ImportRepository
+	profiles  *repository.ImportProfileRepository
+	accounts  *repository.AccountRepository
+	publisher *messaging.EventPublisher
+	tracker   tracking.Observer
+	logger    *zap.Logger
+}
+
+func NewImportHandler(
+	repo *repository.ImportRepository,
+	profiles *repository.ImportProfileRepository,
+	accounts *repository.AccountRepository,
+	publisher *messaging.EventPublisher,
+	tracker tracking.Observer,
+	logger *zap.Logger,
+) *ImportHandler {
+	return &ImportHandler{
+		repo:      repo,
+		profiles:  profiles,
+		accounts:  accounts,
+		publisher: publisher,
+		tracker:   tracker,
+		logger:    logger,
+	}
+}
+
+// CommitImportRequest confirma o lote. Possíveis duplicatas só entram com
+// include_possible_duplicates; overrides trocam a categoria ou pulam linhas.
+type CommitImportRequest struct {
+	IncludePossibleDuplicates bool                    `json:"include_possible_duplicates"`
+	Overrides                 []models.ImportOverride `json:"overrides" binding:"omitempty,dive"`
+}
+
+// Upload lê um extrato OFX ou QIF (multipart: file, account_id, default_category,
+// date_order, decimal_separator) e cria um lote pendente com a pré-visualização dos lançamentos
+func (h *ImportHandler) Upload(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	dateOrder := c.DefaultPostForm("date_order", "dmy")
+	if dateOrder != "dmy" && dateOrder != "mdy" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date_order must be dmy or mdy"})
+		return
+	}
+
+	decimalSeparator := c.PostForm("decimal_separator")
+	if decimalSeparator != "" && decimalSeparator != "." && decimalSeparator != "," {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "decimal_separator must be . or ,"})
+		return
+	}
+
+	upload, ok := h.readStatementUpload(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	st, err := statement.Parse(upload.data, statement.Options{DateOrder: dateOrder, DecimalSeparator: decimalSeparator})
+	if err == statement.ErrUnknownFormat || err == statement.ErrNoEntries {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid statement: " + err.Error()})
+		return
+	}
+
+	h.createStatementBatch(c, userID.(string), upload, st)
+}
+
+// UploadBeancount lê um diário Beancount (multipart: file, account_id,
+// default_category e, opcionalmente, beancount_account) e cria um lote pendente
+// com os lançamentos da conta. Sem beancount_account, a conta do diário é a
+// que tem o metadado account_id igual ao da conta, como na exportação.
+func (h *ImportHandler) UploadBeancount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	upload, ok := h.readStatementUpload(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	target := statement.BeancountTarget{
+		Account:   c.PostForm("beancount_account"),
+		AccountID: upload.account.ID,
+	}
+
+	st, err := statement.ParseBeancount(upload.data, target)
+	if err == statement.ErrBeancountAccountNotFound || err == statement.ErrNoEntries {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid journal: " + err.Error()})
+		return
+	}
+
+	h.createStatementBatch(c, userID.(string), upload, st)
+}
+
+// statementUpload traz os campos comuns ao envio de um arquivo de extrato
+type statementUpload struct {
+	account         *models.Account
+	filename        string
+	data            []byte
+	defaultCategory string
+}
+
+// readStatementUpload valida account_id, default_category e o arquivo do multipart.
+// Em caso de erro, a resposta já foi enviada.
+func (h *ImportHandler) readStatementUpload(c *gin.Context, userID string) (*statementUpload, bool) {
+	accountID := c.PostForm("account_id")
+	if _, err := uuid.Parse(accountID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+		return nil, false
+	}
+
+	defaultCategory := c.DefaultPostForm("default_category", defaultImportCategory)
+	if defaultCategory == "" || utf8.RuneCountInString(defaultCategory) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "default_category must have between 1 and 100 characters"})
+		return nil, false
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return nil, false
+	}
+	if file.Size > maxStatementSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file exceeds 10MB"})
+		return nil, false
+	}
+
+	account, err := h.accounts.FindByID(c.Request.Context(), accountID, userID)
+	if err == repository.ErrAccountNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account not found"})
+		return nil, false
+	}
+	if err != nil {
+		h.logger.Error("failed to get account", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account"})
+		return nil, false
+	}
+	if account.Archived {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account is archived"})
+		return nil, false
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return nil, false
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxStatementSize+1))
+	f.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return nil, false
+	}
+	if len(data) > maxStatementSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file exceeds 10MB"})
+		return nil, false
+	}
+
+	return &statementUpload{
+		account:         account,
+		filename:        file.Filename,
+		data:            data,
+		defaultCategory: defaultCategory,
+	}, true
+}
+
+// createStatementBatch confere a moeda do extrato e grava o lote pendente
+func (h *ImportHandler) createStatementBatch(c *gin.Context, userID string, upload *statementUpload, st *statement.Statement) {
+	if st.Currency != "" && st.Currency != upload.account.Currency {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("statement currency %s does not match the account currency %s", st.Currency, upload.account.Currency),
+		})
+		return
+	}
+
+	entries := make([]*models.ImportEntry, 0, len(st.Entries))
+	for _, e := range st.Entries {
+		entries = append(entries, importEntry(e, upload.defaultCategory))
+	}
+
+	batch := &models.ImportBatch{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		AccountID:  upload.account.ID,
+		Format:     st.Format,
+		Filename:   truncateRunes(upload.filename, 255),
+		Status:     models.ImportStatusPending,
+		ErrorCount: len(st.Errors),
+		CreatedAt:  time.Now(),
+	}
+
+	if err := h.repo.CreateBatch(c.Request.Context(), batch, repository.ImportEntries(entries)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create import batch"})
+		return
+	}
+
+	metrics.ImportBatchesTotal.WithLabelValues(batch.Format, "uploaded").Inc()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"batch":  batch,
+		"errors": st.Errors,
+	})
+}
+
+// UploadCSV lê um CSV com o layout de um perfil salvo (?profile_id=) e cria um
+// lote pendente para a conta (?account_id=). Com ?dry_run=true só valida o
+// arquivo e devolve o relatório por linha, sem gravar nada. O arquivo vem no
+// campo "file" de um multipart ou direto no corpo, e é processado em streaming.
+func (h *ImportHandler) UploadCSV(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+
+	profileID := c.Query("profile_id")
+	if _, err := uuid.Parse(profileID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid profile_id"})
+		return
+	}
+
+	profile, err := h.profiles.FindByID(c.Request.Context(), profileID, userID.(string))
+	if err == repository.ErrImportProfileNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "import profile not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get import profile"})
+		return
+	}
+
+	var account *models.Account
+	if !dryRun {
+		accountID := c.Query("account_id")
+		if _, err := uuid.Parse(accountID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+			return
+		}
+
+		account, err = h.accounts.FindByID(c.Request.Context(), accountID, userID.(string))
+		if err == repository.ErrAccountNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account not found"})
+			return
+		}
+		if err != nil {
+			h.logger.Error("failed to get account", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account"})
+			return
+		}
+		if account.Archived {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account is archived"})
+			return
+		}
+	}
+
+	body, filename, err := csvBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reader, err := statement.NewCSVReader(body, csvMapping(profile))
+	if err != nil {
+		respondCSVError(c, err)
+		return
+	}
+
+	progress := &csvProgress{errors: []statement.LineError{}}
+	next := func() (*models.ImportEntry, error) {
+		for {
+			entry, lineErr, err := reader.Next()
+			if err == io.EOF {
+				return nil, nil
+			}
+			if err != nil {
+				progress.readErr = err
+				return nil, err
+			}
+			if lineErr != nil {
+				progress.errorCount++
+				if len(progress.errors) < maxReportedErrors {
+					progress.errors = append(progress.errors, *lineErr)
+				}
+				continue
+			}
+			return importEntry(entry, profile.DefaultCategory), nil
+		}
+	}
+
+	if dryRun {
+		validateCSV(c, next, progress)
+		return
+	}
+
+	batch := &models.ImportBatch{
+		ID:        uuid.New().String(),
+		UserID:    userID.(string),
+		AccountID: account.ID,
+		Format:    statement.FormatCSV,
+		ProfileID: &profile.ID,
+		Filename:  truncateRunes(filename, 255),
+		Status:    models.ImportStatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	err = h.repo.CreateBatch(c.Request.Context(), batch, func() (*models.ImportEntry, error) {
+		entry, err := next()
+		batch.ErrorCount = progress.errorCount
+		return entry, err
+	})
+	if progress.readErr != nil {
+		respondCSVError(c, progress.readErr)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create import batch"})
+		return
+	}
+
+	metrics.ImportBatchesTotal.WithLabelValues(batch.Format, "uploaded").Inc()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"batch":  batch,
+		"errors": progress.errors,
+	})
+}
+
+// csvProgress acumula os erros por linha enquanto o CSV é lido
+type csvProgress struct {
+	errors     []statement.LineError
+	errorCount int
+	readErr    error // falha de leitura do arquivo, que interrompe a importação
+}
+
+// validateCSV percorre o arquivo sem gravar e responde com o relatório da validação
+func validateCSV(c *gin.Context, next repository.ImportEntrySource, progress *csvProgress) {
+	const sampleSize = 20
+
+	valid := 0
+	var income, expense money.Amount
+	var firstDate, lastDate string
+	sample := []*models.ImportEntry{}
+
+	for {
+		entry, _ := next()
+		if entry == nil {
+			break
+		}
+
+		valid++
+		if entry.Type == "income" {
+			income += entry.Amount
+		} else {
+			expense += entry.Amount
+		}
+		if firstDate == "" || entry.Date < firstDate {
+			firstDate = entry.Date
+		}
+		if entry.Date > lastDate {
+			lastDate = entry.Date
+		}
+		if len(sample) < sampleSize {
+			sample = append(sample, entry)
+		}
+	}
+
+	if progress.readErr != nil {
+		respondCSVError(c, progress.readErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run":       true,
+		"rows":          valid + progress.errorCount,
+		"valid":         valid,
+		"error_count":   progress.errorCount,
+		"errors":        progress.errors,
+		"total_income":  income,
+		"total_expense": expense,
+		"first_date":    firstDate,
+		"last_date":     lastDate,
+		"sample":        sample,
+	})
+}
+
+// csvBody devolve o arquivo enviado sem carregá-lo inteiro: a parte "file" de
+// um multipart ou o próprio corpo da requisição
+func csvBody(c *gin.Context) (io.Reader, string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCSVSize)
+	http.NewResponseController(c.Writer).SetReadDeadline(time.Now().Add(streamTimeout))
+
+	if c.ContentType() != "multipart/form-data" {
+		return c.Request.Body, c.DefaultQuery("filename", "import.csv"), nil
+	}
+
+	multipart, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, "", err
+	}
+	for {
+		part, err := multipart.NextPart()
+		if err == io.EOF {
+			return nil, "", errors.New("file is required")
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if part.FormName() == "file" {
+			return part, part.FileName(), nil
+		}
+		part.Close()
+	}
+}
+
+func respondCSVError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file exceeds 100MB"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid csv: " + err.Error()})
+}
+
+// List lista os lotes de importação do usuário
+func (h *ImportHandler) List(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	batches, err := h.repo.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list import batches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": batches})
+}
+
+// GetByID mostra o lote com os lançamentos paginados (filtro opcional ?status=)
+func (h *ImportHandler) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	batch, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.ImportEntryNew, models.ImportEntryDuplicate, models.ImportEntryPossibleDuplicate:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be new, duplicate or possible_duplicate"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 500 {
+		pageSize = 50
+	}
+
+	entries, total, err := h.repo.Entries(c.Request.Context(), batch.ID, status, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list import entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"batch":     batch,
+		"entries":   entries,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// Commit cria as transações do lote de uma só vez
+func (h *ImportHandler) Commit(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CommitImportRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	overrides := make(map[int]models.ImportOverride, len(req.Overrides))
+	for _, override := range req.Overrides {
+		overrides[override.Line] = override
+	}
+
+	batch, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	account, err := h.accounts.FindByID(c.Request.Context(), batch.AccountID, batch.UserID)
+	if err != nil {
+		h.logger.Error("failed to get account", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account"})
+		return
+	}
+	if account.Archived {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account is archived"})
+		return
+	}
+
+	created, err := h.repo.Commit(c.Request.Context(), batch.ID, batch.UserID, account.Currency, overrides, req.IncludePossibleDuplicates)
+	switch err {
+	case nil:
+	case repository.ErrImportNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "import batch not found"})
+		return
+	case repository.ErrImportNotPending:
+		c.JSON(http.StatusConflict, gin.H{"error": "import batch is not pending"})
+		return
+	case repository.ErrImportConflict:
+		c.JSON(http.StatusConflict, gin.H{"error": "some entries were imported concurrently, try again"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit import batch"})
+		return
+	}
+
+	for _, transaction := range created {
+		h.publish(messaging.TransactionCreated, transaction)
+		metrics.TransactionsCreatedTotal.WithLabelValues(transaction.Type, transaction.Category).Inc()
+	}
+	h.track(c, created, false)
+	metrics.ImportBatchesTotal.WithLabelValues(batch.Format, "committed").Inc()
+
+	batch, ok = h.find(c, batch.UserID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// Rollback apaga todas as transações criadas pelo lote
+func (h *ImportHandler) Rollback(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	batch, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	removed, err := h.repo.Rollback(c.Request.Context(), batch.ID, batch.UserID)
+	switch err {
+	case nil:
+	case repository.ErrImportNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "import batch not found"})
+		return
+	case repository.ErrImportNotCommitted:
+		c.JSON(http.StatusConflict, gin.H{"error": "import batch is not committed"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to roll back import batch"})
+		return
+	}
+
+	for _, transaction := range removed {
+		h.publish(messaging.TransactionDeleted, transaction)
+		metrics.TransactionsDeletedTotal.Inc()
+	}
+	h.track(c, removed, true)
+	metrics.ImportBatchesTotal.WithLabelValues(batch.Format, "rolled_back").Inc()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "import batch rolled back successfully",
+		"transactions_removed": len(removed),
+	})
+}
+
+// Delete descarta um lote que ainda não foi confirmado
+func (h *ImportHandler) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import batch not found"})
+		return
+	}
+
+	err := h.repo.Discard(c.Request.Context(), id, userID.(string))
+	switch err {
+	case nil:
+	case repository.ErrImportNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "import batch not found"})
+		return
+	case repository.ErrImportNotPending:
+		c.JSON(http.StatusConflict, gin.H{"error": "only pending import batches can be discarded, use rollback"})
+		return
+	default:
+		h.logger.Error("failed to discard import batch", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to discard import batch"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "import batch discarded successfully"})
+}
+
+func (h *ImportHandler) find(c *gin.Context, userID string) (*models.ImportBatch, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import batch not found"})
+		return nil, false
+	}
+
+	batch, err := h.repo.FindByID(c.Request.Context(), id, userID)
+	if err == repository.ErrImportNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import batch not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get import batch"})
+		return nil, false
+	}
+
+	return batch, true
+}
+
+func (h *ImportHandler) publish(eventType string, transaction *models.Transaction) {
+	event := messaging.TransactionEvent{
+		EventType:     eventType,
+		TransactionID: transaction.ID,
+		UserID:        transaction.UserID,
+		AccountID:     transaction.AccountID,
+		Description:   transaction.Description,
+		Amount:        transaction.Amount.String(),
+		AmountCents:   transaction.Amount.Cents(),
+		Currency:      transaction.Currency,
+		Type:          transaction.Type,
+		Category:      transaction.Category,
+		Tags:          transaction.Tags,
+		Timestamp:     time.Now(),
+	}
+
+	if err := h.publisher.PublishTransactionEvent(event); err != nil {
+		h.logger.Error("failed to publish transaction event", zap.Error(err))
+	}
+}
+
+// track notifica os acompanhamentos uma vez por conta, categoria, tipo e mês:
+// orçamentos e metas recalculam o período inteiro, então uma transação de cada
+// grupo basta e um extrato grande não dispara uma avaliação por linha
+func (h *ImportHandler) track(c *gin.Context, transactions []*models.Transaction, removed bool) {
+	seen := make(map[string]bool)
+	for _, transaction := range transactions {
+		key := transaction.AccountID + "|" + transaction.Category + "|" + transaction.Type + "|" + transaction.Date.Format("2006-01")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		change := tracking.Change{
+			UserID:    transaction.UserID,
+			UserEmail: c.GetString("email"),
+		}
+		if removed {
+			change.Before = transaction
+		} else {
+			change.After = transaction
+		}
+		h.tracker.TransactionChanged(c.Request.Context(), change)
+	}
+}
+
+// importEntry converte o lançamento do extrato: o sinal vira o tipo e a
+// categoria ausente (ou longa demais) vira a padrão, também nas linhas de um
+// lançamento dividido
+func importEntry(e statement.Entry, defaultCategory string) *models.ImportEntry {
+	entry := &models.ImportEntry{
+		Line:        e.Line,
+		ExternalID:  e.ExternalID,
+		Date:        e.Date.Format("2006-01-02"),
+		Amount:      e.Amount,
+		Type:        "income",
+		Description: e.Description,
+		Category:    e.Category,
+	}
+	if e.Amount < 0 {
+		entry.Amount = -e.Amount
+		entry.Type = "expense"
+	}
+	if entry.Category == "" || utf8.RuneCountInString(entry.Category) > 100 {
+		entry.Category = defaultCategory
+	}
+
+	for _, split := range e.Splits {
+		line := models.TransactionSplit{Category: split.Category, Amount: split.Amount}
+		if line.Amount < 0 {
+			line.Amount = -line.Amount
+		}
+		if line.Category == "" || utf8.RuneCountInString(line.Category) > 100 {
+			line.Category = defaultCategory
+		}
+		entry.Splits = append(entry.Splits, line)
+	}
+	return entry
+}
+
+func truncateRunes(value string, max int) string {
+	if utf8.RuneCountInString(value) <= max {
+		return value
+	}
+	return string([]rune(value)[:max])
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"transaction-service/models"
+	"transaction-service/repository"
+	"transaction-service/statement"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ImportProfileHandler struct {
+	repo   *repository.ImportProfileRepository
+	logger *zap.Logger
+}
+
+func NewImportProfileHandler(repo *repository.ImportProfileRepository, logger *zap.Logger) *ImportProfileHandler {
+	return &ImportProfileHandler{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Colunas são o nome no cabeçalho ou a posição (começando em 1). signed e
+// inverted usam amount_column; debit_credit usa debit_column e credit_column.
+// date_format aceita DD, MM, YYYY, YY, HH, mm e ss (ex.: DD/MM/YYYY).
+type ImportProfileRequest struct {
+	Name               string   `json:"name" binding:"required,max=100"`
+	Delimiter          string   `json:"delimiter" binding:"omitempty,len=1"` // padrão: ","
+	Encoding           string   `json:"encoding" binding:"omitempty,oneof=utf-8 latin1"`
+	HasHeader          *bool    `json:"has_header"` // padrão: true
+	SkipRows           int      `json:"skip_rows" binding:"min=0,max=100"`
+	DateColumn         string   `json:"date_column" binding:"required,max=100"`
+	DateFormat         string   `json:"date_format" binding:"required,max=30"`
+	DescriptionColumns []string `json:"description_columns" binding:"required,min=1,max=5,dive,required,max=100"`
+	AmountColumn       *string  `json:"amount_column" binding:"omitempty,min=1,max=100"`
+	DebitColumn        *string  `json:"debit_column" binding:"omitempty,min=1,max=100"`
+	CreditColumn       *string  `json:"credit_column" binding:"omitempty,min=1,max=100"`
+	CategoryColumn     *string  `json:"category_column" binding:"omitempty,min=1,max=100"`
+	ExternalIDColumn   *string  `json:"external_id_column" binding:"omitempty,min=1,max=100"`
+	DecimalSeparator   string   `json:"decimal_separator" binding:"omitempty,oneof=comma dot"` // padrão: comma
+	SignConvention     string   `json:"sign_convention" binding:"required,oneof=signed inverted debit_credit"`
+	DefaultCategory    string   `json:"default_category" binding:"omitempty,max=100"` // padrão: Outros
+}
+
+// Create cria um perfil de importação de CSV
+func (h *ImportProfileHandler) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req ImportProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	profile := &models.ImportProfile{
+		ID:        uuid.New().String(),
+		UserID:    userID.(string),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if !applyImportProfile(c, profile, &req) {
+		return
+	}
+
+	err := h.repo.Create(c.Request.Context(), profile)
+	if err == repository.ErrImportProfileAlreadyExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create import profile"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, profile)
+}
+
+// List lista os perfis de importação do usuário
+func (h *ImportProfileHandler) List(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	profiles, err := h.repo.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list import profiles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": profiles})
+}
+
+// GetByID busca um perfil de importação
+func (h *ImportProfileHandler) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	profile, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// Update substitui o mapeamento do perfil
+func (h *ImportProfileHandler) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req ImportProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	if !applyImportProfile(c, profile, &req) {
+		return
+	}
+	profile.UpdatedAt = time.Now()
+
+	err := h.repo.Update(c.Request.Context(), profile)
+	if err == repository.ErrImportProfileNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import profile not found"})
+		return
+	}
+
+	if err == repository.ErrImportProfileAlreadyExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update import profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// Delete remove um perfil de importação
+func (h *ImportProfileHandler) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import profile not found"})
+		return
+	}
+
+	err := h.repo.Delete(c.Request.Context(), id, userID.(string))
+	if err == repository.ErrImportProfileNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import profile not found"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete import profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "import profile deleted successfully"})
+}
+
+func (h *ImportProfileHandler) find(c *gin.Context, userID string) (*models.ImportProfile, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import profile not found"})
+		return nil, false
+	}
+
+	profile, err := h.repo.FindByID(c.Request.Context(), id, userID)
+	if err == repository.ErrImportProfileNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import profile not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get import profile"})
+		return nil, false
+	}
+
+	return profile, true
+}
+
+// applyImportProfile preenche o perfil com os padrões e valida o mapeamento.
+// Em caso de erro, a resposta já foi enviada.
+func applyImportProfile(c *gin.Context, profile *models.ImportProfile, req *ImportProfileRequest) bool {
+	profile.Name = req.Name
+	profile.Delimiter = req.Delimiter
+	if profile.Delimiter == "" {
+		profile.Delimiter = ","
+	}
+	profile.Encoding = req.Encoding
+	if profile.Encoding == "" {
+		profile.Encoding = statement.EncodingUTF8
+	}
+	profile.HasHeader = req.HasHeader == nil || *req.HasHeader
+	profile.SkipRows = req.SkipRows
+	profile.DateColumn = req.DateColumn
+	profile.DateFormat = req.DateFormat
+	profile.DescriptionColumns = req.DescriptionColumns
+	profile.AmountColumn = req.AmountColumn
+	profile.DebitColumn = req.DebitColumn
+	profile.CreditColumn = req.CreditColumn
+	profile.CategoryColumn = req.CategoryColumn
+	profile.ExternalIDColumn = req.ExternalIDColumn
+	profile.DecimalSeparator = req.DecimalSeparator
+	if profile.DecimalSeparator == "" {
+		profile.DecimalSeparator = models.DecimalSeparatorComma
+	}
+	profile.SignConvention = req.SignConvention
+	profile.DefaultCategory = req.DefaultCategory
+	if profile.DefaultCategory == "" {
+		profile.DefaultCategory = defaultImportCategory
+	}
+
+	// Colunas que a convenção de sinal não usa não são guardadas
+	if profile.SignConvention == statement.SignDebitCredit {
+		profile.AmountColumn = nil
+	} else {
+		profile.DebitColumn, profile.CreditColumn = nil, nil
+	}
+
+	delimiter, _ := utf8.DecodeRuneInString(profile.Delimiter)
+	if delimiter == '"' || delimiter == '\r' || delimiter == '\n' {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delimiter"})
+		return false
+	}
+
+	if err := csvMapping(profile).Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+// csvMapping converte o perfil salvo no mapeamento usado pelo leitor de CSV
+func csvMapping(profile *models.ImportProfile) statement.CSVMapping {
+	delimiter, _ := utf8.DecodeRuneInString(profile.Delimiter)
+	value := func(column *string) string {
+		if column == nil {
+			return ""
+		}
+		return *column
+	}
+
+	return statement.CSVMapping{
+		Delimiter:          delimiter,
+		Encoding:           profile.Encoding,
+		HasHeader:          profile.HasHeader,
+		SkipRows:           profile.SkipRows,
+		DateColumn:         profile.DateColumn,
+		DateFormat:         profile.DateFormat,
+		DescriptionColumns: profile.DescriptionColumns,
+		AmountColumn:       value(profile.AmountColumn),
+		DebitColumn:        value(profile.DebitColumn),
+		CreditColumn:       value(profile.CreditColumn),
+		CategoryColumn:     value(profile.CategoryColumn),
+		ExternalIDColumn:   value(profile.ExternalIDColumn),
+		DecimalComma:       profile.DecimalSeparator == models.DecimalSeparatorComma,
+		SignConvention:     profile.SignConvention,
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"transaction-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// LedgerHandler expõe o razão de partidas dobradas para auditoria (somente leitura)
+type LedgerHandler struct {
+	repo   *repository.LedgerRepository
+	logger *zap.Logger
+}
+
+func NewLedgerHandler(repo *repository.LedgerRepository, logger *zap.Logger) *LedgerHandler {
+	return &LedgerHandler{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Accounts lista as contas do razão com os saldos
+func (h *LedgerHandler) Accounts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	accounts, err := h.repo.Accounts(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to list ledger accounts", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list ledger accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": accounts})
+}
+
+// Entries lista os lançamentos; source_id filtra pelos de uma transação, transferência ou conta
+func (h *LedgerHandler) Entries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	sourceID := c.Query("source_id")
+	if sourceID != "" {
+		if _, err := uuid.Parse(sourceID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid source_id"})
+			return
+		}
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	entries, err := h.repo.Entries(c.Request.Context(), userID.(string), sourceID, page, pageSize)
+	if err != nil {
+		h.logger.Error("failed to list journal entries", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list journal entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      entries,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// Integrity verifica se o razão fecha e se confere com as transações
+func (h *LedgerHandler) Integrity(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	report, err := h.repo.CheckIntegrity(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to check ledger integrity", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check ledger integrity"})
+		return
+	}
+
+	if !report.Balanced {
+		h.logger.Warn("ledger integrity check failed", zap.String("user_id", userID.(string)))
+	}
+
+	c.JSON(http.StatusOK, report)
+}
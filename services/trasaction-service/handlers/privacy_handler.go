@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+
+	"transaction-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PrivacyHandler exporta os dados pessoais mantidos por este serviço
+type PrivacyHandler struct {
+	transactions *repository.TransactionRepository
+	accounts     *repository.AccountRepository
+	categories   *repository.CategoryRepository
+	tags         *repository.TagRepository
+	settings     *repository.SettingsRepository
+	budgets      *repository.BudgetRepository
+	goals        *repository.GoalRepository
+	recurring    *repository.RecurringRepository
+	imports      *repository.ImportRepository
+	profiles     *repository.ImportProfileRepository
+	logger       *zap.Logger
+}
+
+func NewPrivacyHandler(
+	transactions *repository.TransactionRepository,
+	accounts *repository.AccountRepository,
+	categories *repository.CategoryRepository,
+	tags *repository.TagRepository,
+	settings *repository.SettingsRepository,
+	budgets *repository.BudgetRepository,
+	goals *repository.GoalRepository,
+	recurring *repository.RecurringRepository,
+	imports *repository.ImportRepository,
+	profiles *repository.ImportProfileRepository,
+	logger *zap.Logger,
+) *PrivacyHandler {
+	return &PrivacyHandler{
+		transactions: transactions,
+		accounts:     accounts,
+		categories:   categories,
+		tags:         tags,
+		settings:     settings,
+		budgets:      budgets,
+		goals:        goals,
+		recurring:    recurring,
+		imports:      imports,
+		profiles:     profiles,
+		logger:       logger,
+	}
+}
+
+// ExportPersonalData retorna todos os dados do usuário mantidos por este serviço
+func (h *PrivacyHandler) ExportPersonalData(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	transactions, err := h.transactions.ListAllByUser(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to export personal data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export personal data"})
+		return
+	}
+
+	accounts, err := h.accounts.ListAllByUser(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to export personal data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export personal data"})
+		return
+	}
+
+	categories, err := h.categories.ListAllByUser(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to export personal data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export personal data"})
+		return
+	}
+
+	tags, err := h.tags.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to export personal data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export personal data"})
+		return
+	}
+
+	settings, err := h.settings.Get(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to export personal data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export personal data"})
+		return
+	}
+
+	budgets, err := h.budgets.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to export personal data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export personal data"})
+		return
+	}
+
+	goals, err := h.goals.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to export personal data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export personal data"})
+		return
+	}
+
+	recurringRules, err := h.recurring.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to export personal data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export personal data"})
+		return
+	}
+
+	importBatches, err := h.imports.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to export personal data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export personal data"})
+		return
+	}
+
+	importProfiles, err := h.profiles.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to export personal data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export personal data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"service": "transaction-service",
+		"datasets": []gin.H{
+			{"name": "accounts", "records": accounts},
+			{"name": "categories", "records": categories},
+			{"name": "tags", "records": tags},
+			{"name": "transactions", "records": transactions},
+			{"name": "budgets", "records": budgets},
+			{"name": "goals", "records": goals},
+			{"name": "recurring_rules", "records": recurringRules},
+			{"name": "import_batches", "records": importBatches},
+			{"name": "import_profiles", "records": importProfiles},
+			{"name": "settings", "records": []interface{}{settings}},
+		},
+	})
+}
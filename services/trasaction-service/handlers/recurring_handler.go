@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"transaction-service/models"
+	"transaction-service/money"
+	"transaction-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type RecurringHandler struct {
+	repo     *repository.RecurringRepository
+	accounts *repository.AccountRepository
+	logger   *zap.Logger
+}
+
+func NewRecurringHandler(
+	repo *repository.RecurringRepository,
+	accounts *repository.AccountRepository,
+	logger *zap.Logger,
+) *RecurringHandler {
+	return &RecurringHandler{
+		repo:     repo,
+		accounts: accounts,
+		logger:   logger,
+	}
+}
+
+// DayOfMonth vale só para monthly (padrão: dia de start_date) e Weekday só para
+// weekly (0 = domingo; padrão: dia da semana de start_date)
+type RecurringRuleRequest struct {
+	AccountID   string       `json:"account_id" binding:"required,uuid"`
+	Description string       `json:"description" binding:"required"`
+	Amount      money.Amount `json:"amount" binding:"required,gt=0"`
+	Category    string       `json:"category" binding:"required,max=100"`
+	Type        string       `json:"type" binding:"required,oneof=income expense"`
+	Frequency   string       `json:"frequency" binding:"required,oneof=daily weekly monthly last_business_day yearly"`
+	Interval    int          `json:"interval" binding:"omitempty,min=1,max=366"`
+	DayOfMonth  *int         `json:"day_of_month" binding:"omitempty,min=1,max=31"`
+	Weekday     *int         `json:"weekday" binding:"omitempty,min=0,max=6"`
+	StartDate   string       `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate     *string      `json:"end_date" binding:"omitempty,datetime=2006-01-02"`
+	Active      *bool        `json:"active"` // padrão: true
+}
+
+// OccurrenceRequest pula (skip) ou altera (modify) uma única ocorrência
+type OccurrenceRequest struct {
+	Action      string        `json:"action" binding:"required,oneof=skip modify"`
+	Description *string       `json:"description" binding:"omitempty,min=1"`
+	Amount      *money.Amount `json:"amount" binding:"omitempty,gt=0"`
+	Category    *string       `json:"category" binding:"omitempty,min=1,max=100"`
+}
+
+// Create cria uma regra de recorrência. Ocorrências desde start_date que já
+// passaram são geradas na próxima rodada do worker.
+func (h *RecurringHandler) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req RecurringRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	rule := &models.RecurringRule{
+		ID:        uuid.New().String(),
+		UserID:    userID.(string),
+		CreatedAt: now,
+	}
+
+	if !h.apply(c, rule, &req) {
+		return
+	}
+
+	start, _ := time.Parse("2006-01-02", rule.StartDate)
+	rule.NextOccurrence = firstOccurrence(rule, start)
+
+	if err := h.repo.Create(c.Request.Context(), rule); err != nil {
+		h.logger.Error("failed to create recurring rule", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create recurring rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// List lista as regras de recorrência do usuário
+func (h *RecurringHandler) List(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	rules, err := h.repo.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to list recurring rules", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list recurring rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+// GetByID busca uma regra de recorrência
+func (h *RecurringHandler) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	rule, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// Update altera a série a partir de hoje; transações já geradas não mudam
+func (h *RecurringHandler) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req RecurringRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	wasActive := rule.Active
+	pending := rule.NextOccurrence
+	if !h.apply(c, rule, &req) {
+		return
+	}
+
+	// Ocorrências atrasadas ainda não geradas continuam pendentes; uma série
+	// pausada que volta a ficar ativa recomeça de hoje
+	from := today()
+	if wasActive && pending != nil {
+		if next, err := time.Parse("2006-01-02", *pending); err == nil && next.Before(from) {
+			from = next
+		}
+	}
+	rule.NextOccurrence = firstOccurrence(rule, from)
+
+	err := h.repo.Update(c.Request.Context(), rule)
+	if err == repository.ErrRecurringRuleNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recurring rule not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to update recurring rule", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update recurring rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// Delete remove a regra; as transações já geradas permanecem
+func (h *RecurringHandler) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	err := h.repo.Delete(c.Request.Context(), c.Param("id"), userID.(string))
+	if err == repository.ErrRecurringRuleNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recurring rule not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to delete recurring rule", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete recurring rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "recurring rule deleted successfully"})
+}
+
+// Preview lista as próximas ocorrências (?count=12, máximo 100) com as exceções aplicadas
+func (h *RecurringHandler) Preview(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	count, _ := strconv.Atoi(c.DefaultQuery("count", "12"))
+	if count < 1 || count > 100 {
+		count = 12
+	}
+
+	rule, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	// Inclui as ocorrências atrasadas que o worker ainda vai gerar
+	from := today()
+	if rule.NextOccurrence != nil {
+		if next, err := time.Parse("2006-01-02", *rule.NextOccurrence); err == nil && next.Before(from) {
+			from = next
+		}
+	}
+
+	dates := rule.Occurrences(from, models.SeriesHorizon, count)
+	occurrences := []models.RecurringOccurrence{}
+	if len(dates) == 0 {
+		c.JSON(http.StatusOK, gin.H{"rule": rule, "occurrences": occurrences})
+		return
+	}
+
+	last := dates[len(dates)-1]
+	exceptions, err := h.repo.Exceptions(c.Request.Context(), rule.ID, from, last)
+	if err != nil {
+		h.logger.Error("failed to get recurring exceptions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to preview recurring rule"})
+		return
+	}
+
+	materialized, err := h.repo.MaterializedDates(c.Request.Context(), rule.ID, from, last)
+	if err != nil {
+		h.logger.Error("failed to get materialized occurrences", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to preview recurring rule"})
+		return
+	}
+
+	for _, date := range dates {
+		key := date.Format("2006-01-02")
+		occurrence := rule.Apply(date, exceptions[key])
+		if id, ok := materialized[key]; ok {
+			occurrence.Status = models.OccurrenceMaterialized
+			occurrence.TransactionID = &id
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rule": rule, "occurrences": occurrences})
+}
+
+// SetOccurrence pula ou altera uma única ocorrência ainda não gerada. Depois de
+// gerada, a ocorrência é uma transação comum e se edita em /transactions.
+func (h *RecurringHandler) SetOccurrence(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req OccurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Action == models.OccurrenceModify && req.Description == nil && req.Amount == nil && req.Category == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "modify requires description, amount or category"})
+		return
+	}
+
+	rule, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	date, ok := h.occurrenceDate(c, rule)
+	if !ok {
+		return
+	}
+
+	exception := &models.RecurringException{
+		RuleID:         rule.ID,
+		OccurrenceDate: date.Format("2006-01-02"),
+		Action:         req.Action,
+	}
+	if req.Action == models.OccurrenceModify {
+		exception.Description = req.Description
+		exception.Amount = req.Amount
+		exception.Category = req.Category
+	}
+
+	err := h.repo.SetException(c.Request.Context(), exception)
+	if err == repository.ErrOccurrenceMaterialized {
+		c.JSON(http.StatusConflict, gin.H{"error": "occurrence already materialized, edit the transaction instead"})
+		return
+	}
+
+	if err == repository.ErrRecurringRuleNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recurring rule not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to set recurring exception", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update occurrence"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule.Apply(date, exception))
+}
+
+// ResetOccurrence remove a exceção e devolve a ocorrência aos valores da série
+func (h *RecurringHandler) ResetOccurrence(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	rule, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	date, ok := h.occurrenceDate(c, rule)
+	if !ok {
+		return
+	}
+
+	err := h.repo.DeleteException(c.Request.Context(), rule.ID, date.Format("2006-01-02"))
+	if err == repository.ErrRecurringExceptionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to delete recurring exception", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset occurrence"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule.Apply(date, nil))
+}
+
+// apply valida a requisição e copia os campos para a regra.
+// Em caso de erro, a resposta já foi enviada.
+func (h *RecurringHandler) apply(c *gin.Context, rule *models.RecurringRule, req *RecurringRuleRequest) bool {
+	if req.DayOfMonth != nil && req.Frequency != models.FrequencyMonthly {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "day_of_month is only valid for monthly rules"})
+		return false
+	}
+
+	if req.Weekday != nil && req.Frequency != models.FrequencyWeekly {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "weekday is only valid for weekly rules"})
+		return false
+	}
+
+	if req.EndDate != nil && *req.EndDate < req.StartDate {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return false
+	}
+
+	account, err := h.accounts.FindByID(c.Request.Context(), req.AccountID, rule.UserID)
+	if err == repository.ErrAccountNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account not found"})
+		return false
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get account", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save recurring rule"})
+		return false
+	}
+
+	if account.Archived && account.ID != rule.AccountID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account is archived"})
+		return false
+	}
+
+	interval := req.Interval
+	if interval == 0 {
+		interval = 1
+	}
+
+	rule.AccountID = account.ID
+	rule.Description = req.Description
+	rule.Amount = req.Amount
+	rule.Category = req.Category
+	rule.Type = req.Type
+	rule.Frequency = req.Frequency
+	rule.Interval = interval
+	rule.DayOfMonth = req.DayOfMonth
+	rule.Weekday = req.Weekday
+	rule.StartDate = req.StartDate
+	rule.EndDate = req.EndDate
+	rule.Active = req.Active == nil || *req.Active
+	rule.UpdatedAt = time.Now()
+
+	return true
+}
+
+func (h *RecurringHandler) find(c *gin.Context, userID string) (*models.RecurringRule, bool) {
+	rule, err := h.repo.FindByID(c.Request.Context(), c.Param("id"), userID)
+	if err == repository.ErrRecurringRuleNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recurring rule not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get recurring rule", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get recurring rule"})
+		return nil, false
+	}
+
+	return rule, true
+}
+
+// occurrenceDate lê :date e confere que é uma data da série
+func (h *RecurringHandler) occurrenceDate(c *gin.Context, rule *models.RecurringRule) (time.Time, bool) {
+	date, err := time.Parse("2006-01-02", c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+		return time.Time{}, false
+	}
+
+	if !rule.IsOccurrence(date) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date is not an occurrence of this rule"})
+		return time.Time{}, false
+	}
+
+	return date, true
+}
+
+// firstOccurrence é a primeira data da série a partir de from, ou nil se a série acabou
+func firstOccurrence(rule *models.RecurringRule, from time.Time) *string {
+	date, ok := rule.FirstOccurrence(from)
+	if !ok {
+		return nil
+	}
+	value := date.Format("2006-01-02")
+	return &value
+}
+
+func today() time.Time {
+	year, month, day := time.Now().UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"transaction-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SettingsHandler struct {
+	repo   *repository.SettingsRepository
+	logger *zap.Logger
+}
+
+func NewSettingsHandler(repo *repository.SettingsRepository, logger *zap.Logger) *SettingsHandler {
+	return &SettingsHandler{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Campos vazios mantêm o valor atual; ao menos um deve ser informado
+type UpdateSettingsRequest struct {
+	BaseCurrency string `json:"base_currency" binding:"omitempty,iso4217"`
+	Timezone     string `json:"timezone" binding:"omitempty,max=64"` // nome IANA, ex.: America/Sao_Paulo
+}
+
+// Get retorna as preferências do usuário
+func (h *SettingsHandler) Get(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	settings, err := h.repo.Get(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to get settings", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// Update altera a moeda base usada nas estatísticas e saldos e o fuso horário
+// usado nas análises por período
+func (h *SettingsHandler) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.BaseCurrency == "" && req.Timezone == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "base_currency or timezone is required"})
+		return
+	}
+
+	settings, err := h.repo.Update(c.Request.Context(), userID.(string), req.BaseCurrency, req.Timezone)
+	if err == repository.ErrInvalidTimezone {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to update settings", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"transaction-service/messaging"
+	"transaction-service/models"
+	"transaction-service/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type TagHandler struct {
+	repo      *repository.TagRepository
+	publisher *messaging.EventPublisher
+	logger    *zap.Logger
+}
+
+func NewTagHandler(repo *repository.TagRepository, publisher *messaging.EventPublisher, logger *zap.Logger) *TagHandler {
+	return &TagHandler{
+		repo:      repo,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+type TagRequest struct {
+	Name  string  `json:"name" binding:"required,max=50"`
+	Color *string `json:"color" binding:"omitempty,hexcolor,len=7"` // formato: #RRGGBB
+}
+
+// Tags que ainda não existem em Add são criadas; uma mesma tag não pode estar
+// em Add e em Remove
+type BulkTagRequest struct {
+	TransactionIDs []string `json:"transaction_ids" binding:"required,min=1,max=500,dive,uuid"`
+	Add            []string `json:"add" binding:"omitempty,max=20,dive,max=50"`
+	Remove         []string `json:"remove" binding:"omitempty,max=20,dive,max=50"`
+}
+
+// Create cria uma tag
+func (h *TagHandler) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be blank"})
+		return
+	}
+
+	tag := &models.Tag{
+		ID:        uuid.New().String(),
+		UserID:    userID.(string),
+		Name:      name,
+		Color:     req.Color,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err := h.repo.Create(c.Request.Context(), tag)
+	if err == repository.ErrTagAlreadyExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to create tag", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create tag"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// List lista as tags do usuário com o número de transações de cada uma
+func (h *TagHandler) List(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	tags, err := h.repo.List(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to list tags", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tags})
+}
+
+// GetByID busca uma tag
+func (h *TagHandler) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	tag, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// Update renomeia a tag ou muda a sua cor. Numa renomeação, publica um
+// transaction.updated para cada transação marcada com ela.
+func (h *TagHandler) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be blank"})
+		return
+	}
+
+	tag, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	tag.Name = name
+	tag.Color = req.Color
+	tag.UpdatedAt = time.Now()
+
+	changes, err := h.repo.Update(c.Request.Context(), tag)
+	if err == repository.ErrTagAlreadyExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err == repository.ErrTagNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to update tag", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update tag"})
+		return
+	}
+
+	h.publishTagChanges(changes)
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// Delete apaga a tag; as transações marcadas continuam existindo e cada uma
+// ganha um transaction.updated com a tag removida
+func (h *TagHandler) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return
+	}
+
+	changes, err := h.repo.Delete(c.Request.Context(), id, userID.(string))
+	if err == repository.ErrTagNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to delete tag", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete tag"})
+		return
+	}
+
+	h.publishTagChanges(changes)
+
+	c.JSON(http.StatusOK, gin.H{"message": "tag deleted successfully"})
+}
+
+// BulkUpdate marca e desmarca tags em várias transações de uma vez. Publica um
+// transaction.updated para cada transação cujas tags mudaram.
+func (h *TagHandler) BulkUpdate(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	add, ok := tagNames(c, req.Add)
+	if !ok {
+		return
+	}
+	remove, ok := tagNames(c, req.Remove)
+	if !ok {
+		return
+	}
+
+	if len(add) == 0 && len(remove) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "add or remove is required"})
+		return
+	}
+
+	for _, a := range add {
+		for _, r := range remove {
+			if strings.EqualFold(a, r) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "tag " + a + " is both added and removed"})
+				return
+			}
+		}
+	}
+
+	// IDs repetidos contariam como transações de outro usuário no repositório
+	seen := make(map[string]bool, len(req.TransactionIDs))
+	ids := make([]string, 0, len(req.TransactionIDs))
+	for _, id := range req.TransactionIDs {
+		id = strings.ToLower(id)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	changes, err := h.repo.BulkUpdate(c.Request.Context(), userID.(string), ids, add, remove)
+	if err == repository.ErrTransactionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to update transaction tags", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update transaction tags"})
+		return
+	}
+
+	h.publishTagChanges(changes)
+
+	c.JSON(http.StatusOK, gin.H{"updated": len(changes)})
+}
+
+// publishTagChanges publica um transaction.updated para cada transação cujas
+// tags mudaram
+func (h *TagHandler) publishTagChanges(changes []*repository.TransactionTagChange) {
+	for _, change := range changes {
+		transaction := change.Transaction
+		event := messaging.TransactionEvent{
+			EventType:     "transaction.updated",
+			TransactionID: transaction.ID,
+			UserID:        transaction.UserID,
+			AccountID:     transaction.AccountID,
+			Description:   transaction.Description,
+			Amount:        transaction.Amount.String(),
+			AmountCents:   transaction.Amount.Cents(),
+			Currency:      transaction.Currency,
+			Type:          transaction.Type,
+			Category:      transaction.Category,
+			Tags:          transaction.Tags,
+			TagsAdded:     change.Added,
+			TagsRemoved:   change.Removed,
+			Timestamp:     time.Now(),
+		}
+
+		if err := h.publisher.PublishTransactionEvent(event); err != nil {
+			h.logger.Error("failed to publish transaction event", zap.Error(err))
+		}
+	}
+}
+
+func (h *TagHandler) find(c *gin.Context, userID string) (*models.Tag, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return nil, false
+	}
+
+	tag, err := h.repo.FindByID(c.Request.Context(), id, userID)
+	if err == repository.ErrTagNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get tag", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get tag"})
+		return nil, false
+	}
+
+	return tag, true
+}
+
+// tagNames tira os espaços das pontas dos nomes de tag e recusa nomes em
+// branco. Em caso de erro, a resposta já foi enviada.
+func tagNames(c *gin.Context, names []string) ([]string, bool) {
+	var tags []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tag names must not be blank"})
+			return nil, false
+		}
+		tags = append(tags, name)
+	}
+	return tags, true
+}
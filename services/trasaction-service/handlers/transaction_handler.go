@@ -1,15 +1,22 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"transaction-service/config"
 	"transaction-service/messaging"
 	"transaction-service/metrics"
 	"transaction-service/models"
+	"transaction-service/money"
 	"transaction-service/repository"
+	"transaction-service/tracking"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -17,38 +24,79 @@ import (
 )
 
 type TransactionHandler struct {
-	repo      *repository.TransactionRepository
-	publisher *messaging.EventPublisher
-	config    *config.Config
-	logger    *zap.Logger
+	repo       *repository.TransactionRepository
+	accounts   *repository.AccountRepository
+	categories *repository.CategoryRepository
+	settings   *repository.SettingsRepository
+	publisher  *messaging.EventPublisher
+	tracker    tracking.Observer
+	config     *config.Config
+	logger     *zap.Logger
 }
 
 func NewTransactionHandler(
 	repo *repository.TransactionRepository,
+	accounts *repository.AccountRepository,
+	categories *repository.CategoryRepository,
+	settings *repository.SettingsRepository,
 	publisher *messaging.EventPublisher,
+	tracker tracking.Observer,
 	cfg *config.Config,
 	logger *zap.Logger,
 ) *TransactionHandler {
 	return &TransactionHandler{
-		repo:      repo,
-		publisher: publisher,
-		config:    cfg,
-		logger:    logger,
+		repo:       repo,
+		accounts:   accounts,
+		categories: categories,
+		settings:   settings,
+		publisher:  publisher,
+		tracker:    tracker,
+		config:     cfg,
+		logger:     logger,
 	}
 }
 
+// Amount aceita número ou string decimal com no máximo 2 casas (ex.: 10.5 ou "10.50").
+// A moeda é a da conta; se informada, precisa coincidir com ela.
+// A categoria é informada por category_id ou, para compatibilidade, pelo nome em
+// category, que cria a categoria na raiz se o usuário ainda não a tiver.
 type CreateTransactionRequest struct {
-	Description string  `json:"description" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required,gt=0"`
-	Category    string  `json:"category" binding:"required"`
-	Type        string  `json:"type" binding:"required,oneof=income expense"`
-	Date        string  `json:"date" binding:"required"` // formato: 2006-01-02T15:04:05Z
+	AccountID   string       `json:"account_id" binding:"required,uuid"`
+	Description string       `json:"description" binding:"required"`
+	Amount      money.Amount `json:"amount" binding:"required,gt=0"`
+	Currency    string       `json:"currency" binding:"omitempty,iso4217"`
+	CategoryID  string       `json:"category_id" binding:"omitempty,uuid"`
+	Category    string       `json:"category" binding:"required_without=CategoryID,max=100"`
+	Type        string       `json:"type" binding:"required,oneof=income expense"`
+	Date        string       `json:"date" binding:"required"` // formato: 2006-01-02T15:04:05Z
+	Tags        []string     `json:"tags" binding:"omitempty,max=20,dive,max=50"`
+	// Divide o valor entre categorias; as linhas precisam somar amount
+	Splits []SplitLineRequest `json:"splits" binding:"omitempty,min=2,max=50,dive"`
 }
 
 type UpdateTransactionRequest struct {
-	Description string  `json:"description" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required,gt=0"`
-	Category    string  `json:"category" binding:"required"`
+	AccountID   string       `json:"account_id" binding:"omitempty,uuid"` // vazio mantém a conta atual
+	Description string       `json:"description" binding:"required"`
+	Amount      money.Amount `json:"amount" binding:"required,gt=0"`
+	Currency    string       `json:"currency" binding:"omitempty,iso4217"`
+	CategoryID  string       `json:"category_id" binding:"omitempty,uuid"`
+	Category    string       `json:"category" binding:"required_without=CategoryID,max=100"`
+	Tags        *[]string    `json:"tags" binding:"omitempty,max=20,dive,max=50"` // ausente mantém as atuais
+	// Ausente mantém a divisão atual, que precisa continuar somando amount; vazio desfaz a divisão
+	Splits *[]SplitLineRequest `json:"splits" binding:"omitempty,max=50,dive"`
+}
+
+// SplitLineRequest é uma linha da divisão de uma transação; a categoria segue as
+// mesmas regras da categoria da transação
+type SplitLineRequest struct {
+	CategoryID string       `json:"category_id" binding:"omitempty,uuid"`
+	Category   string       `json:"category" binding:"required_without=CategoryID,max=100"`
+	Amount     money.Amount `json:"amount" binding:"required,gt=0"`
+	Note       *string      `json:"note" binding:"omitempty,max=255"`
+}
+
+type SplitTransactionRequest struct {
+	Splits []SplitLineRequest `json:"splits" binding:"required,min=2,max=50,dive"`
 }
 
 // Create cria uma nova transação
@@ -66,9 +114,28 @@ func (h *TransactionHandler) Create(c *gin.Context) {
 	}
 
 	// Parse da data
-	date, err := time.Parse(time.RFC3339, req.Date)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use RFC3339"})
+	date, ok := parseDate(c, h.settings, h.logger, userID.(string), req.Date)
+	if !ok {
+		return
+	}
+
+	account, ok := h.resolveAccount(c, userID.(string), req.AccountID, req.Currency, false)
+	if !ok {
+		return
+	}
+
+	category, ok := h.resolveCategory(c, userID.(string), req.CategoryID, req.Category, req.Type, nil)
+	if !ok {
+		return
+	}
+
+	tags, ok := tagNames(c, req.Tags)
+	if !ok {
+		return
+	}
+
+	splits, ok := h.resolveSplits(c, userID.(string), req.Splits, req.Type, req.Amount, nil)
+	if !ok {
 		return
 	}
 
@@ -76,13 +143,18 @@ func (h *TransactionHandler) Create(c *gin.Context) {
 	transaction := &models.Transaction{
 		ID:          uuid.New().String(),
 		UserID:      userID.(string),
+		AccountID:   account.ID,
 		Description: req.Description,
 		Amount:      req.Amount,
-		Category:    req.Category,
+		Currency:    account.Currency,
+		Category:    category.Name,
+		CategoryID:  categoryRef(category),
 		Type:        req.Type,
 		Date:        date,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
+		Tags:        tags,
+		Splits:      splits,
 	}
 
 	if err := h.repo.Create(c.Request.Context(), transaction); err != nil {
@@ -96,10 +168,14 @@ func (h *TransactionHandler) Create(c *gin.Context) {
 		EventType:     "transaction.created",
 		TransactionID: transaction.ID,
 		UserID:        transaction.UserID,
+		AccountID:     transaction.AccountID,
 		Description:   transaction.Description,
-		Amount:        transaction.Amount,
+		Amount:        transaction.Amount.String(),
+		AmountCents:   transaction.Amount.Cents(),
+		Currency:      transaction.Currency,
 		Type:          transaction.Type,
 		Category:      transaction.Category,
+		Tags:          transaction.Tags,
 		Timestamp:     time.Now(),
 	}
 
@@ -108,13 +184,22 @@ func (h *TransactionHandler) Create(c *gin.Context) {
 		// Não retorna erro para o cliente, apenas loga
 	}
 
+	h.tracker.TransactionChanged(c.Request.Context(), tracking.Change{
+		UserID:    transaction.UserID,
+		UserEmail: c.GetString("email"),
+		After:     transaction,
+	})
+
 	// Atualiza métricas
 	metrics.TransactionsCreatedTotal.WithLabelValues(transaction.Type, transaction.Category).Inc()
 
 	c.JSON(http.StatusCreated, transaction)
 }
 
-// List lista transações com filtros e paginação
+// List lista transações com filtros e ordenação. Com ?cursor= (vazio na
+// primeira página) a paginação é por cursor: a resposta traz next_cursor e
+// prev_cursor, opacos, e o total só com ?include_total=true. Sem cursor, segue a
+// paginação por número de página (page), que sempre traz o total.
 func (h *TransactionHandler) List(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -122,24 +207,32 @@ func (h *TransactionHandler) List(c *gin.Context) {
 		return
 	}
 
-	// Parâmetros de query
-	filters := repository.TransactionFilters{
-		UserID:   userID.(string),
-		Type:     c.Query("type"),
-		Category: c.Query("category"),
+	filters, ok := transactionFilters(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	sort, ok := transactionSort(c)
+	if !ok {
+		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		h.listPage(c, filters, sort, cursor, pageSize)
+		return
+	}
 
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	if page < 1 {
 		page = 1
 	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
-	}
 
-	transactions, total, err := h.repo.List(c.Request.Context(), filters, page, pageSize)
+	transactions, total, err := h.repo.List(c.Request.Context(), filters, sort, page, pageSize)
 	if err != nil {
 		h.logger.Error("failed to list transactions", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list transactions"})
@@ -154,6 +247,274 @@ func (h *TransactionHandler) List(c *gin.Context) {
 	})
 }
 
+// transactionCursor é o conteúdo de next_cursor e prev_cursor: a ordenação em
+// que foi gerado, a direção da navegação e a chave da transação na borda da página
+type transactionCursor struct {
+	Sort     string                    `json:"s"`
+	Desc     bool                      `json:"o,omitempty"`
+	Backward bool                      `json:"b,omitempty"`
+	Key      repository.TransactionKey `json:"k"`
+}
+
+func encodeCursor(cursor transactionCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*transactionCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor transactionCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(cursor.Key.ID); err != nil {
+		return nil, err
+	}
+
+	switch cursor.Sort {
+	case repository.SortByDate, repository.SortByCategory:
+	case repository.SortByAmount:
+		if _, err := money.Parse(cursor.Key.Value); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("unknown cursor sort")
+	}
+
+	return &cursor, nil
+}
+
+// listPage responde uma página da paginação por cursor. A ordenação vem do
+// cursor, para que sort e order não precisem ser repetidos a cada página.
+func (h *TransactionHandler) listPage(c *gin.Context, filters repository.TransactionFilters, sort repository.TransactionSort, value string, pageSize int) {
+	var after *repository.TransactionKey
+	backward := false
+	if value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+		sort = repository.TransactionSort{Field: cursor.Sort, Desc: cursor.Desc}
+		after, backward = &cursor.Key, cursor.Backward
+	}
+
+	transactions, more, err := h.repo.ListPage(c.Request.Context(), filters, sort, after, backward, pageSize)
+	if err != nil {
+		h.logger.Error("failed to list transactions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list transactions"})
+		return
+	}
+
+	// Há próxima página quando sobrou linha indo para frente ou quando se
+	// voltou de uma página; o mesmo vale, invertido, para a anterior
+	var next, prev *string
+	if len(transactions) > 0 {
+		first, last := transactions[0], transactions[len(transactions)-1]
+		if (!backward && more) || (backward && after != nil) {
+			cursor := encodeCursor(transactionCursor{Sort: sort.Field, Desc: sort.Desc, Key: sort.Key(last)})
+			next = &cursor
+		}
+		if (backward && more) || (!backward && after != nil) {
+			cursor := encodeCursor(transactionCursor{Sort: sort.Field, Desc: sort.Desc, Backward: true, Key: sort.Key(first)})
+			prev = &cursor
+		}
+	}
+
+	response := gin.H{
+		"data":        transactions,
+		"next_cursor": next,
+		"prev_cursor": prev,
+		"page_size":   pageSize,
+	}
+
+	if c.Query("include_total") == "true" {
+		total, err := h.repo.Count(c.Request.Context(), filters)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list transactions"})
+			return
+		}
+		response["total"] = total
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// transactionFilters lê os filtros de query comuns à listagem e à exportação:
+// account_id, type, category (repetido para várias), tag (repetido; tag_match
+// any ou all), start_date e end_date (YYYY-MM-DD, inclusive), min_amount e
+// max_amount (valor absoluto) e q (busca na descrição). Em caso de erro, a resposta já foi enviada.
+func transactionFilters(c *gin.Context, userID string) (repository.TransactionFilters, bool) {
+	filters := repository.TransactionFilters{
+		UserID:    userID,
+		AccountID: c.Query("account_id"),
+		Type:      c.Query("type"),
+		Search:    strings.TrimSpace(c.Query("q")),
+	}
+
+	if filters.AccountID != "" {
+		if _, err := uuid.Parse(filters.AccountID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+			return filters, false
+		}
+	}
+
+	switch filters.Type {
+	case "", "income", "expense", models.TransactionTypeTransfer:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be income, expense or transfer"})
+		return filters, false
+	}
+
+	for _, category := range c.QueryArray("category") {
+		if category == "" || utf8.RuneCountInString(category) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category must have between 1 and 100 characters"})
+			return filters, false
+		}
+		filters.Categories = append(filters.Categories, category)
+	}
+	if len(filters.Categories) > 20 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at most 20 categories can be filtered"})
+		return filters, false
+	}
+
+	for _, tag := range c.QueryArray("tag") {
+		if tag == "" || utf8.RuneCountInString(tag) > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tag must have between 1 and 50 characters"})
+			return filters, false
+		}
+		filters.Tags = append(filters.Tags, tag)
+	}
+	if len(filters.Tags) > 20 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at most 20 tags can be filtered"})
+		return filters, false
+	}
+
+	switch c.DefaultQuery("tag_match", "any") {
+	case "any":
+	case "all":
+		filters.AllTags = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tag_match must be any or all"})
+		return filters, false
+	}
+
+	if utf8.RuneCountInString(filters.Search) > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q must have at most 200 characters"})
+		return filters, false
+	}
+
+	var ok bool
+	if filters.StartDate, ok = queryDate(c, "start_date"); !ok {
+		return filters, false
+	}
+	if filters.EndDate, ok = queryDate(c, "end_date"); !ok {
+		return filters, false
+	}
+	if filters.StartDate != nil && filters.EndDate != nil && filters.EndDate.Before(*filters.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return filters, false
+	}
+
+	if filters.MinAmount, ok = queryAmount(c, "min_amount"); !ok {
+		return filters, false
+	}
+	if filters.MaxAmount, ok = queryAmount(c, "max_amount"); !ok {
+		return filters, false
+	}
+	if filters.MinAmount != nil && filters.MaxAmount != nil && *filters.MaxAmount < *filters.MinAmount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_amount must not be less than min_amount"})
+		return filters, false
+	}
+
+	return filters, true
+}
+
+// parseDate lê a data RFC3339 da requisição e a grava como data do calendário
+// no fuso do usuário, a mesma que orçamentos, metas e análises agrupam.
+// Em caso de erro, a resposta já foi enviada.
+func parseDate(c *gin.Context, settings *repository.SettingsRepository, logger *zap.Logger, userID, value string) (time.Time, bool) {
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use RFC3339"})
+		return time.Time{}, false
+	}
+
+	timezone, err := settings.Timezone(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error("failed to get timezone", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get settings"})
+		return time.Time{}, false
+	}
+
+	// Fusos aceitos pelo PostgreSQL mas desconhecidos do Go ficam em UTC
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		logger.Warn("unknown timezone, using UTC", zap.String("timezone", timezone))
+		loc = time.UTC
+	}
+	return models.CalendarDate(date, loc), true
+}
+
+// queryDate lê um parâmetro opcional no formato YYYY-MM-DD.
+// Em caso de erro, a resposta já foi enviada.
+func queryDate(c *gin.Context, param string) (*time.Time, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, true
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param + " format, use YYYY-MM-DD"})
+		return nil, false
+	}
+	return &date, true
+}
+
+// queryAmount lê um valor opcional não negativo, como 200.00.
+// Em caso de erro, a resposta já foi enviada.
+func queryAmount(c *gin.Context, param string) (*money.Amount, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, true
+	}
+
+	amount, err := money.Parse(value)
+	if err != nil || amount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param + ", use a non-negative decimal such as 200.00"})
+		return nil, false
+	}
+	return &amount, true
+}
+
+// transactionSort lê sort (date, amount ou category) e order (asc ou desc).
+// Em caso de erro, a resposta já foi enviada.
+func transactionSort(c *gin.Context) (repository.TransactionSort, bool) {
+	sort := repository.TransactionSort{Field: c.DefaultQuery("sort", repository.SortByDate)}
+	switch sort.Field {
+	case repository.SortByDate, repository.SortByAmount, repository.SortByCategory:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be date, amount or category"})
+		return sort, false
+	}
+
+	switch c.DefaultQuery("order", "desc") {
+	case "desc":
+		sort.Desc = true
+	case "asc":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return sort, false
+	}
+
+	return sort, true
+}
+
 // GetByID busca uma transação por ID
 func (h *TransactionHandler) GetByID(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -208,27 +569,94 @@ func (h *TransactionHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if transaction.TransferID != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "transaction is part of a transfer, use /api/v1/transfers/" + *transaction.TransferID,
+			"transfer_id": *transaction.TransferID,
+		})
+		return
+	}
+
+	accountID := req.AccountID
+	if accountID == "" {
+		accountID = transaction.AccountID
+	}
+
+	// Lançamentos já existentes numa conta arquivada continuam editáveis
+	account, ok := h.resolveAccount(c, userID.(string), accountID, req.Currency, accountID == transaction.AccountID)
+	if !ok {
+		return
+	}
+
+	category, ok := h.resolveCategory(c, userID.(string), req.CategoryID, req.Category, transaction.Type, transaction.CategoryID)
+	if !ok {
+		return
+	}
+
+	tags := transaction.Tags
+	if req.Tags != nil {
+		if tags, ok = tagNames(c, *req.Tags); !ok {
+			return
+		}
+	}
+
+	splits := transaction.Splits
+	if req.Splits != nil {
+		if splits, ok = h.resolveSplits(c, userID.(string), *req.Splits, transaction.Type, req.Amount, transaction.Splits); !ok {
+			return
+		}
+	} else if len(splits) > 0 && models.SplitsTotal(splits) != req.Amount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "transaction is split: send splits that sum to the new amount, or an empty list to unsplit"})
+		return
+	}
+
+	before := *transaction
+
 	// Atualiza campos
+	transaction.AccountID = account.ID
 	transaction.Description = req.Description
 	transaction.Amount = req.Amount
-	transaction.Category = req.Category
+	transaction.Currency = account.Currency
+	transaction.Category = category.Name
+	transaction.CategoryID = categoryRef(category)
+	transaction.Tags = tags
+	transaction.Splits = splits
 	transaction.UpdatedAt = time.Now()
 
-	if err := h.repo.Update(c.Request.Context(), transaction); err != nil {
+	err = h.repo.Update(c.Request.Context(), transaction, req.Splits != nil)
+	if err == repository.ErrTransactionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return
+	}
+
+	if err == repository.ErrSplitsUnbalanced {
+		c.JSON(http.StatusConflict, gin.H{"error": "transaction splits changed, send splits that sum to the new amount"})
+		return
+	}
+
+	if err != nil {
 		h.logger.Error("failed to update transaction", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update transaction"})
 		return
 	}
 
+	added, removed := models.DiffTags(before.Tags, transaction.Tags)
+
 	// Publica evento
 	event := messaging.TransactionEvent{
 		EventType:     "transaction.updated",
 		TransactionID: transaction.ID,
 		UserID:        transaction.UserID,
+		AccountID:     transaction.AccountID,
 		Description:   transaction.Description,
-		Amount:        transaction.Amount,
+		Amount:        transaction.Amount.String(),
+		AmountCents:   transaction.Amount.Cents(),
+		Currency:      transaction.Currency,
 		Type:          transaction.Type,
 		Category:      transaction.Category,
+		Tags:          transaction.Tags,
+		TagsAdded:     added,
+		TagsRemoved:   removed,
 		Timestamp:     time.Now(),
 	}
 
@@ -236,12 +664,134 @@ func (h *TransactionHandler) Update(c *gin.Context) {
 		h.logger.Error("failed to publish transaction event", zap.Error(err))
 	}
 
+	h.tracker.TransactionChanged(c.Request.Context(), tracking.Change{
+		UserID:    transaction.UserID,
+		UserEmail: c.GetString("email"),
+		Before:    &before,
+		After:     transaction,
+	})
+
 	// Atualiza métricas
 	metrics.TransactionsUpdatedTotal.Inc()
 
 	c.JSON(http.StatusOK, transaction)
 }
 
+// Split divide a transação entre categorias, ou troca as linhas de uma já
+// dividida. As linhas precisam somar o valor da transação.
+func (h *TransactionHandler) Split(c *gin.Context) {
+	var req SplitTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.setSplits(c, req.Splits, false)
+}
+
+// Unsplit desfaz a divisão: a transação volta a contar inteira na sua categoria
+func (h *TransactionHandler) Unsplit(c *gin.Context) {
+	h.setSplits(c, nil, true)
+}
+
+// setSplits grava as linhas da divisão (nenhuma com unsplit) e publica a alteração
+func (h *TransactionHandler) setSplits(c *gin.Context, lines []SplitLineRequest, unsplit bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return
+	}
+
+	transaction, err := h.repo.FindByID(c.Request.Context(), id, userID.(string))
+	if err == repository.ErrTransactionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get transaction", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get transaction"})
+		return
+	}
+
+	if transaction.TransferID != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "transaction is part of a transfer, use /api/v1/transfers/" + *transaction.TransferID,
+			"transfer_id": *transaction.TransferID,
+		})
+		return
+	}
+
+	if unsplit && len(transaction.Splits) == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "transaction is not split"})
+		return
+	}
+
+	splits, ok := h.resolveSplits(c, userID.(string), lines, transaction.Type, transaction.Amount, transaction.Splits)
+	if !ok {
+		return
+	}
+
+	before := *transaction
+
+	transaction.Splits = splits
+	transaction.UpdatedAt = time.Now()
+
+	err = h.repo.SetSplits(c.Request.Context(), transaction)
+	if err == repository.ErrTransactionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return
+	}
+
+	if err == repository.ErrSplitsUnbalanced {
+		c.JSON(http.StatusConflict, gin.H{"error": "transaction amount changed, send the splits again"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to update transaction splits", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update transaction splits"})
+		return
+	}
+
+	event := messaging.TransactionEvent{
+		EventType:     "transaction.updated",
+		TransactionID: transaction.ID,
+		UserID:        transaction.UserID,
+		AccountID:     transaction.AccountID,
+		Description:   transaction.Description,
+		Amount:        transaction.Amount.String(),
+		AmountCents:   transaction.Amount.Cents(),
+		Currency:      transaction.Currency,
+		Type:          transaction.Type,
+		Category:      transaction.Category,
+		Tags:          transaction.Tags,
+		Timestamp:     time.Now(),
+	}
+
+	if err := h.publisher.PublishTransactionEvent(event); err != nil {
+		h.logger.Error("failed to publish transaction event", zap.Error(err))
+	}
+
+	// Os orçamentos das categorias de antes e de depois da divisão são reavaliados
+	h.tracker.TransactionChanged(c.Request.Context(), tracking.Change{
+		UserID:    transaction.UserID,
+		UserEmail: c.GetString("email"),
+		Before:    &before,
+		After:     transaction,
+	})
+
+	metrics.TransactionsUpdatedTotal.Inc()
+
+	c.JSON(http.StatusOK, transaction)
+}
+
 // Delete deleta uma transação
 func (h *TransactionHandler) Delete(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -265,6 +815,14 @@ func (h *TransactionHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	if transaction.TransferID != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "transaction is part of a transfer, use /api/v1/transfers/" + *transaction.TransferID,
+			"transfer_id": *transaction.TransferID,
+		})
+		return
+	}
+
 	if err := h.repo.Delete(c.Request.Context(), id, userID.(string)); err != nil {
 		h.logger.Error("failed to delete transaction", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete transaction"})
@@ -276,9 +834,13 @@ func (h *TransactionHandler) Delete(c *gin.Context) {
 		EventType:     "transaction.deleted",
 		TransactionID: transaction.ID,
 		UserID:        transaction.UserID,
-		Amount:        transaction.Amount,
+		AccountID:     transaction.AccountID,
+		Amount:        transaction.Amount.String(),
+		AmountCents:   transaction.Amount.Cents(),
+		Currency:      transaction.Currency,
 		Type:          transaction.Type,
 		Category:      transaction.Category,
+		Tags:          transaction.Tags,
 		Timestamp:     time.Now(),
 	}
 
@@ -286,6 +848,12 @@ func (h *TransactionHandler) Delete(c *gin.Context) {
 		h.logger.Error("failed to publish transaction event", zap.Error(err))
 	}
 
+	h.tracker.TransactionChanged(c.Request.Context(), tracking.Change{
+		UserID:    transaction.UserID,
+		UserEmail: c.GetString("email"),
+		Before:    transaction,
+	})
+
 	// Atualiza métricas
 	metrics.TransactionsDeletedTotal.Inc()
 
@@ -300,7 +868,26 @@ func (h *TransactionHandler) GetStats(c *gin.Context) {
 		return
 	}
 
-	stats, err := h.repo.GetStats(c.Request.Context(), userID.(string))
+	accountID := c.Query("account_id")
+	if accountID != "" {
+		if _, err := uuid.Parse(accountID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+			return
+		}
+	}
+
+	baseCurrency, err := h.settings.BaseCurrency(c.Request.Context(), userID.(string))
+	if err != nil {
+		h.logger.Error("failed to get base currency", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get stats"})
+		return
+	}
+
+	stats, err := h.repo.GetStats(c.Request.Context(), repository.StatsFilters{
+		UserID:       userID.(string),
+		AccountID:    accountID,
+		BaseCurrency: baseCurrency,
+	})
 	if err != nil {
 		h.logger.Error("failed to get stats", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get stats"})
@@ -309,3 +896,139 @@ func (h *TransactionHandler) GetStats(c *gin.Context) {
 
 	c.JSON(http.StatusOK, stats)
 }
+
+// resolveCategory busca a categoria da transação pelo id ou, sem ele, pelo nome,
+// e valida se ela aceita lançamentos do tipo. A categoria atual de uma transação
+// editada (currentID) continua aceita mesmo arquivada. Um nome ainda sem
+// categoria volta sem id e só é criado ao gravar a transação, depois de todas as
+// validações. Em caso de erro, a resposta já foi enviada.
+func (h *TransactionHandler) resolveCategory(c *gin.Context, userID, categoryID, name, transactionType string, currentID *string) (*models.Category, bool) {
+	if categoryID == "" && strings.TrimSpace(name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category must not be blank"})
+		return nil, false
+	}
+
+	var category *models.Category
+	var err error
+	if categoryID != "" {
+		category, err = h.categories.FindByID(c.Request.Context(), categoryID, userID)
+	} else {
+		category, err = h.categories.FindByName(c.Request.Context(), userID, transactionType, name)
+		if err == repository.ErrCategoryNotFound {
+			return &models.Category{Name: strings.TrimSpace(name), Type: transactionType}, true
+		}
+	}
+
+	if err == repository.ErrCategoryNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get category", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get category"})
+		return nil, false
+	}
+
+	if category.Type != transactionType {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category type must match the transaction type " + transactionType})
+		return nil, false
+	}
+
+	if category.Archived && (currentID == nil || *currentID != category.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category is archived"})
+		return nil, false
+	}
+
+	return category, true
+}
+
+// categoryRef devolve o id da categoria resolvida, ou nil para a que ainda será
+// criada pelo nome ao gravar
+func categoryRef(category *models.Category) *string {
+	if category.ID == "" {
+		return nil
+	}
+	return &category.ID
+}
+
+// resolveSplits valida as linhas da divisão: cada categoria como em
+// resolveCategory (as das linhas atuais continuam aceitas mesmo arquivadas) e a
+// soma igual ao valor da transação. Sem linhas, devolve nil: a transação não
+// fica dividida. Em caso de erro, a resposta já foi enviada.
+func (h *TransactionHandler) resolveSplits(c *gin.Context, userID string, lines []SplitLineRequest, transactionType string, amount money.Amount, current []models.TransactionSplit) ([]models.TransactionSplit, bool) {
+	if len(lines) == 0 {
+		return nil, true
+	}
+
+	if len(lines) == 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a split must have at least 2 lines"})
+		return nil, false
+	}
+
+	splits := make([]models.TransactionSplit, 0, len(lines))
+	for _, line := range lines {
+		var currentID *string
+		for _, split := range current {
+			if split.CategoryID != nil && (*split.CategoryID == line.CategoryID || (line.CategoryID == "" && strings.EqualFold(split.Category, strings.TrimSpace(line.Category)))) {
+				currentID = split.CategoryID
+				break
+			}
+		}
+
+		category, ok := h.resolveCategory(c, userID, line.CategoryID, line.Category, transactionType, currentID)
+		if !ok {
+			return nil, false
+		}
+
+		var note *string
+		if line.Note != nil && strings.TrimSpace(*line.Note) != "" {
+			trimmed := strings.TrimSpace(*line.Note)
+			note = &trimmed
+		}
+
+		splits = append(splits, models.TransactionSplit{
+			CategoryID: categoryRef(category),
+			Category:   category.Name,
+			Amount:     line.Amount,
+			Note:       note,
+		})
+	}
+
+	if total := models.SplitsTotal(splits); total != amount {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "splits must sum to the transaction amount " + amount.String() + ", got " + total.String(),
+		})
+		return nil, false
+	}
+
+	return splits, true
+}
+
+// resolveAccount busca a conta de destino da transação e valida se ela aceita lançamentos.
+// Em caso de erro, a resposta já foi enviada.
+func (h *TransactionHandler) resolveAccount(c *gin.Context, userID, accountID, currency string, allowArchived bool) (*models.Account, bool) {
+	account, err := h.accounts.FindByID(c.Request.Context(), accountID, userID)
+	if err == repository.ErrAccountNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get account", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account"})
+		return nil, false
+	}
+
+	if account.Archived && !allowArchived {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account is archived"})
+		return nil, false
+	}
+
+	if currency != "" && currency != account.Currency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "currency must match the account currency " + account.Currency})
+		return nil, false
+	}
+
+	return account, true
+}
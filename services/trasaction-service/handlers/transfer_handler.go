@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"transaction-service/messaging"
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/money"
+	"transaction-service/repository"
+	"transaction-service/tracking"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type TransferHandler struct {
+	repo      *repository.TransferRepository
+	accounts  *repository.AccountRepository
+	settings  *repository.SettingsRepository
+	publisher *messaging.EventPublisher
+	tracker   tracking.Observer
+	logger    *zap.Logger
+}
+
+func NewTransferHandler(
+	repo *repository.TransferRepository,
+	accounts *repository.AccountRepository,
+	settings *repository.SettingsRepository,
+	publisher *messaging.EventPublisher,
+	tracker tracking.Observer,
+	logger *zap.Logger,
+) *TransferHandler {
+	return &TransferHandler{
+		repo:      repo,
+		accounts:  accounts,
+		settings:  settings,
+		publisher: publisher,
+		tracker:   tracker,
+		logger:    logger,
+	}
+}
+
+// ToAmount é obrigatório apenas entre contas de moedas diferentes
+type TransferRequest struct {
+	FromAccountID string       `json:"from_account_id" binding:"required,uuid"`
+	ToAccountID   string       `json:"to_account_id" binding:"required,uuid,nefield=FromAccountID"`
+	Amount        money.Amount `json:"amount" binding:"required,gt=0"`
+	ToAmount      money.Amount `json:"to_amount" binding:"omitempty,gt=0"`
+	Description   string       `json:"description" binding:"required"`
+	Date          string       `json:"date" binding:"required"` // formato: 2006-01-02T15:04:05Z
+}
+
+// Create cria uma transferência com as duas pernas
+func (h *TransferHandler) Create(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer := &models.Transfer{
+		ID:        uuid.New().String(),
+		UserID:    userID.(string),
+		CreatedAt: time.Now(),
+	}
+
+	if !h.apply(c, transfer, &req) {
+		return
+	}
+
+	if err := h.repo.Create(c.Request.Context(), transfer); err != nil {
+		h.logger.Error("failed to create transfer", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create transfer"})
+		return
+	}
+
+	h.publish(messaging.TransferCreated, transfer)
+	h.track(c, nil, transfer.Legs)
+	metrics.TransfersTotal.WithLabelValues("created").Inc()
+
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// GetByID busca uma transferência com as suas pernas
+func (h *TransferHandler) GetByID(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	transfer, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// Update altera a transferência e as duas pernas de uma vez
+func (h *TransferHandler) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	before := transfer.Legs
+	if !h.apply(c, transfer, &req) {
+		return
+	}
+
+	err := h.repo.Update(c.Request.Context(), transfer)
+	if err == repository.ErrTransferNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transfer not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to update transfer", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update transfer"})
+		return
+	}
+
+	h.publish(messaging.TransferUpdated, transfer)
+	h.track(c, before, transfer.Legs)
+	metrics.TransfersTotal.WithLabelValues("updated").Inc()
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// Delete apaga a transferência e as duas pernas
+func (h *TransferHandler) Delete(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	transfer, ok := h.find(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	err := h.repo.Delete(c.Request.Context(), transfer.ID, transfer.UserID)
+	if err == repository.ErrTransferNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transfer not found"})
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("failed to delete transfer", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete transfer"})
+		return
+	}
+
+	h.publish(messaging.TransferDeleted, transfer)
+	h.track(c, transfer.Legs, nil)
+	metrics.TransfersTotal.WithLabelValues("deleted").Inc()
+
+	c.JSON(http.StatusOK, gin.H{"message": "transfer deleted successfully"})
+}
+
+func (h *TransferHandler) find(c *gin.Context, userID string) (*models.Transfer, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transfer not found"})
+		return nil, false
+	}
+
+	transfer, err := h.repo.FindByID(c.Request.Context(), id, userID)
+	if err == repository.ErrTransferNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transfer not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get transfer", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get transfer"})
+		return nil, false
+	}
+
+	return transfer, true
+}
+
+// apply valida as contas e preenche a transferência e as pernas a partir da requisição.
+// Em caso de erro, a resposta já foi enviada.
+func (h *TransferHandler) apply(c *gin.Context, transfer *models.Transfer, req *TransferRequest) bool {
+	date, ok := parseDate(c, h.settings, h.logger, transfer.UserID, req.Date)
+	if !ok {
+		return false
+	}
+
+	from, ok := h.account(c, transfer.UserID, req.FromAccountID, req.FromAccountID == transfer.FromAccountID)
+	if !ok {
+		return false
+	}
+
+	to, ok := h.account(c, transfer.UserID, req.ToAccountID, req.ToAccountID == transfer.ToAccountID)
+	if !ok {
+		return false
+	}
+
+	toAmount := req.Amount
+	if from.Currency != to.Currency {
+		if req.ToAmount == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to_amount is required between accounts with different currencies"})
+			return false
+		}
+		toAmount = req.ToAmount
+	} else if req.ToAmount != 0 && req.ToAmount != req.Amount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_amount must equal amount between accounts with the same currency"})
+		return false
+	}
+
+	now := time.Now()
+	transfer.FromAccountID = from.ID
+	transfer.ToAccountID = to.ID
+	transfer.Amount = req.Amount
+	transfer.ToAmount = toAmount
+	transfer.Description = req.Description
+	transfer.Date = date
+	transfer.UpdatedAt = now
+
+	legs := []*models.Transaction{}
+	for _, side := range []string{models.TransferSideOut, models.TransferSideIn} {
+		leg := &models.Transaction{
+			ID:          uuid.New().String(),
+			UserID:      transfer.UserID,
+			AccountID:   from.ID,
+			Description: req.Description,
+			Amount:      req.Amount,
+			Currency:    from.Currency,
+			Category:    models.TransferCategory,
+			Type:        models.TransactionTypeTransfer,
+			Date:        date,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if side == models.TransferSideIn {
+			leg.AccountID = to.ID
+			leg.Amount = toAmount
+			leg.Currency = to.Currency
+		}
+
+		// Pernas existentes mantêm o ID
+		for _, existing := range transfer.Legs {
+			if *existing.TransferSide == side {
+				leg.ID = existing.ID
+				leg.CreatedAt = existing.CreatedAt
+			}
+		}
+
+		transferID, legSide := transfer.ID, side
+		leg.TransferID = &transferID
+		leg.TransferSide = &legSide
+		legs = append(legs, leg)
+	}
+	transfer.Legs = legs
+
+	return true
+}
+
+// account busca uma conta da transferência; arquivadas só são aceitas se já faziam parte dela
+func (h *TransferHandler) account(c *gin.Context, userID, accountID string, allowArchived bool) (*models.Account, bool) {
+	account, err := h.accounts.FindByID(c.Request.Context(), accountID, userID)
+	if err == repository.ErrAccountNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account not found"})
+		return nil, false
+	}
+
+	if err != nil {
+		h.logger.Error("failed to get account", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get account"})
+		return nil, false
+	}
+
+	if account.Archived && !allowArchived {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account is archived"})
+		return nil, false
+	}
+
+	return account, true
+}
+
+func (h *TransferHandler) publish(eventType string, transfer *models.Transfer) {
+	event := messaging.TransferEvent{
+		EventType:     eventType,
+		TransferID:    transfer.ID,
+		UserID:        transfer.UserID,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        transfer.Amount.String(),
+		AmountCents:   transfer.Amount.Cents(),
+		ToAmount:      transfer.ToAmount.String(),
+		ToAmountCents: transfer.ToAmount.Cents(),
+		Timestamp:     time.Now(),
+	}
+
+	for _, leg := range transfer.Legs {
+		if *leg.TransferSide == models.TransferSideOut {
+			event.Currency = leg.Currency
+		} else {
+			event.ToCurrency = leg.Currency
+		}
+	}
+
+	if err := h.publisher.PublishTransferEvent(event); err != nil {
+		h.logger.Error("failed to publish transfer event", zap.Error(err))
+	}
+}
+
+// track notifica os acompanhamentos (metas ligadas às contas) perna a perna
+func (h *TransferHandler) track(c *gin.Context, before, after []*models.Transaction) {
+	for _, side := range []string{models.TransferSideOut, models.TransferSideIn} {
+		change := tracking.Change{
+			UserID:    c.GetString("user_id"),
+			UserEmail: c.GetString("email"),
+			Before:    transferLeg(before, side),
+			After:     transferLeg(after, side),
+		}
+		if change.Before != nil || change.After != nil {
+			h.tracker.TransactionChanged(c.Request.Context(), change)
+		}
+	}
+}
+
+func transferLeg(legs []*models.Transaction, side string) *models.Transaction {
+	for _, leg := range legs {
+		if leg.TransferSide != nil && *leg.TransferSide == side {
+			return leg
+		}
+	}
+	return nil
+}
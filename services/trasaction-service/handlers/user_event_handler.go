@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"transaction-service/messaging"
+	"transaction-service/repository"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// ServiceName identifica este serviço nas confirmações enviadas ao auth-service
+const ServiceName = "transaction-service"
+
+// UserEventHandler reage aos eventos de ciclo de vida de usuários publicados pelo auth-service
+type UserEventHandler struct {
+	repo       *repository.TransactionRepository
+	transfers  *repository.TransferRepository
+	ledger     *repository.LedgerRepository
+	accounts   *repository.AccountRepository
+	categories *repository.CategoryRepository
+	tags       *repository.TagRepository
+	settings   *repository.SettingsRepository
+	budgets    *repository.BudgetRepository
+	goals      *repository.GoalRepository
+	recurring  *repository.RecurringRepository
+	imports    *repository.ImportRepository
+	profiles   *repository.ImportProfileRepository
+	publisher  *messaging.EventPublisher
+	logger     *zap.Logger
+}
+
+func NewUserEventHandler(
+	repo *repository.TransactionRepository,
+	transfers *repository.TransferRepository,
+	ledger *repository.LedgerRepository,
+	accounts *repository.AccountRepository,
+	categories *repository.CategoryRepository,
+	tags *repository.TagRepository,
+	settings *repository.SettingsRepository,
+	budgets *repository.BudgetRepository,
+	goals *repository.GoalRepository,
+	recurring *repository.RecurringRepository,
+	imports *repository.ImportRepository,
+	profiles *repository.ImportProfileRepository,
+	publisher *messaging.EventPublisher,
+	logger *zap.Logger,
+) *UserEventHandler {
+	return &UserEventHandler{
+		repo:       repo,
+		transfers:  transfers,
+		ledger:     ledger,
+		accounts:   accounts,
+		categories: categories,
+		tags:       tags,
+		settings:   settings,
+		budgets:    budgets,
+		goals:      goals,
+		recurring:  recurring,
+		imports:    imports,
+		profiles:   profiles,
+		publisher:  publisher,
+		logger:     logger,
+	}
+}
+
+type userDeletionRequestedEvent struct {
+	RequestID    string   `json:"request_id"`
+	UserID       string   `json:"user_id"`
+	Participants []string `json:"participants"`
+	TraceID      string   `json:"trace_id"`
+}
+
+// HandleDeletionRequested apaga os dados do usuário e confirma ao auth-service.
+// É idempotente: uma nova entrega do mesmo pedido apenas repete a confirmação.
+func (h *UserEventHandler) HandleDeletionRequested(ctx context.Context, delivery amqp.Delivery) error {
+	var event userDeletionRequestedEvent
+	if err := json.Unmarshal(delivery.Body, &event); err != nil {
+		h.logger.Error("invalid user deletion event", zap.Error(err))
+		return nil
+	}
+
+	if !isParticipant(event.Participants) {
+		return nil
+	}
+
+	deleted, err := h.repo.DeleteAllByUser(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+
+	budgetsDeleted, err := h.budgets.DeleteAllByUser(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+
+	goalsDeleted, err := h.goals.DeleteAllByUser(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+
+	rulesDeleted, err := h.recurring.DeleteAllByUser(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+
+	batchesDeleted, err := h.imports.DeleteAllByUser(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+
+	profilesDeleted, err := h.profiles.DeleteAllByUser(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Contas só podem ser apagadas depois das transações, transferências e do razão que as referenciam
+	if _, err := h.transfers.DeleteAllByUser(ctx, event.UserID); err != nil {
+		return err
+	}
+
+	entriesDeleted, err := h.ledger.DeleteAllByUser(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+
+	accountsDeleted, err := h.accounts.DeleteAllByUser(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+
+	categoriesDeleted, err := h.categories.DeleteAllByUser(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+
+	tagsDeleted, err := h.tags.DeleteAllByUser(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err := h.settings.DeleteByUser(ctx, event.UserID); err != nil {
+		return err
+	}
+
+	h.logger.Info("user data erased",
+		zap.String("request_id", event.RequestID),
+		zap.String("user_id", event.UserID),
+		zap.Int64("transactions_deleted", deleted),
+		zap.Int64("accounts_deleted", accountsDeleted),
+		zap.Int64("categories_deleted", categoriesDeleted),
+		zap.Int64("tags_deleted", tagsDeleted),
+		zap.Int64("budgets_deleted", budgetsDeleted),
+		zap.Int64("goals_deleted", goalsDeleted),
+		zap.Int64("recurring_rules_deleted", rulesDeleted),
+		zap.Int64("import_batches_deleted", batchesDeleted),
+		zap.Int64("import_profiles_deleted", profilesDeleted),
+		zap.Int64("journal_entries_deleted", entriesDeleted),
+		zap.String("trace_id", event.TraceID),
+	)
+
+	ack := messaging.UserDeletionAcknowledgedEvent{
+		RequestID: event.RequestID,
+		UserID:    event.UserID,
+		Service:   ServiceName,
+		Details: map[string]interface{}{
+			"transactions_deleted": deleted,
+			"accounts_deleted":     accountsDeleted,
+			"categories_deleted":   categoriesDeleted,
+			"tags_deleted":         tagsDeleted,
+			"budgets_deleted":      budgetsDeleted,
+			"goals_deleted":        goalsDeleted,
+			"recurring_rules":      rulesDeleted,
+			"import_batches":       batchesDeleted,
+			"import_profiles":      profilesDeleted,
+			"journal_entries":      entriesDeleted,
+		},
+	}
+
+	return h.publisher.PublishUserDeletionAcknowledged(ctx, ack, event.TraceID)
+}
+
+func isParticipant(participants []string) bool {
+	// Eventos sem lista de participantes são tratados por todos
+	if len(participants) == 0 {
+		return true
+	}
+
+	for _, participant := range participants {
+		if participant == ServiceName {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // fusos dos usuários sem depender do sistema da imagem
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -18,10 +19,14 @@ import (
 	"go.uber.org/zap"
 
 	"transaction-service/config"
+	"transaction-service/fx"
 	"transaction-service/handlers"
-	"transaction-service/middleware"
 	"transaction-service/messaging"
+	"transaction-service/middleware"
+	"transaction-service/money"
+	"transaction-service/recurring"
 	"transaction-service/repository"
+	"transaction-service/tracking"
 )
 
 var (
@@ -48,6 +53,11 @@ func main() {
 	// Carrega configurações
 	cfg := config.Load()
 
+	// Formato JSON dos valores monetários ("number" mantém a compatibilidade com clientes antigos)
+	if err := money.SetJSONFormat(cfg.MoneyJSONFormat); err != nil {
+		logger.Fatal("invalid money configuration", zap.Error(err))
+	}
+
 	// Conecta ao banco de dados
 	db, err := repository.NewPostgresConnection(cfg.DatabaseURL)
 	if err != nil {
@@ -55,6 +65,25 @@ func main() {
 	}
 	defer db.Close()
 
+	exchangeRateRepo := repository.NewExchangeRateRepository(db, logger)
+	rateImporter := fx.NewImporter(exchangeRateRepo, logger)
+
+	// Modo de linha de comando: transaction-service import-rates <arquivo>...
+	if len(os.Args) > 1 && os.Args[1] == "import-rates" {
+		importRates(rateImporter, os.Args[2:])
+		return
+	}
+
+	// Importa as cotações disponíveis localmente
+	if cfg.ExchangeRatesDir != "" {
+		imported, err := rateImporter.ImportDir(context.Background(), cfg.ExchangeRatesDir)
+		if err != nil {
+			logger.Error("failed to import exchange rates", zap.Error(err), zap.String("dir", cfg.ExchangeRatesDir))
+		} else {
+			logger.Info("exchange rates loaded", zap.Int("rates", imported))
+		}
+	}
+
 	// Inicializa RabbitMQ
 	rabbitmq, err := messaging.NewRabbitMQ(cfg.RabbitMQURL, logger)
 	if err != nil {
@@ -67,17 +96,88 @@ func main() {
 
 	// Inicializa repositórios
 	transactionRepo := repository.NewTransactionRepository(db, logger)
+	accountRepo := repository.NewAccountRepository(db, logger)
+	categoryRepo := repository.NewCategoryRepository(db, logger)
+	tagRepo := repository.NewTagRepository(db, logger)
+	transferRepo := repository.NewTransferRepository(db, logger)
+	ledgerRepo := repository.NewLedgerRepository(db, logger)
+	settingsRepo := repository.NewSettingsRepository(db, cfg.DefaultCurrency, cfg.DefaultTimezone, logger)
+	budgetRepo := repository.NewBudgetRepository(db, logger)
+	goalRepo := repository.NewGoalRepository(db, logger)
+	recurringRepo := repository.NewRecurringRepository(db, logger)
+	importRepo := repository.NewImportRepository(db, logger)
+	importProfileRepo := repository.NewImportProfileRepository(db, logger)
+	analyticsRepo := repository.NewAnalyticsRepository(db, logger)
+	userRepo := repository.NewUserRepository(db, logger)
+
+	// Converte para dias do calendário as datas gravadas com horário
+	normalized, err := transactionRepo.NormalizeDates(context.Background(), cfg.DefaultTimezone)
+	if err != nil {
+		logger.Error("failed to normalize transaction dates", zap.Error(err))
+	} else if normalized > 0 {
+		logger.Info("transaction dates normalized", zap.Int("records", normalized))
+	}
+
+	// Acompanhamentos reavaliados a cada alteração de transação
+	budgetTracker := tracking.NewBudgetTracker(budgetRepo, userRepo, publisher, logger)
+	goalTracker := tracking.NewGoalTracker(goalRepo, userRepo, publisher, logger)
+	transactionTracker := tracking.Observers{budgetTracker, goalTracker}
 
 	// Inicializa handlers
 	transactionHandler := handlers.NewTransactionHandler(
 		transactionRepo,
+		accountRepo,
+		categoryRepo,
+		settingsRepo,
 		publisher,
+		transactionTracker,
 		cfg,
 		logger,
 	)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsRepo, settingsRepo, logger)
+	exportHandler := handlers.NewExportHandler(transactionRepo, accountRepo, settingsRepo, logger)
+	accountHandler := handlers.NewAccountHandler(accountRepo, settingsRepo, logger)
+	categoryHandler := handlers.NewCategoryHandler(categoryRepo, logger)
+	tagHandler := handlers.NewTagHandler(tagRepo, publisher, logger)
+	transferHandler := handlers.NewTransferHandler(transferRepo, accountRepo, settingsRepo, publisher, transactionTracker, logger)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerRepo, logger)
+	settingsHandler := handlers.NewSettingsHandler(settingsRepo, logger)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(exchangeRateRepo, settingsRepo, logger)
+	budgetHandler := handlers.NewBudgetHandler(budgetRepo, settingsRepo, budgetTracker, logger)
+	goalHandler := handlers.NewGoalHandler(goalRepo, accountRepo, settingsRepo, goalTracker, logger)
+	recurringHandler := handlers.NewRecurringHandler(recurringRepo, accountRepo, logger)
+	importProfileHandler := handlers.NewImportProfileHandler(importProfileRepo, logger)
+	importHandler := handlers.NewImportHandler(importRepo, importProfileRepo, accountRepo, publisher, transactionTracker, logger)
+	privacyHandler := handlers.NewPrivacyHandler(transactionRepo, accountRepo, categoryRepo, tagRepo, settingsRepo, budgetRepo, goalRepo, recurringRepo, importRepo, importProfileRepo, logger)
+	userEventHandler := handlers.NewUserEventHandler(transactionRepo, transferRepo, ledgerRepo, accountRepo, categoryRepo, tagRepo, settingsRepo, budgetRepo, goalRepo, recurringRepo, importRepo, importProfileRepo, publisher, logger)
+
+	// Consome eventos de usuários (exclusão de conta)
+	consumerCtx, stopConsumers := context.WithCancel(context.Background())
+	defer stopConsumers()
+
+	err = rabbitmq.Consume(
+		consumerCtx,
+		messaging.UsersExchangeName,
+		"transaction_service_user_events",
+		[]string{messaging.UserDeletionRequested},
+		userEventHandler.HandleDeletionRequested,
+	)
+	if err != nil {
+		logger.Fatal("failed to consume user events", zap.Error(err))
+	}
+
+	// Materializa as transações recorrentes vencidas
+	recurringWorker := recurring.NewWorker(
+		recurringRepo,
+		publisher,
+		transactionTracker,
+		time.Duration(cfg.RecurringInterval)*time.Second,
+		logger,
+	)
+	recurringWorker.Start(consumerCtx)
 
 	// Configura o router
-	router := setupRouter(transactionHandler)
+	router := setupRouter(transactionHandler, exportHandler, analyticsHandler, accountHandler, categoryHandler, tagHandler, transferHandler, ledgerHandler, settingsHandler, exchangeRateHandler, budgetHandler, goalHandler, recurringHandler, importHandler, importProfileHandler, privacyHandler)
 
 	// Configura servidor HTTP
 	srv := &http.Server{
@@ -105,6 +205,7 @@ func main() {
 	<-quit
 
 	logger.Info("shutting down server...")
+	stopConsumers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -116,7 +217,24 @@ func main() {
 	logger.Info("server exited successfully")
 }
 
-func setupRouter(transactionHandler *handlers.TransactionHandler) *gin.Engine {
+func setupRouter(
+	transactionHandler *handlers.TransactionHandler,
+	exportHandler *handlers.ExportHandler,
+	analyticsHandler *handlers.AnalyticsHandler,
+	accountHandler *handlers.AccountHandler,
+	categoryHandler *handlers.CategoryHandler,
+	tagHandler *handlers.TagHandler,
+	transferHandler *handlers.TransferHandler,
+	ledgerHandler *handlers.LedgerHandler,
+	settingsHandler *handlers.SettingsHandler,
+	exchangeRateHandler *handlers.ExchangeRateHandler,
+	budgetHandler *handlers.BudgetHandler,
+	goalHandler *handlers.GoalHandler,
+	recurringHandler *handlers.RecurringHandler,
+	importHandler *handlers.ImportHandler,
+	importProfileHandler *handlers.ImportProfileHandler,
+	privacyHandler *handlers.PrivacyHandler,
+) *gin.Engine {
 	// Modo release em produção
 	if os.Getenv("ENVIRONMENT") == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -146,6 +264,7 @@ func setupRouter(transactionHandler *handlers.TransactionHandler) *gin.Engine {
 	// API v1
 	v1 := router.Group("/api/v1")
 	v1.Use(middleware.AuthMiddleware(logger)) // Todas as rotas requerem autenticação
+	v1.Use(middleware.ImpersonationGuard(logger))
 	{
 		transactions := v1.Group("/transactions")
 		{
@@ -155,12 +274,147 @@ func setupRouter(transactionHandler *handlers.TransactionHandler) *gin.Engine {
 			transactions.PUT("/:id", transactionHandler.Update)
 			transactions.DELETE("/:id", transactionHandler.Delete)
 			transactions.GET("/stats", transactionHandler.GetStats)
+			transactions.GET("/analytics", analyticsHandler.Analytics)
+			transactions.GET("/export", exportHandler.Export)
+			transactions.POST("/tags", tagHandler.BulkUpdate)
+			transactions.PUT("/:id/splits", transactionHandler.Split)
+			transactions.DELETE("/:id/splits", transactionHandler.Unsplit)
+		}
+
+		accounts := v1.Group("/accounts")
+		{
+			accounts.POST("", accountHandler.Create)
+			accounts.GET("", accountHandler.List)
+			accounts.GET("/:id", accountHandler.GetByID)
+			accounts.PUT("/:id", accountHandler.Update)
+			accounts.DELETE("/:id", accountHandler.Delete)
+			accounts.GET("/:id/balance", accountHandler.Balance)
+		}
+
+		// Categorias hierárquicas de receitas e despesas
+		categories := v1.Group("/categories")
+		{
+			categories.POST("", categoryHandler.Create)
+			categories.GET("", categoryHandler.List)
+			categories.GET("/:id", categoryHandler.GetByID)
+			categories.PUT("/:id", categoryHandler.Update)
+			categories.POST("/:id/merge", categoryHandler.Merge)
+		}
+
+		// Tags livres, independentes da categoria
+		tags := v1.Group("/tags")
+		{
+			tags.POST("", tagHandler.Create)
+			tags.GET("", tagHandler.List)
+			tags.GET("/:id", tagHandler.GetByID)
+			tags.PUT("/:id", tagHandler.Update)
+			tags.DELETE("/:id", tagHandler.Delete)
+		}
+
+		// Transferências entre contas: criadas, editadas e apagadas como unidade
+		transfers := v1.Group("/transfers")
+		{
+			transfers.POST("", transferHandler.Create)
+			transfers.GET("/:id", transferHandler.GetByID)
+			transfers.PUT("/:id", transferHandler.Update)
+			transfers.DELETE("/:id", transferHandler.Delete)
+		}
+
+		// Razão de partidas dobradas (auditoria)
+		ledger := v1.Group("/ledger")
+		{
+			ledger.GET("/accounts", ledgerHandler.Accounts)
+			ledger.GET("/entries", ledgerHandler.Entries)
+			ledger.GET("/integrity", ledgerHandler.Integrity)
+		}
+
+		// Orçamentos mensais por categoria
+		budgets := v1.Group("/budgets")
+		{
+			budgets.POST("", budgetHandler.Create)
+			budgets.GET("", budgetHandler.List)
+			budgets.GET("/report", budgetHandler.Report)
+			budgets.GET("/:id", budgetHandler.GetByID)
+			budgets.PUT("/:id", budgetHandler.Update)
+			budgets.DELETE("/:id", budgetHandler.Delete)
+		}
+
+		// Metas de economia
+		goals := v1.Group("/goals")
+		{
+			goals.POST("", goalHandler.Create)
+			goals.GET("", goalHandler.List)
+			goals.GET("/:id", goalHandler.GetByID)
+			goals.PUT("/:id", goalHandler.Update)
+			goals.DELETE("/:id", goalHandler.Delete)
+		}
+
+		// Transações recorrentes (geradas pelo worker)
+		recurringRules := v1.Group("/recurring")
+		{
+			recurringRules.POST("", recurringHandler.Create)
+			recurringRules.GET("", recurringHandler.List)
+			recurringRules.GET("/:id", recurringHandler.GetByID)
+			recurringRules.PUT("/:id", recurringHandler.Update)
+			recurringRules.DELETE("/:id", recurringHandler.Delete)
+			recurringRules.GET("/:id/preview", recurringHandler.Preview)
+			recurringRules.PUT("/:id/occurrences/:date", recurringHandler.SetOccurrence)
+			recurringRules.DELETE("/:id/occurrences/:date", recurringHandler.ResetOccurrence)
+		}
+
+		// Importação de extratos OFX/QIF, CSV e diários Beancount: pré-visualização, commit e rollback por lote
+		imports := v1.Group("/imports")
+		{
+			imports.POST("", importHandler.Upload)
+			imports.POST("/csv", importHandler.UploadCSV)
+			imports.POST("/beancount", importHandler.UploadBeancount)
+			imports.GET("", importHandler.List)
+			imports.GET("/:id", importHandler.GetByID)
+			imports.DELETE("/:id", importHandler.Delete)
+			imports.POST("/:id/commit", importHandler.Commit)
+			imports.POST("/:id/rollback", importHandler.Rollback)
+		}
+
+		// Perfis de mapeamento de colunas do CSV
+		importProfiles := v1.Group("/import-profiles")
+		{
+			importProfiles.POST("", importProfileHandler.Create)
+			importProfiles.GET("", importProfileHandler.List)
+			importProfiles.GET("/:id", importProfileHandler.GetByID)
+			importProfiles.PUT("/:id", importProfileHandler.Update)
+			importProfiles.DELETE("/:id", importProfileHandler.Delete)
 		}
+
+		// Preferências (moeda base) e cotações
+		v1.GET("/settings", settingsHandler.Get)
+		v1.PUT("/settings", settingsHandler.Update)
+		v1.GET("/exchange-rates", exchangeRateHandler.Get)
+
+		// Fatia de dados pessoais usada pela exportação LGPD/GDPR do auth-service
+		v1.GET("/privacy/export", privacyHandler.ExportPersonalData)
 	}
 
 	return router
 }
 
+// importRates importa arquivos de cotações informados na linha de comando
+func importRates(importer *fx.Importer, files []string) {
+	if len(files) == 0 {
+		logger.Fatal("usage: transaction-service import-rates <file>...")
+	}
+
+	total := 0
+	for _, file := range files {
+		imported, err := importer.ImportFile(context.Background(), file)
+		if err != nil {
+			logger.Fatal("failed to import exchange rates", zap.Error(err))
+		}
+		total += imported
+	}
+
+	logger.Info("exchange rates import finished", zap.Int("rates", total), zap.Int("files", len(files)))
+}
+
 func initLogger() *zap.Logger {
 	var logger *zap.Logger
 	var err error
@@ -200,4 +454,4 @@ func initTracer() (*tracesdk.TracerProvider, error) {
 
 	otel.SetTracerProvider(tp)
 	return tp, nil
-}
\ No newline at end of file
+}
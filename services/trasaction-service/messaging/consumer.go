@@ -0,0 +1,85 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// HandlerFunc processa uma mensagem. Retornar erro faz a mensagem voltar para a fila.
+type HandlerFunc func(ctx context.Context, delivery amqp.Delivery) error
+
+// Consume declara uma fila durável ligada às routing keys do exchange informado e
+// processa as mensagens até o contexto ser cancelado
+func (r *RabbitMQ) Consume(ctx context.Context, exchange, queueName string, routingKeys []string, handler HandlerFunc) error {
+	channel, err := r.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+
+	_, err = channel.QueueDeclare(
+		queueName, // name
+		true,      // durable
+		false,     // delete when unused
+		false,     // exclusive
+		false,     // no-wait
+		nil,       // arguments
+	)
+	if err != nil {
+		channel.Close()
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+
+	for _, key := range routingKeys {
+		if err := channel.QueueBind(queueName, key, exchange, false, nil); err != nil {
+			channel.Close()
+			return fmt.Errorf("failed to bind queue: %w", err)
+		}
+	}
+
+	if err := channel.Qos(1, 0, false); err != nil {
+		channel.Close()
+		return fmt.Errorf("failed to set qos: %w", err)
+	}
+
+	deliveries, err := channel.Consume(queueName, "", false, false, false, false, nil)
+	if err != nil {
+		channel.Close()
+		return fmt.Errorf("failed to consume queue: %w", err)
+	}
+
+	r.logger.Info("consuming rabbitmq queue",
+		zap.String("exchange", exchange),
+		zap.String("queue", queueName),
+		zap.Strings("routing_keys", routingKeys),
+	)
+
+	go func() {
+		defer channel.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case delivery, ok := <-deliveries:
+				if !ok {
+					r.logger.Warn("rabbitmq delivery channel closed", zap.String("queue", queueName))
+					return
+				}
+				if err := handler(ctx, delivery); err != nil {
+					r.logger.Error("failed to handle message",
+						zap.Error(err),
+						zap.String("queue", queueName),
+						zap.String("routing_key", delivery.RoutingKey),
+					)
+					delivery.Nack(false, !delivery.Redelivered)
+					continue
+				}
+				delivery.Ack(false)
+			}
+		}
+	}()
+
+	return nil
+}
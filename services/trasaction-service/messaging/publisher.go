@@ -21,6 +21,17 @@ const (
 	TransactionCreated = "transaction.created"
 	TransactionUpdated = "transaction.updated"
 	TransactionDeleted = "transaction.deleted"
+	TransferCreated    = "transfer.created"
+	TransferUpdated    = "transfer.updated"
+	TransferDeleted    = "transfer.deleted"
+	BudgetWarning      = "budget.warning"
+	BudgetExceeded     = "budget.exceeded"
+	GoalAchieved       = "goal.achieved"
+
+	// Eventos de ciclo de vida de usuários publicados pelo auth-service
+	UsersExchangeName        = "users_exchange"
+	UserDeletionRequested    = "user.deletion_requested"
+	UserDeletionAcknowledged = "user.deletion_acknowledged"
 )
 
 type RabbitMQ struct {
@@ -38,8 +49,11 @@ type EventPublisher struct {
 type TransactionCreatedEvent struct {
 	TransactionID string    `json:"transaction_id"`
 	UserID        string    `json:"user_id"`
+	AccountID     string    `json:"account_id"`
 	Description   string    `json:"description"`
-	Amount        float64   `json:"amount"`
+	Amount        string    `json:"amount"`
+	AmountCents   int64     `json:"amount_cents"`
+	Currency      string    `json:"currency"`
 	Category      string    `json:"category"`
 	Type          string    `json:"type"`
 	Date          time.Time `json:"date"`
@@ -48,17 +62,85 @@ type TransactionCreatedEvent struct {
 	Timestamp     time.Time `json:"timestamp"`
 }
 
+// UserDeletionAcknowledgedEvent confirma ao auth-service que os dados do usuário foram apagados
+type UserDeletionAcknowledgedEvent struct {
+	RequestID string                 `json:"request_id"`
+	UserID    string                 `json:"user_id"`
+	Service   string                 `json:"service"`
+	Details   map[string]interface{} `json:"details"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// TransactionEvent leva o valor como string decimal exata e em centavos,
+// independente do formato JSON configurado para a API
 type TransactionEvent struct {
 	EventType     string    `json:"event_type"`
 	TransactionID string    `json:"transaction_id"`
 	UserID        string    `json:"user_id"`
+	AccountID     string    `json:"account_id"`
 	Description   string    `json:"description"`
-	Amount        float64   `json:"amount"`
+	Amount        string    `json:"amount"`
+	AmountCents   int64     `json:"amount_cents"`
+	Currency      string    `json:"currency"`
 	Type          string    `json:"type"`
 	Category      string    `json:"category"`
+	Tags          []string  `json:"tags"`
+	TagsAdded     []string  `json:"tags_added,omitempty"`   // só em transaction.updated
+	TagsRemoved   []string  `json:"tags_removed,omitempty"` // só em transaction.updated
 	Timestamp     time.Time `json:"timestamp"`
 }
 
+// TransferEvent descreve uma transferência entre contas; não é receita nem despesa
+type TransferEvent struct {
+	EventType     string    `json:"event_type"`
+	TransferID    string    `json:"transfer_id"`
+	UserID        string    `json:"user_id"`
+	FromAccountID string    `json:"from_account_id"`
+	ToAccountID   string    `json:"to_account_id"`
+	Amount        string    `json:"amount"`
+	AmountCents   int64     `json:"amount_cents"`
+	Currency      string    `json:"currency"`
+	ToAmount      string    `json:"to_amount"`
+	ToAmountCents int64     `json:"to_amount_cents"`
+	ToCurrency    string    `json:"to_currency"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// BudgetEvent avisa que o gasto de uma categoria cruzou um limiar do orçamento do mês.
+// Valores na moeda do orçamento, como string decimal exata e em centavos.
+type BudgetEvent struct {
+	EventType          string    `json:"event_type"`
+	BudgetID           string    `json:"budget_id"`
+	UserID             string    `json:"user_id"`
+	UserEmail          string    `json:"user_email,omitempty"`
+	Category           string    `json:"category"`
+	Month              string    `json:"month"`
+	Currency           string    `json:"currency"`
+	Threshold          int       `json:"threshold"`
+	Percentage         int       `json:"percentage"`
+	BudgetLimit        string    `json:"budget_limit"`
+	BudgetLimitCents   int64     `json:"budget_limit_cents"`
+	CurrentAmount      string    `json:"current_amount"`
+	CurrentAmountCents int64     `json:"current_amount_cents"`
+	Timestamp          time.Time `json:"timestamp"`
+}
+
+// GoalEvent avisa que uma meta de economia foi atingida; publicado uma única vez por meta
+type GoalEvent struct {
+	EventType         string    `json:"event_type"`
+	GoalID            string    `json:"goal_id"`
+	UserID            string    `json:"user_id"`
+	UserEmail         string    `json:"user_email,omitempty"`
+	GoalName          string    `json:"goal_name"`
+	Currency          string    `json:"currency"`
+	TargetAmount      string    `json:"target_amount"`
+	TargetAmountCents int64     `json:"target_amount_cents"`
+	SavedAmount       string    `json:"saved_amount"`
+	SavedAmountCents  int64     `json:"saved_amount_cents"`
+	Deadline          *string   `json:"deadline,omitempty"`
+	Timestamp         time.Time `json:"timestamp"`
+}
+
 // NewRabbitMQ cria uma nova conexão com RabbitMQ
 func NewRabbitMQ(url string, logger *zap.Logger) (*RabbitMQ, error) {
 	// Conecta ao RabbitMQ
@@ -90,6 +172,13 @@ func NewRabbitMQ(url string, logger *zap.Logger) (*RabbitMQ, error) {
 		return nil, fmt.Errorf("failed to declare exchange: %w", err)
 	}
 
+	err = channel.ExchangeDeclare(UsersExchangeName, ExchangeType, true, false, false, false, nil)
+	if err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare users exchange: %w", err)
+	}
+
 	logger.Info("connected to rabbitmq",
 		zap.String("exchange", ExchangeName),
 		zap.String("type", ExchangeType),
@@ -332,3 +421,96 @@ func (p *EventPublisher) PublishTransactionEvent(event TransactionEvent) error {
 
 	return nil
 }
+
+// PublishTransferEvent publica um evento de transferência
+func (p *EventPublisher) PublishTransferEvent(event TransferEvent) error {
+	return p.publishEvent(event.EventType, event)
+}
+
+// PublishBudgetEvent publica um alerta de orçamento
+func (p *EventPublisher) PublishBudgetEvent(event BudgetEvent) error {
+	return p.publishEvent(event.EventType, event)
+}
+
+// PublishGoalEvent publica um evento de meta
+func (p *EventPublisher) PublishGoalEvent(event GoalEvent) error {
+	return p.publishEvent(event.EventType, event)
+}
+
+// publishEvent publica um evento JSON persistente no exchange de transações
+func (p *EventPublisher) publishEvent(routingKey string, event interface{}) error {
+	start := time.Now()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = p.rabbitmq.channel.Publish(
+		ExchangeName,
+		routingKey,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			Body:         body,
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    time.Now(),
+		},
+	)
+
+	if err != nil {
+		metrics.MessagesPublishedTotal.WithLabelValues(routingKey, "error").Inc()
+		metrics.MessagesPublishErrors.WithLabelValues(routingKey, "publish_failed").Inc()
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	metrics.MessagesPublishedTotal.WithLabelValues(routingKey, "success").Inc()
+	metrics.MessagePublishDuration.WithLabelValues(routingKey).Observe(time.Since(start).Seconds())
+
+	p.logger.Info("event published",
+		zap.String("routing_key", routingKey),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	return nil
+}
+
+// PublishUserDeletionAcknowledged confirma a exclusão dos dados de um usuário
+func (p *EventPublisher) PublishUserDeletionAcknowledged(ctx context.Context, event UserDeletionAcknowledgedEvent, traceID string) error {
+	ctx, span := p.tracer.Start(ctx, "PublishUserDeletionAcknowledged")
+	defer span.End()
+
+	event.Timestamp = time.Now()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = p.rabbitmq.channel.PublishWithContext(
+		ctx,
+		UsersExchangeName,
+		UserDeletionAcknowledged,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			Body:         body,
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    time.Now(),
+			Headers: amqp.Table{
+				"trace_id": traceID,
+			},
+		},
+	)
+
+	if err != nil {
+		span.RecordError(err)
+		metrics.MessagesPublishedTotal.WithLabelValues(UserDeletionAcknowledged, "error").Inc()
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	metrics.MessagesPublishedTotal.WithLabelValues(UserDeletionAcknowledged, "success").Inc()
+	return nil
+}
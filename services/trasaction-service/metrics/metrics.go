@@ -47,6 +47,62 @@ var (
 		},
 	)
 
+	// Transferências não entram em transactions_created_total
+	TransfersTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "transfers_total",
+			Help: "Total number of transfers between accounts by operation",
+		},
+		[]string{"operation"},
+	)
+
+	LedgerIntegrityFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ledger_integrity_failures_total",
+			Help: "Total number of failed ledger integrity checks",
+		},
+		[]string{"check"},
+	)
+
+	BudgetAlertsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "budget_alerts_total",
+			Help: "Total number of budget threshold alerts published",
+		},
+		[]string{"threshold"},
+	)
+
+	GoalsAchievedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "goals_achieved_total",
+			Help: "Total number of savings goals achieved",
+		},
+	)
+
+	RecurringMaterializationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "recurring_materializations_total",
+			Help: "Total number of recurring rule materialization runs by status",
+		},
+		[]string{"status"},
+	)
+
+	ImportBatchesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "import_batches_total",
+			Help: "Total number of statement import batches by format and operation",
+		},
+		[]string{"format", "operation"},
+	)
+
+	TransactionExportsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "transaction_exports_total",
+			Help: "Total number of completed transaction exports by format",
+		},
+		[]string{"format"},
+	)
+
 	TransactionAmount = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "transaction_amount",
@@ -10,6 +10,13 @@ import (
 	"go.uber.org/zap"
 )
 
+// purposeRoutes limita os tokens internos com "purpose" à rota do seu propósito.
+// O worker de exportação do auth-service assina um token data_export com o mesmo
+// segredo dos tokens de usuário; fora da exportação ele não vale.
+var purposeRoutes = map[string]string{
+	"data_export": http.MethodGet + " /api/v1/privacy/export",
+}
+
 // AuthMiddleware valida o JWT token
 func AuthMiddleware(logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -51,6 +58,15 @@ func AuthMiddleware(logger *zap.Logger) gin.HandlerFunc {
 
 		// Extrai claims
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if purpose, _ := claims["purpose"].(string); purpose != "" && purposeRoutes[purpose] != c.Request.Method+" "+c.FullPath() {
+				logger.Warn("purpose token used outside its route",
+					zap.String("purpose", purpose),
+					zap.String("path", c.FullPath()),
+				)
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token not valid for this route"})
+				return
+			}
+
 			// Adiciona user_id ao contexto
 			if userID, exists := claims["user_id"]; exists {
 				c.Set("user_id", userID)
@@ -60,6 +76,15 @@ func AuthMiddleware(logger *zap.Logger) gin.HandlerFunc {
 			if email, exists := claims["email"]; exists {
 				c.Set("email", email)
 			}
+
+			// Token de suporte: "act" identifica o agente que age em nome do usuário
+			if act, ok := claims["act"].(map[string]interface{}); ok {
+				if actorID, ok := act["sub"].(string); ok && actorID != "" {
+					c.Set("impersonator_id", actorID)
+					scope, _ := claims["scope"].(string)
+					c.Set("impersonation_scope", scope)
+				}
+			}
 		}
 
 		c.Next()
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"transaction-service/money"
+)
+
+// Tipos de conta
+const (
+	AccountTypeChecking   = "checking"
+	AccountTypeSavings    = "savings"
+	AccountTypeCreditCard = "credit_card"
+	AccountTypeCash       = "cash"
+	AccountTypeInvestment = "investment"
+)
+
+// Account é onde o dinheiro de uma transação está (conta corrente, cartão, dinheiro...).
+// Os valores da conta estão sempre na moeda da conta.
+type Account struct {
+	ID             string       `json:"id" db:"id"`
+	UserID         string       `json:"user_id" db:"user_id"`
+	Name           string       `json:"name" db:"name"`
+	Type           string       `json:"type" db:"type"`
+	Currency       string       `json:"currency" db:"currency"`
+	OpeningBalance money.Amount `json:"opening_balance" db:"opening_balance"`
+	Archived       bool         `json:"archived" db:"archived"`
+	Balance        money.Amount `json:"balance"` // saldo atual: abertura + receitas - despesas ± transferências
+	CreatedAt      time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// AccountBalance é o saldo de uma conta ao final de uma data
+type AccountBalance struct {
+	AccountID string       `json:"account_id"`
+	Currency  string       `json:"currency"`
+	Date      string       `json:"date"`
+	Balance   money.Amount `json:"balance"`
+}
+
+// AccountSubtotal resume as transações de uma conta, na moeda da conta
+type AccountSubtotal struct {
+	AccountID     string       `json:"account_id"`
+	Name          string       `json:"name"`
+	Currency      string       `json:"currency"`
+	TotalIncome   money.Amount `json:"total_income"`
+	TotalExpenses money.Amount `json:"total_expenses"`
+	NetTransfers  money.Amount `json:"net_transfers"` // entradas - saídas por transferência
+	Balance       money.Amount `json:"balance"`
+	TotalCount    int          `json:"total_count"`
+}
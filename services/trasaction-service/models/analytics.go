@@ -0,0 +1,63 @@
+package models
+
+import "transaction-service/money"
+
+// Granularidades da análise por período
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week" // semanas começam na segunda-feira
+	GranularityMonth = "month"
+	GranularityYear  = "year"
+)
+
+// PeriodTotals resume receitas e despesas de um intervalo, na moeda base.
+// Datas no formato YYYY-MM-DD, inclusive, no fuso do usuário.
+type PeriodTotals struct {
+	Start   string       `json:"start"`
+	End     string       `json:"end"`
+	Income  money.Amount `json:"income"`
+	Expense money.Amount `json:"expense"`
+	Net     money.Amount `json:"net"`
+	Count   int          `json:"count"`
+}
+
+// CategoryTotal é o total de uma categoria dentro de um tipo (receita ou despesa)
+type CategoryTotal struct {
+	Category string       `json:"category"`
+	Total    money.Amount `json:"total"`
+	Count    int          `json:"count"`
+	Share    int          `json:"share"` // % do total do tipo no período
+}
+
+// AnalyticsAverages são as médias do período analisado
+type AnalyticsAverages struct {
+	IncomePerBucket  money.Amount `json:"income_per_bucket"`
+	ExpensePerBucket money.Amount `json:"expense_per_bucket"`
+	NetPerBucket     money.Amount `json:"net_per_bucket"`
+	ExpensePerDay    money.Amount `json:"expense_per_day"`
+}
+
+// PeriodComparison compara o período analisado com outro intervalo. As
+// variações são percentuais sobre o intervalo comparado e ficam nulas quando
+// ele não teve movimento.
+type PeriodComparison struct {
+	PeriodTotals
+	IncomeChange  *int `json:"income_change"`
+	ExpenseChange *int `json:"expense_change"`
+}
+
+// Analytics é a série temporal de receitas e despesas de um período.
+// Transferências não entram; transações sem cotação para a moeda base ficam
+// fora dos valores e são contadas em UnconvertedCount.
+type Analytics struct {
+	BaseCurrency     string                     `json:"base_currency"`
+	Timezone         string                     `json:"timezone"`
+	Granularity      string                     `json:"granularity"`
+	Totals           PeriodTotals               `json:"totals"`
+	UnconvertedCount int                        `json:"unconverted_count"`
+	Buckets          []PeriodTotals             `json:"buckets"`
+	ByCategory       map[string][]CategoryTotal `json:"by_category"` // "income" e "expense"
+	Averages         AnalyticsAverages          `json:"averages"`
+	PreviousPeriod   PeriodComparison           `json:"previous_period"`
+	LastYear         PeriodComparison           `json:"last_year"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"transaction-service/money"
+)
+
+// Limiares de alerta de orçamento, em porcentagem do disponível no mês
+const (
+	BudgetWarningThreshold  = 80
+	BudgetExceededThreshold = 100
+)
+
+// Situação de um orçamento no mês
+const (
+	BudgetStatusOK       = "ok"
+	BudgetStatusWarning  = "warning"
+	BudgetStatusExceeded = "exceeded"
+)
+
+// Budget é o limite mensal de gastos de uma categoria
+type Budget struct {
+	ID         string       `json:"id" db:"id"`
+	UserID     string       `json:"user_id" db:"user_id"`
+	Category   string       `json:"category" db:"category"`
+	Amount     money.Amount `json:"amount" db:"amount"`
+	Currency   string       `json:"currency" db:"currency"`
+	Rollover   bool         `json:"rollover" db:"rollover"`
+	StartMonth string       `json:"start_month" db:"start_month"` // formato: 2006-01
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// BudgetStatus compara o orçado com o realizado de um orçamento num mês
+type BudgetStatus struct {
+	BudgetID         string       `json:"budget_id"`
+	Category         string       `json:"category"`
+	Currency         string       `json:"currency"`
+	Month            string       `json:"month"`
+	Limit            money.Amount `json:"limit"`
+	CarriedOver      money.Amount `json:"carried_over"` // sobra acumulada dos meses anteriores
+	Available        money.Amount `json:"available"`
+	Spent            money.Amount `json:"spent"`
+	Remaining        money.Amount `json:"remaining"`
+	Percentage       int          `json:"percentage"`
+	Status           string       `json:"status"`
+	UnconvertedCount int          `json:"unconverted_count"` // despesas sem cotação na data
+}
+
+// BudgetReport é o orçado x realizado de um mês. Gastos de categorias sem
+// orçamento vêm convertidos para a moeda base do usuário.
+type BudgetReport struct {
+	Month            string                  `json:"month"`
+	BaseCurrency     string                  `json:"base_currency"`
+	Budgets          []*BudgetStatus         `json:"budgets"`
+	Unbudgeted       map[string]money.Amount `json:"unbudgeted"`
+	UnconvertedCount int                     `json:"unconverted_count"`
+}
+
+// BudgetMonth retorna o primeiro dia (UTC) do mês de t, usado como chave dos orçamentos
+func BudgetMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"transaction-service/money"
+)
+
+// Category é uma categoria de receitas ou despesas do usuário. O nome é único
+// por tipo, sem diferenciar maiúsculas e acentos, em qualquer nível da árvore.
+type Category struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	ParentID  *string   `json:"parent_id" db:"parent_id"`
+	Name      string    `json:"name" db:"name"`
+	Type      string    `json:"type" db:"type"`   // income ou expense
+	Color     *string   `json:"color" db:"color"` // formato: #RRGGBB
+	Icon      *string   `json:"icon" db:"icon"`
+	Archived  bool      `json:"archived" db:"archived"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CategoryStats é o total de uma categoria na moeda base. Total e Count contam
+// só as transações da própria categoria; os campos RolledUp somam também as
+// subcategorias.
+type CategoryStats struct {
+	CategoryID    string           `json:"category_id"`
+	Name          string           `json:"name"`
+	Type          string           `json:"type"`
+	Total         money.Amount     `json:"total"`
+	Count         int              `json:"count"`
+	RolledUpTotal money.Amount     `json:"rolled_up_total"`
+	RolledUpCount int              `json:"rolled_up_count"`
+	Children      []*CategoryStats `json:"children"`
+}
+
+// DefaultCategory é uma categoria do conjunto inicial de cada usuário
+type DefaultCategory struct {
+	Name     string
+	Type     string
+	Color    string
+	Icon     string
+	Children []string
+}
+
+// DefaultCategories é criado na primeira vez que o usuário usa categorias
+var DefaultCategories = []DefaultCategory{
+	{Name: "Alimentação", Type: "expense", Color: "#E67E22", Icon: "utensils", Children: []string{"Mercado", "Restaurantes"}},
+	{Name: "Moradia", Type: "expense", Color: "#8E44AD", Icon: "home", Children: []string{"Aluguel", "Contas da casa"}},
+	{Name: "Transporte", Type: "expense", Color: "#2980B9", Icon: "car", Children: []string{"Combustível", "Transporte público"}},
+	{Name: "Saúde", Type: "expense", Color: "#C0392B", Icon: "heart", Children: []string{"Farmácia", "Consultas"}},
+	{Name: "Educação", Type: "expense", Color: "#16A085", Icon: "book"},
+	{Name: "Lazer", Type: "expense", Color: "#F1C40F", Icon: "smile"},
+	{Name: "Compras", Type: "expense", Color: "#D35400", Icon: "shopping-bag"},
+	{Name: "Outros", Type: "expense", Color: "#7F8C8D", Icon: "tag"},
+	{Name: "Salário", Type: "income", Color: "#27AE60", Icon: "briefcase"},
+	{Name: "Freelance", Type: "income", Color: "#2ECC71", Icon: "laptop"},
+	{Name: "Investimentos", Type: "income", Color: "#1ABC9C", Icon: "trending-up"},
+	{Name: "Outros", Type: "income", Color: "#95A5A6", Icon: "tag"},
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"transaction-service/money"
+)
+
+// UserSettings guarda as preferências financeiras do usuário
+type UserSettings struct {
+	UserID       string    `json:"user_id" db:"user_id"`
+	BaseCurrency string    `json:"base_currency" db:"base_currency"`
+	Timezone     string    `json:"timezone" db:"timezone"` // nome IANA, ex.: America/Sao_Paulo
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ExchangeRate é a cotação de uma moeda em outra numa data.
+// A taxa é mantida como texto decimal para não perder precisão.
+type ExchangeRate struct {
+	Currency      string    `json:"currency" db:"currency"`
+	QuoteCurrency string    `json:"quote_currency" db:"quote_currency"`
+	Date          time.Time `json:"date" db:"rate_date"`
+	Rate          string    `json:"rate" db:"rate"`
+	Source        string    `json:"source" db:"source"`
+}
+
+// CurrencySubtotal resume as transações de uma moeda, na própria moeda
+type CurrencySubtotal struct {
+	TotalIncome   money.Amount `json:"total_income"`
+	TotalExpenses money.Amount `json:"total_expenses"`
+	Balance       money.Amount `json:"balance"`
+	TotalCount    int          `json:"total_count"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"transaction-service/money"
+)
+
+// Goal é uma meta de economia. As contribuições vêm das transações ligadas:
+// o movimento líquido de uma conta (AccountID) ou os lançamentos de uma
+// categoria (Category), a partir de StartDate.
+type Goal struct {
+	ID           string        `json:"id" db:"id"`
+	UserID       string        `json:"user_id" db:"user_id"`
+	Name         string        `json:"name" db:"name"`
+	TargetAmount money.Amount  `json:"target_amount" db:"target_amount"`
+	Currency     string        `json:"currency" db:"currency"`
+	Deadline     *string       `json:"deadline,omitempty" db:"deadline"` // formato: 2006-01-02
+	AccountID    *string       `json:"account_id,omitempty" db:"account_id"`
+	Category     *string       `json:"category,omitempty" db:"category"`
+	StartDate    string        `json:"start_date" db:"start_date"` // formato: 2006-01-02
+	AchievedAt   *time.Time    `json:"achieved_at,omitempty" db:"achieved_at"`
+	Progress     *GoalProgress `json:"progress,omitempty"`
+	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// GoalProgress é o andamento da meta, com a projeção de conclusão pelo ritmo
+// médio de contribuição desde StartDate
+type GoalProgress struct {
+	Saved               money.Amount       `json:"saved"`
+	Remaining           money.Amount       `json:"remaining"`
+	Percentage          int                `json:"percentage"`
+	ContributionCount   int                `json:"contribution_count"`
+	UnconvertedCount    int                `json:"unconverted_count"` // lançamentos sem cotação na data
+	MonthlyRate         money.Amount       `json:"monthly_rate"`
+	ProjectedCompletion *string            `json:"projected_completion,omitempty"` // vazio sem ritmo positivo
+	RequiredMonthly     *money.Amount      `json:"required_monthly,omitempty"`     // para cumprir o prazo
+	OnTrack             *bool              `json:"on_track,omitempty"`
+	Contributions       []GoalContribution `json:"contributions"`
+}
+
+// GoalContribution é o total contribuído num mês
+type GoalContribution struct {
+	Month  string       `json:"month"` // formato: 2006-01
+	Amount money.Amount `json:"amount"`
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"transaction-service/money"
+)
+
+// Situação de um lote de importação
+const (
+	ImportStatusPending    = "pending"
+	ImportStatusCommitted  = "committed"
+	ImportStatusRolledBack = "rolled_back"
+)
+
+// Classificação de cada lançamento do lote contra as transações existentes
+const (
+	ImportEntryNew               = "new"
+	ImportEntryDuplicate         = "duplicate"          // mesmo FITID/hash já importado na conta
+	ImportEntryPossibleDuplicate = "possible_duplicate" // mesma data, valor e tipo de uma transação existente
+)
+
+// ImportBatch é um arquivo de extrato enviado para uma conta. Fica pendente
+// até o commit, e depois pode ser desfeito por inteiro com o rollback.
+type ImportBatch struct {
+	ID            string         `json:"id" db:"id"`
+	UserID        string         `json:"user_id" db:"user_id"`
+	AccountID     string         `json:"account_id" db:"account_id"`
+	Format        string         `json:"format" db:"format"`
+	ProfileID     *string        `json:"profile_id,omitempty" db:"profile_id"` // só em lotes de CSV
+	Filename      string         `json:"filename" db:"filename"`
+	Status        string         `json:"status" db:"status"`
+	TotalEntries  int            `json:"total_entries" db:"total_entries"`
+	ErrorCount    int            `json:"error_count" db:"error_count"`
+	ImportedCount int            `json:"imported_count" db:"imported_count"`
+	Summary       map[string]int `json:"summary,omitempty"` // lançamentos por classificação
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	CommittedAt   *time.Time     `json:"committed_at,omitempty" db:"committed_at"`
+	RolledBackAt  *time.Time     `json:"rolled_back_at,omitempty" db:"rolled_back_at"`
+}
+
+// ImportEntry é um lançamento do extrato já convertido para os campos da transação
+type ImportEntry struct {
+	Line          int                `json:"line" db:"line"`
+	ExternalID    string             `json:"external_id" db:"external_id"`
+	Date          string             `json:"date" db:"date"` // formato: 2006-01-02
+	Amount        money.Amount       `json:"amount" db:"amount"`
+	Type          string             `json:"type" db:"type"` // income ou expense
+	Description   string             `json:"description" db:"description"`
+	Category      string             `json:"category" db:"category"`
+	Splits        []TransactionSplit `json:"splits,omitempty" db:"splits"` // só em lançamentos divididos
+	Status        string             `json:"status" db:"status"`
+	DuplicateOf   *string            `json:"duplicate_of,omitempty" db:"duplicate_of"`
+	TransactionID *string            `json:"transaction_id,omitempty" db:"transaction_id"`
+}
+
+// ImportOverride ajusta um lançamento no commit: troca a categoria ou o ignora.
+// Num lançamento dividido, a categoria informada substitui a divisão inteira.
+type ImportOverride struct {
+	Line     int     `json:"line" binding:"required,min=1"`
+	Category *string `json:"category" binding:"omitempty,min=1,max=100"`
+	Skip     bool    `json:"skip"`
+}
+
+// Separadores decimais dos perfis de CSV
+const (
+	DecimalSeparatorComma = "comma" // 1.234,56
+	DecimalSeparatorDot   = "dot"   // 1,234.56
+)
+
+// ImportProfile guarda o layout de um CSV (colunas, formato de data e número,
+// convenção de sinal) para ser reutilizado nas importações seguintes. Colunas
+// são o nome no cabeçalho ou a posição começando em 1.
+type ImportProfile struct {
+	ID                 string    `json:"id" db:"id"`
+	UserID             string    `json:"user_id" db:"user_id"`
+	Name               string    `json:"name" db:"name"`
+	Delimiter          string    `json:"delimiter" db:"delimiter"`
+	Encoding           string    `json:"encoding" db:"encoding"` // utf-8 ou latin1
+	HasHeader          bool      `json:"has_header" db:"has_header"`
+	SkipRows           int       `json:"skip_rows" db:"skip_rows"`
+	DateColumn         string    `json:"date_column" db:"date_column"`
+	DateFormat         string    `json:"date_format" db:"date_format"` // ex.: DD/MM/YYYY
+	DescriptionColumns []string  `json:"description_columns" db:"description_columns"`
+	AmountColumn       *string   `json:"amount_column,omitempty" db:"amount_column"`
+	DebitColumn        *string   `json:"debit_column,omitempty" db:"debit_column"`
+	CreditColumn       *string   `json:"credit_column,omitempty" db:"credit_column"`
+	CategoryColumn     *string   `json:"category_column,omitempty" db:"category_column"`
+	ExternalIDColumn   *string   `json:"external_id_column,omitempty" db:"external_id_column"`
+	DecimalSeparator   string    `json:"decimal_separator" db:"decimal_separator"`
+	SignConvention     string    `json:"sign_convention" db:"sign_convention"` // signed, inverted ou debit_credit
+	DefaultCategory    string    `json:"default_category" db:"default_category"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+}
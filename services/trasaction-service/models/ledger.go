@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"transaction-service/money"
+)
+
+// Naturezas das contas do razão
+const (
+	LedgerKindAsset     = "asset"
+	LedgerKindLiability = "liability"
+	LedgerKindIncome    = "income"
+	LedgerKindExpense   = "expense"
+	LedgerKindEquity    = "equity"
+)
+
+// Tipos de lançamento
+const (
+	EntryKindTransaction    = "transaction"
+	EntryKindTransfer       = "transfer"
+	EntryKindOpeningBalance = "opening_balance"
+	EntryKindReversal       = "reversal"
+)
+
+// LedgerAccount é uma conta do razão: carteira do usuário, categoria de receita
+// ou despesa, ou conta de patrimônio (saldos iniciais e conversão de moedas)
+type LedgerAccount struct {
+	ID        string       `json:"id"`
+	Key       string       `json:"key"`
+	Kind      string       `json:"kind"`
+	AccountID *string      `json:"account_id,omitempty"`
+	Category  *string      `json:"category,omitempty"`
+	Currency  string       `json:"currency"`
+	Name      string       `json:"name"`
+	Balance   money.Amount `json:"balance"` // soma das partidas: débitos positivos, créditos negativos
+}
+
+// JournalEntry é um lançamento imutável; as partidas somam zero em cada moeda
+type JournalEntry struct {
+	ID              string    `json:"id"`
+	UserID          string    `json:"user_id"`
+	Kind            string    `json:"kind"`
+	SourceType      string    `json:"source_type"`
+	SourceID        string    `json:"source_id"`
+	ReversesEntryID *string   `json:"reverses_entry_id,omitempty"`
+	Description     string    `json:"description"`
+	Date            time.Time `json:"date"`
+	CreatedAt       time.Time `json:"created_at"`
+	Postings        []Posting `json:"postings"`
+}
+
+// Posting é uma partida de um lançamento
+type Posting struct {
+	LedgerAccountID string       `json:"ledger_account_id"`
+	LedgerAccount   string       `json:"ledger_account"`
+	Amount          money.Amount `json:"amount"`
+	Currency        string       `json:"currency"`
+}
+
+// IntegrityCheck é o resultado de uma verificação do razão
+type IntegrityCheck struct {
+	Name     string   `json:"name"`
+	Passed   bool     `json:"passed"`
+	Failures []string `json:"failures"`
+}
+
+// IntegrityReport prova (ou não) que o razão fecha e que confere com a API
+type IntegrityReport struct {
+	Balanced  bool             `json:"balanced"`
+	CheckedAt time.Time        `json:"checked_at"`
+	Checks    []IntegrityCheck `json:"checks"`
+}
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"time"
+
+	"transaction-service/money"
+)
+
+// Frequências das regras de recorrência
+const (
+	FrequencyDaily           = "daily"
+	FrequencyWeekly          = "weekly"            // no dia da semana Weekday
+	FrequencyMonthly         = "monthly"           // no dia DayOfMonth (ajustado ao fim de meses curtos)
+	FrequencyLastBusinessDay = "last_business_day" // último dia útil (seg-sex) do mês; feriados não são considerados
+	FrequencyYearly          = "yearly"            // no mesmo dia e mês de StartDate
+)
+
+// Ações sobre uma ocorrência isolada da série
+const (
+	OccurrenceSkip   = "skip"
+	OccurrenceModify = "modify"
+)
+
+// Situação de uma ocorrência na pré-visualização
+const (
+	OccurrenceScheduled    = "scheduled"
+	OccurrenceSkipped      = "skipped"
+	OccurrenceModified     = "modified"
+	OccurrenceMaterialized = "materialized"
+)
+
+// SeriesHorizon é o limite das buscas em séries sem data final
+var SeriesHorizon = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// RecurringRule gera transações concretas numa agenda do tipo RRULE.
+// Interval repete a cada N períodos (ex.: weekly com Interval 2 = quinzenal).
+type RecurringRule struct {
+	ID             string       `json:"id" db:"id"`
+	UserID         string       `json:"user_id" db:"user_id"`
+	AccountID      string       `json:"account_id" db:"account_id"`
+	Description    string       `json:"description" db:"description"`
+	Amount         money.Amount `json:"amount" db:"amount"`
+	Category       string       `json:"category" db:"category"`
+	Type           string       `json:"type" db:"type"` // income ou expense
+	Frequency      string       `json:"frequency" db:"frequency"`
+	Interval       int          `json:"interval" db:"interval"`
+	DayOfMonth     *int         `json:"day_of_month,omitempty" db:"day_of_month"`
+	Weekday        *int         `json:"weekday,omitempty" db:"weekday"` // 0 = domingo
+	StartDate      string       `json:"start_date" db:"start_date"`     // formato: 2006-01-02
+	EndDate        *string      `json:"end_date,omitempty" db:"end_date"`
+	NextOccurrence *string      `json:"next_occurrence,omitempty" db:"next_occurrence"` // vazio quando a série terminou
+	Active         bool         `json:"active" db:"active"`
+	CreatedAt      time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// RecurringException pula ou altera uma única ocorrência sem mexer na série
+type RecurringException struct {
+	RuleID         string        `json:"rule_id" db:"rule_id"`
+	OccurrenceDate string        `json:"occurrence_date" db:"occurrence_date"`
+	Action         string        `json:"action" db:"action"`
+	Description    *string       `json:"description,omitempty" db:"description"`
+	Amount         *money.Amount `json:"amount,omitempty" db:"amount"`
+	Category       *string       `json:"category,omitempty" db:"category"`
+}
+
+// RecurringOccurrence é uma ocorrência da série, já com a exceção aplicada
+type RecurringOccurrence struct {
+	Date          string       `json:"date"`
+	Description   string       `json:"description"`
+	Amount        money.Amount `json:"amount"`
+	Category      string       `json:"category"`
+	Status        string       `json:"status"`
+	TransactionID *string      `json:"transaction_id,omitempty"`
+}
+
+// Apply aplica a exceção (se houver) aos valores padrão da regra
+func (r *RecurringRule) Apply(date time.Time, exception *RecurringException) RecurringOccurrence {
+	occurrence := RecurringOccurrence{
+		Date:        date.Format("2006-01-02"),
+		Description: r.Description,
+		Amount:      r.Amount,
+		Category:    r.Category,
+		Status:      OccurrenceScheduled,
+	}
+
+	if exception == nil {
+		return occurrence
+	}
+
+	if exception.Action == OccurrenceSkip {
+		occurrence.Status = OccurrenceSkipped
+		return occurrence
+	}
+
+	occurrence.Status = OccurrenceModified
+	if exception.Description != nil {
+		occurrence.Description = *exception.Description
+	}
+	if exception.Amount != nil {
+		occurrence.Amount = *exception.Amount
+	}
+	if exception.Category != nil {
+		occurrence.Category = *exception.Category
+	}
+	return occurrence
+}
+
+// Occurrences retorna as datas da série entre from e to (inclusive), no máximo
+// limit datas (0 = sem limite). As datas são dias em UTC.
+func (r *RecurringRule) Occurrences(from, to time.Time, limit int) []time.Time {
+	start, err := time.Parse("2006-01-02", r.StartDate)
+	if err != nil {
+		return nil
+	}
+	if r.EndDate != nil {
+		if end, err := time.Parse("2006-01-02", *r.EndDate); err == nil && end.Before(to) {
+			to = end
+		}
+	}
+	if from.Before(start) {
+		from = start
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	dates := []time.Time{}
+	for period := 0; ; period += interval {
+		date, ok := r.occurrenceInPeriod(start, period)
+		if !ok {
+			break
+		}
+		if date.After(to) {
+			break
+		}
+		if date.Before(from) {
+			continue
+		}
+		dates = append(dates, date)
+		if limit > 0 && len(dates) >= limit {
+			break
+		}
+	}
+	return dates
+}
+
+// FirstOccurrence retorna a primeira data da série a partir de from (inclusive);
+// false quando a série já terminou
+func (r *RecurringRule) FirstOccurrence(from time.Time) (time.Time, bool) {
+	dates := r.Occurrences(from, SeriesHorizon, 1)
+	if len(dates) == 0 {
+		return time.Time{}, false
+	}
+	return dates[0], true
+}
+
+// IsOccurrence informa se date é uma data da série
+func (r *RecurringRule) IsOccurrence(date time.Time) bool {
+	dates := r.Occurrences(date, date, 1)
+	return len(dates) == 1 && dates[0].Equal(date)
+}
+
+// occurrenceInPeriod calcula a data da ocorrência no período de índice n a partir de start
+func (r *RecurringRule) occurrenceInPeriod(start time.Time, n int) (time.Time, bool) {
+	switch r.Frequency {
+	case FrequencyDaily:
+		return start.AddDate(0, 0, n), true
+
+	case FrequencyWeekly:
+		// Semana de start deslocada até o dia da semana configurado
+		weekday := int(start.Weekday())
+		if r.Weekday != nil {
+			weekday = *r.Weekday
+		}
+		offset := (weekday - int(start.Weekday()) + 7) % 7
+		return start.AddDate(0, 0, 7*n+offset), true
+
+	case FrequencyMonthly:
+		day := start.Day()
+		if r.DayOfMonth != nil {
+			day = *r.DayOfMonth
+		}
+		first := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+		if last := daysIn(first); day > last {
+			day = last
+		}
+		return first.AddDate(0, 0, day-1), true
+
+	case FrequencyLastBusinessDay:
+		first := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+		date := first.AddDate(0, 1, -1)
+		for date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			date = date.AddDate(0, 0, -1)
+		}
+		return date, true
+
+	case FrequencyYearly:
+		first := time.Date(start.Year()+n, start.Month(), 1, 0, 0, 0, 0, time.UTC)
+		day := start.Day()
+		if last := daysIn(first); day > last {
+			day = last
+		}
+		return first.AddDate(0, 0, day-1), true
+	}
+
+	return time.Time{}, false
+}
+
+func daysIn(first time.Time) int {
+	return first.AddDate(0, 1, -1).Day()
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func strPtr(v string) *string {
+	return &v
+}
+
+func TestRecurringRuleOccurrences(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  RecurringRule
+		from  string
+		to    string
+		limit int
+		want  []string
+	}{
+		{
+			name: "diária",
+			rule: RecurringRule{Frequency: FrequencyDaily, Interval: 1, StartDate: "2024-02-27"},
+			from: "2024-02-27", to: "2024-03-01",
+			want: []string{"2024-02-27", "2024-02-28", "2024-02-29", "2024-03-01"},
+		},
+		{
+			name: "diária a cada 3 dias, a partir do meio da série",
+			rule: RecurringRule{Frequency: FrequencyDaily, Interval: 3, StartDate: "2024-01-01"},
+			from: "2024-01-05", to: "2024-01-14",
+			want: []string{"2024-01-07", "2024-01-10", "2024-01-13"},
+		},
+		{
+			name: "semanal desloca até o dia da semana",
+			rule: RecurringRule{Frequency: FrequencyWeekly, Interval: 1, Weekday: intPtr(5), StartDate: "2024-01-01"},
+			from: "2024-01-01", to: "2024-01-20",
+			want: []string{"2024-01-05", "2024-01-12", "2024-01-19"},
+		},
+		{
+			name: "quinzenal",
+			rule: RecurringRule{Frequency: FrequencyWeekly, Interval: 2, StartDate: "2024-01-03"},
+			from: "2024-01-01", to: "2024-02-15",
+			want: []string{"2024-01-03", "2024-01-17", "2024-01-31", "2024-02-14"},
+		},
+		{
+			name: "mensal no dia 31 ajusta meses curtos",
+			rule: RecurringRule{Frequency: FrequencyMonthly, Interval: 1, StartDate: "2024-01-31"},
+			from: "2024-01-01", to: "2024-05-31",
+			want: []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30", "2024-05-31"},
+		},
+		{
+			name: "mensal com dia anterior ao início pula o primeiro mês",
+			rule: RecurringRule{Frequency: FrequencyMonthly, Interval: 1, DayOfMonth: intPtr(10), StartDate: "2024-01-15"},
+			from: "2024-01-01", to: "2024-03-31",
+			want: []string{"2024-02-10", "2024-03-10"},
+		},
+		{
+			name: "trimestral",
+			rule: RecurringRule{Frequency: FrequencyMonthly, Interval: 3, StartDate: "2024-11-05"},
+			from: "2024-01-01", to: "2025-12-31",
+			want: []string{"2024-11-05", "2025-02-05", "2025-05-05", "2025-08-05", "2025-11-05"},
+		},
+		{
+			name: "último dia útil recua do fim de semana",
+			rule: RecurringRule{Frequency: FrequencyLastBusinessDay, Interval: 1, StartDate: "2024-03-01"},
+			from: "2024-03-01", to: "2024-06-30",
+			want: []string{"2024-03-29", "2024-04-30", "2024-05-31", "2024-06-28"},
+		},
+		{
+			name: "anual em 29/02 cai em 28/02 fora dos bissextos",
+			rule: RecurringRule{Frequency: FrequencyYearly, Interval: 1, StartDate: "2024-02-29"},
+			from: "2024-01-01", to: "2028-12-31",
+			want: []string{"2024-02-29", "2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"},
+		},
+		{
+			name: "data final encerra a série",
+			rule: RecurringRule{Frequency: FrequencyMonthly, Interval: 1, StartDate: "2024-01-10", EndDate: strPtr("2024-03-09")},
+			from: "2024-01-01", to: "2024-12-31",
+			want: []string{"2024-01-10", "2024-02-10"},
+		},
+		{
+			name: "limite de datas",
+			rule: RecurringRule{Frequency: FrequencyDaily, Interval: 1, StartDate: "2024-01-01"},
+			from: "2024-01-01", to: "9999-12-31", limit: 2,
+			want: []string{"2024-01-01", "2024-01-02"},
+		},
+		{
+			name: "intervalo zero vale como 1",
+			rule: RecurringRule{Frequency: FrequencyDaily, StartDate: "2024-01-01"},
+			from: "2024-01-01", to: "2024-01-02",
+			want: []string{"2024-01-01", "2024-01-02"},
+		},
+		{
+			name: "frequência desconhecida",
+			rule: RecurringRule{Frequency: "hourly", Interval: 1, StartDate: "2024-01-01"},
+			from: "2024-01-01", to: "2024-01-31",
+			want: []string{},
+		},
+		{
+			name: "data inicial inválida",
+			rule: RecurringRule{Frequency: FrequencyDaily, Interval: 1, StartDate: "01/01/2024"},
+			from: "2024-01-01", to: "2024-01-31",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates := tt.rule.Occurrences(day(tt.from), day(tt.to), tt.limit)
+
+			var got []string
+			if dates != nil {
+				got = []string{}
+				for _, date := range dates {
+					got = append(got, date.Format("2006-01-02"))
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Occurrences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurringRuleIsOccurrence(t *testing.T) {
+	rule := RecurringRule{Frequency: FrequencyWeekly, Interval: 2, StartDate: "2024-01-03"}
+
+	if !rule.IsOccurrence(day("2024-01-17")) {
+		t.Error("2024-01-17 should be an occurrence")
+	}
+	if rule.IsOccurrence(day("2024-01-10")) {
+		t.Error("2024-01-10 should not be an occurrence")
+	}
+
+	first, ok := rule.FirstOccurrence(day("2024-01-04"))
+	if !ok || !first.Equal(day("2024-01-17")) {
+		t.Errorf("FirstOccurrence() = %v, %v; want 2024-01-17", first, ok)
+	}
+}
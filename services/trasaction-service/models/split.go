@@ -0,0 +1,31 @@
+package models
+
+import "transaction-service/money"
+
+// TransactionSplit é uma linha da divisão de uma transação entre categorias,
+// como um recibo de supermercado com mercearia, limpeza e farmácia. As linhas
+// somam o valor da transação e são do mesmo tipo dela.
+type TransactionSplit struct {
+	CategoryID *string      `json:"category_id" db:"category_id"`
+	Category   string       `json:"category" db:"category"`
+	Amount     money.Amount `json:"amount" db:"amount"`
+	Note       *string      `json:"note,omitempty" db:"note"`
+}
+
+// Lines devolve as linhas de categoria da transação: as da divisão ou, se ela
+// não estiver dividida, uma só com a categoria e o valor da transação
+func (t *Transaction) Lines() []TransactionSplit {
+	if len(t.Splits) > 0 {
+		return t.Splits
+	}
+	return []TransactionSplit{{CategoryID: t.CategoryID, Category: t.Category, Amount: t.Amount}}
+}
+
+// SplitsTotal soma os valores das linhas
+func SplitsTotal(splits []TransactionSplit) money.Amount {
+	var total money.Amount
+	for _, split := range splits {
+		total += split.Amount
+	}
+	return total
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"transaction-service/money"
+)
+
+// Tag marca transações de qualquer categoria. O nome é único por usuário, sem
+// diferenciar maiúsculas e acentos.
+type Tag struct {
+	ID               string    `json:"id" db:"id"`
+	UserID           string    `json:"user_id" db:"user_id"`
+	Name             string    `json:"name" db:"name"`
+	Color            *string   `json:"color" db:"color"` // formato: #RRGGBB
+	TransactionCount int       `json:"transaction_count"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TagSubtotal resume as receitas e despesas marcadas com uma tag, na moeda base.
+// Uma transação com várias tags entra no subtotal de cada uma.
+type TagSubtotal struct {
+	Tag           string       `json:"tag"`
+	TotalIncome   money.Amount `json:"total_income"`
+	TotalExpenses money.Amount `json:"total_expenses"`
+	Balance       money.Amount `json:"balance"`
+	TotalCount    int          `json:"total_count"`
+}
+
+// DiffTags devolve as tags que entraram e as que saíram de before para after
+func DiffTags(before, after []string) (added, removed []string) {
+	in := func(tags []string, tag string) bool {
+		for _, t := range tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, tag := range after {
+		if !in(before, tag) {
+			added = append(added, tag)
+		}
+	}
+	for _, tag := range before {
+		if !in(after, tag) {
+			removed = append(removed, tag)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"transaction-service/money"
+)
+
+const (
+	// TransactionTypeTransfer identifica as pernas de uma transferência nas listagens
+	TransactionTypeTransfer = "transfer"
+
+	TransferSideOut = "out"
+	TransferSideIn  = "in"
+
+	// TransferCategory é a categoria atribuída às pernas
+	TransferCategory = "Transferência"
+)
+
+// Transfer move dinheiro entre duas contas do usuário. É gravada como duas
+// pernas ligadas (saída e entrada) que não contam como receita nem despesa.
+// Entre moedas diferentes, cada perna tem o valor na moeda da sua conta.
+type Transfer struct {
+	ID            string         `json:"id" db:"id"`
+	UserID        string         `json:"user_id" db:"user_id"`
+	FromAccountID string         `json:"from_account_id" db:"from_account_id"`
+	ToAccountID   string         `json:"to_account_id" db:"to_account_id"`
+	Amount        money.Amount   `json:"amount"`    // valor debitado da origem
+	ToAmount      money.Amount   `json:"to_amount"` // valor creditado no destino
+	Description   string         `json:"description" db:"description"`
+	Date          time.Time      `json:"date" db:"date"`
+	Legs          []*Transaction `json:"legs"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" db:"updated_at"`
+}
@@ -1,24 +1,66 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"transaction-service/money"
+)
 
 type Transaction struct {
-	ID          string    `json:"id" db:"id"`
-	UserID      string    `json:"user_id" db:"user_id"`
-	Description string    `json:"description" db:"description"`
-	Amount      float64   `json:"amount" db:"amount"`
-	Category    string    `json:"category" db:"category"`
-	Type        string    `json:"type" db:"type"` // income or expense
-	Date        time.Time `json:"date" db:"date"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          string       `json:"id" db:"id"`
+	UserID      string       `json:"user_id" db:"user_id"`
+	AccountID   string       `json:"account_id" db:"account_id"`
+	Description string       `json:"description" db:"description"`
+	Amount      money.Amount `json:"amount" db:"amount"`
+	Currency    string       `json:"currency" db:"currency"`
+	Category    string       `json:"category" db:"category"`
+	CategoryID  *string      `json:"category_id" db:"category_id"` // nulo nas transferências
+	Type        string       `json:"type" db:"type"`               // income, expense or transfer
+	Date        time.Time    `json:"date" db:"date"`
+	// Preenchidos apenas nas pernas de uma transferência
+	TransferID   *string `json:"transfer_id,omitempty" db:"transfer_id"`
+	TransferSide *string `json:"transfer_side,omitempty" db:"transfer_side"`
+	// Preenchidos apenas nas transações geradas por uma regra de recorrência
+	RecurringRuleID *string `json:"recurring_rule_id,omitempty" db:"recurring_rule_id"`
+	RecurringDate   *string `json:"recurring_date,omitempty" db:"recurring_date"` // formato: 2006-01-02
+	// Preenchidos apenas nas transações importadas de extratos
+	ExternalID    *string   `json:"external_id,omitempty" db:"external_id"`
+	ImportBatchID *string   `json:"import_batch_id,omitempty" db:"import_batch_id"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	// Nomes das tags, em ordem alfabética; carregados nas listagens e na busca por id
+	Tags []string `json:"tags,omitempty"`
+	// Divisão do valor entre categorias; vazia se a transação não estiver dividida
+	Splits []TransactionSplit `json:"splits,omitempty"`
+}
+
+// CalendarDate reduz t à data do calendário do usuário, à meia-noite UTC, que é
+// como as datas das transações são gravadas. Um horário é convertido para o fuso
+// loc; meia-noite no próprio offset vale como data sem horário, como as que vêm
+// das recorrências, importações e transferências.
+func CalendarDate(t time.Time, loc *time.Location) time.Time {
+	if hour, min, sec := t.Clock(); hour != 0 || min != 0 || sec != 0 || t.Nanosecond() != 0 {
+		t = t.In(loc)
+	}
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
 }
 
+// TransactionStats traz os totais convertidos para a moeda base do usuário,
+// pela cotação da data de cada transação, e os subtotais na moeda original
 type TransactionStats struct {
-	TotalIncome    float64            `json:"total_income"`
-	TotalExpenses  float64            `json:"total_expenses"`
-	Balance        float64            `json:"balance"`
-	TotalCount     int                `json:"total_count"`
-	ByCategory     map[string]float64 `json:"by_category"`
-	LastTransaction *Transaction      `json:"last_transaction,omitempty"`
-}
\ No newline at end of file
+	BaseCurrency     string                      `json:"base_currency"`
+	TotalIncome      money.Amount                `json:"total_income"`
+	TotalExpenses    money.Amount                `json:"total_expenses"`
+	Balance          money.Amount                `json:"balance"`
+	TotalCount       int                         `json:"total_count"`
+	UnconvertedCount int                         `json:"unconverted_count"` // sem cotação na data
+	ByCategory       map[string]money.Amount     `json:"by_category"`
+	ByCurrency       map[string]CurrencySubtotal `json:"by_currency"`
+	ByAccount        []AccountSubtotal           `json:"by_account"`
+	LastTransaction  *Transaction                `json:"last_transaction,omitempty"`
+	// Árvore de categorias por tipo ("income" e "expense"), com os totais das
+	// subcategorias somados nas categorias acima delas
+	CategoryTree map[string][]*CategoryStats `json:"category_tree"`
+	ByTag        []TagSubtotal               `json:"by_tag"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarDate(t *testing.T) {
+	saoPaulo := time.FixedZone("BRT", -3*60*60)
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"meia-noite UTC é só a data", "2024-01-05T00:00:00Z", "2024-01-05"},
+		{"meia-noite no próprio offset é só a data", "2024-01-05T00:00:00-03:00", "2024-01-05"},
+		{"noite no fuso do usuário", "2024-01-06T01:30:00Z", "2024-01-05"},
+		{"horário com offset de outro fuso", "2024-01-31T23:30:00+09:00", "2024-01-31"},
+		{"virada de mês", "2024-02-01T02:59:59Z", "2024-01-31"},
+		{"meio do dia", "2024-01-05T15:00:00Z", "2024-01-05"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := time.Parse(time.RFC3339, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := CalendarDate(value, saoPaulo)
+			if got.Format(time.RFC3339) != tt.want+"T00:00:00Z" {
+				t.Errorf("CalendarDate(%s) = %s, want %s", tt.value, got.Format(time.RFC3339), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrTooManyDecimals = errors.New("amount must have at most 2 decimal places")
+	ErrOutOfRange      = errors.New("amount out of range")
+)
+
+// Dígitos inteiros aceitos pela coluna DECIMAL(15,2)
+const maxIntegerDigits = 13
+
+// Amount é um valor monetário exato em centavos (unidades menores da moeda).
+// Toda a aritmética é inteira; a conversão para decimal só acontece na borda
+// (JSON, banco de dados e eventos).
+type Amount int64
+
+// FromCents cria um valor a partir de centavos
+func FromCents(cents int64) Amount {
+	return Amount(cents)
+}
+
+// Parse converte um decimal como "1234.5" ou "-10.25" em centavos.
+// Rejeita notação científica e mais de duas casas decimais.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidAmount
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		intPart, fracPart = s[:dot], s[dot+1:]
+	}
+
+	if intPart == "" && fracPart == "" {
+		return 0, ErrInvalidAmount
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, ErrInvalidAmount
+	}
+
+	// Zeros à direita não contam como casas decimais ("10.500" == "10.50")
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > 2 {
+		return 0, ErrTooManyDecimals
+	}
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if len(intPart) > maxIntegerDigits {
+		return 0, ErrOutOfRange
+	}
+
+	cents, _ := strconv.ParseInt(intPart+fracPart+strings.Repeat("0", 2-len(fracPart)), 10, 64)
+	if negative {
+		cents = -cents
+	}
+
+	return Amount(cents), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Cents retorna o valor em centavos
+func (a Amount) Cents() int64 {
+	return int64(a)
+}
+
+// String formata o valor como decimal com duas casas, ex.: "-1234.50"
+func (a Amount) String() string {
+	cents := int64(a)
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// Float64 converte para ponto flutuante. Use apenas em métricas, nunca em cálculos.
+func (a Amount) Float64() float64 {
+	return float64(a) / 100
+}
+
+// Abs retorna o valor absoluto
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// MarshalJSON codifica o valor no formato configurado em SetJSONFormat
+func (a Amount) MarshalJSON() ([]byte, error) {
+	switch jsonFormat {
+	case FormatString:
+		return []byte(`"` + a.String() + `"`), nil
+	case FormatCents:
+		return []byte(strconv.FormatInt(int64(a), 10)), nil
+	default:
+		return []byte(a.String()), nil
+	}
+}
+
+// UnmarshalJSON aceita número ou string decimal, independente do formato de saída.
+// O número é lido a partir do texto original, sem passar por float64.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+
+	text = strings.Trim(text, `"`)
+	parsed, err := Parse(text)
+	if err != nil {
+		return fmt.Errorf("amount %s: %w", string(data), err)
+	}
+
+	*a = parsed
+	return nil
+}
+
+// Scan lê colunas NUMERIC/DECIMAL do banco
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return a.scanText(string(v))
+	case string:
+		return a.scanText(v)
+	case int64:
+		*a = Amount(v * 100)
+		return nil
+	case nil:
+		*a = 0
+		return nil
+	}
+
+	return fmt.Errorf("cannot scan %T into money.Amount", src)
+}
+
+func (a *Amount) scanText(text string) error {
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value grava o valor como decimal textual, convertido sem perda pelo PostgreSQL
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
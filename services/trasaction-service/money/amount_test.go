@@ -0,0 +1,163 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Amount
+		err   error
+	}{
+		{name: "inteiro", input: "10", want: 1000},
+		{name: "uma casa", input: "1234.5", want: 123450},
+		{name: "duas casas", input: "10.25", want: 1025},
+		{name: "negativo", input: "-10.25", want: -1025},
+		{name: "sinal positivo", input: "+3.10", want: 310},
+		{name: "sem parte inteira", input: ".5", want: 50},
+		{name: "sem parte decimal", input: "7.", want: 700},
+		{name: "espaços", input: "  42.00 ", want: 4200},
+		{name: "zeros à direita", input: "10.500", want: 1050},
+		{name: "zeros à esquerda", input: "0007.01", want: 701},
+		{name: "zero", input: "0", want: 0},
+		{name: "máximo da coluna", input: "9999999999999.99", want: 999999999999999},
+		{name: "vazio", input: "", err: ErrInvalidAmount},
+		{name: "só sinal", input: "-", err: ErrInvalidAmount},
+		{name: "só ponto", input: ".", err: ErrInvalidAmount},
+		{name: "notação científica", input: "1e3", err: ErrInvalidAmount},
+		{name: "vírgula", input: "1,50", err: ErrInvalidAmount},
+		{name: "dois pontos", input: "1.2.3", err: ErrInvalidAmount},
+		{name: "sinal duplo", input: "--1", err: ErrInvalidAmount},
+		{name: "três casas", input: "1.005", err: ErrTooManyDecimals},
+		{name: "fora da coluna", input: "10000000000000", err: ErrOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.input, err, tt.err)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("Parse(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		want   string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{123450, "1234.50"},
+		{-100, "-1.00"},
+		{999999999999999, "9999999999999.99"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q, want %q", tt.amount, got, tt.want)
+		}
+
+		parsed, err := Parse(tt.want)
+		if err != nil || parsed != tt.amount {
+			t.Errorf("Parse(%q) = %d, %v; want %d", tt.want, parsed, err, tt.amount)
+		}
+	}
+}
+
+func TestMarshalJSONFormats(t *testing.T) {
+	defer SetJSONFormat(FormatNumber)
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatNumber, `{"amount":-1234.50}`},
+		{FormatString, `{"amount":"-1234.50"}`},
+		{FormatCents, `{"amount":-123450}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if err := SetJSONFormat(tt.format); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := json.Marshal(struct {
+				Amount Amount `json:"amount"`
+			}{Amount: -123450})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("json.Marshal = %s, want %s", data, tt.want)
+			}
+		})
+	}
+
+	if err := SetJSONFormat("float"); err == nil {
+		t.Error("SetJSONFormat(\"float\") should fail")
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Amount
+		wantErr bool
+	}{
+		{input: `10.25`, want: 1025},
+		{input: `"10.25"`, want: 1025},
+		{input: `0.1`, want: 10},
+		{input: `null`, want: 0},
+		{input: `1.001`, wantErr: true},
+		{input: `"abc"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var got Amount
+		err := got.UnmarshalJSON([]byte(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("UnmarshalJSON(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("UnmarshalJSON(%s) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want Amount
+	}{
+		{[]byte("1234.56"), 123456},
+		{"-0.01", -1},
+		{int64(12), 1200},
+		{nil, 0},
+	}
+
+	for _, tt := range tests {
+		var got Amount
+		if err := got.Scan(tt.src); err != nil {
+			t.Fatalf("Scan(%v) error = %v", tt.src, err)
+		}
+		if got != tt.want {
+			t.Errorf("Scan(%v) = %d, want %d", tt.src, got, tt.want)
+		}
+	}
+
+	var a Amount
+	if err := a.Scan(1.5); err == nil {
+		t.Error("Scan(float64) should fail")
+	}
+}
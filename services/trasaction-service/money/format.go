@@ -0,0 +1,26 @@
+package money
+
+import "fmt"
+
+// Formatos de saída JSON dos valores monetários
+const (
+	// FormatNumber mantém o número decimal usado pelos clientes antigos (modo de compatibilidade)
+	FormatNumber = "number"
+	// FormatString codifica como string decimal, ex.: "1234.50"
+	FormatString = "string"
+	// FormatCents codifica como inteiro em centavos, ex.: 123450
+	FormatCents = "cents"
+)
+
+var jsonFormat = FormatNumber
+
+// SetJSONFormat define como os valores são codificados nas respostas da API
+func SetJSONFormat(format string) error {
+	switch format {
+	case FormatNumber, FormatString, FormatCents:
+		jsonFormat = format
+		return nil
+	}
+
+	return fmt.Errorf("unsupported money JSON format %q", format)
+}
@@ -0,0 +1,121 @@
+// Package recurring materializa as regras de recorrência em transações concretas.
+package recurring
+
+import (
+	"context"
+	"time"
+
+	"transaction-service/messaging"
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/repository"
+	"transaction-service/tracking"
+
+	"go.uber.org/zap"
+)
+
+// Regras processadas por rodada; o restante fica para a próxima
+const batchSize = 100
+
+// Worker varre periodicamente as regras vencidas e gera as transações até hoje.
+// Pode rodar em várias réplicas: cada regra é travada durante a materialização.
+type Worker struct {
+	repo      *repository.RecurringRepository
+	publisher *messaging.EventPublisher
+	tracker   tracking.Observer
+	interval  time.Duration
+	logger    *zap.Logger
+}
+
+func NewWorker(
+	repo *repository.RecurringRepository,
+	publisher *messaging.EventPublisher,
+	tracker tracking.Observer,
+	interval time.Duration,
+	logger *zap.Logger,
+) *Worker {
+	return &Worker{
+		repo:      repo,
+		publisher: publisher,
+		tracker:   tracker,
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// Start executa uma rodada imediatamente e depois a cada intervalo, até ctx ser cancelado
+func (w *Worker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		w.tick(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.tick(ctx)
+			}
+		}
+	}()
+}
+
+func (w *Worker) tick(ctx context.Context) {
+	year, month, day := time.Now().UTC().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	ids, err := w.repo.DueRuleIDs(ctx, today, batchSize)
+	if err != nil {
+		w.logger.Error("failed to list due recurring rules", zap.Error(err))
+		return
+	}
+
+	for _, id := range ids {
+		created, err := w.repo.Materialize(ctx, id, today)
+		if err != nil {
+			metrics.RecurringMaterializationsTotal.WithLabelValues("error").Inc()
+			continue
+		}
+
+		for _, transaction := range created {
+			w.publish(transaction)
+			w.tracker.TransactionChanged(ctx, tracking.Change{
+				UserID: transaction.UserID,
+				After:  transaction,
+			})
+		}
+
+		metrics.RecurringMaterializationsTotal.WithLabelValues("success").Inc()
+		if len(created) > 0 {
+			w.logger.Info("recurring transactions materialized",
+				zap.String("rule_id", id),
+				zap.Int("transactions", len(created)),
+			)
+		}
+	}
+}
+
+func (w *Worker) publish(transaction *models.Transaction) {
+	event := messaging.TransactionEvent{
+		EventType:     messaging.TransactionCreated,
+		TransactionID: transaction.ID,
+		UserID:        transaction.UserID,
+		AccountID:     transaction.AccountID,
+		Description:   transaction.Description,
+		Amount:        transaction.Amount.String(),
+		AmountCents:   transaction.Amount.Cents(),
+		Currency:      transaction.Currency,
+		Type:          transaction.Type,
+		Category:      transaction.Category,
+		Tags:          transaction.Tags,
+		Timestamp:     time.Now(),
+	}
+
+	if err := w.publisher.PublishTransactionEvent(event); err != nil {
+		w.logger.Error("failed to publish transaction event", zap.Error(err))
+		return
+	}
+
+	metrics.TransactionsCreatedTotal.WithLabelValues(transaction.Type, transaction.Category).Inc()
+}
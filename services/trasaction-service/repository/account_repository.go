@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/money"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrAccountNotFound      = errors.New("account not found")
+	ErrAccountAlreadyExists = errors.New("account with this name already exists")
+	ErrAccountInUse         = errors.New("account has transactions")
+)
+
+type AccountRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewAccountRepository(db *sql.DB, logger *zap.Logger) *AccountRepository {
+	return &AccountRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Saldo atual calculado a partir das partidas da conta no razão
+const accountSelect = `
+	SELECT a.id, a.user_id, a.name, a.type, a.currency, a.opening_balance, a.archived,
+		` + accountBalanceSQL + ` as balance,
+		a.created_at, a.updated_at
+	FROM accounts a
+`
+
+// accountBalanceSQL é o saldo da conta a (alias a) pela soma das suas partidas
+const accountBalanceSQL = `COALESCE((
+			SELECT SUM(p.amount)
+			FROM postings p
+			JOIN ledger_accounts la ON la.id = p.ledger_account_id
+			WHERE la.account_id = a.id
+		), 0)`
+
+func scanAccount(row rowScanner) (*models.Account, error) {
+	a := &models.Account{}
+	err := row.Scan(
+		&a.ID,
+		&a.UserID,
+		&a.Name,
+		&a.Type,
+		&a.Currency,
+		&a.OpeningBalance,
+		&a.Archived,
+		&a.Balance,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Create cria uma nova conta e lança o saldo de abertura no razão
+func (r *AccountRepository) Create(ctx context.Context, account *models.Account) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("insert_account").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		INSERT INTO accounts (id, user_id, name, type, currency, opening_balance, archived, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query,
+			account.ID,
+			account.UserID,
+			account.Name,
+			account.Type,
+			account.Currency,
+			account.OpeningBalance,
+			account.Archived,
+			account.CreatedAt,
+			account.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		return postOpeningBalance(ctx, tx, account, account.OpeningBalance)
+	})
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrAccountAlreadyExists
+	}
+
+	if err != nil {
+		r.logger.Error("failed to create account",
+			zap.Error(err),
+			zap.String("user_id", account.UserID),
+		)
+		return err
+	}
+
+	account.Balance = account.OpeningBalance
+	return nil
+}
+
+// List lista as contas do usuário com o saldo atual
+func (r *AccountRepository) List(ctx context.Context, userID string, includeArchived bool) ([]*models.Account, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_accounts").Observe(time.Since(start).Seconds())
+	}()
+
+	query := accountSelect + ` WHERE a.user_id = $1`
+	if !includeArchived {
+		query += ` AND NOT a.archived`
+	}
+	query += ` ORDER BY a.archived, a.name`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to list accounts",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []*models.Account{}
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+// FindByID busca uma conta do usuário com o saldo atual
+func (r *AccountRepository) FindByID(ctx context.Context, id, userID string) (*models.Account, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_account_by_id").Observe(time.Since(start).Seconds())
+	}()
+
+	account, err := scanAccount(r.db.QueryRowContext(ctx, accountSelect+` WHERE a.id = $1 AND a.user_id = $2`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrAccountNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find account",
+			zap.Error(err),
+			zap.String("id", id),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// Update altera nome, tipo, saldo de abertura e arquivamento. A moeda não muda.
+// A diferença no saldo de abertura é lançada como ajuste no razão.
+func (r *AccountRepository) Update(ctx context.Context, account *models.Account) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("update_account").Observe(time.Since(start).Seconds())
+	}()
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var previous money.Amount
+		err := tx.QueryRowContext(ctx,
+			`SELECT opening_balance, created_at FROM accounts WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+			account.ID, account.UserID,
+		).Scan(&previous, &account.CreatedAt)
+		if err == sql.ErrNoRows {
+			return ErrAccountNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE accounts
+			SET name = $1, type = $2, opening_balance = $3, archived = $4, updated_at = $5
+			WHERE id = $6 AND user_id = $7
+		`,
+			account.Name,
+			account.Type,
+			account.OpeningBalance,
+			account.Archived,
+			account.UpdatedAt,
+			account.ID,
+			account.UserID,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE ledger_accounts
+			SET name = $1, kind = CASE WHEN $2 = 'credit_card' THEN 'liability' ELSE 'asset' END
+			WHERE account_id = $3
+		`, account.Name, account.Type, account.ID)
+		if err != nil {
+			return err
+		}
+
+		return postOpeningBalance(ctx, tx, account, account.OpeningBalance-previous)
+	})
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrAccountAlreadyExists
+	}
+
+	if err != nil && err != ErrAccountNotFound {
+		r.logger.Error("failed to update account",
+			zap.Error(err),
+			zap.String("id", account.ID),
+		)
+	}
+
+	return err
+}
+
+// Delete remove uma conta sem histórico no razão; contas em uso devem ser arquivadas
+func (r *AccountRepository) Delete(ctx context.Context, id, userID string) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("delete_account").Observe(time.Since(start).Seconds())
+	}()
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		// A conta do razão sem partidas não impede a exclusão
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM ledger_accounts la
+			WHERE la.account_id = $1 AND la.user_id = $2
+				AND NOT EXISTS (SELECT 1 FROM postings p WHERE p.ledger_account_id = la.id)
+		`, id, userID)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `DELETE FROM accounts WHERE id = $1 AND user_id = $2`, id, userID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrAccountNotFound
+		}
+
+		return nil
+	})
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return ErrAccountInUse
+	}
+
+	if err != nil && err != ErrAccountNotFound {
+		r.logger.Error("failed to delete account",
+			zap.Error(err),
+			zap.String("id", id),
+		)
+	}
+
+	return err
+}
+
+// BalanceAt calcula o saldo da conta ao final do dia informado pelas partidas com data até ele
+func (r *AccountRepository) BalanceAt(ctx context.Context, id, userID string, date time.Time) (*models.AccountBalance, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_account_balance").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		SELECT a.currency, COALESCE(SUM(p.amount), 0)
+		FROM accounts a
+		LEFT JOIN ledger_accounts la ON la.account_id = a.id
+		LEFT JOIN postings p ON p.ledger_account_id = la.id
+			AND p.entry_id IN (SELECT id FROM journal_entries WHERE user_id = $2 AND date < $3::date + 1)
+		WHERE a.id = $1 AND a.user_id = $2
+		GROUP BY a.id
+	`
+
+	balance := &models.AccountBalance{AccountID: id, Date: date.Format("2006-01-02")}
+	err := r.db.QueryRowContext(ctx, query, id, userID, date).Scan(&balance.Currency, &balance.Balance)
+	if err == sql.ErrNoRows {
+		return nil, ErrAccountNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to get account balance",
+			zap.Error(err),
+			zap.String("id", id),
+		)
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+// ListAllByUser retorna todas as contas do usuário (usado na exportação de dados pessoais)
+func (r *AccountRepository) ListAllByUser(ctx context.Context, userID string) ([]*models.Account, error) {
+	return r.List(ctx, userID, true)
+}
+
+// DeleteAllByUser apaga as contas do usuário; as transações devem ter sido apagadas antes
+func (r *AccountRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM accounts WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("failed to delete user accounts",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
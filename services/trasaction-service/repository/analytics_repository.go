@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/money"
+
+	"go.uber.org/zap"
+)
+
+// AnalyticsRepository calcula séries temporais de receitas e despesas
+type AnalyticsRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewAnalyticsRepository(db *sql.DB, logger *zap.Logger) *AnalyticsRepository {
+	return &AnalyticsRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// AnalyticsFilters delimita a análise. Start e End são datas no fuso do
+// usuário, inclusive.
+type AnalyticsFilters struct {
+	UserID       string
+	AccountID    string // vazio considera todas as contas
+	BaseCurrency string
+	Timezone     string // fuso das datas gravadas, só para a resposta
+	Granularity  string
+	Start        time.Time
+	End          time.Time
+}
+
+// analyticsQuery traz numa só consulta as três partes da análise, distinguidas
+// pela primeira coluna: os intervalos da série (bucket), os totais do período e
+// dos dois comparados (period) e as categorias do período (category).
+//
+// $1 usuário, $2 conta (opcional), $3 moeda base, $4 granularidade,
+// $5/$6 período, $7/$8 período anterior, $9/$10 mesmo período do ano anterior.
+// As datas já são gravadas como data do calendário no fuso do usuário (ver
+// models.CalendarDate), então o date_trunc é o mesmo dos orçamentos e metas.
+// Transações divididas entram por linha, cada uma na sua categoria, mas as
+// contagens da série e dos períodos são de transações. As linhas convertidas só
+// são arredondadas depois de somadas, para não perder centavos por linha.
+const analyticsQuery = `
+	WITH periods (period, start_date, end_date) AS (
+		VALUES ('current', $5::date, $6::date), ('previous', $7::date, $8::date), ('last_year', $9::date, $10::date)
+	),
+	flows AS (
+		SELECT p.period, f.transaction_id, f.local_date, f.type, f.category, f.converted
+		FROM (
+			SELECT
+				t.transaction_id,
+				t.date AS local_date,
+				t.type,
+				t.category,
+				t.amount * fx_rate(t.currency, $3, t.date::date) AS converted
+			FROM transaction_lines t
+			WHERE t.user_id = $1 AND ($2::uuid IS NULL OR t.account_id = $2::uuid)
+				AND t.type <> 'transfer'
+				AND t.date >= LEAST($5::date, $7::date, $9::date)
+				AND t.date < GREATEST($6::date, $8::date, $10::date) + 1
+		) f
+		JOIN periods p ON f.local_date >= p.start_date AND f.local_date < p.end_date + 1
+	),
+	buckets AS (
+		SELECT generate_series(date_trunc($4, $5::timestamp), $6::timestamp, ('1 ' || $4)::interval) AS bucket
+	)
+	SELECT 'bucket', 'current', b.bucket::date, '', '',
+		COALESCE(ROUND(SUM(f.converted) FILTER (WHERE f.type = 'income'), 2), 0),
+		COALESCE(ROUND(SUM(f.converted) FILTER (WHERE f.type = 'expense'), 2), 0),
+		COUNT(DISTINCT f.transaction_id),
+		COUNT(DISTINCT f.transaction_id) FILTER (WHERE f.converted IS NULL)
+	FROM buckets b
+	LEFT JOIN flows f ON f.period = 'current' AND date_trunc($4, f.local_date) = b.bucket
+	GROUP BY b.bucket
+	UNION ALL
+	SELECT 'period', p.period, NULL::date, '', '',
+		COALESCE(ROUND(SUM(f.converted) FILTER (WHERE f.type = 'income'), 2), 0),
+		COALESCE(ROUND(SUM(f.converted) FILTER (WHERE f.type = 'expense'), 2), 0),
+		COUNT(DISTINCT f.transaction_id),
+		COUNT(DISTINCT f.transaction_id) FILTER (WHERE f.converted IS NULL)
+	FROM periods p
+	LEFT JOIN flows f ON f.period = p.period
+	GROUP BY p.period
+	UNION ALL
+	SELECT 'category', 'current', NULL::date, f.type, f.category,
+		COALESCE(ROUND(SUM(f.converted), 2), 0),
+		0,
+		COUNT(*),
+		COUNT(*) FILTER (WHERE f.converted IS NULL)
+	FROM flows f
+	WHERE f.period = 'current'
+	GROUP BY f.type, f.category
+	ORDER BY 1, 3
+`
+
+// Analytics calcula a série do período com as médias e as comparações com o
+// período anterior de mesmo tamanho e com o mesmo período do ano anterior
+func (r *AnalyticsRepository) Analytics(ctx context.Context, filters AnalyticsFilters) (*models.Analytics, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_transaction_analytics").Observe(time.Since(start).Seconds())
+	}()
+
+	previousStart, previousEnd := previousPeriod(filters.Start, filters.End)
+	lastYearStart, lastYearEnd := lastYear(filters.Start), lastYear(filters.End)
+
+	analytics := &models.Analytics{
+		BaseCurrency: filters.BaseCurrency,
+		Timezone:     filters.Timezone,
+		Granularity:  filters.Granularity,
+		Totals:       periodTotals(filters.Start, filters.End),
+		Buckets:      []models.PeriodTotals{},
+		ByCategory: map[string][]models.CategoryTotal{
+			"income":  {},
+			"expense": {},
+		},
+		PreviousPeriod: models.PeriodComparison{PeriodTotals: periodTotals(previousStart, previousEnd)},
+		LastYear:       models.PeriodComparison{PeriodTotals: periodTotals(lastYearStart, lastYearEnd)},
+	}
+
+	accountID := sql.NullString{String: filters.AccountID, Valid: filters.AccountID != ""}
+	rows, err := r.db.QueryContext(ctx, analyticsQuery,
+		filters.UserID,
+		accountID,
+		filters.BaseCurrency,
+		filters.Granularity,
+		filters.Start, filters.End,
+		previousStart, previousEnd,
+		lastYearStart, lastYearEnd,
+	)
+	if err != nil {
+		r.logger.Error("failed to get transaction analytics",
+			zap.Error(err),
+			zap.String("user_id", filters.UserID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind, period, flowType, category string
+		var bucket sql.NullTime
+		var income, expense money.Amount
+		var count, unconverted int
+		if err := rows.Scan(&kind, &period, &bucket, &flowType, &category, &income, &expense, &count, &unconverted); err != nil {
+			return nil, err
+		}
+
+		switch kind {
+		case "bucket":
+			totals := periodTotals(bucket.Time, bucketEnd(bucket.Time, filters.Granularity))
+			if bucket.Time.Before(filters.Start) {
+				totals.Start = filters.Start.Format("2006-01-02")
+			}
+			if totals.End > filters.End.Format("2006-01-02") {
+				totals.End = filters.End.Format("2006-01-02")
+			}
+			totals.Income, totals.Expense, totals.Net, totals.Count = income, expense, income-expense, count
+			analytics.Buckets = append(analytics.Buckets, totals)
+		case "period":
+			target := &analytics.Totals
+			switch period {
+			case "previous":
+				target = &analytics.PreviousPeriod.PeriodTotals
+			case "last_year":
+				target = &analytics.LastYear.PeriodTotals
+			default:
+				analytics.UnconvertedCount = unconverted
+			}
+			target.Income, target.Expense, target.Net, target.Count = income, expense, income-expense, count
+		case "category":
+			analytics.ByCategory[flowType] = append(analytics.ByCategory[flowType], models.CategoryTotal{
+				Category: category,
+				Total:    income,
+				Count:    count,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for flowType, total := range map[string]money.Amount{"income": analytics.Totals.Income, "expense": analytics.Totals.Expense} {
+		categories := analytics.ByCategory[flowType]
+		sort.SliceStable(categories, func(i, j int) bool { return categories[i].Total > categories[j].Total })
+		for i := range categories {
+			categories[i].Share = int(ratio(categories[i].Total.Cents(), total.Cents()))
+		}
+	}
+
+	if buckets := int64(len(analytics.Buckets)); buckets > 0 {
+		analytics.Averages.IncomePerBucket = money.FromCents(divRound(analytics.Totals.Income.Cents(), buckets))
+		analytics.Averages.ExpensePerBucket = money.FromCents(divRound(analytics.Totals.Expense.Cents(), buckets))
+		analytics.Averages.NetPerBucket = money.FromCents(divRound(analytics.Totals.Net.Cents(), buckets))
+	}
+	days := int64(filters.End.Sub(filters.Start).Hours()/24) + 1
+	analytics.Averages.ExpensePerDay = money.FromCents(divRound(analytics.Totals.Expense.Cents(), days))
+
+	for _, comparison := range []*models.PeriodComparison{&analytics.PreviousPeriod, &analytics.LastYear} {
+		comparison.IncomeChange = change(analytics.Totals.Income, comparison.Income)
+		comparison.ExpenseChange = change(analytics.Totals.Expense, comparison.Expense)
+	}
+
+	return analytics, nil
+}
+
+func periodTotals(start, end time.Time) models.PeriodTotals {
+	return models.PeriodTotals{Start: start.Format("2006-01-02"), End: end.Format("2006-01-02")}
+}
+
+// previousPeriod é o intervalo de mesmo tamanho logo antes do período. Meses
+// inteiros são comparados com o mesmo número de meses inteiros.
+func previousPeriod(start, end time.Time) (time.Time, time.Time) {
+	previousEnd := start.AddDate(0, 0, -1)
+
+	if start.Day() == 1 && end.AddDate(0, 0, 1).Day() == 1 {
+		months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+		return start.AddDate(0, -months, 0), previousEnd
+	}
+
+	days := int(end.Sub(start).Hours()/24) + 1
+	return start.AddDate(0, 0, -days), previousEnd
+}
+
+// lastYear é a mesma data no ano anterior; 29/02 vira 28/02
+func lastYear(date time.Time) time.Time {
+	shifted := date.AddDate(-1, 0, 0)
+	if shifted.Day() != date.Day() {
+		shifted = shifted.AddDate(0, 0, -shifted.Day())
+	}
+	return shifted
+}
+
+// bucketEnd é o último dia do intervalo que começa em start
+func bucketEnd(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case models.GranularityWeek:
+		return start.AddDate(0, 0, 6)
+	case models.GranularityMonth:
+		return start.AddDate(0, 1, -1)
+	case models.GranularityYear:
+		return start.AddDate(1, 0, -1)
+	default:
+		return start
+	}
+}
+
+// change é a variação percentual de current sobre base, nula sem base
+func change(current, base money.Amount) *int {
+	if base == 0 {
+		return nil
+	}
+	value := int(ratio(current.Cents()-base.Cents(), base.Cents()))
+	return &value
+}
+
+// ratio é part/total em pontos percentuais, arredondado
+func ratio(part, total int64) int64 {
+	if total == 0 {
+		return 0
+	}
+	if total < 0 {
+		part, total = -part, -total
+	}
+	return divRound(part*100, total)
+}
+
+// divRound divide arredondando a metade para longe do zero
+func divRound(a, b int64) int64 {
+	if (a < 0) != (b < 0) {
+		return (a - b/2) / b
+	}
+	return (a + b/2) / b
+}
@@ -0,0 +1,472 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/money"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrBudgetNotFound      = errors.New("budget not found")
+	ErrBudgetAlreadyExists = errors.New("budget for this category already exists")
+)
+
+type BudgetRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewBudgetRepository(db *sql.DB, logger *zap.Logger) *BudgetRepository {
+	return &BudgetRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const budgetSelect = `
+	SELECT id, user_id, category, amount, currency, rollover, start_month, created_at, updated_at
+	FROM budgets
+`
+
+func scanBudget(row rowScanner) (*models.Budget, error) {
+	b := &models.Budget{}
+	var startMonth time.Time
+	err := row.Scan(
+		&b.ID,
+		&b.UserID,
+		&b.Category,
+		&b.Amount,
+		&b.Currency,
+		&b.Rollover,
+		&startMonth,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	b.StartMonth = startMonth.Format("2006-01")
+	return b, nil
+}
+
+// budgetStart converte start_month ("2006-01") no primeiro dia do mês
+func budgetStart(b *models.Budget) (time.Time, error) {
+	return time.Parse("2006-01", b.StartMonth)
+}
+
+// Create cria um orçamento; cada categoria tem no máximo um por usuário
+func (r *BudgetRepository) Create(ctx context.Context, budget *models.Budget) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("insert_budget").Observe(time.Since(start).Seconds())
+	}()
+
+	startMonth, err := budgetStart(budget)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO budgets (id, user_id, category, amount, currency, rollover, start_month, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		budget.ID,
+		budget.UserID,
+		budget.Category,
+		budget.Amount,
+		budget.Currency,
+		budget.Rollover,
+		startMonth,
+		budget.CreatedAt,
+		budget.UpdatedAt,
+	)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrBudgetAlreadyExists
+	}
+
+	if err != nil {
+		r.logger.Error("failed to create budget",
+			zap.Error(err),
+			zap.String("user_id", budget.UserID),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// List lista os orçamentos do usuário
+func (r *BudgetRepository) List(ctx context.Context, userID string) ([]*models.Budget, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_budgets").Observe(time.Since(start).Seconds())
+	}()
+
+	rows, err := r.db.QueryContext(ctx, budgetSelect+` WHERE user_id = $1 ORDER BY category`, userID)
+	if err != nil {
+		r.logger.Error("failed to list budgets",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := []*models.Budget{}
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, rows.Err()
+}
+
+// FindByID busca um orçamento do usuário
+func (r *BudgetRepository) FindByID(ctx context.Context, id, userID string) (*models.Budget, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_budget").Observe(time.Since(start).Seconds())
+	}()
+
+	budget, err := scanBudget(r.db.QueryRowContext(ctx, budgetSelect+` WHERE id = $1 AND user_id = $2`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrBudgetNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find budget",
+			zap.Error(err),
+			zap.String("budget_id", id),
+		)
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+// FindByCategory busca o orçamento de uma categoria do usuário
+func (r *BudgetRepository) FindByCategory(ctx context.Context, userID, category string) (*models.Budget, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_budget_by_category").Observe(time.Since(start).Seconds())
+	}()
+
+	budget, err := scanBudget(r.db.QueryRowContext(ctx, budgetSelect+` WHERE user_id = $1 AND category = $2`, userID, category))
+	if err == sql.ErrNoRows {
+		return nil, ErrBudgetNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find budget by category",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+
+	return budget, nil
+}
+
+// Update altera limite, rollover e mês inicial; categoria e moeda são fixas
+func (r *BudgetRepository) Update(ctx context.Context, budget *models.Budget) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("update_budget").Observe(time.Since(start).Seconds())
+	}()
+
+	startMonth, err := budgetStart(budget)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE budgets
+		SET amount = $1, rollover = $2, start_month = $3, updated_at = $4
+		WHERE id = $5 AND user_id = $6
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		budget.Amount,
+		budget.Rollover,
+		startMonth,
+		budget.UpdatedAt,
+		budget.ID,
+		budget.UserID,
+	)
+	if err != nil {
+		r.logger.Error("failed to update budget",
+			zap.Error(err),
+			zap.String("budget_id", budget.ID),
+		)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrBudgetNotFound
+	}
+
+	return nil
+}
+
+// Delete remove um orçamento e o histórico de alertas dele
+func (r *BudgetRepository) Delete(ctx context.Context, id, userID string) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("delete_budget").Observe(time.Since(start).Seconds())
+	}()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		r.logger.Error("failed to delete budget",
+			zap.Error(err),
+			zap.String("budget_id", id),
+		)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrBudgetNotFound
+	}
+
+	return nil
+}
+
+// DeleteAllByUser remove todos os orçamentos do usuário
+func (r *BudgetRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("failed to delete user budgets",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Status calcula o orçado x realizado do orçamento no mês. Despesas em outras
+// moedas são convertidas pela cotação da data e arredondadas só no total do mês;
+// com rollover, a sobra de cada mês desde start_month é somada ao limite do mês
+// seguinte.
+func (r *BudgetRepository) Status(ctx context.Context, budget *models.Budget, month time.Time) (*models.BudgetStatus, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_budget_status").Observe(time.Since(start).Seconds())
+	}()
+
+	month = models.BudgetMonth(month)
+	from := month
+	if budget.Rollover {
+		startMonth, err := budgetStart(budget)
+		if err != nil {
+			return nil, err
+		}
+		if startMonth.Before(month) {
+			from = startMonth
+		}
+	}
+
+	query := `
+		SELECT date_trunc('month', date)::date, COALESCE(ROUND(SUM(converted), 2), 0), COUNT(*) FILTER (WHERE converted IS NULL)
+		FROM (
+			SELECT date, amount * fx_rate(currency, $3, date::date) as converted
+			FROM transaction_lines
+			WHERE user_id = $1 AND category = $2 AND type = 'expense' AND date >= $4 AND date < $5
+		) t
+		GROUP BY 1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, budget.UserID, budget.Category, budget.Currency, from, month.AddDate(0, 1, 0))
+	if err != nil {
+		r.logger.Error("failed to get budget spending",
+			zap.Error(err),
+			zap.String("budget_id", budget.ID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	spent := make(map[string]money.Amount)
+	unconverted := 0
+	for rows.Next() {
+		var m time.Time
+		var total money.Amount
+		var count int
+		if err := rows.Scan(&m, &total, &count); err != nil {
+			return nil, err
+		}
+		spent[m.Format("2006-01")] = total
+		if m.Equal(month) {
+			unconverted = count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Acumula a sobra dos meses anteriores; estouros não reduzem o limite seguinte
+	var carried money.Amount
+	for m := from; m.Before(month); m = m.AddDate(0, 1, 0) {
+		if left := budget.Amount + carried - spent[m.Format("2006-01")]; left > 0 {
+			carried = left
+		} else {
+			carried = 0
+		}
+	}
+
+	status := &models.BudgetStatus{
+		BudgetID:         budget.ID,
+		Category:         budget.Category,
+		Currency:         budget.Currency,
+		Month:            month.Format("2006-01"),
+		Limit:            budget.Amount,
+		CarriedOver:      carried,
+		Available:        budget.Amount + carried,
+		Spent:            spent[month.Format("2006-01")],
+		UnconvertedCount: unconverted,
+	}
+	status.Remaining = status.Available - status.Spent
+	status.Percentage = int(status.Spent * 100 / status.Available)
+
+	switch {
+	case status.Percentage >= models.BudgetExceededThreshold:
+		status.Status = models.BudgetStatusExceeded
+	case status.Percentage >= models.BudgetWarningThreshold:
+		status.Status = models.BudgetStatusWarning
+	default:
+		status.Status = models.BudgetStatusOK
+	}
+
+	return status, nil
+}
+
+// Report monta o orçado x realizado do mês para todos os orçamentos em vigor,
+// mais os gastos das categorias sem orçamento convertidos para a moeda base
+func (r *BudgetRepository) Report(ctx context.Context, userID, baseCurrency string, month time.Time) (*models.BudgetReport, error) {
+	month = models.BudgetMonth(month)
+
+	budgets, err := r.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.BudgetReport{
+		Month:        month.Format("2006-01"),
+		BaseCurrency: baseCurrency,
+		Budgets:      []*models.BudgetStatus{},
+		Unbudgeted:   make(map[string]money.Amount),
+	}
+
+	for _, budget := range budgets {
+		startMonth, err := budgetStart(budget)
+		if err != nil {
+			return nil, err
+		}
+		if startMonth.After(month) {
+			continue
+		}
+
+		status, err := r.Status(ctx, budget, month)
+		if err != nil {
+			return nil, err
+		}
+		report.Budgets = append(report.Budgets, status)
+	}
+
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_unbudgeted_spending").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		SELECT category, COALESCE(ROUND(SUM(converted), 2), 0), COUNT(*) FILTER (WHERE converted IS NULL)
+		FROM (
+			SELECT t.category, t.amount * fx_rate(t.currency, $2, t.date::date) as converted
+			FROM transaction_lines t
+			WHERE t.user_id = $1 AND t.type = 'expense' AND t.date >= $3 AND t.date < $4
+				AND NOT EXISTS (
+					SELECT 1 FROM budgets b
+					WHERE b.user_id = t.user_id AND b.category = t.category AND b.start_month <= $3
+				)
+		) t
+		GROUP BY category
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, baseCurrency, month, month.AddDate(0, 1, 0))
+	if err != nil {
+		r.logger.Error("failed to get unbudgeted spending",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var category string
+		var total money.Amount
+		var unconverted int
+		if err := rows.Scan(&category, &total, &unconverted); err != nil {
+			return nil, err
+		}
+		report.Unbudgeted[category] = total
+		report.UnconvertedCount += unconverted
+	}
+
+	return report, rows.Err()
+}
+
+// SetAlert registra (reached) ou limpa o alerta de um limiar no mês. Retorna true
+// só quando o alerta acabou de ser registrado, isto é, o limiar foi cruzado agora.
+func (r *BudgetRepository) SetAlert(ctx context.Context, budgetID string, month time.Time, threshold int, reached bool) (bool, error) {
+	month = models.BudgetMonth(month)
+
+	if !reached {
+		_, err := r.db.ExecContext(ctx,
+			`DELETE FROM budget_alerts WHERE budget_id = $1 AND month = $2 AND threshold = $3`,
+			budgetID, month, threshold,
+		)
+		return false, err
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO budget_alerts (budget_id, month, threshold)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, budgetID, month, threshold)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
@@ -0,0 +1,747 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/money"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrCategoryAlreadyExists  = errors.New("category with this name already exists")
+	ErrCategoryParentNotFound = errors.New("parent category not found")
+	ErrCategoryTargetNotFound = errors.New("target category not found")
+	ErrCategoryTypeMismatch   = errors.New("categories must have the same type")
+	ErrCategoryCycle          = errors.New("category cannot be placed under itself or its subcategories")
+	ErrCategoryArchived       = errors.New("category is archived")
+	ErrCategoryBudgetConflict = errors.New("both categories have a budget, delete one of them first")
+)
+
+type CategoryRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewCategoryRepository(db *sql.DB, logger *zap.Logger) *CategoryRepository {
+	return &CategoryRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const categoryColumns = `id, user_id, parent_id, name, type, color, icon, archived, created_at, updated_at`
+
+func scanCategory(row rowScanner) (*models.Category, error) {
+	c := &models.Category{}
+	err := row.Scan(
+		&c.ID,
+		&c.UserID,
+		&c.ParentID,
+		&c.Name,
+		&c.Type,
+		&c.Color,
+		&c.Icon,
+		&c.Archived,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// upsertCategory grava a categoria ou, se o usuário já tiver uma do mesmo tipo
+// com o nome equivalente, devolve a existente
+func upsertCategory(ctx context.Context, db dbtx, category *models.Category) (*models.Category, error) {
+	return scanCategory(db.QueryRowContext(ctx, `
+		WITH inserted AS (
+			INSERT INTO categories (user_id, parent_id, name, type, color, icon)
+			VALUES ($1, $2, btrim($3), $4, $5, $6)
+			ON CONFLICT DO NOTHING
+			RETURNING `+categoryColumns+`
+		)
+		SELECT `+categoryColumns+` FROM inserted
+		UNION ALL
+		SELECT `+categoryColumns+` FROM categories
+		WHERE user_id = $1 AND type = $4 AND category_key(name) = category_key($3)
+		LIMIT 1
+	`,
+		category.UserID,
+		category.ParentID,
+		category.Name,
+		category.Type,
+		category.Color,
+		category.Icon,
+	))
+}
+
+// ensureDefaultCategories cria o conjunto inicial de categorias para o usuário
+// que ainda não tem nenhuma
+func ensureDefaultCategories(ctx context.Context, db dbtx, userID string) error {
+	var exists bool
+	err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE user_id = $1)`, userID).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+
+	for _, d := range models.DefaultCategories {
+		color, icon := d.Color, d.Icon
+		parent, err := upsertCategory(ctx, db, &models.Category{
+			UserID: userID,
+			Name:   d.Name,
+			Type:   d.Type,
+			Color:  &color,
+			Icon:   &icon,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, name := range d.Children {
+			_, err := upsertCategory(ctx, db, &models.Category{
+				UserID:   userID,
+				ParentID: &parent.ID,
+				Name:     name,
+				Type:     d.Type,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ensureTransactionCategory liga a receita ou despesa sem category_id à
+// categoria do seu nome, criando-a na raiz se o usuário ainda não a tiver, e
+// grava o nome com a grafia da categoria. Transferências ficam sem categoria.
+func ensureTransactionCategory(ctx context.Context, db dbtx, transaction *models.Transaction) error {
+	if transaction.Type != "income" && transaction.Type != "expense" {
+		transaction.CategoryID = nil
+		return nil
+	}
+	if transaction.CategoryID != nil || strings.TrimSpace(transaction.Category) == "" {
+		return nil
+	}
+
+	if err := ensureDefaultCategories(ctx, db, transaction.UserID); err != nil {
+		return err
+	}
+
+	category, err := upsertCategory(ctx, db, &models.Category{
+		UserID: transaction.UserID,
+		Name:   transaction.Category,
+		Type:   transaction.Type,
+	})
+	if err != nil {
+		return err
+	}
+
+	transaction.CategoryID = &category.ID
+	transaction.Category = category.Name
+	return nil
+}
+
+// List lista as categorias do usuário por tipo e nome, criando o conjunto
+// inicial no primeiro acesso
+func (r *CategoryRepository) List(ctx context.Context, userID string, includeArchived bool) ([]*models.Category, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_categories").Observe(time.Since(start).Seconds())
+	}()
+
+	if err := ensureDefaultCategories(ctx, r.db, userID); err != nil {
+		r.logger.Error("failed to create default categories",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+
+	return r.list(ctx, userID, includeArchived)
+}
+
+func (r *CategoryRepository) list(ctx context.Context, userID string, includeArchived bool) ([]*models.Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE user_id = $1`
+	if !includeArchived {
+		query += ` AND NOT archived`
+	}
+	query += ` ORDER BY type, archived, name`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to list categories",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*models.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// FindByID busca uma categoria do usuário
+func (r *CategoryRepository) FindByID(ctx context.Context, id, userID string) (*models.Category, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_category_by_id").Observe(time.Since(start).Seconds())
+	}()
+
+	category, err := findCategory(ctx, r.db, id, userID, false)
+	if err != nil && err != ErrCategoryNotFound {
+		r.logger.Error("failed to find category",
+			zap.Error(err),
+			zap.String("id", id),
+			zap.String("user_id", userID),
+		)
+	}
+
+	return category, err
+}
+
+func findCategory(ctx context.Context, db dbtx, id, userID string, forUpdate bool) (*models.Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE id = $1 AND user_id = $2`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	category, err := scanCategory(db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+// FindByName busca a categoria do tipo com o nome equivalente, sem criá-la. Quem
+// informa a categoria pelo nome e não a encontra deixa category_id vazio: ela é
+// criada na mesma transação da gravação (ensureTransactionCategory).
+func (r *CategoryRepository) FindByName(ctx context.Context, userID, categoryType, name string) (*models.Category, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_category_by_name").Observe(time.Since(start).Seconds())
+	}()
+
+	category, err := scanCategory(r.db.QueryRowContext(ctx, `
+		SELECT `+categoryColumns+` FROM categories
+		WHERE user_id = $1 AND type = $2 AND category_key(name) = category_key($3)
+		LIMIT 1
+	`, userID, categoryType, name))
+	if err == sql.ErrNoRows {
+		return nil, ErrCategoryNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find category by name",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// checkParent valida a categoria mãe: do mesmo usuário e tipo, ativa e fora da
+// subárvore da própria categoria
+func checkParent(ctx context.Context, db dbtx, category *models.Category) error {
+	if category.ParentID == nil {
+		return nil
+	}
+
+	parent, err := findCategory(ctx, db, *category.ParentID, category.UserID, false)
+	if err == ErrCategoryNotFound {
+		return ErrCategoryParentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if parent.Type != category.Type {
+		return ErrCategoryTypeMismatch
+	}
+	if parent.Archived {
+		return ErrCategoryArchived
+	}
+
+	isDescendant, err := isCategoryAncestor(ctx, db, category.ID, parent.ID)
+	if err != nil {
+		return err
+	}
+	if isDescendant {
+		return ErrCategoryCycle
+	}
+	return nil
+}
+
+// isCategoryAncestor informa se ancestorID é a própria id ou está acima dela na árvore
+func isCategoryAncestor(ctx context.Context, db dbtx, ancestorID, id string) (bool, error) {
+	var found bool
+	err := db.QueryRowContext(ctx, `
+		WITH RECURSIVE ancestors (id, parent_id) AS (
+			SELECT id, parent_id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)
+	`, id, ancestorID).Scan(&found)
+	return found, err
+}
+
+// Create cria uma categoria; a mãe, se houver, deve ser do mesmo tipo
+func (r *CategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("insert_category").Observe(time.Since(start).Seconds())
+	}()
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := ensureDefaultCategories(ctx, tx, category.UserID); err != nil {
+			return err
+		}
+		if err := checkParent(ctx, tx, category); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO categories (id, user_id, parent_id, name, type, color, icon, archived, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`,
+			category.ID,
+			category.UserID,
+			category.ParentID,
+			category.Name,
+			category.Type,
+			category.Color,
+			category.Icon,
+			category.Archived,
+			category.CreatedAt,
+			category.UpdatedAt,
+		)
+		return err
+	})
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrCategoryAlreadyExists
+	}
+
+	if err != nil && !isCategoryError(err) {
+		r.logger.Error("failed to create category",
+			zap.Error(err),
+			zap.String("user_id", category.UserID),
+		)
+	}
+
+	return err
+}
+
+// Update altera nome, mãe, cor, ícone e arquivamento; o tipo é fixo. Arquivar
+// arquiva também as subcategorias. Ao renomear, transações, recorrências e
+// orçamentos passam a usar o novo nome e os lançamentos do razão são
+// reclassificados para a conta da categoria com o novo nome.
+func (r *CategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("update_category").Observe(time.Since(start).Seconds())
+	}()
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		current, err := findCategory(ctx, tx, category.ID, category.UserID, true)
+		if err != nil {
+			return err
+		}
+		category.Type = current.Type
+		category.CreatedAt = current.CreatedAt
+
+		// A mãe atual continua valendo mesmo se tiver sido arquivada
+		if !sameCategoryID(category.ParentID, current.ParentID) {
+			if err := checkParent(ctx, tx, category); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE categories
+			SET parent_id = $1, name = $2, color = $3, icon = $4, archived = $5, updated_at = $6
+			WHERE id = $7 AND user_id = $8
+		`,
+			category.ParentID,
+			category.Name,
+			category.Color,
+			category.Icon,
+			category.Archived,
+			category.UpdatedAt,
+			category.ID,
+			category.UserID,
+		)
+		if err != nil {
+			return err
+		}
+
+		if category.Archived && !current.Archived {
+			_, err = tx.ExecContext(ctx, `
+				WITH RECURSIVE subtree (id) AS (
+					SELECT id FROM categories WHERE parent_id = $1
+					UNION
+					SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+				)
+				UPDATE categories SET archived = TRUE, updated_at = $2
+				WHERE id IN (SELECT id FROM subtree) AND NOT archived
+			`, category.ID, category.UpdatedAt)
+			if err != nil {
+				return err
+			}
+		}
+
+		if category.Name == current.Name {
+			return nil
+		}
+
+		if err := renameCategoryReferences(ctx, tx, category.UserID, category.Type, current.Name, category.Name); err != nil {
+			return err
+		}
+		_, err = recategorizeTransactions(ctx, tx, category.UserID, category.ID, category)
+		return err
+	})
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrCategoryAlreadyExists
+	}
+
+	if err != nil && !isCategoryError(err) {
+		r.logger.Error("failed to update category",
+			zap.Error(err),
+			zap.String("id", category.ID),
+		)
+	}
+
+	return err
+}
+
+// Merge move as transações e as subcategorias de source para target e apaga
+// source. Recorrências e o orçamento de source passam para target; se as duas
+// tiverem orçamento, a junção é recusada com ErrCategoryBudgetConflict. Devolve
+// target e o número de transações movidas.
+func (r *CategoryRepository) Merge(ctx context.Context, sourceID, targetID, userID string) (*models.Category, int64, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("merge_category").Observe(time.Since(start).Seconds())
+	}()
+
+	var target *models.Category
+	var moved int64
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		source, err := findCategory(ctx, tx, sourceID, userID, true)
+		if err != nil {
+			return err
+		}
+		target, err = findCategory(ctx, tx, targetID, userID, true)
+		if err == ErrCategoryNotFound {
+			return ErrCategoryTargetNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if source.Type != target.Type {
+			return ErrCategoryTypeMismatch
+		}
+		if target.Archived {
+			return ErrCategoryArchived
+		}
+		isDescendant, err := isCategoryAncestor(ctx, tx, source.ID, target.ID)
+		if err != nil {
+			return err
+		}
+		if isDescendant {
+			return ErrCategoryCycle
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE categories SET parent_id = $1 WHERE parent_id = $2`,
+			target.ID, source.ID,
+		); err != nil {
+			return err
+		}
+
+		if err := renameCategoryReferences(ctx, tx, userID, source.Type, source.Name, target.Name); err != nil {
+			return err
+		}
+		moved, err = recategorizeTransactions(ctx, tx, userID, source.ID, target)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, source.ID)
+		return err
+	})
+
+	if err != nil && !isCategoryError(err) {
+		r.logger.Error("failed to merge categories",
+			zap.Error(err),
+			zap.String("source_id", sourceID),
+			zap.String("target_id", targetID),
+		)
+	}
+
+	return target, moved, err
+}
+
+// renameCategoryReferences troca o nome da categoria nas recorrências e no
+// orçamento, que a referenciam pelo nome. Se o novo nome já tiver um orçamento,
+// devolve ErrCategoryBudgetConflict em vez de deixar o de oldName órfão.
+func renameCategoryReferences(ctx context.Context, tx *sql.Tx, userID, categoryType, oldName, newName string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE recurring_rules SET category = $1
+		WHERE user_id = $2 AND type = $3 AND category = $4
+	`, newName, userID, categoryType, oldName)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE recurring_exceptions e SET category = $1
+		FROM recurring_rules r
+		WHERE r.id = e.rule_id AND r.user_id = $2 AND r.type = $3 AND e.category = $4
+	`, newName, userID, categoryType, oldName)
+	if err != nil {
+		return err
+	}
+
+	if categoryType != "expense" {
+		return nil
+	}
+
+	var conflict bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM budgets WHERE user_id = $1 AND category = $2)
+			AND EXISTS (SELECT 1 FROM budgets WHERE user_id = $1 AND category = $3)
+	`, userID, oldName, newName).Scan(&conflict)
+	if err != nil {
+		return err
+	}
+	if conflict {
+		return ErrCategoryBudgetConflict
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE budgets SET category = $1
+		WHERE user_id = $2 AND category = $3
+	`, newName, userID, oldName)
+	return err
+}
+
+// recategorizeTransactions passa as transações e as linhas de divisão da
+// categoria fromID para category e reclassifica os seus lançamentos no razão,
+// estornando-os e lançando-os de novo na conta da categoria com o nome atual.
+// Devolve quantas transações mudaram.
+func recategorizeTransactions(ctx context.Context, tx *sql.Tx, userID, fromID string, category *models.Category) (int64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		WITH moved AS (
+			UPDATE transactions SET category_id = $1, category = $2
+			WHERE user_id = $3 AND category_id = $4
+			RETURNING id
+		),
+		split AS (
+			UPDATE transaction_splits s SET category_id = $1, category = $2
+			FROM transactions t
+			WHERE t.id = s.transaction_id AND t.user_id = $3 AND s.category_id = $4
+			RETURNING s.transaction_id AS id
+		)
+		SELECT id FROM moved
+		UNION
+		SELECT id FROM split
+	`, category.ID, category.Name, userID, fromID)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// Lidas depois das atualizações acima, já com os nomes novos
+	rows, err = tx.QueryContext(ctx, `SELECT `+transactionColumns+` FROM transactions WHERE id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+
+	transactions := []*models.Transaction{}
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		transactions = append(transactions, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if err := loadTransactionSplits(ctx, tx, transactions); err != nil {
+		return 0, err
+	}
+
+	for _, t := range transactions {
+		if err := reverseSource(ctx, tx, userID, "transaction", t.ID); err != nil {
+			return 0, err
+		}
+		if err := postTransaction(ctx, tx, t); err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(transactions)), nil
+}
+
+func sameCategoryID(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// isCategoryError informa se err é um erro de validação das categorias
+func isCategoryError(err error) bool {
+	switch err {
+	case ErrCategoryNotFound, ErrCategoryParentNotFound, ErrCategoryTargetNotFound, ErrCategoryTypeMismatch, ErrCategoryCycle, ErrCategoryArchived, ErrCategoryBudgetConflict:
+		return true
+	}
+	return false
+}
+
+// ListAllByUser lista todas as categorias do usuário, inclusive as arquivadas,
+// sem criar o conjunto inicial (exportação de dados)
+func (r *CategoryRepository) ListAllByUser(ctx context.Context, userID string) ([]*models.Category, error) {
+	return r.list(ctx, userID, true)
+}
+
+// DeleteAllByUser apaga as categorias do usuário; as transações devem ter sido apagadas antes
+func (r *CategoryRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM categories WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("failed to delete user categories",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// categoryNode é uma categoria com os totais das suas transações, para montar a
+// árvore das estatísticas
+type categoryNode struct {
+	id, parentID, name, categoryType string
+	total                            money.Amount
+	count                            int
+}
+
+// categoryTree monta, por tipo, a árvore das categorias com transações: o total
+// de cada uma é somado em todas as categorias acima dela. Categorias sem
+// transações na subárvore ficam de fora; irmãs vêm do maior para o menor total.
+func categoryTree(nodes []categoryNode) map[string][]*models.CategoryStats {
+	byID := make(map[string]*categoryNode, len(nodes))
+	for i := range nodes {
+		byID[nodes[i].id] = &nodes[i]
+	}
+
+	stats := make(map[string]*models.CategoryStats)
+	statsFor := func(n *categoryNode) *models.CategoryStats {
+		s, ok := stats[n.id]
+		if !ok {
+			s = &models.CategoryStats{
+				CategoryID: n.id,
+				Name:       n.name,
+				Type:       n.categoryType,
+				Children:   []*models.CategoryStats{},
+			}
+			stats[n.id] = s
+		}
+		return s
+	}
+
+	for i := range nodes {
+		n := &nodes[i]
+		if n.count == 0 {
+			continue
+		}
+		own := statsFor(n)
+		own.Total, own.Count = n.total, n.count
+
+		// Sobe até a raiz; o limite protege de um ciclo gravado por engano
+		for current, depth := n, 0; current != nil && depth <= len(nodes); depth++ {
+			s := statsFor(current)
+			s.RolledUpTotal += n.total
+			s.RolledUpCount += n.count
+			current = byID[current.parentID]
+		}
+	}
+
+	tree := map[string][]*models.CategoryStats{
+		"income":  {},
+		"expense": {},
+	}
+	for id, s := range stats {
+		if parent, ok := stats[byID[id].parentID]; ok {
+			parent.Children = append(parent.Children, s)
+		} else {
+			tree[s.Type] = append(tree[s.Type], s)
+		}
+	}
+
+	var sortLevel func(level []*models.CategoryStats)
+	sortLevel = func(level []*models.CategoryStats) {
+		sort.Slice(level, func(i, j int) bool {
+			if level[i].RolledUpTotal != level[j].RolledUpTotal {
+				return level[i].RolledUpTotal > level[j].RolledUpTotal
+			}
+			return level[i].Name < level[j].Name
+		})
+		for _, s := range level {
+			sortLevel(s.Children)
+		}
+	}
+	for _, level := range tree {
+		sortLevel(level)
+	}
+
+	return tree
+}
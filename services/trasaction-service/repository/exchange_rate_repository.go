@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+)
+
+type ExchangeRateRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewExchangeRateRepository(db *sql.DB, logger *zap.Logger) *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Upsert grava as cotações numa única transação; reimportar o mesmo arquivo só atualiza os valores
+func (r *ExchangeRateRepository) Upsert(ctx context.Context, rates []models.ExchangeRate) (int, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("upsert_exchange_rates").Observe(time.Since(start).Seconds())
+	}()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO exchange_rates (currency, quote_currency, rate_date, rate, source)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (currency, quote_currency, rate_date)
+		DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source, imported_at = NOW()
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.ExecContext(ctx, rate.Currency, rate.QuoteCurrency, rate.Date, rate.Rate, rate.Source); err != nil {
+			r.logger.Error("failed to upsert exchange rate",
+				zap.Error(err),
+				zap.String("currency", rate.Currency),
+				zap.Time("date", rate.Date),
+			)
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(rates), nil
+}
+
+// Convert retorna o fator de conversão entre duas moedas vigente na data
+// (última cotação disponível até a data, cruzando via BRL)
+func (r *ExchangeRateRepository) Convert(ctx context.Context, from, to string, date time.Time) (string, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_exchange_rate").Observe(time.Since(start).Seconds())
+	}()
+
+	var rate sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		`SELECT ROUND(fx_rate($1, $2, $3::date), 10)::TEXT`,
+		from, to, date,
+	).Scan(&rate)
+	if err != nil {
+		r.logger.Error("failed to convert currency",
+			zap.Error(err),
+			zap.String("from", from),
+			zap.String("to", to),
+		)
+		return "", err
+	}
+
+	if !rate.Valid {
+		return "", ErrExchangeRateNotFound
+	}
+
+	return rate.String, nil
+}
@@ -0,0 +1,456 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/money"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrGoalNotFound      = errors.New("goal not found")
+	ErrGoalAlreadyExists = errors.New("goal with this name already exists")
+)
+
+// Duração média de um mês em centésimos de dia, usada nas projeções
+const centiDaysPerMonth = 3044
+
+type GoalRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewGoalRepository(db *sql.DB, logger *zap.Logger) *GoalRepository {
+	return &GoalRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const goalSelect = `
+	SELECT id, user_id, name, target_amount, currency, deadline, account_id, category,
+		start_date, achieved_at, created_at, updated_at
+	FROM goals
+`
+
+func scanGoal(row rowScanner) (*models.Goal, error) {
+	g := &models.Goal{}
+	var deadline sql.NullTime
+	var accountID, category sql.NullString
+	var startDate time.Time
+	var achievedAt sql.NullTime
+	err := row.Scan(
+		&g.ID,
+		&g.UserID,
+		&g.Name,
+		&g.TargetAmount,
+		&g.Currency,
+		&deadline,
+		&accountID,
+		&category,
+		&startDate,
+		&achievedAt,
+		&g.CreatedAt,
+		&g.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline.Valid {
+		value := deadline.Time.Format("2006-01-02")
+		g.Deadline = &value
+	}
+	if accountID.Valid {
+		g.AccountID = &accountID.String
+	}
+	if category.Valid {
+		g.Category = &category.String
+	}
+	if achievedAt.Valid {
+		g.AchievedAt = &achievedAt.Time
+	}
+	g.StartDate = startDate.Format("2006-01-02")
+	return g, nil
+}
+
+// nullableString converte ponteiros opcionais para parâmetros SQL
+func nullableString(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
+
+// Create cria uma meta
+func (r *GoalRepository) Create(ctx context.Context, goal *models.Goal) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("insert_goal").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		INSERT INTO goals (id, user_id, name, target_amount, currency, deadline, account_id, category, start_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6::date, $7, $8, $9::date, $10, $11)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		goal.ID,
+		goal.UserID,
+		goal.Name,
+		goal.TargetAmount,
+		goal.Currency,
+		nullableString(goal.Deadline),
+		nullableString(goal.AccountID),
+		nullableString(goal.Category),
+		goal.StartDate,
+		goal.CreatedAt,
+		goal.UpdatedAt,
+	)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrGoalAlreadyExists
+	}
+
+	if err != nil {
+		r.logger.Error("failed to create goal",
+			zap.Error(err),
+			zap.String("user_id", goal.UserID),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// List lista as metas do usuário, as pendentes primeiro
+func (r *GoalRepository) List(ctx context.Context, userID string) ([]*models.Goal, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_goals").Observe(time.Since(start).Seconds())
+	}()
+
+	rows, err := r.db.QueryContext(ctx, goalSelect+` WHERE user_id = $1 ORDER BY achieved_at IS NOT NULL, deadline NULLS LAST, name`, userID)
+	if err != nil {
+		r.logger.Error("failed to list goals",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := []*models.Goal{}
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+
+	return goals, rows.Err()
+}
+
+// FindByID busca uma meta do usuário
+func (r *GoalRepository) FindByID(ctx context.Context, id, userID string) (*models.Goal, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_goal").Observe(time.Since(start).Seconds())
+	}()
+
+	goal, err := scanGoal(r.db.QueryRowContext(ctx, goalSelect+` WHERE id = $1 AND user_id = $2`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrGoalNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find goal",
+			zap.Error(err),
+			zap.String("goal_id", id),
+		)
+		return nil, err
+	}
+
+	return goal, nil
+}
+
+// FindPendingByLinks busca as metas ainda não atingidas ligadas a alguma das
+// contas ou categorias informadas
+func (r *GoalRepository) FindPendingByLinks(ctx context.Context, userID string, accountIDs, categories []string) ([]*models.Goal, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_goals_by_links").Observe(time.Since(start).Seconds())
+	}()
+
+	query := goalSelect + `
+		WHERE user_id = $1 AND achieved_at IS NULL
+			AND (account_id = ANY($2::uuid[]) OR category = ANY($3::text[]))
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, pq.Array(accountIDs), pq.Array(categories))
+	if err != nil {
+		r.logger.Error("failed to find goals by links",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := []*models.Goal{}
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+
+	return goals, rows.Err()
+}
+
+// Update altera nome, valor alvo e prazo; o vínculo e o início são fixos
+func (r *GoalRepository) Update(ctx context.Context, goal *models.Goal) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("update_goal").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		UPDATE goals
+		SET name = $1, target_amount = $2, deadline = $3::date, updated_at = $4
+		WHERE id = $5 AND user_id = $6
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		goal.Name,
+		goal.TargetAmount,
+		nullableString(goal.Deadline),
+		goal.UpdatedAt,
+		goal.ID,
+		goal.UserID,
+	)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrGoalAlreadyExists
+	}
+
+	if err != nil {
+		r.logger.Error("failed to update goal",
+			zap.Error(err),
+			zap.String("goal_id", goal.ID),
+		)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrGoalNotFound
+	}
+
+	return nil
+}
+
+// Delete remove uma meta
+func (r *GoalRepository) Delete(ctx context.Context, id, userID string) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("delete_goal").Observe(time.Since(start).Seconds())
+	}()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM goals WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		r.logger.Error("failed to delete goal",
+			zap.Error(err),
+			zap.String("goal_id", id),
+		)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrGoalNotFound
+	}
+
+	return nil
+}
+
+// DeleteAllByUser remove todas as metas do usuário
+func (r *GoalRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM goals WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("failed to delete user goals",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// MarkAchieved registra que a meta foi atingida. Retorna true apenas para quem
+// fez a marcação, o que garante um único goal.achieved por meta.
+func (r *GoalRepository) MarkAchieved(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE goals SET achieved_at = NOW() WHERE id = $1 AND achieved_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// ClearAchieved desfaz a marcação quando o evento não pôde ser publicado
+func (r *GoalRepository) ClearAchieved(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE goals SET achieved_at = NULL WHERE id = $1`, id)
+	return err
+}
+
+// Progress soma as contribuições da meta, convertidas para a moeda dela, e
+// projeta a conclusão pelo ritmo médio diário desde start_date
+func (r *GoalRepository) Progress(ctx context.Context, goal *models.Goal, now time.Time) (*models.GoalProgress, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_goal_progress").Observe(time.Since(start).Seconds())
+	}()
+
+	// Numa conta conta o movimento líquido (inclusive transferências); numa
+	// categoria, o valor de cada lançamento ou linha de divisão
+	source, contribution, link, linkValue := `transaction_lines`, `t.amount`, `t.category = $2 AND t.type <> 'transfer'`, ""
+	if goal.AccountID != nil {
+		source, contribution, link, linkValue = `transactions`, signedAmountSQL, `t.account_id = $2::uuid`, *goal.AccountID
+	} else if goal.Category != nil {
+		linkValue = *goal.Category
+	}
+
+	query := `
+		SELECT date_trunc('month', date)::date, COALESCE(ROUND(SUM(converted), 2), 0), COUNT(*), COUNT(*) FILTER (WHERE converted IS NULL)
+		FROM (
+			SELECT t.date, (` + contribution + `) * fx_rate(t.currency, $3, t.date::date) as converted
+			FROM ` + source + ` t
+			WHERE t.user_id = $1 AND ` + link + ` AND t.date >= $4::date
+		) c
+		GROUP BY 1
+		ORDER BY 1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, goal.UserID, linkValue, goal.Currency, goal.StartDate)
+	if err != nil {
+		r.logger.Error("failed to get goal progress",
+			zap.Error(err),
+			zap.String("goal_id", goal.ID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	progress := &models.GoalProgress{Contributions: []models.GoalContribution{}}
+	for rows.Next() {
+		var month time.Time
+		var total money.Amount
+		var count, unconverted int
+		if err := rows.Scan(&month, &total, &count, &unconverted); err != nil {
+			return nil, err
+		}
+		progress.Saved += total
+		progress.ContributionCount += count
+		progress.UnconvertedCount += unconverted
+		progress.Contributions = append(progress.Contributions, models.GoalContribution{
+			Month:  month.Format("2006-01"),
+			Amount: total,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	projectGoal(goal, progress, now)
+	return progress, nil
+}
+
+// projectGoal preenche percentual, ritmo mensal e projeções da meta
+func projectGoal(goal *models.Goal, progress *models.GoalProgress, now time.Time) {
+	year, month, day := now.UTC().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	progress.Remaining = goal.TargetAmount - progress.Saved
+	if progress.Remaining < 0 {
+		progress.Remaining = 0
+	}
+	progress.Percentage = int(progress.Saved * 100 / goal.TargetAmount)
+	if progress.Percentage < 0 {
+		progress.Percentage = 0
+	}
+
+	startDate, err := time.Parse("2006-01-02", goal.StartDate)
+	if err != nil {
+		return
+	}
+
+	days := int64(today.Sub(startDate).Hours()/24) + 1
+	if days < 1 {
+		days = 1
+	}
+	saved := progress.Saved.Cents()
+	progress.MonthlyRate = money.FromCents(saved * centiDaysPerMonth / (days * 100))
+
+	var projected time.Time
+	switch {
+	case progress.Remaining == 0:
+		projected = today
+	case saved > 0:
+		// Dias restantes no ritmo atual, arredondados para cima
+		remaining := progress.Remaining.Cents()
+		projected = today.AddDate(0, 0, int((remaining*days+saved-1)/saved))
+	}
+	if !projected.IsZero() {
+		value := projected.Format("2006-01-02")
+		progress.ProjectedCompletion = &value
+	}
+
+	if goal.Deadline == nil {
+		return
+	}
+
+	deadline, err := time.Parse("2006-01-02", *goal.Deadline)
+	if err != nil {
+		return
+	}
+
+	onTrack := !projected.IsZero() && !projected.After(deadline)
+	progress.OnTrack = &onTrack
+
+	if progress.Remaining > 0 {
+		required := progress.Remaining
+		if daysLeft := int64(deadline.Sub(today).Hours() / 24); daysLeft > 0 {
+			cents := progress.Remaining.Cents() * centiDaysPerMonth
+			// Com menos de um mês de prazo, basta o que falta
+			if perMonth := money.FromCents((cents + daysLeft*100 - 1) / (daysLeft * 100)); perMonth < required {
+				required = perMonth
+			}
+		}
+		progress.RequiredMonthly = &required
+	}
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrImportProfileNotFound      = errors.New("import profile not found")
+	ErrImportProfileAlreadyExists = errors.New("import profile already exists")
+)
+
+type ImportProfileRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewImportProfileRepository(db *sql.DB, logger *zap.Logger) *ImportProfileRepository {
+	return &ImportProfileRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const importProfileSelect = `
+	SELECT id, user_id, name, delimiter, encoding, has_header, skip_rows, date_column, date_format,
+		description_columns, amount_column, debit_column, credit_column, category_column, external_id_column,
+		decimal_separator, sign_convention, default_category, created_at, updated_at
+	FROM import_profiles
+`
+
+func scanImportProfile(row rowScanner) (*models.ImportProfile, error) {
+	p := &models.ImportProfile{}
+	err := row.Scan(
+		&p.ID,
+		&p.UserID,
+		&p.Name,
+		&p.Delimiter,
+		&p.Encoding,
+		&p.HasHeader,
+		&p.SkipRows,
+		&p.DateColumn,
+		&p.DateFormat,
+		pq.Array(&p.DescriptionColumns),
+		&p.AmountColumn,
+		&p.DebitColumn,
+		&p.CreditColumn,
+		&p.CategoryColumn,
+		&p.ExternalIDColumn,
+		&p.DecimalSeparator,
+		&p.SignConvention,
+		&p.DefaultCategory,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Create grava um perfil de importação
+func (r *ImportProfileRepository) Create(ctx context.Context, profile *models.ImportProfile) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("insert_import_profile").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		INSERT INTO import_profiles (id, user_id, name, delimiter, encoding, has_header, skip_rows, date_column,
+			date_format, description_columns, amount_column, debit_column, credit_column, category_column,
+			external_id_column, decimal_separator, sign_convention, default_category, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		profile.ID,
+		profile.UserID,
+		profile.Name,
+		profile.Delimiter,
+		profile.Encoding,
+		profile.HasHeader,
+		profile.SkipRows,
+		profile.DateColumn,
+		profile.DateFormat,
+		pq.Array(profile.DescriptionColumns),
+		profile.AmountColumn,
+		profile.DebitColumn,
+		profile.CreditColumn,
+		profile.CategoryColumn,
+		profile.ExternalIDColumn,
+		profile.DecimalSeparator,
+		profile.SignConvention,
+		profile.DefaultCategory,
+		profile.CreatedAt,
+		profile.UpdatedAt,
+	)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrImportProfileAlreadyExists
+	}
+
+	if err != nil {
+		r.logger.Error("failed to create import profile",
+			zap.Error(err),
+			zap.String("user_id", profile.UserID),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// List lista os perfis do usuário por nome
+func (r *ImportProfileRepository) List(ctx context.Context, userID string) ([]*models.ImportProfile, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_import_profiles").Observe(time.Since(start).Seconds())
+	}()
+
+	rows, err := r.db.QueryContext(ctx, importProfileSelect+` WHERE user_id = $1 ORDER BY name`, userID)
+	if err != nil {
+		r.logger.Error("failed to list import profiles",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []*models.ImportProfile{}
+	for rows.Next() {
+		profile, err := scanImportProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, rows.Err()
+}
+
+// FindByID busca um perfil do usuário
+func (r *ImportProfileRepository) FindByID(ctx context.Context, id, userID string) (*models.ImportProfile, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_import_profile").Observe(time.Since(start).Seconds())
+	}()
+
+	profile, err := scanImportProfile(r.db.QueryRowContext(ctx, importProfileSelect+` WHERE id = $1 AND user_id = $2`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrImportProfileNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find import profile",
+			zap.Error(err),
+			zap.String("profile_id", id),
+		)
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// Update altera o perfil; lotes já importados não mudam
+func (r *ImportProfileRepository) Update(ctx context.Context, profile *models.ImportProfile) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("update_import_profile").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		UPDATE import_profiles
+		SET name = $1, delimiter = $2, encoding = $3, has_header = $4, skip_rows = $5, date_column = $6,
+			date_format = $7, description_columns = $8, amount_column = $9, debit_column = $10,
+			credit_column = $11, category_column = $12, external_id_column = $13, decimal_separator = $14,
+			sign_convention = $15, default_category = $16, updated_at = $17
+		WHERE id = $18 AND user_id = $19
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		profile.Name,
+		profile.Delimiter,
+		profile.Encoding,
+		profile.HasHeader,
+		profile.SkipRows,
+		profile.DateColumn,
+		profile.DateFormat,
+		pq.Array(profile.DescriptionColumns),
+		profile.AmountColumn,
+		profile.DebitColumn,
+		profile.CreditColumn,
+		profile.CategoryColumn,
+		profile.ExternalIDColumn,
+		profile.DecimalSeparator,
+		profile.SignConvention,
+		profile.DefaultCategory,
+		profile.UpdatedAt,
+		profile.ID,
+		profile.UserID,
+	)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrImportProfileAlreadyExists
+	}
+
+	if err != nil {
+		r.logger.Error("failed to update import profile",
+			zap.Error(err),
+			zap.String("profile_id", profile.ID),
+		)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrImportProfileNotFound
+	}
+
+	return nil
+}
+
+// Delete remove o perfil; os lotes que o usaram ficam sem referência
+func (r *ImportProfileRepository) Delete(ctx context.Context, id, userID string) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("delete_import_profile").Observe(time.Since(start).Seconds())
+	}()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM import_profiles WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		r.logger.Error("failed to delete import profile",
+			zap.Error(err),
+			zap.String("profile_id", id),
+		)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrImportProfileNotFound
+	}
+
+	return nil
+}
+
+// DeleteAllByUser remove todos os perfis do usuário
+func (r *ImportProfileRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM import_profiles WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("failed to delete user import profiles",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,623 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrImportNotFound     = errors.New("import batch not found")
+	ErrImportNotPending   = errors.New("import batch is not pending")
+	ErrImportNotCommitted = errors.New("import batch is not committed")
+	ErrImportConflict     = errors.New("statement entries were imported concurrently")
+)
+
+type ImportRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewImportRepository(db *sql.DB, logger *zap.Logger) *ImportRepository {
+	return &ImportRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const importBatchSelect = `
+	SELECT id, user_id, account_id, format, profile_id, filename, status, total_entries, error_count, imported_count,
+		created_at, committed_at, rolled_back_at
+	FROM import_batches
+`
+
+func scanImportBatch(row rowScanner) (*models.ImportBatch, error) {
+	b := &models.ImportBatch{}
+	var committedAt, rolledBackAt sql.NullTime
+	err := row.Scan(
+		&b.ID,
+		&b.UserID,
+		&b.AccountID,
+		&b.Format,
+		&b.ProfileID,
+		&b.Filename,
+		&b.Status,
+		&b.TotalEntries,
+		&b.ErrorCount,
+		&b.ImportedCount,
+		&b.CreatedAt,
+		&committedAt,
+		&rolledBackAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if committedAt.Valid {
+		b.CommittedAt = &committedAt.Time
+	}
+	if rolledBackAt.Valid {
+		b.RolledBackAt = &rolledBackAt.Time
+	}
+	return b, nil
+}
+
+// classifyImportEntries compara os lançamentos ainda novos do lote com as transações
+// da conta: mesmo external_id é duplicata; mesma data, valor e tipo é possível duplicata.
+// Repetições do mesmo external_id dentro do arquivo ficam só na primeira linha.
+func classifyImportEntries(ctx context.Context, db dbtx, batchID, accountID string) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE import_entries e
+		SET status = 'duplicate', duplicate_of = t.id
+		FROM transactions t
+		WHERE e.batch_id = $1 AND e.status <> 'duplicate'
+			AND t.account_id = $2 AND t.external_id = e.external_id
+	`, batchID, accountID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `
+		UPDATE import_entries e
+		SET status = 'duplicate', duplicate_of = NULL
+		WHERE e.batch_id = $1 AND e.status <> 'duplicate'
+			AND EXISTS (
+				SELECT 1 FROM import_entries p
+				WHERE p.batch_id = e.batch_id AND p.external_id = e.external_id AND p.line < e.line
+			)
+	`, batchID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `
+		UPDATE import_entries e
+		SET status = 'possible_duplicate', duplicate_of = (
+			SELECT t.id FROM transactions t
+			WHERE t.account_id = $2 AND t.date::date = e.date AND t.amount = e.amount AND t.type = e.type
+			ORDER BY t.created_at
+			LIMIT 1
+		)
+		WHERE e.batch_id = $1 AND e.status = 'new'
+			AND EXISTS (
+				SELECT 1 FROM transactions t
+				WHERE t.account_id = $2 AND t.date::date = e.date AND t.amount = e.amount AND t.type = e.type
+			)
+	`, batchID, accountID)
+	return err
+}
+
+// ImportEntrySource entrega os lançamentos do lote um a um; retorna nil no fim
+type ImportEntrySource func() (*models.ImportEntry, error)
+
+// ImportEntries adapta uma lista já lida para ImportEntrySource
+func ImportEntries(entries []*models.ImportEntry) ImportEntrySource {
+	next := 0
+	return func() (*models.ImportEntry, error) {
+		if next == len(entries) {
+			return nil, nil
+		}
+		next++
+		return entries[next-1], nil
+	}
+}
+
+// CreateBatch grava o lote pendente com os lançamentos e os classifica. Os
+// lançamentos são copiados para o banco à medida que a fonte os entrega, então
+// arquivos grandes não precisam caber em memória. TotalEntries é contado aqui;
+// ErrorCount é lido do lote depois que a fonte termina.
+func (r *ImportRepository) CreateBatch(ctx context.Context, batch *models.ImportBatch, source ImportEntrySource) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("insert_import_batch").Observe(time.Since(start).Seconds())
+	}()
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO import_batches (id, user_id, account_id, format, profile_id, filename, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`,
+			batch.ID,
+			batch.UserID,
+			batch.AccountID,
+			batch.Format,
+			batch.ProfileID,
+			batch.Filename,
+			batch.Status,
+			batch.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		stmt, err := tx.PrepareContext(ctx, pq.CopyIn("import_entries",
+			"batch_id", "line", "external_id", "date", "amount", "type", "description", "category", "splits", "status"))
+		if err != nil {
+			return err
+		}
+
+		batch.TotalEntries = 0
+		for {
+			e, err := source()
+			if err != nil {
+				stmt.Close()
+				return err
+			}
+			if e == nil {
+				break
+			}
+
+			splits, err := importSplits(e.Splits)
+			if err != nil {
+				stmt.Close()
+				return err
+			}
+
+			if _, err := stmt.ExecContext(ctx, batch.ID, e.Line, e.ExternalID, e.Date, e.Amount.String(),
+				e.Type, e.Description, e.Category, splits, models.ImportEntryNew); err != nil {
+				stmt.Close()
+				return fmt.Errorf("copy import entries: %w", err)
+			}
+			batch.TotalEntries++
+		}
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			stmt.Close()
+			return fmt.Errorf("copy import entries: %w", err)
+		}
+		if err := stmt.Close(); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE import_batches SET total_entries = $1, error_count = $2 WHERE id = $3`,
+			batch.TotalEntries, batch.ErrorCount, batch.ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := classifyImportEntries(ctx, tx, batch.ID, batch.AccountID); err != nil {
+			return err
+		}
+
+		batch.Summary, err = importSummary(ctx, tx, batch.ID)
+		return err
+	})
+
+	if err != nil {
+		r.logger.Error("failed to create import batch",
+			zap.Error(err),
+			zap.String("user_id", batch.UserID),
+			zap.String("account_id", batch.AccountID),
+		)
+	}
+
+	return err
+}
+
+func importSummary(ctx context.Context, db dbtx, batchID string) (map[string]int, error) {
+	rows, err := db.QueryContext(ctx, `SELECT status, COUNT(*) FROM import_entries WHERE batch_id = $1 GROUP BY status`, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := map[string]int{
+		models.ImportEntryNew:               0,
+		models.ImportEntryDuplicate:         0,
+		models.ImportEntryPossibleDuplicate: 0,
+	}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		summary[status] = count
+	}
+
+	return summary, rows.Err()
+}
+
+// List lista os lotes do usuário, do mais recente para o mais antigo
+func (r *ImportRepository) List(ctx context.Context, userID string) ([]*models.ImportBatch, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_import_batches").Observe(time.Since(start).Seconds())
+	}()
+
+	rows, err := r.db.QueryContext(ctx, importBatchSelect+` WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		r.logger.Error("failed to list import batches",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []*models.ImportBatch{}
+	for rows.Next() {
+		batch, err := scanImportBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, rows.Err()
+}
+
+// FindByID busca um lote do usuário com o resumo da classificação
+func (r *ImportRepository) FindByID(ctx context.Context, id, userID string) (*models.ImportBatch, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_import_batch").Observe(time.Since(start).Seconds())
+	}()
+
+	batch, err := scanImportBatch(r.db.QueryRowContext(ctx, importBatchSelect+` WHERE id = $1 AND user_id = $2`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrImportNotFound
+	}
+
+	if err == nil {
+		batch.Summary, err = importSummary(ctx, r.db, batch.ID)
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find import batch",
+			zap.Error(err),
+			zap.String("batch_id", id),
+		)
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// Entries lista os lançamentos do lote em ordem de linha, opcionalmente por classificação
+func (r *ImportRepository) Entries(ctx context.Context, batchID, status string, page, pageSize int) ([]*models.ImportEntry, int, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_import_entries").Observe(time.Since(start).Seconds())
+	}()
+
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM import_entries WHERE batch_id = $1 AND ($2 = '' OR status = $2)`,
+		batchID, status,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT line, external_id, date, amount, type, description, category, splits, status, duplicate_of, transaction_id
+		FROM import_entries
+		WHERE batch_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY line
+		LIMIT $3 OFFSET $4
+	`, batchID, status, pageSize, (page-1)*pageSize)
+	if err != nil {
+		r.logger.Error("failed to list import entries",
+			zap.Error(err),
+			zap.String("batch_id", batchID),
+		)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []*models.ImportEntry{}
+	for rows.Next() {
+		e, err := scanImportEntry(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, total, rows.Err()
+}
+
+// importSplits serializa as linhas de um lançamento dividido para a coluna
+// splits; nil nos demais
+func importSplits(splits []models.TransactionSplit) (interface{}, error) {
+	if len(splits) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(splits)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func scanImportEntry(row rowScanner) (*models.ImportEntry, error) {
+	e := &models.ImportEntry{}
+	var date time.Time
+	var splits []byte
+	err := row.Scan(
+		&e.Line,
+		&e.ExternalID,
+		&date,
+		&e.Amount,
+		&e.Type,
+		&e.Description,
+		&e.Category,
+		&splits,
+		&e.Status,
+		&e.DuplicateOf,
+		&e.TransactionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(splits) > 0 {
+		if err := json.Unmarshal(splits, &e.Splits); err != nil {
+			return nil, err
+		}
+	}
+
+	e.Date = date.Format("2006-01-02")
+	return e, nil
+}
+
+// Commit cria de uma vez as transações do lote pendente: os lançamentos novos e,
+// se includePossibleDuplicates, também as possíveis duplicatas. Duplicatas exatas
+// nunca são importadas. A classificação é refeita dentro da transação, então
+// lançamentos importados por outro lote depois da pré-visualização são ignorados.
+func (r *ImportRepository) Commit(ctx context.Context, batchID, userID, currency string, overrides map[int]models.ImportOverride, includePossibleDuplicates bool) ([]*models.Transaction, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("commit_import_batch").Observe(time.Since(start).Seconds())
+	}()
+
+	created := []*models.Transaction{}
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		batch, err := lockImportBatch(ctx, tx, batchID, userID)
+		if err != nil {
+			return err
+		}
+		if batch.Status != models.ImportStatusPending {
+			return ErrImportNotPending
+		}
+
+		if err := classifyImportEntries(ctx, tx, batch.ID, batch.AccountID); err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, `
+			SELECT line, external_id, date, amount, type, description, category, splits, status, duplicate_of, transaction_id
+			FROM import_entries
+			WHERE batch_id = $1 AND (status = 'new' OR ($2 AND status = 'possible_duplicate'))
+			ORDER BY line
+		`, batch.ID, includePossibleDuplicates)
+		if err != nil {
+			return err
+		}
+
+		entries := []*models.ImportEntry{}
+		for rows.Next() {
+			e, err := scanImportEntry(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			entries = append(entries, e)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, e := range entries {
+			if override, ok := overrides[e.Line]; ok {
+				if override.Skip {
+					continue
+				}
+				if override.Category != nil {
+					e.Category = *override.Category
+					e.Splits = nil
+				}
+			}
+
+			date, err := time.Parse("2006-01-02", e.Date)
+			if err != nil {
+				return err
+			}
+
+			externalID, importBatchID := e.ExternalID, batch.ID
+			transaction := &models.Transaction{
+				ID:            uuid.New().String(),
+				UserID:        batch.UserID,
+				AccountID:     batch.AccountID,
+				Description:   e.Description,
+				Amount:        e.Amount,
+				Currency:      currency,
+				Category:      e.Category,
+				Type:          e.Type,
+				Date:          date,
+				Splits:        e.Splits,
+				ExternalID:    &externalID,
+				ImportBatchID: &importBatchID,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+
+			if err := insertTransaction(ctx, tx, transaction); err != nil {
+				if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+					return ErrImportConflict
+				}
+				return err
+			}
+			if err := postTransaction(ctx, tx, transaction); err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx,
+				`UPDATE import_entries SET category = $1, transaction_id = $2 WHERE batch_id = $3 AND line = $4`,
+				transaction.Category, transaction.ID, batch.ID, e.Line,
+			)
+			if err != nil {
+				return err
+			}
+
+			created = append(created, transaction)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE import_batches SET status = $1, imported_count = $2, committed_at = $3 WHERE id = $4
+		`, models.ImportStatusCommitted, len(created), now, batch.ID)
+		return err
+	})
+
+	if err != nil && err != ErrImportNotFound && err != ErrImportNotPending && err != ErrImportConflict {
+		r.logger.Error("failed to commit import batch",
+			zap.Error(err),
+			zap.String("batch_id", batchID),
+		)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// Rollback desfaz um lote importado: apaga e estorna todas as transações criadas por
+// ele, inclusive as que foram editadas depois do commit
+func (r *ImportRepository) Rollback(ctx context.Context, batchID, userID string) ([]*models.Transaction, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("rollback_import_batch").Observe(time.Since(start).Seconds())
+	}()
+
+	removed := []*models.Transaction{}
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		batch, err := lockImportBatch(ctx, tx, batchID, userID)
+		if err != nil {
+			return err
+		}
+		if batch.Status != models.ImportStatusCommitted {
+			return ErrImportNotCommitted
+		}
+
+		rows, err := tx.QueryContext(ctx,
+			`SELECT `+transactionColumns+` FROM transactions WHERE import_batch_id = $1 FOR UPDATE`, batch.ID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			transaction, err := scanTransaction(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			removed = append(removed, transaction)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, transaction := range removed {
+			if err := reverseSource(ctx, tx, transaction.UserID, "transaction", transaction.ID); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM transactions WHERE import_batch_id = $1`, batch.ID); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE import_batches SET status = $1, rolled_back_at = $2 WHERE id = $3
+		`, models.ImportStatusRolledBack, time.Now(), batch.ID)
+		return err
+	})
+
+	if err != nil && err != ErrImportNotFound && err != ErrImportNotCommitted {
+		r.logger.Error("failed to roll back import batch",
+			zap.Error(err),
+			zap.String("batch_id", batchID),
+		)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
+
+func lockImportBatch(ctx context.Context, tx *sql.Tx, id, userID string) (*models.ImportBatch, error) {
+	batch, err := scanImportBatch(tx.QueryRowContext(ctx, importBatchSelect+` WHERE id = $1 AND user_id = $2 FOR UPDATE`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrImportNotFound
+	}
+	return batch, err
+}
+
+// Discard descarta um lote ainda pendente
+func (r *ImportRepository) Discard(ctx context.Context, id, userID string) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		batch, err := lockImportBatch(ctx, tx, id, userID)
+		if err != nil {
+			return err
+		}
+		if batch.Status != models.ImportStatusPending {
+			return ErrImportNotPending
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM import_batches WHERE id = $1`, batch.ID)
+		return err
+	})
+}
+
+// DeleteAllByUser remove todos os lotes do usuário; as transações importadas são
+// apagadas à parte
+func (r *ImportRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM import_batches WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("failed to delete user import batches",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,618 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/money"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Contas de patrimônio usadas pelo razão
+const (
+	openingBalanceAccountName = "Saldos iniciais"
+	conversionAccountName     = "Conversão de moedas"
+)
+
+// LedgerRepository consulta e verifica o razão de partidas dobradas.
+// A escrita acontece pelas funções post*/reverseSource, chamadas pelos outros
+// repositórios dentro da mesma transação do banco que altera a API.
+type LedgerRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewLedgerRepository(db *sql.DB, logger *zap.Logger) *LedgerRepository {
+	return &LedgerRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// posting é uma partida ainda sem a conta do razão resolvida
+type posting struct {
+	key       string
+	kind      string
+	accountID string // carteiras: a conta é criada a partir de accounts
+	category  string
+	currency  string
+	name      string
+	amount    money.Amount
+}
+
+func walletPosting(accountID string, amount money.Amount) posting {
+	return posting{key: "wallet:" + accountID, accountID: accountID, amount: amount}
+}
+
+func categoryPosting(kind, category, currency string, amount money.Amount) posting {
+	return posting{
+		key:      kind + ":" + currency + ":" + category,
+		kind:     kind,
+		category: category,
+		currency: currency,
+		name:     category,
+		amount:   amount,
+	}
+}
+
+func equityPosting(purpose, name, currency string, amount money.Amount) posting {
+	return posting{
+		key:      "equity:" + purpose + ":" + currency,
+		kind:     models.LedgerKindEquity,
+		currency: currency,
+		name:     name,
+		amount:   amount,
+	}
+}
+
+// ensureLedgerAccount devolve a conta do razão da partida, criando-a se necessário
+func ensureLedgerAccount(ctx context.Context, db dbtx, userID string, p posting) (string, string, error) {
+	var id, currency string
+
+	if p.accountID != "" {
+		err := db.QueryRowContext(ctx, `
+			INSERT INTO ledger_accounts (user_id, key, kind, account_id, currency, name)
+			SELECT user_id, 'wallet:' || id, CASE WHEN type = 'credit_card' THEN 'liability' ELSE 'asset' END, id, currency, name
+			FROM accounts
+			WHERE id = $1 AND user_id = $2
+			ON CONFLICT (user_id, key) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id, currency
+		`, p.accountID, userID).Scan(&id, &currency)
+		return id, currency, err
+	}
+
+	var category sql.NullString
+	if p.category != "" {
+		category = sql.NullString{String: p.category, Valid: true}
+	}
+
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO ledger_accounts (user_id, key, kind, category, currency, name)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, key) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id, currency
+	`, userID, p.key, p.kind, category, p.currency, p.name).Scan(&id, &currency)
+	return id, currency, err
+}
+
+// postEntry grava um lançamento e as suas partidas. O banco rejeita o commit se
+// as partidas não somarem zero em cada moeda.
+func postEntry(ctx context.Context, db dbtx, entry *models.JournalEntry, postings []posting) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("insert_journal_entry").Observe(time.Since(start).Seconds())
+	}()
+
+	entry.ID = uuid.New().String()
+	entry.CreatedAt = time.Now()
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO journal_entries (id, user_id, kind, source_type, source_id, reverses_entry_id, description, date, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`,
+		entry.ID,
+		entry.UserID,
+		entry.Kind,
+		entry.SourceType,
+		entry.SourceID,
+		entry.ReversesEntryID,
+		entry.Description,
+		entry.Date,
+		entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert journal entry: %w", err)
+	}
+
+	entry.Postings = []models.Posting{}
+	for _, p := range postings {
+		if p.amount == 0 {
+			continue
+		}
+
+		ledgerAccountID, currency, err := ensureLedgerAccount(ctx, db, entry.UserID, p)
+		if err != nil {
+			return fmt.Errorf("resolve ledger account %s: %w", p.key, err)
+		}
+
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO postings (id, entry_id, ledger_account_id, amount, currency)
+			VALUES ($1, $2, $3, $4, $5)
+		`, uuid.New().String(), entry.ID, ledgerAccountID, p.amount, currency)
+		if err != nil {
+			return fmt.Errorf("insert posting: %w", err)
+		}
+
+		entry.Postings = append(entry.Postings, models.Posting{
+			LedgerAccountID: ledgerAccountID,
+			LedgerAccount:   p.key,
+			Amount:          p.amount,
+			Currency:        currency,
+		})
+	}
+
+	return nil
+}
+
+// postTransaction lança uma receita ou despesa: a carteira contra a conta da
+// categoria, ou contra a de cada linha se a transação estiver dividida
+func postTransaction(ctx context.Context, db dbtx, t *models.Transaction) error {
+	sign := money.Amount(1)
+	if t.Type == "expense" {
+		sign = -1
+	}
+
+	entry := &models.JournalEntry{
+		UserID:      t.UserID,
+		Kind:        models.EntryKindTransaction,
+		SourceType:  "transaction",
+		SourceID:    t.ID,
+		Description: t.Description,
+		Date:        t.Date,
+	}
+
+	postings := []posting{walletPosting(t.AccountID, sign*t.Amount)}
+	for _, line := range t.Lines() {
+		postings = append(postings, categoryPosting(t.Type, line.Category, t.Currency, -sign*line.Amount))
+	}
+
+	return postEntry(ctx, db, entry, postings)
+}
+
+// postTransfer lança uma transferência. Entre moedas diferentes, cada perna passa
+// pela conta de conversão da sua moeda para que cada moeda feche em zero.
+func postTransfer(ctx context.Context, db dbtx, transfer *models.Transfer) error {
+	entry := &models.JournalEntry{
+		UserID:      transfer.UserID,
+		Kind:        models.EntryKindTransfer,
+		SourceType:  "transfer",
+		SourceID:    transfer.ID,
+		Description: transfer.Description,
+		Date:        transfer.Date,
+	}
+
+	var out, in *models.Transaction
+	for _, leg := range transfer.Legs {
+		if *leg.TransferSide == models.TransferSideOut {
+			out = leg
+		} else {
+			in = leg
+		}
+	}
+
+	postings := []posting{
+		walletPosting(out.AccountID, -out.Amount),
+		walletPosting(in.AccountID, in.Amount),
+	}
+	if out.Currency != in.Currency {
+		postings = append(postings,
+			equityPosting("conversion", conversionAccountName, out.Currency, out.Amount),
+			equityPosting("conversion", conversionAccountName, in.Currency, -in.Amount),
+		)
+	}
+
+	return postEntry(ctx, db, entry, postings)
+}
+
+// postOpeningBalance lança uma variação do saldo de abertura contra o patrimônio.
+// A data é a de abertura da conta, para que os saldos históricos continuem coerentes.
+func postOpeningBalance(ctx context.Context, db dbtx, account *models.Account, delta money.Amount) error {
+	if delta == 0 {
+		return nil
+	}
+
+	entry := &models.JournalEntry{
+		UserID:      account.UserID,
+		Kind:        models.EntryKindOpeningBalance,
+		SourceType:  "account",
+		SourceID:    account.ID,
+		Description: "Saldo inicial: " + account.Name,
+		Date:        account.CreatedAt,
+	}
+
+	return postEntry(ctx, db, entry, []posting{
+		walletPosting(account.ID, delta),
+		equityPosting("opening", openingBalanceAccountName, account.Currency, -delta),
+	})
+}
+
+// activeEntry é um lançamento ainda não estornado
+type activeEntry struct {
+	id, kind, description string
+	date                  time.Time
+}
+
+// activeEntries lista os lançamentos ainda vigentes de um registro da API
+func activeEntries(ctx context.Context, db dbtx, userID, sourceType, sourceID string) ([]activeEntry, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT e.id, e.kind, e.description, e.date
+		FROM journal_entries e
+		WHERE e.user_id = $1 AND e.source_type = $2 AND e.source_id = $3
+			AND e.kind <> 'reversal'
+			AND NOT EXISTS (SELECT 1 FROM journal_entries r WHERE r.reverses_entry_id = e.id)
+		ORDER BY e.created_at
+	`, userID, sourceType, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []activeEntry{}
+	for rows.Next() {
+		var e activeEntry
+		if err := rows.Scan(&e.id, &e.kind, &e.description, &e.date); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// reverseSource estorna os lançamentos ainda vigentes de um registro da API.
+// O estorno tem as partidas invertidas e herda a data do lançamento original.
+func reverseSource(ctx context.Context, db dbtx, userID, sourceType, sourceID string) error {
+	entries, err := activeEntries(ctx, db, userID, sourceType, sourceID)
+	if err != nil {
+		return err
+	}
+
+	for _, original := range entries {
+		reversedID := original.id
+		reversal := &models.JournalEntry{
+			ID:              uuid.New().String(),
+			UserID:          userID,
+			Kind:            models.EntryKindReversal,
+			SourceType:      sourceType,
+			SourceID:        sourceID,
+			ReversesEntryID: &reversedID,
+			Description:     "Estorno: " + original.description,
+			Date:            original.date,
+			CreatedAt:       time.Now(),
+		}
+
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO journal_entries (id, user_id, kind, source_type, source_id, reverses_entry_id, description, date, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`,
+			reversal.ID,
+			reversal.UserID,
+			reversal.Kind,
+			reversal.SourceType,
+			reversal.SourceID,
+			reversal.ReversesEntryID,
+			reversal.Description,
+			reversal.Date,
+			reversal.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("insert reversal entry: %w", err)
+		}
+
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO postings (id, entry_id, ledger_account_id, amount, currency)
+			SELECT uuid_generate_v4(), $1, ledger_account_id, -amount, currency
+			FROM postings
+			WHERE entry_id = $2
+		`, reversal.ID, original.id)
+		if err != nil {
+			return fmt.Errorf("insert reversal postings: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// redateSource estorna os lançamentos vigentes de um registro da API e os lança
+// de novo em date, com as mesmas partidas
+func redateSource(ctx context.Context, db dbtx, userID, sourceType, sourceID string, date time.Time) error {
+	entries, err := activeEntries(ctx, db, userID, sourceType, sourceID)
+	if err != nil {
+		return err
+	}
+
+	if err := reverseSource(ctx, db, userID, sourceType, sourceID); err != nil {
+		return err
+	}
+
+	for _, original := range entries {
+		entryID := uuid.New().String()
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO journal_entries (id, user_id, kind, source_type, source_id, description, date, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, entryID, userID, original.kind, sourceType, sourceID, original.description, date, time.Now())
+		if err != nil {
+			return fmt.Errorf("insert redated entry: %w", err)
+		}
+
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO postings (id, entry_id, ledger_account_id, amount, currency)
+			SELECT uuid_generate_v4(), $1, ledger_account_id, amount, currency
+			FROM postings
+			WHERE entry_id = $2
+		`, entryID, original.id)
+		if err != nil {
+			return fmt.Errorf("insert redated postings: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Accounts lista as contas do razão do usuário com os saldos
+func (r *LedgerRepository) Accounts(ctx context.Context, userID string) ([]*models.LedgerAccount, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_ledger_accounts").Observe(time.Since(start).Seconds())
+	}()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT la.id, la.key, la.kind, la.account_id, la.category, la.currency, la.name, COALESCE(SUM(p.amount), 0)
+		FROM ledger_accounts la
+		LEFT JOIN postings p ON p.ledger_account_id = la.id
+		WHERE la.user_id = $1
+		GROUP BY la.id
+		ORDER BY la.kind, la.name, la.currency
+	`, userID)
+	if err != nil {
+		r.logger.Error("failed to list ledger accounts", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []*models.LedgerAccount{}
+	for rows.Next() {
+		a := &models.LedgerAccount{}
+		if err := rows.Scan(&a.ID, &a.Key, &a.Kind, &a.AccountID, &a.Category, &a.Currency, &a.Name, &a.Balance); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+
+	return accounts, rows.Err()
+}
+
+// Entries lista os lançamentos do usuário, mais recentes primeiro, opcionalmente de um registro da API
+func (r *LedgerRepository) Entries(ctx context.Context, userID, sourceID string, page, pageSize int) ([]*models.JournalEntry, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_journal_entries").Observe(time.Since(start).Seconds())
+	}()
+
+	source := sql.NullString{String: sourceID, Valid: sourceID != ""}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, kind, source_type, source_id, reverses_entry_id, description, date, created_at
+		FROM journal_entries
+		WHERE user_id = $1 AND ($2::uuid IS NULL OR source_id = $2::uuid)
+		ORDER BY created_at DESC, id
+		LIMIT $3 OFFSET $4
+	`, userID, source, pageSize, (page-1)*pageSize)
+	if err != nil {
+		r.logger.Error("failed to list journal entries", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+
+	entries := []*models.JournalEntry{}
+	byID := map[string]*models.JournalEntry{}
+	ids := []string{}
+	for rows.Next() {
+		e := &models.JournalEntry{Postings: []models.Posting{}}
+		err := rows.Scan(&e.ID, &e.UserID, &e.Kind, &e.SourceType, &e.SourceID, &e.ReversesEntryID, &e.Description, &e.Date, &e.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		entries = append(entries, e)
+		byID[e.ID] = e
+		ids = append(ids, e.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return entries, nil
+	}
+
+	postingRows, err := r.db.QueryContext(ctx, `
+		SELECT p.entry_id, p.ledger_account_id, la.key, p.amount, p.currency
+		FROM postings p
+		JOIN ledger_accounts la ON la.id = p.ledger_account_id
+		WHERE p.entry_id = ANY($1::uuid[])
+		ORDER BY p.amount DESC
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer postingRows.Close()
+
+	for postingRows.Next() {
+		var entryID string
+		var p models.Posting
+		if err := postingRows.Scan(&entryID, &p.LedgerAccountID, &p.LedgerAccount, &p.Amount, &p.Currency); err != nil {
+			return nil, err
+		}
+		byID[entryID].Postings = append(byID[entryID].Postings, p)
+	}
+
+	return entries, postingRows.Err()
+}
+
+// CheckIntegrity verifica se o razão do usuário fecha e se confere com os registros da API
+func (r *LedgerRepository) CheckIntegrity(ctx context.Context, userID string) (*models.IntegrityReport, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("check_ledger_integrity").Observe(time.Since(start).Seconds())
+	}()
+
+	checks := []struct {
+		name  string
+		query string
+	}{
+		{
+			// Todo lançamento soma zero em cada moeda
+			name: "entries_balanced",
+			query: `
+				SELECT 'entry ' || e.id || ': ' || SUM(p.amount) || ' ' || p.currency
+				FROM journal_entries e
+				JOIN postings p ON p.entry_id = e.id
+				WHERE e.user_id = $1
+				GROUP BY e.id, p.currency
+				HAVING SUM(p.amount) <> 0
+			`,
+		},
+		{
+			// Balancete: a soma de todas as partidas é zero em cada moeda
+			name: "trial_balance",
+			query: `
+				SELECT p.currency || ': ' || SUM(p.amount)
+				FROM postings p
+				JOIN ledger_accounts la ON la.id = p.ledger_account_id
+				WHERE la.user_id = $1
+				GROUP BY p.currency
+				HAVING SUM(p.amount) <> 0
+			`,
+		},
+		{
+			// O saldo de cada carteira no razão confere com as transações da API
+			name: "account_balances_match",
+			query: `
+				SELECT 'account ' || a.id || ': ledger ' || COALESCE(l.total, 0) || ' vs transactions ' || (a.opening_balance + COALESCE(t.total, 0))
+				FROM accounts a
+				LEFT JOIN (
+					SELECT la.account_id, SUM(p.amount) as total
+					FROM ledger_accounts la
+					JOIN postings p ON p.ledger_account_id = la.id
+					WHERE la.user_id = $1 AND la.account_id IS NOT NULL
+					GROUP BY la.account_id
+				) l ON l.account_id = a.id
+				LEFT JOIN (
+					SELECT t.account_id, SUM(` + signedAmountSQL + `) as total
+					FROM transactions t
+					WHERE t.user_id = $1
+					GROUP BY t.account_id
+				) t ON t.account_id = a.id
+				WHERE a.user_id = $1 AND COALESCE(l.total, 0) <> a.opening_balance + COALESCE(t.total, 0)
+			`,
+		},
+		{
+			// Cada receita, despesa e transferência tem exatamente um lançamento vigente
+			name: "sources_posted",
+			query: `
+				SELECT s.source_type || ' ' || s.source_id || ': ' || COALESCE(c.active, 0) || ' active entries'
+				FROM (
+					SELECT 'transaction' as source_type, id as source_id FROM transactions
+					WHERE user_id = $1 AND type <> 'transfer'
+					UNION ALL
+					SELECT 'transfer', id FROM transfers WHERE user_id = $1
+				) s
+				LEFT JOIN (
+					SELECT e.source_type, e.source_id, COUNT(*) as active
+					FROM journal_entries e
+					WHERE e.user_id = $1 AND e.kind IN ('transaction', 'transfer')
+						AND NOT EXISTS (SELECT 1 FROM journal_entries rv WHERE rv.reverses_entry_id = e.id)
+					GROUP BY e.source_type, e.source_id
+				) c ON c.source_type = s.source_type AND c.source_id = s.source_id
+				WHERE COALESCE(c.active, 0) <> 1
+			`,
+		},
+	}
+
+	report := &models.IntegrityReport{Balanced: true, CheckedAt: time.Now(), Checks: []models.IntegrityCheck{}}
+	for _, check := range checks {
+		rows, err := r.db.QueryContext(ctx, check.query, userID)
+		if err != nil {
+			r.logger.Error("failed to check ledger integrity", zap.Error(err), zap.String("check", check.name))
+			return nil, err
+		}
+
+		result := models.IntegrityCheck{Name: check.name, Failures: []string{}}
+		for rows.Next() {
+			var failure string
+			if err := rows.Scan(&failure); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			result.Failures = append(result.Failures, failure)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		result.Passed = len(result.Failures) == 0
+		if !result.Passed {
+			report.Balanced = false
+			metrics.LedgerIntegrityFailuresTotal.WithLabelValues(check.name).Inc()
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	return report, nil
+}
+
+// DeleteAllByUser apaga o razão do usuário (exclusão de conta). É a única exclusão
+// permitida pelos gatilhos de imutabilidade.
+func (r *LedgerRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SET LOCAL ledger.allow_erasure = 'on'`); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM postings
+		WHERE entry_id IN (SELECT id FROM journal_entries WHERE user_id = $1)
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM journal_entries WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ledger_accounts WHERE user_id = $1`, userID); err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, tx.Commit()
+}
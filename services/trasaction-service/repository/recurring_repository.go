@@ -0,0 +1,532 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRecurringRuleNotFound      = errors.New("recurring rule not found")
+	ErrOccurrenceMaterialized     = errors.New("occurrence already materialized")
+	ErrRecurringExceptionNotFound = errors.New("occurrence has no exception")
+)
+
+type RecurringRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewRecurringRepository(db *sql.DB, logger *zap.Logger) *RecurringRepository {
+	return &RecurringRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const recurringRuleSelect = `
+	SELECT id, user_id, account_id, description, amount, category, type, frequency, interval,
+		day_of_month, weekday, start_date, end_date, next_occurrence, active, created_at, updated_at
+	FROM recurring_rules
+`
+
+func scanRecurringRule(row rowScanner) (*models.RecurringRule, error) {
+	r := &models.RecurringRule{}
+	var dayOfMonth, weekday sql.NullInt64
+	var startDate time.Time
+	var endDate, nextOccurrence sql.NullTime
+	err := row.Scan(
+		&r.ID,
+		&r.UserID,
+		&r.AccountID,
+		&r.Description,
+		&r.Amount,
+		&r.Category,
+		&r.Type,
+		&r.Frequency,
+		&r.Interval,
+		&dayOfMonth,
+		&weekday,
+		&startDate,
+		&endDate,
+		&nextOccurrence,
+		&r.Active,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if dayOfMonth.Valid {
+		value := int(dayOfMonth.Int64)
+		r.DayOfMonth = &value
+	}
+	if weekday.Valid {
+		value := int(weekday.Int64)
+		r.Weekday = &value
+	}
+	r.StartDate = startDate.Format("2006-01-02")
+	if endDate.Valid {
+		value := endDate.Time.Format("2006-01-02")
+		r.EndDate = &value
+	}
+	if nextOccurrence.Valid {
+		value := nextOccurrence.Time.Format("2006-01-02")
+		r.NextOccurrence = &value
+	}
+	return r, nil
+}
+
+// Create grava uma regra de recorrência
+func (r *RecurringRepository) Create(ctx context.Context, rule *models.RecurringRule) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("insert_recurring_rule").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		INSERT INTO recurring_rules (id, user_id, account_id, description, amount, category, type, frequency, interval,
+			day_of_month, weekday, start_date, end_date, next_occurrence, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12::date, $13::date, $14::date, $15, $16, $17)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		rule.ID,
+		rule.UserID,
+		rule.AccountID,
+		rule.Description,
+		rule.Amount,
+		rule.Category,
+		rule.Type,
+		rule.Frequency,
+		rule.Interval,
+		rule.DayOfMonth,
+		rule.Weekday,
+		rule.StartDate,
+		nullableString(rule.EndDate),
+		nullableString(rule.NextOccurrence),
+		rule.Active,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.Error("failed to create recurring rule",
+			zap.Error(err),
+			zap.String("user_id", rule.UserID),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// List lista as regras do usuário
+func (r *RecurringRepository) List(ctx context.Context, userID string) ([]*models.RecurringRule, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_recurring_rules").Observe(time.Since(start).Seconds())
+	}()
+
+	rows, err := r.db.QueryContext(ctx, recurringRuleSelect+` WHERE user_id = $1 ORDER BY active DESC, next_occurrence NULLS LAST, description`, userID)
+	if err != nil {
+		r.logger.Error("failed to list recurring rules",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []*models.RecurringRule{}
+	for rows.Next() {
+		rule, err := scanRecurringRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// FindByID busca uma regra do usuário
+func (r *RecurringRepository) FindByID(ctx context.Context, id, userID string) (*models.RecurringRule, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_recurring_rule").Observe(time.Since(start).Seconds())
+	}()
+
+	rule, err := scanRecurringRule(r.db.QueryRowContext(ctx, recurringRuleSelect+` WHERE id = $1 AND user_id = $2`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrRecurringRuleNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find recurring rule",
+			zap.Error(err),
+			zap.String("rule_id", id),
+		)
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// Update altera a regra. Só afeta ocorrências ainda não materializadas.
+func (r *RecurringRepository) Update(ctx context.Context, rule *models.RecurringRule) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("update_recurring_rule").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		UPDATE recurring_rules
+		SET account_id = $1, description = $2, amount = $3, category = $4, type = $5, frequency = $6,
+			interval = $7, day_of_month = $8, weekday = $9, start_date = $10::date, end_date = $11::date,
+			next_occurrence = $12::date, active = $13, updated_at = $14
+		WHERE id = $15 AND user_id = $16
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		rule.AccountID,
+		rule.Description,
+		rule.Amount,
+		rule.Category,
+		rule.Type,
+		rule.Frequency,
+		rule.Interval,
+		rule.DayOfMonth,
+		rule.Weekday,
+		rule.StartDate,
+		nullableString(rule.EndDate),
+		nullableString(rule.NextOccurrence),
+		rule.Active,
+		rule.UpdatedAt,
+		rule.ID,
+		rule.UserID,
+	)
+	if err != nil {
+		r.logger.Error("failed to update recurring rule",
+			zap.Error(err),
+			zap.String("rule_id", rule.ID),
+		)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecurringRuleNotFound
+	}
+
+	return nil
+}
+
+// Delete remove a regra; as transações já geradas permanecem
+func (r *RecurringRepository) Delete(ctx context.Context, id, userID string) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("delete_recurring_rule").Observe(time.Since(start).Seconds())
+	}()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM recurring_rules WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		r.logger.Error("failed to delete recurring rule",
+			zap.Error(err),
+			zap.String("rule_id", id),
+		)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecurringRuleNotFound
+	}
+
+	return nil
+}
+
+// DeleteAllByUser remove todas as regras do usuário
+func (r *RecurringRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM recurring_rules WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("failed to delete user recurring rules",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Exceptions retorna as exceções da regra entre from e to, indexadas pela data
+func (r *RecurringRepository) Exceptions(ctx context.Context, ruleID string, from, to time.Time) (map[string]*models.RecurringException, error) {
+	return loadExceptions(ctx, r.db, ruleID, from, to)
+}
+
+func loadExceptions(ctx context.Context, db dbtx, ruleID string, from, to time.Time) (map[string]*models.RecurringException, error) {
+	query := `
+		SELECT rule_id, occurrence_date, action, description, amount, category
+		FROM recurring_exceptions
+		WHERE rule_id = $1 AND occurrence_date BETWEEN $2 AND $3
+	`
+
+	rows, err := db.QueryContext(ctx, query, ruleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exceptions := make(map[string]*models.RecurringException)
+	for rows.Next() {
+		e := &models.RecurringException{}
+		var date time.Time
+		if err := rows.Scan(&e.RuleID, &date, &e.Action, &e.Description, &e.Amount, &e.Category); err != nil {
+			return nil, err
+		}
+		e.OccurrenceDate = date.Format("2006-01-02")
+		exceptions[e.OccurrenceDate] = e
+	}
+
+	return exceptions, rows.Err()
+}
+
+// MaterializedDates retorna as transações já geradas pela regra entre from e to, por data
+func (r *RecurringRepository) MaterializedDates(ctx context.Context, ruleID string, from, to time.Time) (map[string]string, error) {
+	query := `
+		SELECT recurring_date, id
+		FROM transactions
+		WHERE recurring_rule_id = $1 AND recurring_date BETWEEN $2 AND $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, ruleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	materialized := make(map[string]string)
+	for rows.Next() {
+		var date time.Time
+		var id string
+		if err := rows.Scan(&date, &id); err != nil {
+			return nil, err
+		}
+		materialized[date.Format("2006-01-02")] = id
+	}
+
+	return materialized, rows.Err()
+}
+
+// SetException pula ou altera uma ocorrência ainda não materializada
+func (r *RecurringRepository) SetException(ctx context.Context, exception *models.RecurringException) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		// Trava a regra para não concorrer com o worker
+		var id string
+		err := tx.QueryRowContext(ctx, `SELECT id FROM recurring_rules WHERE id = $1 FOR UPDATE`, exception.RuleID).Scan(&id)
+		if err == sql.ErrNoRows {
+			return ErrRecurringRuleNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var exists bool
+		err = tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM transactions WHERE recurring_rule_id = $1 AND recurring_date = $2::date)`,
+			exception.RuleID, exception.OccurrenceDate,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrOccurrenceMaterialized
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO recurring_exceptions (rule_id, occurrence_date, action, description, amount, category)
+			VALUES ($1, $2::date, $3, $4, $5, $6)
+			ON CONFLICT (rule_id, occurrence_date) DO UPDATE
+			SET action = EXCLUDED.action, description = EXCLUDED.description,
+				amount = EXCLUDED.amount, category = EXCLUDED.category, created_at = NOW()
+		`,
+			exception.RuleID,
+			exception.OccurrenceDate,
+			exception.Action,
+			exception.Description,
+			exception.Amount,
+			exception.Category,
+		)
+		return err
+	})
+}
+
+// DeleteException devolve a ocorrência aos valores da série
+func (r *RecurringRepository) DeleteException(ctx context.Context, ruleID, date string) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM recurring_exceptions WHERE rule_id = $1 AND occurrence_date = $2::date`, ruleID, date)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecurringExceptionNotFound
+	}
+
+	return nil
+}
+
+// DueRuleIDs lista as regras ativas com ocorrências até today, em contas não arquivadas
+func (r *RecurringRepository) DueRuleIDs(ctx context.Context, today time.Time, limit int) ([]string, error) {
+	query := `
+		SELECT r.id
+		FROM recurring_rules r
+		JOIN accounts a ON a.id = r.account_id
+		WHERE r.active AND r.next_occurrence <= $1 AND NOT a.archived
+		ORDER BY r.next_occurrence
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, today, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// Materialize gera as transações pendentes da regra até today, numa única
+// transação do banco. A regra é travada com SKIP LOCKED, então réplicas
+// concorrentes não processam a mesma regra; o índice único por
+// (recurring_rule_id, recurring_date) impede duplicatas em qualquer caso.
+func (r *RecurringRepository) Materialize(ctx context.Context, ruleID string, today time.Time) ([]*models.Transaction, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("materialize_recurring_rule").Observe(time.Since(start).Seconds())
+	}()
+
+	created := []*models.Transaction{}
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		rule, err := scanRecurringRule(tx.QueryRowContext(ctx,
+			recurringRuleSelect+` WHERE id = $1 AND active AND next_occurrence <= $2 FOR UPDATE SKIP LOCKED`,
+			ruleID, today,
+		))
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var currency string
+		if err := tx.QueryRowContext(ctx, `SELECT currency FROM accounts WHERE id = $1`, rule.AccountID).Scan(&currency); err != nil {
+			return err
+		}
+
+		next, err := time.Parse("2006-01-02", *rule.NextOccurrence)
+		if err != nil {
+			return err
+		}
+
+		exceptions, err := loadExceptions(ctx, tx, rule.ID, next, today)
+		if err != nil {
+			return err
+		}
+
+		for _, date := range rule.Occurrences(next, today, 0) {
+			occurrence := rule.Apply(date, exceptions[date.Format("2006-01-02")])
+			if occurrence.Status == models.OccurrenceSkipped {
+				continue
+			}
+
+			var exists bool
+			err := tx.QueryRowContext(ctx,
+				`SELECT EXISTS (SELECT 1 FROM transactions WHERE recurring_rule_id = $1 AND recurring_date = $2)`,
+				rule.ID, date,
+			).Scan(&exists)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+
+			ruleID, recurringDate := rule.ID, occurrence.Date
+			now := time.Now()
+			transaction := &models.Transaction{
+				ID:              uuid.New().String(),
+				UserID:          rule.UserID,
+				AccountID:       rule.AccountID,
+				Description:     occurrence.Description,
+				Amount:          occurrence.Amount,
+				Currency:        currency,
+				Category:        occurrence.Category,
+				Type:            rule.Type,
+				Date:            date,
+				RecurringRuleID: &ruleID,
+				RecurringDate:   &recurringDate,
+				CreatedAt:       now,
+				UpdatedAt:       now,
+			}
+
+			if err := insertTransaction(ctx, tx, transaction); err != nil {
+				return err
+			}
+			if err := postTransaction(ctx, tx, transaction); err != nil {
+				return err
+			}
+			created = append(created, transaction)
+		}
+
+		// Avança para a primeira ocorrência depois de hoje (NULL se a série acabou)
+		var nextOccurrence sql.NullTime
+		if upcoming, ok := rule.FirstOccurrence(today.AddDate(0, 0, 1)); ok {
+			nextOccurrence = sql.NullTime{Time: upcoming, Valid: true}
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE recurring_rules SET next_occurrence = $1 WHERE id = $2`, nextOccurrence, rule.ID)
+		return err
+	})
+
+	if err != nil {
+		r.logger.Error("failed to materialize recurring rule",
+			zap.Error(err),
+			zap.String("rule_id", ruleID),
+		)
+		return nil, err
+	}
+
+	return created, nil
+}
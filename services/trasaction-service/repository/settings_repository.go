@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// SettingsRepository mantém as preferências do usuário neste serviço
+type SettingsRepository struct {
+	db              *sql.DB
+	defaultCurrency string
+	defaultTimezone string
+	logger          *zap.Logger
+}
+
+func NewSettingsRepository(db *sql.DB, defaultCurrency, defaultTimezone string, logger *zap.Logger) *SettingsRepository {
+	return &SettingsRepository{
+		db:              db,
+		defaultCurrency: defaultCurrency,
+		defaultTimezone: defaultTimezone,
+		logger:          logger,
+	}
+}
+
+// Get retorna as preferências do usuário; quem nunca as alterou recebe a moeda
+// e o fuso padrão
+func (r *SettingsRepository) Get(ctx context.Context, userID string) (*models.UserSettings, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_user_settings").Observe(time.Since(start).Seconds())
+	}()
+
+	settings := &models.UserSettings{UserID: userID, Timezone: r.defaultTimezone}
+	var timezone sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		`SELECT base_currency, timezone, updated_at FROM user_settings WHERE user_id = $1`,
+		userID,
+	).Scan(&settings.BaseCurrency, &timezone, &settings.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		settings.BaseCurrency = r.defaultCurrency
+		return settings, nil
+	}
+
+	if err != nil {
+		r.logger.Error("failed to get user settings",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+
+	if timezone.Valid {
+		settings.Timezone = timezone.String
+	}
+
+	return settings, nil
+}
+
+// BaseCurrency retorna apenas a moeda base do usuário
+func (r *SettingsRepository) BaseCurrency(ctx context.Context, userID string) (string, error) {
+	settings, err := r.Get(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return settings.BaseCurrency, nil
+}
+
+// Timezone retorna apenas o fuso horário do usuário
+func (r *SettingsRepository) Timezone(ctx context.Context, userID string) (string, error) {
+	settings, err := r.Get(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return settings.Timezone, nil
+}
+
+// Update altera a moeda base e/ou o fuso horário do usuário; vazio mantém o
+// valor atual. O fuso é validado pelo próprio PostgreSQL, que faz as contas.
+func (r *SettingsRepository) Update(ctx context.Context, userID, baseCurrency, timezone string) (*models.UserSettings, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("upsert_user_settings").Observe(time.Since(start).Seconds())
+	}()
+
+	if timezone != "" {
+		if _, err := r.db.ExecContext(ctx, `SELECT NOW() AT TIME ZONE $1`, timezone); err != nil {
+			if isInvalidTimezone(err) {
+				return nil, ErrInvalidTimezone
+			}
+			return nil, err
+		}
+	}
+
+	query := `
+		INSERT INTO user_settings (user_id, base_currency, timezone)
+		VALUES ($1, COALESCE(NULLIF($2, ''), $4), NULLIF($3, ''))
+		ON CONFLICT (user_id) DO UPDATE SET
+			base_currency = COALESCE(NULLIF($2, ''), user_settings.base_currency),
+			timezone = COALESCE(NULLIF($3, ''), user_settings.timezone)
+		RETURNING base_currency, timezone, updated_at
+	`
+
+	settings := &models.UserSettings{UserID: userID, Timezone: r.defaultTimezone}
+	var stored sql.NullString
+	err := r.db.QueryRowContext(ctx, query, userID, baseCurrency, timezone, r.defaultCurrency).
+		Scan(&settings.BaseCurrency, &stored, &settings.UpdatedAt)
+	if err != nil {
+		r.logger.Error("failed to update user settings",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+
+	if stored.Valid {
+		settings.Timezone = stored.String
+	}
+
+	return settings, nil
+}
+
+// isInvalidTimezone reconhece o erro do PostgreSQL para fuso desconhecido
+func isInvalidTimezone(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "22023"
+}
+
+// DeleteByUser apaga as preferências do usuário (exclusão de conta)
+func (r *SettingsRepository) DeleteByUser(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM user_settings WHERE user_id = $1`, userID)
+	return err
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSplitsUnbalanced = errors.New("splits must sum to the transaction amount")
+)
+
+// ensureSplitCategories resolve pelo nome a categoria das linhas que ainda não
+// têm uma, como ensureTransactionCategory faz com a transação
+func ensureSplitCategories(ctx context.Context, db dbtx, transaction *models.Transaction) error {
+	for i := range transaction.Splits {
+		split := &transaction.Splits[i]
+		if split.CategoryID != nil {
+			continue
+		}
+
+		if err := ensureDefaultCategories(ctx, db, transaction.UserID); err != nil {
+			return err
+		}
+
+		category, err := upsertCategory(ctx, db, &models.Category{
+			UserID: transaction.UserID,
+			Name:   split.Category,
+			Type:   transaction.Type,
+		})
+		if err != nil {
+			return err
+		}
+
+		split.CategoryID = &category.ID
+		split.Category = category.Name
+	}
+	return nil
+}
+
+// setTransactionSplits troca as linhas da transação pelas de transaction.Splits;
+// sem linhas, a transação deixa de estar dividida
+func setTransactionSplits(ctx context.Context, db dbtx, transaction *models.Transaction) error {
+	if len(transaction.Splits) > 0 && models.SplitsTotal(transaction.Splits) != transaction.Amount {
+		return ErrSplitsUnbalanced
+	}
+
+	if err := ensureSplitCategories(ctx, db, transaction); err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM transaction_splits WHERE transaction_id = $1`, transaction.ID); err != nil {
+		return err
+	}
+
+	for i, split := range transaction.Splits {
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO transaction_splits (transaction_id, line, category, category_id, amount, note)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, transaction.ID, i+1, split.Category, split.CategoryID, split.Amount, split.Note)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadTransactionSplits preenche Splits das transações numa só consulta
+func loadTransactionSplits(ctx context.Context, db dbtx, transactions []*models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(transactions))
+	byID := make(map[string]*models.Transaction, len(transactions))
+	for _, t := range transactions {
+		t.Splits = nil
+		ids = append(ids, t.ID)
+		byID[t.ID] = t
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT transaction_id, category, category_id, amount, note
+		FROM transaction_splits
+		WHERE transaction_id = ANY($1::uuid[])
+		ORDER BY transaction_id, line
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var split models.TransactionSplit
+		if err := rows.Scan(&id, &split.Category, &split.CategoryID, &split.Amount, &split.Note); err != nil {
+			return err
+		}
+		if t, ok := byID[id]; ok {
+			t.Splits = append(t.Splits, split)
+		}
+	}
+
+	return rows.Err()
+}
+
+// SetSplits divide a transação entre as linhas de transaction.Splits, ou desfaz
+// a divisão se não houver linhas. O lançamento anterior é estornado e um novo é
+// gravado com uma partida por linha. Se o valor da transação mudou desde que as
+// linhas foram validadas, devolve ErrSplitsUnbalanced.
+func (r *TransactionRepository) SetSplits(ctx context.Context, transaction *models.Transaction) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("update_transaction_splits").Observe(time.Since(start).Seconds())
+	}()
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE transactions SET updated_at = $1
+			WHERE id = $2 AND user_id = $3 AND type <> 'transfer' AND amount = $4
+		`, transaction.UpdatedAt, transaction.ID, transaction.UserID, transaction.Amount)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			// Uma edição concorrente pode ter mudado o valor depois da validação
+			var exists bool
+			err := tx.QueryRowContext(ctx, `
+				SELECT EXISTS (SELECT 1 FROM transactions WHERE id = $1 AND user_id = $2 AND type <> 'transfer')
+			`, transaction.ID, transaction.UserID).Scan(&exists)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return ErrSplitsUnbalanced
+			}
+			return ErrTransactionNotFound
+		}
+
+		if err := setTransactionSplits(ctx, tx, transaction); err != nil {
+			return err
+		}
+
+		if err := reverseSource(ctx, tx, transaction.UserID, "transaction", transaction.ID); err != nil {
+			return err
+		}
+		return postTransaction(ctx, tx, transaction)
+	})
+
+	if err != nil && err != ErrTransactionNotFound && err != ErrSplitsUnbalanced {
+		r.logger.Error("failed to update transaction splits",
+			zap.Error(err),
+			zap.String("id", transaction.ID),
+		)
+	}
+
+	return err
+}
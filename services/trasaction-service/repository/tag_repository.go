@@ -0,0 +1,517 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrTagAlreadyExists = errors.New("tag with this name already exists")
+)
+
+type TagRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewTagRepository(db *sql.DB, logger *zap.Logger) *TagRepository {
+	return &TagRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const tagSelect = `
+	SELECT g.id, g.user_id, g.name, g.color,
+		(SELECT COUNT(*) FROM transaction_tags tt WHERE tt.tag_id = g.id),
+		g.created_at, g.updated_at
+	FROM tags g
+`
+
+func scanTag(row rowScanner) (*models.Tag, error) {
+	t := &models.Tag{}
+	err := row.Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Name,
+		&t.Color,
+		&t.TransactionCount,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// upsertTag devolve o id e o nome da tag do usuário equivalente a name,
+// criando-a se ainda não existir
+func upsertTag(ctx context.Context, db dbtx, userID, name string) (string, string, error) {
+	var id, canonical string
+	err := db.QueryRowContext(ctx, `
+		WITH inserted AS (
+			INSERT INTO tags (user_id, name)
+			VALUES ($1, btrim($2))
+			ON CONFLICT DO NOTHING
+			RETURNING id, name
+		)
+		SELECT id, name FROM inserted
+		UNION ALL
+		SELECT id, name FROM tags WHERE user_id = $1 AND category_key(name) = category_key($2)
+		LIMIT 1
+	`, userID, name).Scan(&id, &canonical)
+	return id, canonical, err
+}
+
+// setTransactionTags troca as tags da transação pelas de transaction.Tags,
+// criando as que o usuário ainda não tem, e grava em Tags os nomes existentes
+func setTransactionTags(ctx context.Context, db dbtx, transaction *models.Transaction) error {
+	ids := []string{}
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, name := range transaction.Tags {
+		id, canonical, err := upsertTag(ctx, db, transaction.UserID, name)
+		if err != nil {
+			return err
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		names = append(names, canonical)
+	}
+
+	_, err := db.ExecContext(ctx,
+		`DELETE FROM transaction_tags WHERE transaction_id = $1 AND tag_id <> ALL($2::uuid[])`,
+		transaction.ID, pq.Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO transaction_tags (transaction_id, tag_id)
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING
+	`, transaction.ID, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(names)
+	transaction.Tags = names
+	return nil
+}
+
+// loadTransactionTags preenche Tags das transações numa só consulta
+func loadTransactionTags(ctx context.Context, db dbtx, transactions []*models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*models.Transaction, len(transactions))
+	ids := make([]string, 0, len(transactions))
+	for _, t := range transactions {
+		t.Tags = nil
+		byID[t.ID] = t
+		ids = append(ids, t.ID)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT tt.transaction_id, g.name
+		FROM transaction_tags tt
+		JOIN tags g ON g.id = tt.tag_id
+		WHERE tt.transaction_id = ANY($1::uuid[])
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return err
+		}
+		if t, ok := byID[id]; ok {
+			t.Tags = append(t.Tags, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range transactions {
+		sort.Strings(t.Tags)
+	}
+	return nil
+}
+
+// List lista as tags do usuário com o número de transações de cada uma
+func (r *TagRepository) List(ctx context.Context, userID string) ([]*models.Tag, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_tags").Observe(time.Since(start).Seconds())
+	}()
+
+	rows, err := r.db.QueryContext(ctx, tagSelect+` WHERE g.user_id = $1 ORDER BY g.name`, userID)
+	if err != nil {
+		r.logger.Error("failed to list tags",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*models.Tag{}
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// FindByID busca uma tag do usuário
+func (r *TagRepository) FindByID(ctx context.Context, id, userID string) (*models.Tag, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_tag_by_id").Observe(time.Since(start).Seconds())
+	}()
+
+	tag, err := scanTag(r.db.QueryRowContext(ctx, tagSelect+` WHERE g.id = $1 AND g.user_id = $2`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrTagNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find tag",
+			zap.Error(err),
+			zap.String("id", id),
+			zap.String("user_id", userID),
+		)
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// Create cria uma tag
+func (r *TagRepository) Create(ctx context.Context, tag *models.Tag) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("insert_tag").Observe(time.Since(start).Seconds())
+	}()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO tags (id, user_id, name, color, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`,
+		tag.ID,
+		tag.UserID,
+		tag.Name,
+		tag.Color,
+		tag.CreatedAt,
+		tag.UpdatedAt,
+	)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrTagAlreadyExists
+	}
+
+	if err != nil {
+		r.logger.Error("failed to create tag",
+			zap.Error(err),
+			zap.String("user_id", tag.UserID),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// TransactionTagChange é a mudança nas tags de uma transação numa edição em lote
+type TransactionTagChange struct {
+	Transaction *models.Transaction // com as tags depois da mudança
+	Added       []string
+	Removed     []string
+}
+
+// Update renomeia a tag ou muda a sua cor; as transações marcadas passam a
+// mostrar o novo nome. Devolve as transações cujas tags mudaram com o novo nome.
+func (r *TagRepository) Update(ctx context.Context, tag *models.Tag) ([]*TransactionTagChange, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("update_tag").Observe(time.Since(start).Seconds())
+	}()
+
+	var changes []*TransactionTagChange
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		changes, err = changeTaggedTransactions(ctx, tx, tag.UserID, tag.ID, func() error {
+			result, err := tx.ExecContext(ctx, `
+				UPDATE tags SET name = $1, color = $2, updated_at = $3
+				WHERE id = $4 AND user_id = $5
+			`,
+				tag.Name,
+				tag.Color,
+				tag.UpdatedAt,
+				tag.ID,
+				tag.UserID,
+			)
+			if err != nil {
+				return err
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if rowsAffected == 0 {
+				return ErrTagNotFound
+			}
+			return nil
+		})
+		return err
+	})
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, ErrTagAlreadyExists
+	}
+
+	if err != nil && err != ErrTagNotFound {
+		r.logger.Error("failed to update tag",
+			zap.Error(err),
+			zap.String("id", tag.ID),
+		)
+	}
+
+	return changes, err
+}
+
+// Delete apaga a tag e a desmarca de todas as transações. Devolve as transações
+// que estavam marcadas com ela.
+func (r *TagRepository) Delete(ctx context.Context, id, userID string) ([]*TransactionTagChange, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("delete_tag").Observe(time.Since(start).Seconds())
+	}()
+
+	var changes []*TransactionTagChange
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		changes, err = changeTaggedTransactions(ctx, tx, userID, id, func() error {
+			result, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE id = $1 AND user_id = $2`, id, userID)
+			if err != nil {
+				return err
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if rowsAffected == 0 {
+				return ErrTagNotFound
+			}
+			return nil
+		})
+		return err
+	})
+
+	if err != nil && err != ErrTagNotFound {
+		r.logger.Error("failed to delete tag",
+			zap.Error(err),
+			zap.String("id", id),
+		)
+	}
+
+	return changes, err
+}
+
+// changeTaggedTransactions trava as transações marcadas com a tag, aplica change
+// e devolve as que tiveram as tags mudadas, como em BulkUpdate
+func changeTaggedTransactions(ctx context.Context, tx *sql.Tx, userID, tagID string, change func() error) ([]*TransactionTagChange, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE user_id = $1 AND id IN (SELECT transaction_id FROM transaction_tags WHERE tag_id = $2)
+		ORDER BY id
+		FOR UPDATE
+	`, userID, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := []*models.Transaction{}
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadTransactionTags(ctx, tx, transactions); err != nil {
+		return nil, err
+	}
+	before := make(map[string][]string, len(transactions))
+	for _, t := range transactions {
+		before[t.ID] = t.Tags
+	}
+
+	if err := change(); err != nil {
+		return nil, err
+	}
+
+	if err := loadTransactionTags(ctx, tx, transactions); err != nil {
+		return nil, err
+	}
+
+	changes := []*TransactionTagChange{}
+	for _, t := range transactions {
+		added, removed := models.DiffTags(before[t.ID], t.Tags)
+		if len(added) > 0 || len(removed) > 0 {
+			changes = append(changes, &TransactionTagChange{Transaction: t, Added: added, Removed: removed})
+		}
+	}
+	return changes, nil
+}
+
+// BulkUpdate marca as transações com as tags de add, criando as que não
+// existem, e desmarca as de remove. Todas as transações precisam ser do
+// usuário. Devolve só as transações cujas tags mudaram.
+func (r *TagRepository) BulkUpdate(ctx context.Context, userID string, transactionIDs, add, remove []string) ([]*TransactionTagChange, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("bulk_update_transaction_tags").Observe(time.Since(start).Seconds())
+	}()
+
+	changes := []*TransactionTagChange{}
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT `+transactionColumns+`
+			FROM transactions
+			WHERE user_id = $1 AND id = ANY($2::uuid[])
+			ORDER BY id
+			FOR UPDATE
+		`, userID, pq.Array(transactionIDs))
+		if err != nil {
+			return err
+		}
+
+		transactions := []*models.Transaction{}
+		for rows.Next() {
+			t, err := scanTransaction(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			transactions = append(transactions, t)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(transactions) != len(transactionIDs) {
+			return ErrTransactionNotFound
+		}
+
+		if err := loadTransactionTags(ctx, tx, transactions); err != nil {
+			return err
+		}
+		before := make(map[string][]string, len(transactions))
+		for _, t := range transactions {
+			before[t.ID] = t.Tags
+		}
+
+		tagIDs := []string{}
+		for _, name := range add {
+			id, _, err := upsertTag(ctx, tx, userID, name)
+			if err != nil {
+				return err
+			}
+			tagIDs = append(tagIDs, id)
+		}
+
+		if len(tagIDs) > 0 {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO transaction_tags (transaction_id, tag_id)
+				SELECT t, g FROM unnest($1::uuid[]) t, unnest($2::uuid[]) g
+				ON CONFLICT DO NOTHING
+			`, pq.Array(transactionIDs), pq.Array(tagIDs))
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(remove) > 0 {
+			_, err := tx.ExecContext(ctx, `
+				DELETE FROM transaction_tags tt
+				USING tags g
+				WHERE g.id = tt.tag_id AND g.user_id = $1
+					AND tt.transaction_id = ANY($2::uuid[])
+					AND category_key(g.name) IN (SELECT category_key(name) FROM unnest($3::text[]) name)
+			`, userID, pq.Array(transactionIDs), pq.Array(remove))
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := loadTransactionTags(ctx, tx, transactions); err != nil {
+			return err
+		}
+		for _, t := range transactions {
+			added, removed := models.DiffTags(before[t.ID], t.Tags)
+			if len(added) > 0 || len(removed) > 0 {
+				changes = append(changes, &TransactionTagChange{Transaction: t, Added: added, Removed: removed})
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil && err != ErrTransactionNotFound {
+		r.logger.Error("failed to bulk update transaction tags",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+	}
+
+	return changes, err
+}
+
+// DeleteAllByUser apaga as tags do usuário
+func (r *TagRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tags WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("failed to delete user tags",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
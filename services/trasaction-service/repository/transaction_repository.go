@@ -5,12 +5,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"transaction-service/metrics"
 	"transaction-service/models"
+	"transaction-service/money"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -24,9 +26,228 @@ type TransactionRepository struct {
 }
 
 type TransactionFilters struct {
-	UserID   string
-	Type     string
-	Category string
+	UserID     string
+	AccountID  string
+	Type       string
+	Categories []string      // qualquer uma das categorias, da transação ou de uma linha da divisão
+	StartDate  *time.Time    // inclusive
+	EndDate    *time.Time    // inclusive, o dia inteiro
+	MinAmount  *money.Amount // valor absoluto, inclusive
+	MaxAmount  *money.Amount
+	Search     string   // busca textual na descrição, sem diferenciar acentos
+	Tags       []string // nomes das tags, sem diferenciar maiúsculas e acentos
+	AllTags    bool     // exige todas as tags; sem ele basta uma
+}
+
+// where monta a condição dos filtros sobre transactions e os argumentos
+func (f TransactionFilters) where() (string, []interface{}) {
+	where := `user_id = $1`
+	args := []interface{}{f.UserID}
+
+	if f.Type != "" {
+		args = append(args, f.Type)
+		where += fmt.Sprintf(` AND type = $%d`, len(args))
+	}
+
+	if len(f.Categories) > 0 {
+		args = append(args, pq.Array(f.Categories))
+		where += fmt.Sprintf(` AND (category = ANY($%d) OR EXISTS (
+			SELECT 1 FROM transaction_splits s WHERE s.transaction_id = transactions.id AND s.category = ANY($%d)))`, len(args), len(args))
+	}
+
+	if f.AccountID != "" {
+		args = append(args, f.AccountID)
+		where += fmt.Sprintf(` AND account_id = $%d`, len(args))
+	}
+
+	if f.StartDate != nil {
+		args = append(args, *f.StartDate)
+		where += fmt.Sprintf(` AND date >= $%d::date`, len(args))
+	}
+
+	if f.EndDate != nil {
+		args = append(args, *f.EndDate)
+		where += fmt.Sprintf(` AND date < $%d::date + 1`, len(args))
+	}
+
+	if f.MinAmount != nil {
+		args = append(args, *f.MinAmount)
+		where += fmt.Sprintf(` AND amount >= $%d`, len(args))
+	}
+
+	if f.MaxAmount != nil {
+		args = append(args, *f.MaxAmount)
+		where += fmt.Sprintf(` AND amount <= $%d`, len(args))
+	}
+
+	// Mesma configuração da coluna gerada, para que o índice GIN seja usado
+	if f.Search != "" {
+		args = append(args, f.Search)
+		where += fmt.Sprintf(` AND description_search @@ websearch_to_tsquery('public.portuguese_unaccent', $%d)`, len(args))
+	}
+
+	if len(f.Tags) > 0 {
+		args = append(args, pq.Array(f.Tags))
+		tagged := fmt.Sprintf(`
+			FROM transaction_tags tt
+			JOIN tags g ON g.id = tt.tag_id
+			WHERE tt.transaction_id = transactions.id
+				AND category_key(g.name) IN (SELECT category_key(name) FROM unnest($%d::text[]) name)`, len(args))
+		if f.AllTags {
+			where += fmt.Sprintf(` AND (SELECT COUNT(DISTINCT g.id) %s) = (SELECT COUNT(DISTINCT category_key(name)) FROM unnest($%d::text[]) name)`, tagged, len(args))
+		} else {
+			where += ` AND EXISTS (SELECT 1 ` + tagged + `)`
+		}
+	}
+
+	return where, args
+}
+
+// Campos de ordenação da listagem
+const (
+	SortByDate     = "date"
+	SortByAmount   = "amount"
+	SortByCategory = "category"
+)
+
+// TransactionSort é a ordenação da listagem; o padrão é a data mais recente primeiro
+type TransactionSort struct {
+	Field string
+	Desc  bool
+}
+
+// direction é a direção da ordenação; reverse a inverte (página anterior)
+func (s TransactionSort) direction(reverse bool) string {
+	if s.Desc != reverse {
+		return `DESC`
+	}
+	return `ASC`
+}
+
+// keyColumns são as colunas da ordenação. Todas seguem a mesma direção e
+// terminam em (date, created_at, id), o que deixa a ordem estável entre as
+// páginas e permite comparar a chave inteira de uma vez.
+func (s TransactionSort) keyColumns() []string {
+	switch s.Field {
+	case SortByAmount:
+		return []string{`amount`, `date`, `created_at`, `id`}
+	case SortByCategory:
+		return []string{`category`, `date`, `created_at`, `id`}
+	default:
+		return []string{`date`, `created_at`, `id`}
+	}
+}
+
+// orderBy monta o ORDER BY a partir de campos conhecidos
+func (s TransactionSort) orderBy(reverse bool) string {
+	columns := s.keyColumns()
+	for i := range columns {
+		columns[i] += ` ` + s.direction(reverse)
+	}
+	return strings.Join(columns, `, `)
+}
+
+// TransactionKey é a posição de uma transação na ordenação da listagem.
+// Value só é usado quando a ordenação é por valor ou categoria.
+type TransactionKey struct {
+	Value     string    `json:"v,omitempty"`
+	Date      time.Time `json:"d"`
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+// Key devolve a posição da transação na ordenação
+func (s TransactionSort) Key(t *models.Transaction) TransactionKey {
+	key := TransactionKey{Date: t.Date, CreatedAt: t.CreatedAt, ID: t.ID}
+	switch s.Field {
+	case SortByAmount:
+		key.Value = t.Amount.String()
+	case SortByCategory:
+		key.Value = t.Category
+	}
+	return key
+}
+
+// args são os valores da chave na ordem de keyColumns
+func (k TransactionKey) args(sort TransactionSort) []interface{} {
+	args := []interface{}{k.Date, k.CreatedAt, k.ID}
+	if sort.Field == SortByAmount || sort.Field == SortByCategory {
+		args = append([]interface{}{k.Value}, args...)
+	}
+	return args
+}
+
+// StatsFilters delimita as transações consideradas em GetStats
+type StatsFilters struct {
+	UserID       string
+	AccountID    string // vazio considera todas as contas
+	BaseCurrency string
+}
+
+// Colunas lidas por scanTransaction, na mesma ordem
+const transactionColumns = `id, user_id, account_id, description, amount, currency, category, category_id, type, date, transfer_id, transfer_side, recurring_rule_id, recurring_date, external_id, import_batch_id, created_at, updated_at`
+
+// signedAmountSQL é o efeito de uma transação (alias t) no saldo da sua conta
+const signedAmountSQL = `CASE WHEN t.type = 'income' OR t.transfer_side = 'in' THEN t.amount ELSE -t.amount END`
+
+// rowScanner abstrai *sql.Row e *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// withTx executa fn numa transação do banco, com commit se fn não retornar erro
+func withTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// dbtx abstrai *sql.DB e *sql.Tx para operações que podem rodar dentro de uma transação
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
+	t := &models.Transaction{}
+	var recurringDate sql.NullTime
+	err := row.Scan(
+		&t.ID,
+		&t.UserID,
+		&t.AccountID,
+		&t.Description,
+		&t.Amount,
+		&t.Currency,
+		&t.Category,
+		&t.CategoryID,
+		&t.Type,
+		&t.Date,
+		&t.TransferID,
+		&t.TransferSide,
+		&t.RecurringRuleID,
+		&recurringDate,
+		&t.ExternalID,
+		&t.ImportBatchID,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if recurringDate.Valid {
+		value := recurringDate.Time.Format("2006-01-02")
+		t.RecurringDate = &value
+	}
+	return t, nil
 }
 
 func NewPostgresConnection(databaseURL string) (*sql.DB, error) {
@@ -55,76 +276,98 @@ func NewTransactionRepository(db *sql.DB, logger *zap.Logger) *TransactionReposi
 	}
 }
 
-// Create cria uma nova transação
+// Create cria uma nova transação e o seu lançamento no razão
 func (r *TransactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
 	start := time.Now()
 	defer func() {
 		metrics.DatabaseQueryDuration.WithLabelValues("insert_transaction").Observe(time.Since(start).Seconds())
 	}()
 
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := insertTransaction(ctx, tx, transaction); err != nil {
+			return err
+		}
+		return postTransaction(ctx, tx, transaction)
+	})
+
+	if err != nil {
+		r.logger.Error("failed to create transaction",
+			zap.Error(err),
+			zap.String("transaction_id", transaction.ID),
+			zap.String("user_id", transaction.UserID),
+		)
+		return err
+	}
+
+	// Atualiza métrica de valor
+	metrics.TransactionAmount.WithLabelValues(transaction.Type).Observe(transaction.Amount.Float64())
+
+	return nil
+}
+
+func insertTransaction(ctx context.Context, db dbtx, transaction *models.Transaction) error {
+	if err := ensureTransactionCategory(ctx, db, transaction); err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO transactions (id, user_id, description, amount, category, type, date, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO transactions (` + transactionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14::date, $15, $16, $17, $18)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := db.ExecContext(ctx, query,
 		transaction.ID,
 		transaction.UserID,
+		transaction.AccountID,
 		transaction.Description,
 		transaction.Amount,
+		transaction.Currency,
 		transaction.Category,
+		transaction.CategoryID,
 		transaction.Type,
 		transaction.Date,
+		transaction.TransferID,
+		transaction.TransferSide,
+		transaction.RecurringRuleID,
+		transaction.RecurringDate,
+		transaction.ExternalID,
+		transaction.ImportBatchID,
 		transaction.CreatedAt,
 		transaction.UpdatedAt,
 	)
-
 	if err != nil {
-		r.logger.Error("failed to create transaction",
-			zap.Error(err),
-			zap.String("transaction_id", transaction.ID),
-			zap.String("user_id", transaction.UserID),
-		)
 		return err
 	}
 
-	// Atualiza métrica de valor
-	metrics.TransactionAmount.WithLabelValues(transaction.Type).Observe(transaction.Amount)
+	if len(transaction.Tags) > 0 {
+		if err := setTransactionTags(ctx, db, transaction); err != nil {
+			return err
+		}
+	}
+
+	if len(transaction.Splits) > 0 {
+		return setTransactionSplits(ctx, db, transaction)
+	}
 
 	return nil
 }
 
-// List lista transações com filtros e paginação
-func (r *TransactionRepository) List(ctx context.Context, filters TransactionFilters, page, pageSize int) ([]*models.Transaction, int, error) {
+// List lista transações com filtros e paginação por número de página
+func (r *TransactionRepository) List(ctx context.Context, filters TransactionFilters, sort TransactionSort, page, pageSize int) ([]*models.Transaction, int, error) {
 	start := time.Now()
 	defer func() {
 		metrics.DatabaseQueryDuration.WithLabelValues("select_transactions").Observe(time.Since(start).Seconds())
 	}()
 
-	// Query base
+	// Query base com os filtros opcionais
+	where, args := filters.where()
 	query := `
-		SELECT id, user_id, description, amount, category, type, date, created_at, updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
-		WHERE user_id = $1
-	`
-	args := []interface{}{filters.UserID}
-	argCount := 1
+		WHERE ` + where
+	argCount := len(args)
 
-	// Aplica filtros opcionais
-	if filters.Type != "" {
-		argCount++
-		query += ` AND type = $` + fmt.Sprintf("%d", argCount)
-		args = append(args, filters.Type)
-	}
-
-	if filters.Category != "" {
-		argCount++
-		query += ` AND category = $` + fmt.Sprintf("%d", argCount)
-		args = append(args, filters.Category)
-	}
-
-	// Ordena por data mais recente
-	query += ` ORDER BY date DESC, created_at DESC`
+	query += ` ORDER BY ` + sort.orderBy(false)
 
 	// Paginação
 	offset := (page - 1) * pageSize
@@ -136,60 +379,373 @@ func (r *TransactionRepository) List(ctx context.Context, filters TransactionFil
 	query += ` OFFSET $` + fmt.Sprintf("%d", argCount)
 	args = append(args, offset)
 
-	// Executa query
+	transactions, err := r.query(ctx, filters.UserID, query, args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := r.Count(ctx, filters)
+	if err != nil {
+		return transactions, 0, err
+	}
+
+	return transactions, total, nil
+}
+
+// ListPage lista até limit transações logo depois de after na ordenação (ou
+// logo antes, com backward), sem OFFSET: o custo não cresce com a posição e
+// inserções durante a navegação não repetem nem pulam linhas. Sem after, a
+// página é a primeira (ou a última, com backward). more indica se há mais
+// transações na direção percorrida. O resultado está sempre na ordem de sort.
+func (r *TransactionRepository) ListPage(ctx context.Context, filters TransactionFilters, sort TransactionSort, after *TransactionKey, backward bool, limit int) ([]*models.Transaction, bool, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_transactions_page").Observe(time.Since(start).Seconds())
+	}()
+
+	where, args := filters.where()
+	if after != nil {
+		// Comparação de linha: (a, b, c) > ($1, $2, $3) segue a ordem lexicográfica
+		placeholders := []string{}
+		for _, value := range after.args(sort) {
+			args = append(args, value)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+
+		operator := `>`
+		if sort.direction(backward) == `DESC` {
+			operator = `<`
+		}
+		where += fmt.Sprintf(` AND (%s) %s (%s)`,
+			strings.Join(sort.keyColumns(), `, `), operator, strings.Join(placeholders, `, `))
+	}
+
+	// Uma linha a mais só para saber se existe outra página
+	args = append(args, limit+1)
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE ` + where + `
+		ORDER BY ` + sort.orderBy(backward) + `
+		LIMIT $` + fmt.Sprintf("%d", len(args))
+
+	transactions, err := r.query(ctx, filters.UserID, query, args)
+	if err != nil {
+		return nil, false, err
+	}
+
+	more := len(transactions) > limit
+	if more {
+		transactions = transactions[:limit]
+	}
+
+	if backward {
+		for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+			transactions[i], transactions[j] = transactions[j], transactions[i]
+		}
+	}
+
+	return transactions, more, nil
+}
+
+// Count conta as transações dos filtros
+func (r *TransactionRepository) Count(ctx context.Context, filters TransactionFilters) (int, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("count_transactions").Observe(time.Since(start).Seconds())
+	}()
+
+	where, args := filters.where()
+	query := `
+		SELECT COUNT(*)
+		FROM transactions
+		WHERE ` + where
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		r.logger.Error("failed to count transactions", zap.Error(err))
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// query executa uma listagem de transações
+func (r *TransactionRepository) query(ctx context.Context, userID, query string, args []interface{}) ([]*models.Transaction, error) {
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("failed to list transactions",
 			zap.Error(err),
-			zap.String("user_id", filters.UserID),
+			zap.String("user_id", userID),
 		)
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
-	// Processa resultados
 	transactions := []*models.Transaction{}
 	for rows.Next() {
-		t := &models.Transaction{}
-		err := rows.Scan(
-			&t.ID,
-			&t.UserID,
-			&t.Description,
-			&t.Amount,
-			&t.Category,
-			&t.Type,
-			&t.Date,
-			&t.CreatedAt,
-			&t.UpdatedAt,
-		)
+		t, err := scanTransaction(rows)
 		if err != nil {
 			r.logger.Error("failed to scan transaction", zap.Error(err))
 			continue
 		}
 		transactions = append(transactions, t)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	// Conta total de registros
-	countQuery := `
-		SELECT COUNT(*)
+	if err := loadTransactionTags(ctx, r.db, transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, loadTransactionSplits(ctx, r.db, transactions)
+}
+
+// ListAllByUser retorna todas as transações do usuário (usado na exportação de dados pessoais)
+func (r *TransactionRepository) ListAllByUser(ctx context.Context, userID string) ([]*models.Transaction, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_all_user_transactions").Observe(time.Since(start).Seconds())
+	}()
+
+	query := `
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE user_id = $1
+		ORDER BY date, created_at
 	`
-	countArgs := []interface{}{filters.UserID}
 
-	if filters.Type != "" {
-		countQuery += ` AND type = $2`
-		countArgs = append(countArgs, filters.Type)
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.Error("failed to list user transactions",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return nil, err
 	}
+	defer rows.Close()
 
-	var total int
-	err = r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total)
+	transactions := []*models.Transaction{}
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadTransactionTags(ctx, r.db, transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, loadTransactionSplits(ctx, r.db, transactions)
+}
+
+// exportFetchSize é quantas linhas o cursor da exportação traz por vez
+const exportFetchSize = 500
+
+// Export percorre as transações dos filtros em ordem cronológica com um cursor no
+// servidor, chamando fn para cada uma sem acumular o resultado em memória. Com
+// byAccount, as transações vêm agrupadas por conta. Um erro de fn interrompe a leitura.
+func (r *TransactionRepository) Export(ctx context.Context, filters TransactionFilters, byAccount bool, fn func(*models.Transaction) error) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("export_transactions").Observe(time.Since(start).Seconds())
+	}()
+
+	where, args := filters.where()
+	order := `date, created_at, id`
+	if byAccount {
+		order = `account_id, ` + order
+	}
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			DECLARE transactions_export NO SCROLL CURSOR FOR
+			SELECT `+transactionColumns+`
+			FROM transactions
+			WHERE `+where+`
+			ORDER BY `+order, args...)
+		if err != nil {
+			r.logger.Error("failed to open export cursor",
+				zap.Error(err),
+				zap.String("user_id", filters.UserID),
+			)
+			return err
+		}
+
+		for {
+			rows, err := tx.QueryContext(ctx, fmt.Sprintf(`FETCH FORWARD %d FROM transactions_export`, exportFetchSize))
+			if err != nil {
+				return err
+			}
+
+			batch := []*models.Transaction{}
+			for rows.Next() {
+				t, err := scanTransaction(rows)
+				if err != nil {
+					rows.Close()
+					return err
+				}
+				batch = append(batch, t)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			// Tags e linhas da divisão só podem ser lidas depois de fechar o FETCH
+			if err := loadTransactionTags(ctx, tx, batch); err != nil {
+				return err
+			}
+
+			if err := loadTransactionSplits(ctx, tx, batch); err != nil {
+				return err
+			}
+
+			for _, t := range batch {
+				if err := fn(t); err != nil {
+					return err
+				}
+			}
+
+			if len(batch) < exportFetchSize {
+				return nil
+			}
+		}
+	})
+}
+
+// ExportRanges retorna o período (primeira e última data) das transações dos
+// filtros em cada conta
+func (r *TransactionRepository) ExportRanges(ctx context.Context, filters TransactionFilters) (map[string][2]time.Time, error) {
+	where, args := filters.where()
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT account_id, MIN(date), MAX(date)
+		FROM transactions
+		WHERE `+where+`
+		GROUP BY account_id
+	`, args...)
 	if err != nil {
-		r.logger.Error("failed to count transactions", zap.Error(err))
-		return transactions, 0, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return transactions, total, nil
+	ranges := make(map[string][2]time.Time)
+	for rows.Next() {
+		var accountID string
+		var first, last time.Time
+		if err := rows.Scan(&accountID, &first, &last); err != nil {
+			return nil, err
+		}
+		ranges[accountID] = [2]time.Time{first, last}
+	}
+
+	return ranges, rows.Err()
+}
+
+// NormalizeDates converte para a data do calendário do usuário as datas de
+// transações e transferências ainda gravadas com horário, de antes de as datas
+// passarem a ser dias do calendário. Sem fuso configurado (ou com um fuso que não
+// existe mais) vale defaultTimezone. Os lançamentos do razão são estornados e
+// lançados de novo na nova data. Registros já convertidos não são tocados, então
+// pode rodar a cada início do serviço.
+func (r *TransactionRepository) NormalizeDates(ctx context.Context, defaultTimezone string) (int, error) {
+	fallback, err := time.LoadLocation(defaultTimezone)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT 'transaction', t.id, t.user_id, t.date, COALESCE(s.timezone, '')
+		FROM transactions t
+		LEFT JOIN user_settings s ON s.user_id = t.user_id
+		WHERE t.type <> 'transfer' AND t.date <> date_trunc('day', t.date)
+		UNION ALL
+		SELECT 'transfer', tr.id, tr.user_id, tr.date, COALESCE(s.timezone, '')
+		FROM transfers tr
+		LEFT JOIN user_settings s ON s.user_id = tr.user_id
+		WHERE tr.date <> date_trunc('day', tr.date)
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	type timed struct {
+		sourceType, id, userID, timezone string
+		date                             time.Time
+	}
+	records := []timed{}
+	for rows.Next() {
+		var t timed
+		if err := rows.Scan(&t.sourceType, &t.id, &t.userID, &t.date, &t.timezone); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		records = append(records, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	locations := map[string]*time.Location{"": fallback}
+	normalized := 0
+	for _, record := range records {
+		loc, ok := locations[record.timezone]
+		if !ok {
+			if loc, err = time.LoadLocation(record.timezone); err != nil {
+				loc = fallback
+			}
+			locations[record.timezone] = loc
+		}
+		date := models.CalendarDate(record.date, loc)
+
+		changed := false
+		err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+			// A condição na data antiga deixa de fora o que foi alterado nesse meio tempo
+			table := "transactions"
+			if record.sourceType == "transfer" {
+				table = "transfers"
+			}
+			result, err := tx.ExecContext(ctx, `UPDATE `+table+` SET date = $1 WHERE id = $2 AND date = $3`, date, record.id, record.date)
+			if err != nil {
+				return err
+			}
+
+			if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+				return err
+			}
+
+			if record.sourceType == "transfer" {
+				if _, err := tx.ExecContext(ctx, `UPDATE transactions SET date = $1 WHERE transfer_id = $2`, date, record.id); err != nil {
+					return err
+				}
+			}
+
+			changed = true
+			return redateSource(ctx, tx, record.userID, record.sourceType, record.id, date)
+		})
+		if err != nil {
+			r.logger.Error("failed to normalize date",
+				zap.Error(err),
+				zap.String("source_type", record.sourceType),
+				zap.String("id", record.id),
+			)
+			return normalized, err
+		}
+
+		if changed {
+			normalized++
+		}
+	}
+
+	return normalized, nil
 }
 
 // FindByID busca uma transação por ID
@@ -200,23 +756,12 @@ func (r *TransactionRepository) FindByID(ctx context.Context, id, userID string)
 	}()
 
 	query := `
-		SELECT id, user_id, description, amount, category, type, date, created_at, updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE id = $1 AND user_id = $2
 	`
 
-	transaction := &models.Transaction{}
-	err := r.db.QueryRowContext(ctx, query, id, userID).Scan(
-		&transaction.ID,
-		&transaction.UserID,
-		&transaction.Description,
-		&transaction.Amount,
-		&transaction.Category,
-		&transaction.Type,
-		&transaction.Date,
-		&transaction.CreatedAt,
-		&transaction.UpdatedAt,
-	)
+	transaction, err := scanTransaction(r.db.QueryRowContext(ctx, query, id, userID))
 
 	if err == sql.ErrNoRows {
 		return nil, ErrTransactionNotFound
@@ -231,11 +776,21 @@ func (r *TransactionRepository) FindByID(ctx context.Context, id, userID string)
 		return nil, err
 	}
 
+	if err := loadTransactionTags(ctx, r.db, []*models.Transaction{transaction}); err != nil {
+		return nil, err
+	}
+
+	if err := loadTransactionSplits(ctx, r.db, []*models.Transaction{transaction}); err != nil {
+		return nil, err
+	}
+
 	return transaction, nil
 }
 
-// Update atualiza uma transação
-func (r *TransactionRepository) Update(ctx context.Context, transaction *models.Transaction) error {
+// Update atualiza uma transação. O lançamento anterior é estornado e um novo é
+// gravado; pernas de transferência só mudam pela transferência. Sem replaceSplits
+// a divisão gravada é mantida e precisa fechar com o novo valor (ErrSplitsUnbalanced).
+func (r *TransactionRepository) Update(ctx context.Context, transaction *models.Transaction, replaceSplits bool) error {
 	start := time.Now()
 	defer func() {
 		metrics.DatabaseQueryDuration.WithLabelValues("update_transaction").Observe(time.Since(start).Seconds())
@@ -243,117 +798,205 @@ func (r *TransactionRepository) Update(ctx context.Context, transaction *models.
 
 	query := `
 		UPDATE transactions
-		SET description = $1, amount = $2, category = $3, updated_at = $4
-		WHERE id = $5 AND user_id = $6
+		SET account_id = $1, description = $2, amount = $3, currency = $4, category = $5, category_id = $6, updated_at = $7
+		WHERE id = $8 AND user_id = $9 AND type <> 'transfer'
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
-		transaction.Description,
-		transaction.Amount,
-		transaction.Category,
-		transaction.UpdatedAt,
-		transaction.ID,
-		transaction.UserID,
-	)
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := ensureTransactionCategory(ctx, tx, transaction); err != nil {
+			return err
+		}
 
-	if err != nil {
+		result, err := tx.ExecContext(ctx, query,
+			transaction.AccountID,
+			transaction.Description,
+			transaction.Amount,
+			transaction.Currency,
+			transaction.Category,
+			transaction.CategoryID,
+			transaction.UpdatedAt,
+			transaction.ID,
+			transaction.UserID,
+		)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrTransactionNotFound
+		}
+
+		if err := setTransactionTags(ctx, tx, transaction); err != nil {
+			return err
+		}
+
+		if replaceSplits {
+			if err := setTransactionSplits(ctx, tx, transaction); err != nil {
+				return err
+			}
+		} else {
+			// A divisão gravada é relida com a linha já travada: uma alteração
+			// concorrente em /splits não é sobrescrita pela cópia lida antes
+			if err := loadTransactionSplits(ctx, tx, []*models.Transaction{transaction}); err != nil {
+				return err
+			}
+			if len(transaction.Splits) > 0 && models.SplitsTotal(transaction.Splits) != transaction.Amount {
+				return ErrSplitsUnbalanced
+			}
+		}
+
+		if err := reverseSource(ctx, tx, transaction.UserID, "transaction", transaction.ID); err != nil {
+			return err
+		}
+		return postTransaction(ctx, tx, transaction)
+	})
+
+	if err != nil && err != ErrTransactionNotFound && err != ErrSplitsUnbalanced {
 		r.logger.Error("failed to update transaction",
 			zap.Error(err),
 			zap.String("id", transaction.ID),
 		)
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return ErrTransactionNotFound
 	}
 
-	return nil
+	return err
 }
 
-// Delete deleta uma transação
+// Delete deleta uma transação e estorna o seu lançamento
 func (r *TransactionRepository) Delete(ctx context.Context, id, userID string) error {
 	start := time.Now()
 	defer func() {
 		metrics.DatabaseQueryDuration.WithLabelValues("delete_transaction").Observe(time.Since(start).Seconds())
 	}()
 
-	query := `DELETE FROM transactions WHERE id = $1 AND user_id = $2`
+	query := `DELETE FROM transactions WHERE id = $1 AND user_id = $2 AND type <> 'transfer'`
 
-	result, err := r.db.ExecContext(ctx, query, id, userID)
-	if err != nil {
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, id, userID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrTransactionNotFound
+		}
+
+		return reverseSource(ctx, tx, userID, "transaction", id)
+	})
+
+	if err != nil && err != ErrTransactionNotFound {
 		r.logger.Error("failed to delete transaction",
 			zap.Error(err),
 			zap.String("id", id),
 			zap.String("user_id", userID),
 		)
-		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+	return err
+}
+
+// DeleteAllByUser apaga todas as transações do usuário (exclusão de conta)
+func (r *TransactionRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("delete_user_transactions").Observe(time.Since(start).Seconds())
+	}()
 
-	if rowsAffected == 0 {
-		return ErrTransactionNotFound
+	result, err := r.db.ExecContext(ctx, `DELETE FROM transactions WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("failed to delete user transactions",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return 0, err
 	}
 
-	return nil
+	return result.RowsAffected()
 }
 
-// GetStats retorna estatísticas das transações do usuário
-func (r *TransactionRepository) GetStats(ctx context.Context, userID string) (*models.TransactionStats, error) {
+// GetStats retorna estatísticas das transações do usuário na moeda base.
+// Cada transação é convertida pela cotação da sua data; as que não têm cotação
+// ficam fora dos totais convertidos, mas entram nos subtotais por moeda e por conta.
+// Transferências não contam como receita nem despesa.
+func (r *TransactionRepository) GetStats(ctx context.Context, filters StatsFilters) (*models.TransactionStats, error) {
 	start := time.Now()
 	defer func() {
 		metrics.DatabaseQueryDuration.WithLabelValues("select_transaction_stats").Observe(time.Since(start).Seconds())
 	}()
 
 	stats := &models.TransactionStats{
-		ByCategory: make(map[string]float64),
+		BaseCurrency: filters.BaseCurrency,
+		ByCategory:   make(map[string]money.Amount),
+		CategoryTree: categoryTree(nil),
+		ByTag:        []models.TagSubtotal{},
+		ByCurrency:   make(map[string]models.CurrencySubtotal),
+		ByAccount:    []models.AccountSubtotal{},
 	}
 
-	// Total de receitas e despesas
+	// $1 usuário, $2 conta (opcional), $3 moeda base
+	scope := `user_id = $1 AND ($2::uuid IS NULL OR account_id = $2::uuid)`
+	// Transferências só movem dinheiro entre contas: ficam fora de receitas e despesas
+	flowScope := scope + ` AND type <> 'transfer'`
+	accountID := sql.NullString{String: filters.AccountID, Valid: filters.AccountID != ""}
+	args := []interface{}{filters.UserID, accountID}
+	convertedArgs := []interface{}{filters.UserID, accountID, filters.BaseCurrency}
+
+	// Total de receitas e despesas convertidos. Aqui e nos subtotais abaixo o
+	// arredondamento vem depois da soma, então as categorias de uma transação
+	// dividida fecham com o total dela
 	query := `
-		SELECT 
-			COALESCE(SUM(CASE WHEN type = 'income' THEN amount ELSE 0 END), 0) as total_income,
-			COALESCE(SUM(CASE WHEN type = 'expense' THEN amount ELSE 0 END), 0) as total_expenses,
-			COUNT(*) as total_count
-		FROM transactions
-		WHERE user_id = $1
+		SELECT
+			COALESCE(ROUND(SUM(CASE WHEN type = 'income' THEN converted ELSE 0 END), 2), 0) as total_income,
+			COALESCE(ROUND(SUM(CASE WHEN type = 'expense' THEN converted ELSE 0 END), 2), 0) as total_expenses,
+			COUNT(*) as total_count,
+			COUNT(*) FILTER (WHERE converted IS NULL) as unconverted_count
+		FROM (
+			SELECT type, amount * fx_rate(currency, $3, date::date) as converted
+			FROM transactions
+			WHERE ` + flowScope + `
+		) t
 	`
 
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+	err := r.db.QueryRowContext(ctx, query, convertedArgs...).Scan(
 		&stats.TotalIncome,
 		&stats.TotalExpenses,
 		&stats.TotalCount,
+		&stats.UnconvertedCount,
 	)
 
 	if err != nil {
 		r.logger.Error("failed to get transaction stats",
 			zap.Error(err),
-			zap.String("user_id", userID),
+			zap.String("user_id", filters.UserID),
 		)
 		return nil, err
 	}
 
 	stats.Balance = stats.TotalIncome - stats.TotalExpenses
 
-	// Estatísticas por categoria
+	// Estatísticas por categoria; cada linha de uma transação dividida conta na sua
 	categoryQuery := `
-		SELECT category, SUM(amount) as total
-		FROM transactions
-		WHERE user_id = $1
+		SELECT category, ROUND(SUM(converted), 2) as total
+		FROM (
+			SELECT category, amount * fx_rate(currency, $3, date::date) as converted
+			FROM transaction_lines
+			WHERE ` + flowScope + `
+		) t
+		WHERE converted IS NOT NULL
 		GROUP BY category
 		ORDER BY total DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, categoryQuery, userID)
+	rows, err := r.db.QueryContext(ctx, categoryQuery, convertedArgs...)
 	if err != nil {
 		r.logger.Error("failed to get category stats", zap.Error(err))
 		return stats, nil // Retorna stats parcial
@@ -362,35 +1005,161 @@ func (r *TransactionRepository) GetStats(ctx context.Context, userID string) (*m
 
 	for rows.Next() {
 		var category string
-		var total float64
+		var total money.Amount
 		if err := rows.Scan(&category, &total); err != nil {
 			continue
 		}
 		stats.ByCategory[category] = total
 	}
 
+	// Árvore de categorias: o total de cada categoria, somado nas categorias acima
+	treeQuery := `
+		SELECT c.id, COALESCE(c.parent_id::text, ''), c.name, c.type, COALESCE(t.total, 0), COALESCE(t.count, 0)
+		FROM categories c
+		LEFT JOIN (
+			SELECT category_id, ROUND(SUM(converted), 2) as total, COUNT(*) as count
+			FROM (
+				SELECT category_id, amount * fx_rate(currency, $3, date::date) as converted
+				FROM transaction_lines
+				WHERE ` + flowScope + ` AND category_id IS NOT NULL
+			) t
+			WHERE converted IS NOT NULL
+			GROUP BY category_id
+		) t ON t.category_id = c.id
+		WHERE c.user_id = $1
+	`
+
+	treeRows, err := r.db.QueryContext(ctx, treeQuery, convertedArgs...)
+	if err != nil {
+		r.logger.Error("failed to get category tree stats", zap.Error(err))
+		return stats, nil
+	}
+	defer treeRows.Close()
+
+	nodes := []categoryNode{}
+	for treeRows.Next() {
+		var n categoryNode
+		if err := treeRows.Scan(&n.id, &n.parentID, &n.name, &n.categoryType, &n.total, &n.count); err != nil {
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	stats.CategoryTree = categoryTree(nodes)
+
+	// Subtotais por tag; uma transação com várias tags conta em cada uma
+	tagQuery := `
+		SELECT
+			g.name,
+			COALESCE(ROUND(SUM(t.converted) FILTER (WHERE t.type = 'income'), 2), 0),
+			COALESCE(ROUND(SUM(t.converted) FILTER (WHERE t.type = 'expense'), 2), 0),
+			COUNT(*)
+		FROM (
+			SELECT id, type, amount * fx_rate(currency, $3, date::date) as converted
+			FROM transactions
+			WHERE ` + flowScope + `
+		) t
+		JOIN transaction_tags tt ON tt.transaction_id = t.id
+		JOIN tags g ON g.id = tt.tag_id
+		GROUP BY g.id, g.name
+		ORDER BY g.name
+	`
+
+	tagRows, err := r.db.QueryContext(ctx, tagQuery, convertedArgs...)
+	if err != nil {
+		r.logger.Error("failed to get tag stats", zap.Error(err))
+		return stats, nil
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var subtotal models.TagSubtotal
+		if err := tagRows.Scan(&subtotal.Tag, &subtotal.TotalIncome, &subtotal.TotalExpenses, &subtotal.TotalCount); err != nil {
+			continue
+		}
+		subtotal.Balance = subtotal.TotalIncome - subtotal.TotalExpenses
+		stats.ByTag = append(stats.ByTag, subtotal)
+	}
+
+	// Subtotais na moeda original
+	currencyQuery := `
+		SELECT
+			currency,
+			COALESCE(SUM(CASE WHEN type = 'income' THEN amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN type = 'expense' THEN amount ELSE 0 END), 0),
+			COUNT(*)
+		FROM transactions
+		WHERE ` + flowScope + `
+		GROUP BY currency
+	`
+
+	currencyRows, err := r.db.QueryContext(ctx, currencyQuery, args...)
+	if err != nil {
+		r.logger.Error("failed to get currency stats", zap.Error(err))
+		return stats, nil
+	}
+	defer currencyRows.Close()
+
+	for currencyRows.Next() {
+		var currency string
+		var subtotal models.CurrencySubtotal
+		if err := currencyRows.Scan(&currency, &subtotal.TotalIncome, &subtotal.TotalExpenses, &subtotal.TotalCount); err != nil {
+			continue
+		}
+		subtotal.Balance = subtotal.TotalIncome - subtotal.TotalExpenses
+		stats.ByCurrency[currency] = subtotal
+	}
+
+	// Subtotais por conta, na moeda da conta; o saldo vem do razão
+	accountQuery := `
+		SELECT
+			a.id, a.name, a.currency,
+			COALESCE(SUM(CASE WHEN t.type = 'income' THEN t.amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN t.type = 'expense' THEN t.amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN t.type = 'transfer' THEN ` + signedAmountSQL + ` ELSE 0 END), 0),
+			` + accountBalanceSQL + `,
+			COUNT(t.id)
+		FROM accounts a
+		LEFT JOIN transactions t ON t.account_id = a.id
+		WHERE a.user_id = $1 AND ($2::uuid IS NULL OR a.id = $2::uuid)
+		GROUP BY a.id
+		ORDER BY a.name
+	`
+
+	accountRows, err := r.db.QueryContext(ctx, accountQuery, args...)
+	if err != nil {
+		r.logger.Error("failed to get account stats", zap.Error(err))
+		return stats, nil
+	}
+	defer accountRows.Close()
+
+	for accountRows.Next() {
+		var subtotal models.AccountSubtotal
+		err := accountRows.Scan(
+			&subtotal.AccountID,
+			&subtotal.Name,
+			&subtotal.Currency,
+			&subtotal.TotalIncome,
+			&subtotal.TotalExpenses,
+			&subtotal.NetTransfers,
+			&subtotal.Balance,
+			&subtotal.TotalCount,
+		)
+		if err != nil {
+			continue
+		}
+		stats.ByAccount = append(stats.ByAccount, subtotal)
+	}
+
 	// Última transação
 	lastQuery := `
-		SELECT id, user_id, description, amount, category, type, date, created_at, updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
-		WHERE user_id = $1
+		WHERE ` + scope + `
 		ORDER BY date DESC, created_at DESC
 		LIMIT 1
 	`
 
-	lastTransaction := &models.Transaction{}
-	err = r.db.QueryRowContext(ctx, lastQuery, userID).Scan(
-		&lastTransaction.ID,
-		&lastTransaction.UserID,
-		&lastTransaction.Description,
-		&lastTransaction.Amount,
-		&lastTransaction.Category,
-		&lastTransaction.Type,
-		&lastTransaction.Date,
-		&lastTransaction.CreatedAt,
-		&lastTransaction.UpdatedAt,
-	)
-
+	lastTransaction, err := scanTransaction(r.db.QueryRowContext(ctx, lastQuery, args...))
 	if err == nil {
 		stats.LastTransaction = lastTransaction
 	} else if err != sql.ErrNoRows {
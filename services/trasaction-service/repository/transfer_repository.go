@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"transaction-service/metrics"
+	"transaction-service/models"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrTransferNotFound = errors.New("transfer not found")
+)
+
+type TransferRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewTransferRepository(db *sql.DB, logger *zap.Logger) *TransferRepository {
+	return &TransferRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create grava a transferência e as suas duas pernas numa única transação do banco
+func (r *TransferRepository) Create(ctx context.Context, transfer *models.Transfer) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("insert_transfer").Observe(time.Since(start).Seconds())
+	}()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO transfers (id, user_id, from_account_id, to_account_id, description, date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		transfer.ID,
+		transfer.UserID,
+		transfer.FromAccountID,
+		transfer.ToAccountID,
+		transfer.Description,
+		transfer.Date,
+		transfer.CreatedAt,
+		transfer.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.Error("failed to create transfer",
+			zap.Error(err),
+			zap.String("user_id", transfer.UserID),
+		)
+		return err
+	}
+
+	for _, leg := range transfer.Legs {
+		if err := insertTransaction(ctx, tx, leg); err != nil {
+			r.logger.Error("failed to create transfer leg",
+				zap.Error(err),
+				zap.String("transfer_id", transfer.ID),
+			)
+			return err
+		}
+	}
+
+	if err := postTransfer(ctx, tx, transfer); err != nil {
+		r.logger.Error("failed to post transfer to ledger",
+			zap.Error(err),
+			zap.String("transfer_id", transfer.ID),
+		)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindByID busca a transferência com as suas pernas
+func (r *TransferRepository) FindByID(ctx context.Context, id, userID string) (*models.Transfer, error) {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("select_transfer_by_id").Observe(time.Since(start).Seconds())
+	}()
+
+	transfer := &models.Transfer{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, from_account_id, to_account_id, description, date, created_at, updated_at
+		FROM transfers
+		WHERE id = $1 AND user_id = $2
+	`, id, userID).Scan(
+		&transfer.ID,
+		&transfer.UserID,
+		&transfer.FromAccountID,
+		&transfer.ToAccountID,
+		&transfer.Description,
+		&transfer.Date,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrTransferNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find transfer",
+			zap.Error(err),
+			zap.String("id", id),
+		)
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE transfer_id = $1
+		ORDER BY transfer_side DESC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		leg, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfer.Legs = append(transfer.Legs, leg)
+
+		if *leg.TransferSide == models.TransferSideOut {
+			transfer.Amount = leg.Amount
+		} else {
+			transfer.ToAmount = leg.Amount
+		}
+	}
+
+	return transfer, rows.Err()
+}
+
+// Update altera a transferência e as duas pernas de uma vez
+func (r *TransferRepository) Update(ctx context.Context, transfer *models.Transfer) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("update_transfer").Observe(time.Since(start).Seconds())
+	}()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE transfers
+		SET from_account_id = $1, to_account_id = $2, description = $3, date = $4, updated_at = $5
+		WHERE id = $6 AND user_id = $7
+	`,
+		transfer.FromAccountID,
+		transfer.ToAccountID,
+		transfer.Description,
+		transfer.Date,
+		transfer.UpdatedAt,
+		transfer.ID,
+		transfer.UserID,
+	)
+	if err != nil {
+		r.logger.Error("failed to update transfer",
+			zap.Error(err),
+			zap.String("id", transfer.ID),
+		)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrTransferNotFound
+	}
+
+	for _, leg := range transfer.Legs {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE transactions
+			SET account_id = $1, description = $2, amount = $3, currency = $4, date = $5, updated_at = $6
+			WHERE transfer_id = $7 AND transfer_side = $8
+		`,
+			leg.AccountID,
+			leg.Description,
+			leg.Amount,
+			leg.Currency,
+			leg.Date,
+			leg.UpdatedAt,
+			transfer.ID,
+			*leg.TransferSide,
+		)
+		if err != nil {
+			r.logger.Error("failed to update transfer leg",
+				zap.Error(err),
+				zap.String("transfer_id", transfer.ID),
+			)
+			return err
+		}
+	}
+
+	// Estorna o lançamento anterior e lança a transferência alterada
+	if err := reverseSource(ctx, tx, transfer.UserID, "transfer", transfer.ID); err != nil {
+		return err
+	}
+
+	if err := postTransfer(ctx, tx, transfer); err != nil {
+		r.logger.Error("failed to post transfer to ledger",
+			zap.Error(err),
+			zap.String("transfer_id", transfer.ID),
+		)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete apaga a transferência e estorna o seu lançamento; as pernas são apagadas em cascata
+func (r *TransferRepository) Delete(ctx context.Context, id, userID string) error {
+	start := time.Now()
+	defer func() {
+		metrics.DatabaseQueryDuration.WithLabelValues("delete_transfer").Observe(time.Since(start).Seconds())
+	}()
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM transfers WHERE id = $1 AND user_id = $2`, id, userID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrTransferNotFound
+		}
+
+		return reverseSource(ctx, tx, userID, "transfer", id)
+	})
+
+	if err != nil && err != ErrTransferNotFound {
+		r.logger.Error("failed to delete transfer",
+			zap.Error(err),
+			zap.String("id", id),
+		)
+	}
+
+	return err
+}
+
+// DeleteAllByUser apaga as transferências do usuário e as suas pernas (exclusão de conta)
+func (r *TransferRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM transfers WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("failed to delete user transfers",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// UserRepository lê os dados de contato mantidos pelo auth-service no banco compartilhado
+type UserRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewUserRepository(db *sql.DB, logger *zap.Logger) *UserRepository {
+	return &UserRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// FindEmail retorna o email atual do usuário
+func (r *UserRepository) FindEmail(ctx context.Context, userID string) (string, error) {
+	var email string
+	err := r.db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&email)
+	if err == sql.ErrNoRows {
+		return "", ErrUserNotFound
+	}
+
+	if err != nil {
+		r.logger.Error("failed to find user email", zap.Error(err), zap.String("user_id", userID))
+		return "", err
+	}
+
+	return email, nil
+}
@@ -0,0 +1,410 @@
+package statement
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"transaction-service/money"
+)
+
+// FormatBeancount identifica lotes importados de um diário Beancount
+const FormatBeancount = "beancount"
+
+var ErrBeancountAccountNotFound = errors.New("account not found in the journal")
+
+// BeancountTarget escolhe a conta do diário que vira o extrato: pelo nome
+// (ex.: Assets:Nubank) ou pelo metadado account_id da diretiva open, que a
+// exportação do serviço escreve
+type BeancountTarget struct {
+	Account   string
+	AccountID string
+}
+
+// beancountPosting é uma partida de um lançamento do diário
+type beancountPosting struct {
+	account  string
+	amount   *money.Amount // nil quando o valor foi omitido para ser inferido
+	currency string
+	cost     bool // {custo}: lotes de investimento, não suportados
+}
+
+type beancountTxn struct {
+	line      int
+	date      time.Time
+	narration string
+	meta      map[string]string
+	postings  []beancountPosting
+	err       string // erro de leitura do lançamento
+}
+
+type beancountOpen struct {
+	currencies []string
+	meta       map[string]string
+}
+
+// ParseBeancount lê um diário Beancount e devolve como extrato os lançamentos
+// da conta escolhida. Só lançamentos entre a conta e contas Income: ou Expenses:
+// são importados; a categoria é o metadado category da diretiva open dessa conta
+// ou, sem ele, o último componente do nome. Com mais de uma conta de categoria o
+// lançamento vem dividido, uma linha por partida. Transferências e saldos de
+// abertura entram como erros da linha.
+func ParseBeancount(data []byte, target BeancountTarget) (*Statement, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	txns, opens := parseBeancountDirectives(toUTF8(data))
+
+	account := target.Account
+	if account == "" && target.AccountID != "" {
+		for name, open := range opens {
+			if open.meta["account_id"] == target.AccountID {
+				account = name
+				break
+			}
+		}
+	}
+	if account == "" {
+		return nil, ErrBeancountAccountNotFound
+	}
+
+	st := &Statement{Format: FormatBeancount}
+	if open, ok := opens[account]; ok && len(open.currencies) == 1 {
+		st.Currency = open.currencies[0]
+	}
+
+	found := false
+	hasher := newEntryHasher()
+	for _, txn := range txns {
+		var own []beancountPosting
+		var others []beancountPosting
+		for _, p := range txn.postings {
+			if p.account == account {
+				own = append(own, p)
+			} else {
+				others = append(others, p)
+			}
+		}
+		if len(own) == 0 {
+			continue
+		}
+		found = true
+
+		if txn.err != "" {
+			st.Errors = append(st.Errors, LineError{Line: txn.line, Error: txn.err})
+			continue
+		}
+
+		entry, lineErr := beancountEntry(txn, own, others, opens, st)
+		if lineErr != "" {
+			st.Errors = append(st.Errors, LineError{Line: txn.line, Error: lineErr})
+			continue
+		}
+
+		if id := txn.meta["id"]; id != "" {
+			entry.ExternalID = "beancount:" + id
+		} else {
+			hasher.assign(&entry)
+		}
+		st.Entries = append(st.Entries, entry)
+	}
+
+	if !found {
+		if _, ok := opens[account]; !ok {
+			return nil, ErrBeancountAccountNotFound
+		}
+		return nil, ErrNoEntries
+	}
+
+	return st, nil
+}
+
+// beancountEntry converte o lançamento em Entry ou devolve o motivo da recusa
+func beancountEntry(txn *beancountTxn, own, others []beancountPosting, opens map[string]*beancountOpen, st *Statement) (Entry, string) {
+	if len(own) > 1 {
+		return Entry{}, "more than one posting to the account"
+	}
+	posting := own[0]
+	if posting.cost {
+		return Entry{}, "postings with cost are not supported"
+	}
+
+	amount := posting.amount
+	currency := posting.currency
+	if amount == nil {
+		// Valor omitido: é o que equilibra as demais partidas, todas na mesma moeda
+		var sum money.Amount
+		for _, p := range others {
+			if p.amount == nil || p.cost || (currency != "" && p.currency != currency) {
+				return Entry{}, "could not infer the posting amount"
+			}
+			currency = p.currency
+			sum += *p.amount
+		}
+		inferred := -sum
+		amount = &inferred
+	}
+
+	if st.Currency == "" {
+		st.Currency = currency
+	}
+	if currency != st.Currency {
+		return Entry{}, fmt.Sprintf("currency %s does not match the account currency %s", currency, st.Currency)
+	}
+
+	if len(others) == 0 {
+		return Entry{}, "only postings against Income or Expenses accounts are imported"
+	}
+	if *amount == 0 {
+		return Entry{}, "amount must not be zero"
+	}
+
+	// Cada partida de categoria vira uma linha; uma delas pode ter o valor omitido
+	splits := make([]EntrySplit, 0, len(others))
+	omitted := -1
+	remaining := *amount
+	for i, other := range others {
+		root, _, _ := strings.Cut(other.account, ":")
+		if root != "Income" && root != "Expenses" {
+			return Entry{}, "only postings against Income or Expenses accounts are imported"
+		}
+		if other.cost {
+			return Entry{}, "postings with cost are not supported"
+		}
+
+		split := EntrySplit{Category: beancountCategory(opens, other.account)}
+		if other.amount == nil {
+			if omitted >= 0 {
+				return Entry{}, "could not infer the posting amount"
+			}
+			omitted = i
+		} else {
+			if other.currency != currency {
+				return Entry{}, "postings in different currencies are not supported"
+			}
+			split.Amount = -*other.amount
+			remaining -= split.Amount
+		}
+		splits = append(splits, split)
+	}
+	if omitted >= 0 {
+		splits[omitted].Amount = remaining
+	} else if remaining != 0 {
+		return Entry{}, "postings do not balance"
+	}
+
+	for _, split := range splits {
+		if split.Amount == 0 || (split.Amount < 0) != (*amount < 0) {
+			return Entry{}, "split lines must have the same sign as the transaction"
+		}
+	}
+
+	entry := Entry{
+		Line:        txn.line,
+		Date:        txn.date,
+		Amount:      *amount,
+		Description: txn.narration,
+		Category:    splits[0].Category,
+	}
+	if len(splits) > 1 {
+		entry.Splits = splits
+	}
+	return entry, ""
+}
+
+// beancountCategory é o metadado category da diretiva open da conta ou, sem ele,
+// o último componente do nome com hífens trocados por espaços
+func beancountCategory(opens map[string]*beancountOpen, account string) string {
+	if open, ok := opens[account]; ok && open.meta["category"] != "" {
+		return open.meta["category"]
+	}
+	category := account[strings.LastIndexByte(account, ':')+1:]
+	return strings.ReplaceAll(category, "-", " ")
+}
+
+// parseBeancountDirectives lê as diretivas open e os lançamentos do diário;
+// as demais (option, balance, price, pad...) são ignoradas
+func parseBeancountDirectives(text string) ([]*beancountTxn, map[string]*beancountOpen) {
+	var txns []*beancountTxn
+	opens := make(map[string]*beancountOpen)
+
+	var txn *beancountTxn
+	var open *beancountOpen
+	for i, raw := range strings.Split(text, "\n") {
+		line := stripBeancountComment(strings.TrimRight(raw, "\r"))
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// Linhas recuadas pertencem à diretiva anterior
+		if line[0] == ' ' || line[0] == '\t' {
+			fields := strings.Fields(line)
+			if key, ok := beancountMetaKey(fields[0]); ok {
+				value := unquoteBeancount(strings.TrimSpace(strings.TrimSpace(line)[len(fields[0]):]))
+				if txn != nil {
+					txn.meta[key] = value
+				} else if open != nil {
+					open.meta[key] = value
+				}
+				continue
+			}
+			if txn != nil && txn.err == "" {
+				posting, err := parseBeancountPosting(fields)
+				if err != "" {
+					txn.err = fmt.Sprintf("line %d: %s", i+1, err)
+				}
+				txn.postings = append(txn.postings, posting)
+			}
+			continue
+		}
+
+		txn, open = nil, nil
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		date, err := parseBeancountDate(fields[0])
+		if err != nil {
+			continue
+		}
+
+		switch kind := fields[1]; {
+		case kind == "open" && len(fields) >= 3:
+			open = &beancountOpen{meta: make(map[string]string)}
+			if len(fields) >= 4 && !strings.HasPrefix(fields[3], `"`) {
+				open.currencies = strings.Split(fields[3], ",")
+			}
+			opens[fields[2]] = open
+		case kind == "txn" || (len(kind) == 1 && !unicode.IsLetter(rune(kind[0]))):
+			txn = &beancountTxn{line: i + 1, date: date, meta: make(map[string]string)}
+			strs := beancountStrings(line)
+			switch {
+			case len(strs) == 1:
+				txn.narration = strs[0]
+			case len(strs) > 1 && strs[0] != "" && strs[1] != "":
+				txn.narration = strs[0] + " - " + strs[1] // beneficiário - descrição
+			case len(strs) > 1:
+				txn.narration = strs[0] + strs[1]
+			}
+			txns = append(txns, txn)
+		}
+	}
+
+	return txns, opens
+}
+
+// parseBeancountPosting lê "[flag] Conta [valor MOEDA] [{custo}] [@ preço | @@ total]"
+func parseBeancountPosting(fields []string) (beancountPosting, string) {
+	if len(fields[0]) == 1 && !unicode.IsLetter(rune(fields[0][0])) {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || !strings.Contains(fields[0], ":") {
+		return beancountPosting{}, "invalid posting"
+	}
+
+	posting := beancountPosting{account: fields[0]}
+	if len(fields) == 1 {
+		return posting, ""
+	}
+	if len(fields) < 3 {
+		return posting, "posting amount must have a currency"
+	}
+
+	amount, err := parseBeancountNumber(fields[1])
+	if err != nil {
+		return posting, "invalid amount " + fields[1]
+	}
+	posting.amount = &amount
+	posting.currency = fields[2]
+
+	for _, field := range fields[3:] {
+		if strings.HasPrefix(field, "{") {
+			posting.cost = true
+		}
+	}
+	return posting, ""
+}
+
+// parseBeancountNumber aceita separador de milhar e casas extras com zero
+// ("1,234.50", "10.500"); expressões não são suportadas
+func parseBeancountNumber(value string) (money.Amount, error) {
+	value = strings.ReplaceAll(value, ",", "")
+	if dot := strings.IndexByte(value, '.'); dot >= 0 {
+		for len(value)-dot > 3 && value[len(value)-1] == '0' {
+			value = value[:len(value)-1]
+		}
+	}
+	return money.Parse(value)
+}
+
+func parseBeancountDate(value string) (time.Time, error) {
+	return time.Parse("2006-01-02", strings.ReplaceAll(value, "/", "-"))
+}
+
+// beancountMetaKey reconhece "chave:" de um metadado (começa em minúscula)
+func beancountMetaKey(field string) (string, bool) {
+	if len(field) < 2 || !strings.HasSuffix(field, ":") || field[0] < 'a' || field[0] > 'z' {
+		return "", false
+	}
+	return strings.TrimSuffix(field, ":"), true
+}
+
+// stripBeancountComment remove o comentário (;) fora de strings
+func stripBeancountComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inString {
+				i++
+			}
+		case '"':
+			inString = !inString
+		case ';':
+			if !inString {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// beancountStrings devolve as strings entre aspas da linha, sem os escapes
+func beancountStrings(line string) []string {
+	var strs []string
+	for {
+		start := strings.IndexByte(line, '"')
+		if start < 0 {
+			return strs
+		}
+
+		var b strings.Builder
+		end := -1
+		for i := start + 1; i < len(line); i++ {
+			if line[i] == '\\' && i+1 < len(line) {
+				i++
+				b.WriteByte(line[i])
+				continue
+			}
+			if line[i] == '"' {
+				end = i
+				break
+			}
+			b.WriteByte(line[i])
+		}
+		if end < 0 {
+			return strs
+		}
+
+		strs = append(strs, b.String())
+		line = line[end+1:]
+	}
+}
+
+func unquoteBeancount(value string) string {
+	if strs := beancountStrings(value); len(strs) > 0 && strings.HasPrefix(value, `"`) {
+		return strs[0]
+	}
+	return value
+}
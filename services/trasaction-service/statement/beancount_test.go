@@ -0,0 +1,169 @@
+package statement
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const beancountJournal = `; Diário de teste
+option "operating_currency" "BRL"
+
+2024-01-01 open Assets:Nubank BRL
+  account_id: "acc-1"
+2024-01-01 open Assets:Poupanca BRL
+2024-01-01 open Expenses:Alimentacao
+  category: "Alimentação"
+2024-01-01 open Expenses:Casa-e-Jardim
+2024-01-01 open Income:Salario
+2024-01-01 open Assets:Corretora:ACME ACME
+
+2024-01-05 * "Mercado" ; compra do mês
+  id: "t1"
+  Assets:Nubank         -1,234.50 BRL
+  Expenses:Alimentacao
+
+2024/01/06 ! "Empresa" "Salário"
+  Income:Salario        -5000.000 BRL
+  Assets:Nubank
+
+2024-01-07 txn "Loja; centro"
+  Expenses:Casa-e-Jardim   80 BRL
+  Assets:Nubank            -80 BRL
+
+2024-01-08 * "Transferência"
+  Assets:Nubank          -100 BRL
+  Assets:Poupanca         100 BRL
+
+2024-01-09 * "Ações"
+  Assets:Corretora:ACME    1 ACME {50 BRL}
+  Assets:Nubank          -50 BRL
+
+2024-01-10 * "Duas partidas na conta"
+  Assets:Nubank          -10 BRL
+  Assets:Nubank          -10 BRL
+  Expenses:Alimentacao    20 BRL
+
+2024-01-11 * "Moeda errada"
+  Assets:Nubank          -10 USD
+  Expenses:Alimentacao    10 USD
+
+2024-01-12 * "Valor inválido"
+  Assets:Nubank          -1O BRL
+  Expenses:Alimentacao
+
+2024-01-13 balance Assets:Nubank  3000 BRL
+2024-01-13 * "Outra conta"
+  Assets:Poupanca         -5 BRL
+  Expenses:Alimentacao     5 BRL
+
+2024-01-14 * "Supermercado"
+  Assets:Nubank          -60 BRL
+  Expenses:Alimentacao    45 BRL
+  Expenses:Casa-e-Jardim
+
+2024-01-15 * "Estorno misturado"
+  Assets:Nubank          -10 BRL
+  Expenses:Alimentacao    20 BRL
+  Income:Salario         -10 BRL
+`
+
+func TestParseBeancount(t *testing.T) {
+	st, err := ParseBeancount([]byte(beancountJournal), BeancountTarget{AccountID: "acc-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st.Format != FormatBeancount || st.Currency != "BRL" {
+		t.Errorf("format/currency = %s/%s", st.Format, st.Currency)
+	}
+
+	want := []struct {
+		line        int
+		id          string
+		date        string
+		amount      int64
+		description string
+		category    string
+	}{
+		{line: 13, id: "beancount:t1", date: "2024-01-05", amount: -123450, description: "Mercado", category: "Alimentação"},
+		{line: 18, date: "2024-01-06", amount: 500000, description: "Empresa - Salário", category: "Salario"},
+		{line: 22, date: "2024-01-07", amount: -8000, description: "Loja; centro", category: "Casa e Jardim"},
+		{line: 52, date: "2024-01-14", amount: -6000, description: "Supermercado", category: "Alimentação"},
+	}
+	if len(st.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v (errors %+v)", len(st.Entries), len(want), st.Entries, st.Errors)
+	}
+	for i, w := range want {
+		entry := st.Entries[i]
+		if entry.Line != w.line || entry.Date.Format("2006-01-02") != w.date || entry.Amount.Cents() != w.amount ||
+			entry.Description != w.description || entry.Category != w.category {
+			t.Errorf("entry %d = %+v, want %+v", i, entry, w)
+		}
+		if w.id != "" && entry.ExternalID != w.id {
+			t.Errorf("entry %d id = %q, want %q", i, entry.ExternalID, w.id)
+		}
+		if w.id == "" && !strings.HasPrefix(entry.ExternalID, "hash:") {
+			t.Errorf("entry %d id = %q, want a hash id", i, entry.ExternalID)
+		}
+	}
+
+	// Mais de uma conta de categoria vira um lançamento dividido
+	wantSplits := []EntrySplit{{Category: "Alimentação", Amount: -4500}, {Category: "Casa e Jardim", Amount: -1500}}
+	if got := st.Entries[3].Splits; !reflect.DeepEqual(got, wantSplits) {
+		t.Errorf("splits = %+v, want %+v", got, wantSplits)
+	}
+	if st.Entries[0].Splits != nil {
+		t.Errorf("single category entry has splits %+v", st.Entries[0].Splits)
+	}
+
+	wantErrors := map[int]string{
+		26: "only postings against Income or Expenses accounts are imported",
+		30: "only postings against Income or Expenses accounts are imported",
+		34: "more than one posting to the account",
+		39: "currency USD does not match the account currency BRL",
+		43: "invalid amount -1O",
+		57: "split lines must have the same sign as the transaction",
+	}
+	if len(st.Errors) != len(wantErrors) {
+		t.Fatalf("errors = %+v", st.Errors)
+	}
+	for _, lineErr := range st.Errors {
+		if want, ok := wantErrors[lineErr.Line]; !ok || !strings.Contains(lineErr.Error, want) {
+			t.Errorf("line %d error = %q, want %q", lineErr.Line, lineErr.Error, want)
+		}
+	}
+}
+
+func TestParseBeancountTarget(t *testing.T) {
+	st, err := ParseBeancount([]byte(beancountJournal), BeancountTarget{Account: "Assets:Poupanca"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Entries) != 1 || st.Entries[0].Amount.Cents() != -500 {
+		t.Errorf("entries = %+v", st.Entries)
+	}
+
+	if _, err := ParseBeancount([]byte(beancountJournal), BeancountTarget{AccountID: "acc-2"}); err != ErrBeancountAccountNotFound {
+		t.Errorf("unknown account_id error = %v", err)
+	}
+	if _, err := ParseBeancount([]byte(beancountJournal), BeancountTarget{Account: "Assets:Itau"}); err != ErrBeancountAccountNotFound {
+		t.Errorf("unknown account error = %v", err)
+	}
+	if _, err := ParseBeancount([]byte(beancountJournal), BeancountTarget{Account: "Income:Salario"}); err != nil {
+		t.Errorf("account with postings error = %v", err)
+	}
+
+	st, err = ParseBeancount([]byte(beancountJournal), BeancountTarget{Account: "Assets:Corretora:ACME"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Entries) != 0 || len(st.Errors) != 1 || st.Errors[0].Error != "postings with cost are not supported" {
+		t.Errorf("cost postings: entries = %+v, errors = %+v", st.Entries, st.Errors)
+	}
+
+	empty := "2024-01-01 open Assets:Nubank BRL\n"
+	if _, err := ParseBeancount([]byte(empty), BeancountTarget{Account: "Assets:Nubank"}); err != ErrNoEntries {
+		t.Errorf("account without postings error = %v", err)
+	}
+}
@@ -0,0 +1,449 @@
+package statement
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"transaction-service/money"
+)
+
+// FormatCSV identifica os lotes importados de planilhas CSV
+const FormatCSV = "csv"
+
+// Convenções de sinal do CSV
+const (
+	SignSigned      = "signed"       // negativo é saída (extratos de conta)
+	SignInverted    = "inverted"     // positivo é saída (faturas de cartão)
+	SignDebitCredit = "debit_credit" // débito e crédito em colunas separadas
+)
+
+// Encodings aceitos no CSV
+const (
+	EncodingUTF8   = "utf-8"
+	EncodingLatin1 = "latin1"
+)
+
+// CSVMapping descreve o layout de um CSV. Colunas são indicadas pelo nome no
+// cabeçalho ou pela posição (começando em 1); sem cabeçalho, só pela posição.
+type CSVMapping struct {
+	Delimiter          rune
+	Encoding           string
+	HasHeader          bool
+	SkipRows           int // linhas ignoradas antes do cabeçalho (ou dos dados)
+	DateColumn         string
+	DateFormat         string // ex.: DD/MM/YYYY, YYYY-MM-DD
+	DescriptionColumns []string
+	AmountColumn       string // SignSigned e SignInverted
+	DebitColumn        string // SignDebitCredit
+	CreditColumn       string // SignDebitCredit
+	CategoryColumn     string
+	ExternalIDColumn   string
+	DecimalComma       bool // "1.234,56" em vez de "1,234.56"
+	SignConvention     string
+}
+
+var dateFormatTokens = strings.NewReplacer(
+	"YYYY", "2006",
+	"YY", "06",
+	"MM", "01",
+	"DD", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+)
+
+// DateLayout converte um formato como "DD/MM/YYYY" no layout de time.Parse
+func DateLayout(format string) (string, error) {
+	layout := dateFormatTokens.Replace(format)
+	for _, r := range layout {
+		if unicode.IsLetter(r) {
+			return "", fmt.Errorf("invalid date format %q, use DD, MM, YYYY or YY, HH, mm and ss", format)
+		}
+	}
+	if !strings.Contains(layout, "01") || !strings.Contains(layout, "02") || !strings.Contains(layout, "06") {
+		return "", fmt.Errorf("invalid date format %q, day, month and year are required", format)
+	}
+	return layout, nil
+}
+
+// Validate confere o mapeamento sem depender do arquivo
+func (m CSVMapping) Validate() error {
+	if _, err := DateLayout(m.DateFormat); err != nil {
+		return err
+	}
+
+	columns := map[string]string{"date_column": m.DateColumn}
+	switch m.SignConvention {
+	case SignSigned, SignInverted:
+		if m.AmountColumn == "" {
+			return errors.New("amount_column is required for the " + m.SignConvention + " sign convention")
+		}
+		columns["amount_column"] = m.AmountColumn
+	case SignDebitCredit:
+		if m.DebitColumn == "" || m.CreditColumn == "" {
+			return errors.New("debit_column and credit_column are required for the debit_credit sign convention")
+		}
+		columns["debit_column"] = m.DebitColumn
+		columns["credit_column"] = m.CreditColumn
+	default:
+		return fmt.Errorf("invalid sign convention %q", m.SignConvention)
+	}
+
+	if len(m.DescriptionColumns) == 0 {
+		return errors.New("at least one description column is required")
+	}
+	for i, column := range m.DescriptionColumns {
+		columns[fmt.Sprintf("description_columns[%d]", i)] = column
+	}
+	if m.CategoryColumn != "" {
+		columns["category_column"] = m.CategoryColumn
+	}
+	if m.ExternalIDColumn != "" {
+		columns["external_id_column"] = m.ExternalIDColumn
+	}
+
+	for field, column := range columns {
+		if strings.TrimSpace(column) == "" {
+			return fmt.Errorf("%s is required", field)
+		}
+		if _, isPosition := columnPosition(column); !isPosition && !m.HasHeader {
+			return fmt.Errorf("%s must be a column position when the file has no header", field)
+		}
+	}
+
+	return nil
+}
+
+// columnPosition interpreta colunas numéricas como posição (1 = primeira)
+func columnPosition(column string) (int, bool) {
+	position, err := strconv.Atoi(strings.TrimSpace(column))
+	if err != nil || position < 1 {
+		return 0, false
+	}
+	return position - 1, true
+}
+
+// CSVReader lê o CSV registro a registro, sem carregar o arquivo em memória
+type CSVReader struct {
+	mapping CSVMapping
+	layout  string
+	reader  *csv.Reader
+	skipped int
+	hasher  *entryHasher
+
+	date, amount, debit, credit, category, externalID int
+	description                                       []int
+}
+
+// NewCSVReader prepara a leitura e resolve as colunas pelo cabeçalho
+func NewCSVReader(r io.Reader, m CSVMapping) (*CSVReader, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	layout, _ := DateLayout(m.DateFormat)
+
+	buffered := bufio.NewReaderSize(r, 64*1024)
+	if m.Encoding == EncodingLatin1 {
+		buffered = bufio.NewReaderSize(&latin1Reader{r: buffered}, 64*1024)
+	} else if bom, _ := buffered.Peek(3); string(bom) == "\ufeff" {
+		buffered.Discard(3)
+	}
+
+	// Preâmbulos (nome do banco, período) costumam ter outro número de campos
+	for i := 0; i < m.SkipRows; i++ {
+		if _, err := buffered.ReadString('\n'); err != nil {
+			if err == io.EOF {
+				return nil, ErrNoEntries
+			}
+			return nil, err
+		}
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = m.Delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	c := &CSVReader{
+		mapping: m,
+		layout:  layout,
+		reader:  reader,
+		skipped: m.SkipRows,
+		hasher:  newEntryHasher(),
+	}
+
+	var header map[string]int
+	if m.HasHeader {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil, ErrNoEntries
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid header: %w", err)
+		}
+
+		header = make(map[string]int, len(record))
+		for i, name := range record {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if _, exists := header[name]; !exists {
+				header[name] = i
+			}
+		}
+	}
+
+	resolve := func(column string) (int, error) {
+		if column == "" {
+			return -1, nil
+		}
+		if position, ok := columnPosition(column); ok {
+			return position, nil
+		}
+		index, ok := header[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			return 0, fmt.Errorf("column %q not found in the header", column)
+		}
+		return index, nil
+	}
+
+	var err error
+	for _, target := range []struct {
+		column string
+		index  *int
+	}{
+		{m.DateColumn, &c.date},
+		{m.AmountColumn, &c.amount},
+		{m.DebitColumn, &c.debit},
+		{m.CreditColumn, &c.credit},
+		{m.CategoryColumn, &c.category},
+		{m.ExternalIDColumn, &c.externalID},
+	} {
+		if *target.index, err = resolve(target.column); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, column := range m.DescriptionColumns {
+		index, err := resolve(column)
+		if err != nil {
+			return nil, err
+		}
+		c.description = append(c.description, index)
+	}
+
+	return c, nil
+}
+
+// Next retorna o próximo lançamento. Registros inválidos voltam como *LineError
+// sem interromper a leitura; o erro é io.EOF no fim do arquivo.
+func (c *CSVReader) Next() (Entry, *LineError, error) {
+	for {
+		record, err := c.reader.Read()
+		if err == io.EOF {
+			return Entry{}, nil, io.EOF
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return Entry{}, &LineError{Line: parseErr.Line + c.skipped, Error: parseErr.Err.Error()}, nil
+			}
+			return Entry{}, nil, err
+		}
+
+		if blankRecord(record) {
+			continue
+		}
+
+		line, _ := c.reader.FieldPos(0)
+		line += c.skipped
+
+		entry, err := c.entry(record)
+		if err != nil {
+			return Entry{}, &LineError{Line: line, Error: err.Error()}, nil
+		}
+
+		entry.Line = line
+		if entry.ExternalID == "" {
+			c.hasher.assign(&entry)
+		} else {
+			entry.ExternalID = "csv:" + entry.ExternalID
+		}
+		return entry, nil, nil
+	}
+}
+
+func (c *CSVReader) entry(record []string) (Entry, error) {
+	field := func(index int) string {
+		if index < 0 || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	rawDate := field(c.date)
+	date, err := time.Parse(c.layout, rawDate)
+	if err != nil && len(rawDate) > len(c.layout) {
+		// Datas com hora quando o formato só tem o dia
+		date, err = time.Parse(c.layout, strings.TrimSpace(rawDate[:len(c.layout)]))
+	}
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid date %q, expected %s", rawDate, c.mapping.DateFormat)
+	}
+	year, month, day := date.Date()
+	date = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	var amount money.Amount
+	switch c.mapping.SignConvention {
+	case SignDebitCredit:
+		debit, err := c.optionalAmount(field(c.debit))
+		if err != nil {
+			return Entry{}, fmt.Errorf("invalid debit: %w", err)
+		}
+		credit, err := c.optionalAmount(field(c.credit))
+		if err != nil {
+			return Entry{}, fmt.Errorf("invalid credit: %w", err)
+		}
+		if debit != 0 && credit != 0 {
+			return Entry{}, errors.New("row has both debit and credit")
+		}
+		amount = abs(credit) - abs(debit)
+	default:
+		raw := field(c.amount)
+		if raw == "" {
+			return Entry{}, errors.New("missing amount")
+		}
+		amount, err = parseLocaleAmount(raw, c.mapping.DecimalComma)
+		if err != nil {
+			return Entry{}, fmt.Errorf("invalid amount %q: %w", raw, err)
+		}
+		if c.mapping.SignConvention == SignInverted {
+			amount = -amount
+		}
+	}
+	if amount == 0 {
+		return Entry{}, errors.New("zero amount")
+	}
+
+	parts := make([]string, 0, len(c.description))
+	for _, index := range c.description {
+		if value := strings.Join(strings.Fields(field(index)), " "); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	if len(parts) == 0 {
+		return Entry{}, errors.New("missing description")
+	}
+
+	return Entry{
+		ExternalID:  field(c.externalID),
+		Date:        date,
+		Amount:      amount,
+		Description: strings.Join(parts, " - "),
+		Category:    field(c.category),
+	}, nil
+}
+
+func (c *CSVReader) optionalAmount(raw string) (money.Amount, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	return parseLocaleAmount(raw, c.mapping.DecimalComma)
+}
+
+// parseLocaleAmount lê valores no separador decimal informado, aceitando símbolo
+// de moeda, parênteses ou "-" no fim para negativos e sufixo D/C ("150,00 D")
+func parseLocaleAmount(value string, decimalComma bool) (money.Amount, error) {
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, value)
+	value = strings.ToUpper(value)
+
+	negative := false
+	switch {
+	case strings.HasSuffix(value, "D"):
+		negative, value = true, strings.TrimSuffix(value, "D")
+	case strings.HasSuffix(value, "C"):
+		value = strings.TrimSuffix(value, "C")
+	}
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative, value = !negative, value[1:len(value)-1]
+	}
+	if strings.HasSuffix(value, "-") {
+		negative, value = !negative, strings.TrimSuffix(value, "-")
+	}
+	value = strings.TrimLeft(value, "R$US€£")
+	if strings.HasPrefix(value, "-") {
+		negative, value = !negative, value[1:]
+	}
+	value = strings.TrimLeft(value, "R$US€£")
+
+	if decimalComma {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.Replace(value, ",", ".", 1)
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+	value = strings.ReplaceAll(value, "'", "")
+
+	if value == "" || strings.ContainsAny(value, "+-") {
+		return 0, errors.New("not a number")
+	}
+
+	amount, err := money.Parse(value)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func abs(amount money.Amount) money.Amount {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
+
+func blankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// latin1Reader converte Latin-1 em UTF-8 durante a leitura
+type latin1Reader struct {
+	r   io.Reader
+	buf []byte
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	if len(p) < utf8.UTFMax {
+		return 0, io.ErrShortBuffer
+	}
+	if cap(l.buf) < len(p)/2 {
+		l.buf = make([]byte, len(p)/2)
+	}
+
+	n, err := l.r.Read(l.buf[:len(p)/2])
+	written := 0
+	for _, b := range l.buf[:n] {
+		written += utf8.EncodeRune(p[written:], rune(b))
+	}
+	return written, err
+}
@@ -0,0 +1,201 @@
+package statement
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"transaction-service/money"
+)
+
+// readCSV lê o arquivo inteiro e separa lançamentos e erros de linha
+func readCSV(t *testing.T, input string, m CSVMapping) ([]Entry, []LineError) {
+	t.Helper()
+
+	reader, err := NewCSVReader(strings.NewReader(input), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []Entry{}
+	lineErrors := []LineError{}
+	for {
+		entry, lineErr, err := reader.Next()
+		if err == io.EOF {
+			return entries, lineErrors
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lineErr != nil {
+			lineErrors = append(lineErrors, *lineErr)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		mapping CSVMapping
+		want    []Entry
+		errors  []int
+	}{
+		{
+			name: "extrato com cabeçalho e vírgula decimal",
+			input: "Banco XPTO\nPeríodo: 01/2024\n" +
+				"Data;Histórico;Documento;Valor;ID\n" +
+				"05/01/2024;Supermercado;123;-1.234,56;T1\n" +
+				";;;;\n" +
+				"06/01/2024 10:32;Salário;;3.000,00 C;T2\n",
+			mapping: CSVMapping{
+				Delimiter: ';', HasHeader: true, SkipRows: 2,
+				DateColumn: "data", DateFormat: "DD/MM/YYYY",
+				DescriptionColumns: []string{"Histórico", "Documento"},
+				AmountColumn:       "Valor", ExternalIDColumn: "ID",
+				DecimalComma: true, SignConvention: SignSigned,
+			},
+			want: []Entry{
+				{Line: 4, ExternalID: "csv:T1", Date: date("2024-01-05"), Amount: -123456, Description: "Supermercado - 123"},
+				{Line: 6, ExternalID: "csv:T2", Date: date("2024-01-06"), Amount: 300000, Description: "Salário"},
+			},
+		},
+		{
+			name:  "fatura de cartão com sinal invertido, sem cabeçalho",
+			input: "2024-02-01,Padaria,\"1,250.00\",Alimentação\n2024-02-02,Estorno,(15.00),\n",
+			mapping: CSVMapping{
+				Delimiter: ',', DateColumn: "1", DateFormat: "YYYY-MM-DD",
+				DescriptionColumns: []string{"2"}, AmountColumn: "3", CategoryColumn: "4",
+				SignConvention: SignInverted,
+			},
+			want: []Entry{
+				{Line: 1, Date: date("2024-02-01"), Amount: -125000, Description: "Padaria", Category: "Alimentação"},
+				{Line: 2, Date: date("2024-02-02"), Amount: 1500, Description: "Estorno"},
+			},
+		},
+		{
+			name: "débito e crédito em colunas e erros de linha",
+			input: "data,descricao,debito,credito\n" +
+				"10/03/24,Aluguel,\"R$ 1,500.00\",\n" +
+				"11/03/24,Pix recebido,,200\n" +
+				"12/03/24,Ambos,10,20\n" +
+				"13/03/24,Vazio,,\n" +
+				"32/03/24,Data,5,\n" +
+				"14/03/24,,5,\n",
+			mapping: CSVMapping{
+				Delimiter: ',', HasHeader: true,
+				DateColumn: "data", DateFormat: "DD/MM/YY",
+				DescriptionColumns: []string{"descricao"},
+				DebitColumn:        "debito", CreditColumn: "credito",
+				SignConvention: SignDebitCredit,
+			},
+			want: []Entry{
+				{Line: 2, Date: date("2024-03-10"), Amount: -150000, Description: "Aluguel"},
+				{Line: 3, Date: date("2024-03-11"), Amount: 20000, Description: "Pix recebido"},
+			},
+			errors: []int{4, 5, 6, 7},
+		},
+		{
+			name:  "valor com D no fim e menos à direita",
+			input: "01/04/2024|Tarifa|12,90 D\n02/04/2024|Juros|3,10-\n",
+			mapping: CSVMapping{
+				Delimiter: '|', DateColumn: "1", DateFormat: "DD/MM/YYYY",
+				DescriptionColumns: []string{"2"}, AmountColumn: "3",
+				DecimalComma: true, SignConvention: SignSigned,
+			},
+			want: []Entry{
+				{Line: 1, Date: date("2024-04-01"), Amount: -1290, Description: "Tarifa"},
+				{Line: 2, Date: date("2024-04-02"), Amount: -310, Description: "Juros"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, lineErrors := readCSV(t, tt.input, tt.mapping)
+
+			if len(entries) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d: %+v (errors %+v)", len(entries), len(tt.want), entries, lineErrors)
+			}
+			for i, want := range tt.want {
+				got := entries[i]
+				if want.ExternalID == "" && strings.HasPrefix(got.ExternalID, "hash:") {
+					got.ExternalID = ""
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("entry %d = %+v, want %+v", i, got, want)
+				}
+			}
+
+			lines := []int{}
+			for _, lineErr := range lineErrors {
+				lines = append(lines, lineErr.Line)
+			}
+			if len(lines) != len(tt.errors) {
+				t.Fatalf("error lines = %v (%+v), want %v", lines, lineErrors, tt.errors)
+			}
+			for i := range lines {
+				if lines[i] != tt.errors[i] {
+					t.Errorf("error lines = %v, want %v", lines, tt.errors)
+				}
+			}
+		})
+	}
+}
+
+func TestCSVReaderLatin1(t *testing.T) {
+	input := "01/05/2024;Pap\xe9is;-9,90\n"
+	entries, _ := readCSV(t, input, CSVMapping{
+		Delimiter: ';', Encoding: EncodingLatin1,
+		DateColumn: "1", DateFormat: "DD/MM/YYYY",
+		DescriptionColumns: []string{"2"}, AmountColumn: "3",
+		DecimalComma: true, SignConvention: SignSigned,
+	})
+
+	if len(entries) != 1 || entries[0].Description != "Papéis" || entries[0].Amount != money.FromCents(-990) {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+func TestNewCSVReaderErrors(t *testing.T) {
+	base := CSVMapping{
+		Delimiter: ',', HasHeader: true,
+		DateColumn: "date", DateFormat: "YYYY-MM-DD",
+		DescriptionColumns: []string{"memo"}, AmountColumn: "amount",
+		SignConvention: SignSigned,
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		modify func(m *CSVMapping)
+		want   string
+	}{
+		{name: "coluna fora do cabeçalho", input: "date,memo,value\n", want: `column "amount" not found`},
+		{name: "arquivo vazio", input: "", want: ErrNoEntries.Error()},
+		{name: "formato de data inválido", modify: func(m *CSVMapping) { m.DateFormat = "DD/MMM" }, want: "invalid date format"},
+		{name: "sem ano", modify: func(m *CSVMapping) { m.DateFormat = "DD/MM" }, want: "day, month and year are required"},
+		{name: "convenção inválida", modify: func(m *CSVMapping) { m.SignConvention = "both" }, want: "invalid sign convention"},
+		{name: "débito sem crédito", modify: func(m *CSVMapping) { m.SignConvention = SignDebitCredit; m.DebitColumn = "debit" }, want: "debit_column and credit_column are required"},
+		{name: "nome de coluna sem cabeçalho", modify: func(m *CSVMapping) { m.HasHeader = false }, want: "must be a column position"},
+		{name: "sem descrição", modify: func(m *CSVMapping) { m.DescriptionColumns = nil }, want: "at least one description column"},
+		{name: "preâmbulo maior que o arquivo", input: "a\n", modify: func(m *CSVMapping) { m.SkipRows = 3 }, want: ErrNoEntries.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := base
+			if tt.modify != nil {
+				tt.modify(&m)
+			}
+
+			_, err := NewCSVReader(strings.NewReader(tt.input), m)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewCSVReader() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package statement
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// parseOFX lê as variantes SGML (OFX 1.x, tags de valor sem fechamento) e
+// XML (OFX 2.x). Os dois viram a mesma sequência de tags e valores: o valor
+// de uma tag é o texto até a próxima "<", e tags de fechamento só importam
+// para os agregados (STMTTRN).
+func parseOFX(text string, opts Options) (*Statement, error) {
+	start := strings.Index(strings.ToUpper(text), "<OFX>")
+	if start < 0 {
+		return nil, ErrUnknownFormat
+	}
+	text = text[start:]
+
+	st := &Statement{Format: FormatOFX}
+	var current map[string]string
+	line := 0
+
+	for len(text) > 0 {
+		open := strings.IndexByte(text, '<')
+		if open < 0 {
+			break
+		}
+		closeTag := strings.IndexByte(text[open:], '>')
+		if closeTag < 0 {
+			break
+		}
+
+		tag := strings.ToUpper(strings.TrimSpace(text[open+1 : open+closeTag]))
+		text = text[open+closeTag+1:]
+
+		value := text
+		if next := strings.IndexByte(text, '<'); next >= 0 {
+			value = text[:next]
+		}
+		value = strings.TrimSpace(html.UnescapeString(value))
+
+		switch {
+		case tag == "STMTTRN":
+			current = make(map[string]string)
+		case tag == "/STMTTRN":
+			if current != nil {
+				line++
+				entry, err := ofxEntry(current, line, opts.DecimalSeparator)
+				if err != nil {
+					st.Errors = append(st.Errors, LineError{Line: line, Error: err.Error()})
+				} else {
+					st.Entries = append(st.Entries, entry)
+				}
+			}
+			current = nil
+		case tag == "CURDEF":
+			st.Currency = strings.ToUpper(value)
+		case strings.HasPrefix(tag, "/") || strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!"):
+			// Fechamentos de tags de valor e instruções XML
+		default:
+			if current != nil && value != "" {
+				current[tag] = value
+			}
+		}
+	}
+
+	return st, nil
+}
+
+func ofxEntry(fields map[string]string, line int, decimal string) (Entry, error) {
+	date, err := parseOFXDate(fields["DTPOSTED"])
+	if err != nil {
+		return Entry{}, err
+	}
+
+	amount, err := parseAmount(fields["TRNAMT"], decimal)
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid TRNAMT %q: %w", fields["TRNAMT"], err)
+	}
+	if amount == 0 {
+		return Entry{}, fmt.Errorf("zero amount")
+	}
+
+	// NAME costuma ser truncado em 32 caracteres; MEMO complementa
+	description := fields["NAME"]
+	if memo := fields["MEMO"]; memo != "" && !strings.Contains(description, memo) {
+		if description == "" || strings.HasPrefix(memo, description) {
+			description = memo
+		} else {
+			description += " - " + memo
+		}
+	}
+	if description == "" {
+		description = fields["TRNTYPE"]
+	}
+
+	return Entry{
+		Line:        line,
+		ExternalID:  fields["FITID"],
+		Date:        date,
+		Amount:      amount,
+		Description: description,
+	}, nil
+}
+
+// parseOFXDate aceita YYYYMMDD[HHMMSS[.XXX]][[-3:BRT]]; só a data importa
+func parseOFXDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid DTPOSTED %q", value)
+	}
+
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid DTPOSTED %q", value)
+	}
+	return date, nil
+}
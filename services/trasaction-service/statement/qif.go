@@ -0,0 +1,185 @@
+package statement
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type qifRecord struct {
+	line   int
+	fields map[byte]string
+}
+
+// parseQIF lê registros "!Type:Bank"/"CCard"/"Cash" (D data, T/U valor,
+// P favorecido, M memo, N número, L categoria, ^ fim do registro). A ordem
+// dia/mês é deduzida das próprias datas quando possível.
+func parseQIF(text string, opts Options) (*Statement, error) {
+	st := &Statement{Format: FormatQIF}
+	records := []qifRecord{}
+	current := qifRecord{fields: make(map[byte]string)}
+	investment := false
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		raw := strings.TrimRight(scanner.Text(), "\r")
+		if raw == "" {
+			continue
+		}
+
+		if raw[0] == '!' {
+			header := strings.ToUpper(raw)
+			investment = strings.HasPrefix(header, "!TYPE:INVST")
+			continue
+		}
+
+		if raw[0] == '^' {
+			if len(current.fields) > 0 && !investment {
+				current.line = len(records) + 1
+				records = append(records, current)
+			}
+			current = qifRecord{fields: make(map[byte]string)}
+			continue
+		}
+
+		// Linhas de split (S/E/$) e de endereço (A) não viram lançamentos próprios
+		code := raw[0]
+		if _, exists := current.fields[code]; !exists {
+			current.fields[code] = strings.TrimSpace(raw[1:])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(current.fields) > 0 && !investment {
+		current.line = len(records) + 1
+		records = append(records, current)
+	}
+
+	dayFirst := qifDayFirst(records, opts.DateOrder)
+
+	for _, record := range records {
+		entry, err := qifEntry(record, dayFirst, opts.DecimalSeparator)
+		if err != nil {
+			st.Errors = append(st.Errors, LineError{Line: record.line, Error: err.Error()})
+			continue
+		}
+		st.Entries = append(st.Entries, entry)
+	}
+
+	return st, nil
+}
+
+func qifEntry(record qifRecord, dayFirst bool, decimal string) (Entry, error) {
+	date, err := parseQIFDate(record.fields['D'], dayFirst)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	rawAmount := record.fields['T']
+	if rawAmount == "" {
+		rawAmount = record.fields['U']
+	}
+	amount, err := parseAmount(rawAmount, decimal)
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid amount %q: %w", rawAmount, err)
+	}
+	if amount == 0 {
+		return Entry{}, fmt.Errorf("zero amount")
+	}
+
+	description := record.fields['P']
+	if memo := record.fields['M']; memo != "" {
+		if description == "" {
+			description = memo
+		} else if !strings.Contains(description, memo) {
+			description += " - " + memo
+		}
+	}
+	if description == "" {
+		description = "QIF " + record.fields['N']
+	}
+
+	// "[Conta]" indica transferência no Quicken; não é uma categoria
+	category := record.fields['L']
+	if strings.HasPrefix(category, "[") {
+		category = ""
+	}
+	if i := strings.IndexByte(category, '/'); i >= 0 {
+		category = category[:i]
+	}
+
+	return Entry{
+		Line:        record.line,
+		ExternalID:  "",
+		Date:        date,
+		Amount:      amount,
+		Description: strings.TrimSpace(description),
+		Category:    strings.TrimSpace(category),
+	}, nil
+}
+
+// qifDayFirst decide a ordem das datas: um primeiro componente acima de 12
+// indica dia/mês, um segundo acima de 12 indica mês/dia
+func qifDayFirst(records []qifRecord, order string) bool {
+	for _, record := range records {
+		parts := splitQIFDate(record.fields['D'])
+		if len(parts) != 3 {
+			continue
+		}
+		first, _ := strconv.Atoi(parts[0])
+		second, _ := strconv.Atoi(parts[1])
+		if first > 12 {
+			return true
+		}
+		if second > 12 {
+			return false
+		}
+	}
+	return order != "mdy"
+}
+
+func splitQIFDate(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == '/' || r == '-' || r == '.' || r == '\'' || r == ' '
+	})
+}
+
+// parseQIFDate aceita 31/12/2024, 12/31'24, 31.12.24 e afins
+func parseQIFDate(value string, dayFirst bool) (time.Time, error) {
+	parts := splitQIFDate(value)
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", value)
+		}
+		numbers[i] = n
+	}
+
+	day, month, year := numbers[0], numbers[1], numbers[2]
+	if !dayFirst {
+		day, month = month, day
+	}
+
+	// Anos com dois dígitos; o apóstrofo do Quicken marca anos 2000+
+	if year < 100 {
+		year += 2000
+		if year > time.Now().Year()+1 && !strings.Contains(value, "'") {
+			year -= 100
+		}
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day || int(date.Month()) != month {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
@@ -0,0 +1,183 @@
+// Package statement lê extratos bancários (OFX, QIF e CSV) e diários Beancount
+// e os normaliza em lançamentos prontos para importação.
+package statement
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"transaction-service/money"
+)
+
+// Formatos de extrato
+const (
+	FormatOFX = "ofx"
+	FormatQIF = "qif"
+)
+
+var (
+	ErrUnknownFormat   = errors.New("unrecognized statement format, expected OFX or QIF")
+	ErrNoEntries       = errors.New("statement has no entries")
+	ErrAmbiguousAmount = errors.New("ambiguous thousands or decimal separator, set decimal_separator")
+)
+
+// Entry é um lançamento do extrato. Amount tem sinal: negativo é saída.
+type Entry struct {
+	Line        int // posição no arquivo (1 = primeiro lançamento)
+	ExternalID  string
+	Date        time.Time
+	Amount      money.Amount
+	Description string
+	Category    string       // só quando o arquivo traz (QIF "L")
+	Splits      []EntrySplit // lançamento dividido entre categorias (Beancount)
+}
+
+// EntrySplit é uma linha de um lançamento dividido. Amount tem o sinal do
+// lançamento e as linhas somam o valor dele.
+type EntrySplit struct {
+	Category string
+	Amount   money.Amount
+}
+
+// LineError descreve um lançamento que não pôde ser lido
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Statement é o resultado da leitura de um arquivo
+type Statement struct {
+	Format   string
+	Currency string // vazio quando o arquivo não informa
+	Entries  []Entry
+	Errors   []LineError
+}
+
+// Options ajusta a leitura de formatos ambíguos
+type Options struct {
+	// DateOrder é a ordem das datas do QIF quando o arquivo não permite
+	// deduzir: "dmy" (padrão) ou "mdy"
+	DateOrder string
+	// DecimalSeparator é o separador decimal dos valores: "." ou ",". Vazio
+	// deduz pelo valor e recusa os ambíguos, como "1.500" ou "1,234".
+	DecimalSeparator string
+}
+
+// Parse detecta o formato pelo conteúdo e lê o extrato
+func Parse(data []byte, opts Options) (*Statement, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	text := toUTF8(data)
+
+	head := strings.ToUpper(text)
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+
+	var st *Statement
+	var err error
+	switch {
+	case strings.Contains(head, "<OFX>") || strings.Contains(head, "OFXHEADER"):
+		st, err = parseOFX(text, opts)
+	case strings.HasPrefix(strings.TrimSpace(head), "!TYPE:") || strings.HasPrefix(strings.TrimSpace(head), "!ACCOUNT"):
+		st, err = parseQIF(text, opts)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(st.Entries) == 0 && len(st.Errors) == 0 {
+		return nil, ErrNoEntries
+	}
+
+	assignHashIDs(st.Entries)
+	return st, nil
+}
+
+// toUTF8 converte arquivos em Latin-1/Windows-1252 (comuns nos bancos brasileiros)
+func toUTF8(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+
+	var b strings.Builder
+	b.Grow(len(data))
+	for _, c := range data {
+		b.WriteRune(rune(c))
+	}
+	return b.String()
+}
+
+// assignHashIDs identifica lançamentos sem FITID pelo conteúdo
+func assignHashIDs(entries []Entry) {
+	hasher := newEntryHasher()
+	for i := range entries {
+		if entries[i].ExternalID != "" {
+			entries[i].ExternalID = "fitid:" + entries[i].ExternalID
+			continue
+		}
+		hasher.assign(&entries[i])
+	}
+}
+
+// entryHasher gera IDs a partir do conteúdo do lançamento. O número da
+// repetição entra no hash para que compras idênticas no mesmo dia não se
+// confundam, e a mesma reimportação gere os mesmos IDs.
+type entryHasher struct {
+	seen map[string]int
+}
+
+func newEntryHasher() *entryHasher {
+	return &entryHasher{seen: make(map[string]int)}
+}
+
+func (h *entryHasher) assign(entry *Entry) {
+	key := fmt.Sprintf("%s|%d|%s",
+		entry.Date.Format("2006-01-02"),
+		entry.Amount.Cents(),
+		strings.ToLower(strings.Join(strings.Fields(entry.Description), " ")),
+	)
+	h.seen[key]++
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", key, h.seen[key])))
+	entry.ExternalID = "hash:" + hex.EncodeToString(sum[:16])
+}
+
+// parseAmount aceita ponto ou vírgula decimal e separadores de milhar
+// ("-1.234,56", "1,234.56", "-10,5"). Sem decimal informado, um único separador
+// seguido de exatamente 3 dígitos tanto pode ser milhar quanto decimal e é recusado.
+func parseAmount(value, decimal string) (money.Amount, error) {
+	value = strings.TrimSpace(value)
+	value = strings.ReplaceAll(value, " ", "")
+
+	switch decimal {
+	case ".":
+		value = strings.ReplaceAll(value, ",", "")
+	case ",":
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.Replace(value, ",", ".", 1)
+	default:
+		lastDot := strings.LastIndexByte(value, '.')
+		lastComma := strings.LastIndexByte(value, ',')
+		separator := max(lastDot, lastComma)
+		if strings.Count(value, ".")+strings.Count(value, ",") == 1 && len(value)-separator-1 == 3 {
+			return 0, ErrAmbiguousAmount
+		}
+
+		switch {
+		case lastComma > lastDot:
+			value = strings.ReplaceAll(value, ".", "")
+			value = strings.Replace(value, ",", ".", 1)
+		case lastDot > lastComma:
+			value = strings.ReplaceAll(value, ",", "")
+		}
+	}
+
+	return money.Parse(value)
+}
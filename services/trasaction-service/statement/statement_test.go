@@ -0,0 +1,268 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"transaction-service/money"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value   string
+		decimal string
+		want    money.Amount
+		err     error
+	}{
+		{value: "-1.234,56", want: -123456},
+		{value: "1,234.56", want: 123456},
+		{value: "-10,5", want: -1050},
+		{value: "10.5", want: 1050},
+		{value: "1 234,56", want: 123456},
+		{value: "42", want: 4200},
+		{value: "0,01", want: 1},
+		{value: "1.500", err: ErrAmbiguousAmount},
+		{value: "-1,234", err: ErrAmbiguousAmount},
+		{value: "1.500", decimal: ",", want: 150000},
+		{value: "1.500", decimal: ".", want: 150},
+		{value: "1,234", decimal: ".", want: 123400},
+		{value: "1,234", decimal: ",", err: money.ErrTooManyDecimals},
+		{value: "1.234.567,89", decimal: ",", want: 123456789},
+		{value: "abc", err: money.ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+"/"+tt.decimal, func(t *testing.T) {
+			got, err := parseAmount(tt.value, tt.decimal)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("parseAmount(%q, %q) error = %v, want %v", tt.value, tt.decimal, err, tt.err)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("parseAmount(%q, %q) = %d, want %d", tt.value, tt.decimal, got, tt.want)
+			}
+		})
+	}
+}
+
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>brl
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240105120000[-3:BRT]
+<TRNAMT>-150.25
+<FITID>A1
+<NAME>SUPERMERCADO
+<MEMO>COMPRA CARTAO
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240110
+<TRNAMT>3000,00
+<FITID>A2
+<MEMO>SALARIO &amp; BONUS
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>2024011
+<TRNAMT>-10.00
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>FEE
+<DTPOSTED>20240112
+<TRNAMT>-1.500
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const ofxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>USD</CURDEF><BANKTRANLIST>
+<STMTTRN><TRNTYPE>POS</TRNTYPE><DTPOSTED>20240301</DTPOSTED><TRNAMT>-9.99</TRNAMT><FITID>X9</FITID><NAME>COFFEE</NAME></STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`
+
+func TestParseOFX(t *testing.T) {
+	st, err := Parse([]byte(ofxSGML), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st.Format != FormatOFX || st.Currency != "BRL" {
+		t.Errorf("format/currency = %s/%s, want ofx/BRL", st.Format, st.Currency)
+	}
+
+	want := []Entry{
+		{Line: 1, ExternalID: "fitid:A1", Date: date("2024-01-05"), Amount: -15025, Description: "SUPERMERCADO - COMPRA CARTAO"},
+		{Line: 2, ExternalID: "fitid:A2", Date: date("2024-01-10"), Amount: 300000, Description: "SALARIO & BONUS"},
+	}
+	if len(st.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(st.Entries), len(want), st.Entries)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(st.Entries[i], want[i]) {
+			t.Errorf("entry %d = %+v, want %+v", i, st.Entries[i], want[i])
+		}
+	}
+
+	if len(st.Errors) != 2 || st.Errors[0].Line != 3 || st.Errors[1].Line != 4 {
+		t.Fatalf("errors = %+v, want lines 3 and 4", st.Errors)
+	}
+	if !strings.Contains(st.Errors[1].Error, ErrAmbiguousAmount.Error()) {
+		t.Errorf("line 4 error = %q, want ambiguous amount", st.Errors[1].Error)
+	}
+
+	st, err = Parse([]byte(ofxSGML), Options{DecimalSeparator: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Entries) != 3 || st.Entries[2].Amount != -150 {
+		t.Errorf("with decimal separator: entries = %+v", st.Entries)
+	}
+
+	st, err = Parse([]byte(ofxXML), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Entries) != 1 || st.Currency != "USD" || st.Entries[0].Amount != -999 || st.Entries[0].Description != "COFFEE" {
+		t.Errorf("xml = %+v", st)
+	}
+}
+
+func TestParseQIF(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		opts   Options
+		want   []Entry
+		errors []int
+	}{
+		{
+			name: "dia/mês deduzido",
+			input: "!Type:Bank\n" +
+				"D25/12/2023\nT-1.234,56\nPLoja\nMPresentes\nLCompras:Natal\n^\n" +
+				"D02/01/2024\nT500,00\nPSalario\nL[Poupanca]\n^\n",
+			want: []Entry{
+				{Line: 1, Date: date("2023-12-25"), Amount: -123456, Description: "Loja - Presentes", Category: "Compras:Natal"},
+				{Line: 2, Date: date("2024-01-02"), Amount: 50000, Description: "Salario"},
+			},
+		},
+		{
+			name:  "mês/dia deduzido com ano do Quicken",
+			input: "!Type:CCard\nD12/31'23\nU-20.00\nMPadaria\n^\nD1/5'24\nT-3.5\nN1001\n^\n",
+			want: []Entry{
+				{Line: 1, Date: date("2023-12-31"), Amount: -2000, Description: "Padaria"},
+				{Line: 2, Date: date("2024-01-05"), Amount: -350, Description: "QIF 1001"},
+			},
+		},
+		{
+			name:  "ordem informada quando não dá para deduzir",
+			input: "!Type:Bank\nD01/02/2024\nT-10\nPA\n^",
+			opts:  Options{DateOrder: "mdy"},
+			want:  []Entry{{Line: 1, Date: date("2024-01-02"), Amount: -1000, Description: "A"}},
+		},
+		{
+			name:  "categoria com classe e split ignorado",
+			input: "!Type:Bank\nD10/01/2024\nT-30,00\nPFeira\nLAlimentacao/Casa\nSAlimentacao\n$-30,00\n^\n",
+			want:  []Entry{{Line: 1, Date: date("2024-01-10"), Amount: -3000, Description: "Feira", Category: "Alimentacao"}},
+		},
+		{
+			name:   "valores inválidos e ambíguos viram erros de linha",
+			input:  "!Type:Bank\nD10/01/2024\nT0\nPZero\n^\nD11/01/2024\nT-1.500\nPTarifa\n^\nD32/01/2024\nT-1\nPData\n^\n",
+			errors: []int{1, 2, 3},
+		},
+		{
+			name:  "investimentos são ignorados",
+			input: "!Type:Invst\nD10/01/2024\nT-100\nPAcoes\n^\n!Type:Bank\nD10/01/2024\nT-1,5\nPTaxa\n^\n",
+			want:  []Entry{{Line: 1, Date: date("2024-01-10"), Amount: -150, Description: "Taxa"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := parseQIF(tt.input, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(st.Entries) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d: %+v", len(st.Entries), len(tt.want), st.Entries)
+			}
+			for i := range tt.want {
+				if !reflect.DeepEqual(st.Entries[i], tt.want[i]) {
+					t.Errorf("entry %d = %+v, want %+v", i, st.Entries[i], tt.want[i])
+				}
+			}
+
+			lines := []int{}
+			for _, lineErr := range st.Errors {
+				lines = append(lines, lineErr.Line)
+			}
+			if len(lines) != len(tt.errors) {
+				t.Fatalf("error lines = %v, want %v", lines, tt.errors)
+			}
+			for i := range lines {
+				if lines[i] != tt.errors[i] {
+					t.Errorf("error lines = %v, want %v", lines, tt.errors)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDetectsFormat(t *testing.T) {
+	if _, err := Parse([]byte("date,amount\n2024-01-01,10"), Options{}); err != ErrUnknownFormat {
+		t.Errorf("csv error = %v, want ErrUnknownFormat", err)
+	}
+	if _, err := Parse([]byte("!Type:Bank\n"), Options{}); err != ErrNoEntries {
+		t.Errorf("empty qif error = %v, want ErrNoEntries", err)
+	}
+
+	// Arquivos em Latin-1 são convertidos para UTF-8
+	latin1 := []byte("!Type:Bank\nD10/01/2024\nT-5\nPPadaria S\xe3o Jo\xe3o\n^\n")
+	st, err := Parse(latin1, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Entries[0].Description != "Padaria São João" {
+		t.Errorf("description = %q", st.Entries[0].Description)
+	}
+}
+
+func TestHashIDsAreStable(t *testing.T) {
+	input := "!Type:Bank\nD10/01/2024\nT-5\nPCafe\n^\nD10/01/2024\nT-5\nPCAFE \n^\n"
+
+	first, err := Parse([]byte(input), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Parse([]byte(input), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := first.Entries[0].ExternalID, first.Entries[1].ExternalID
+	if !strings.HasPrefix(a, "hash:") || a == b {
+		t.Errorf("identical entries should get distinct hash IDs: %q, %q", a, b)
+	}
+	if second.Entries[0].ExternalID != a || second.Entries[1].ExternalID != b {
+		t.Error("reimporting the same file should produce the same IDs")
+	}
+}
@@ -0,0 +1,151 @@
+package tracking
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"transaction-service/messaging"
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/repository"
+
+	"go.uber.org/zap"
+)
+
+// BudgetTracker reavalia o orçamento da categoria e do mês afetados por cada
+// despesa e publica budget.warning/budget.exceeded ao cruzar 80% e 100%
+type BudgetTracker struct {
+	repo      *repository.BudgetRepository
+	users     *repository.UserRepository
+	publisher *messaging.EventPublisher
+	logger    *zap.Logger
+}
+
+func NewBudgetTracker(
+	repo *repository.BudgetRepository,
+	users *repository.UserRepository,
+	publisher *messaging.EventPublisher,
+	logger *zap.Logger,
+) *BudgetTracker {
+	return &BudgetTracker{
+		repo:      repo,
+		users:     users,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// Limiares em ordem crescente, com o evento publicado ao cruzar cada um
+var budgetThresholds = []struct {
+	percentage int
+	eventType  string
+}{
+	{models.BudgetWarningThreshold, messaging.BudgetWarning},
+	{models.BudgetExceededThreshold, messaging.BudgetExceeded},
+}
+
+// TransactionChanged reavalia os orçamentos de antes e depois da alteração
+// (uma edição pode mudar a categoria ou o mês da despesa), um por categoria
+// das linhas de uma despesa dividida
+func (t *BudgetTracker) TransactionChanged(ctx context.Context, change Change) {
+	type key struct {
+		category string
+		month    time.Time
+	}
+
+	seen := make(map[key]bool)
+	for _, transaction := range []*models.Transaction{change.Before, change.After} {
+		if transaction == nil || transaction.Type != "expense" {
+			continue
+		}
+
+		for _, line := range transaction.Lines() {
+			k := key{line.Category, models.BudgetMonth(transaction.Date)}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+
+			budget, err := t.repo.FindByCategory(ctx, change.UserID, k.category)
+			if err == repository.ErrBudgetNotFound {
+				continue
+			}
+			if err != nil {
+				t.logger.Error("failed to find budget", zap.Error(err), zap.String("user_id", change.UserID))
+				continue
+			}
+
+			t.Evaluate(ctx, budget, k.month, change.UserEmail)
+		}
+	}
+}
+
+// Evaluate compara o gasto do mês com os limiares do orçamento. Cada limiar é
+// publicado uma vez por mês; se o gasto voltar abaixo dele, o alerta é rearmado.
+func (t *BudgetTracker) Evaluate(ctx context.Context, budget *models.Budget, month time.Time, userEmail string) {
+	if month.Format("2006-01") < budget.StartMonth {
+		return
+	}
+
+	// Sem destinatário o alerta não é armado: o notification-service descartaria o
+	// evento e o limiar não seria mais avisado neste mês
+	userEmail = recipient(ctx, t.users, budget.UserID, userEmail)
+	if userEmail == "" {
+		t.logger.Warn("budget alert skipped: no recipient", zap.String("budget_id", budget.ID))
+		return
+	}
+
+	status, err := t.repo.Status(ctx, budget, month)
+	if err != nil {
+		t.logger.Error("failed to evaluate budget", zap.Error(err), zap.String("budget_id", budget.ID))
+		return
+	}
+
+	// Um salto de 50% para 120% registra os dois limiares, mas só avisa o maior
+	var crossed string
+	var crossedThreshold int
+	for _, threshold := range budgetThresholds {
+		inserted, err := t.repo.SetAlert(ctx, budget.ID, month, threshold.percentage, status.Percentage >= threshold.percentage)
+		if err != nil {
+			t.logger.Error("failed to record budget alert", zap.Error(err), zap.String("budget_id", budget.ID))
+			return
+		}
+		if inserted {
+			crossed = threshold.eventType
+			crossedThreshold = threshold.percentage
+		}
+	}
+
+	if crossed == "" {
+		return
+	}
+
+	event := messaging.BudgetEvent{
+		EventType:          crossed,
+		BudgetID:           budget.ID,
+		UserID:             budget.UserID,
+		UserEmail:          userEmail,
+		Category:           budget.Category,
+		Month:              status.Month,
+		Currency:           status.Currency,
+		Threshold:          crossedThreshold,
+		Percentage:         status.Percentage,
+		BudgetLimit:        status.Available.String(),
+		BudgetLimitCents:   status.Available.Cents(),
+		CurrentAmount:      status.Spent.String(),
+		CurrentAmountCents: status.Spent.Cents(),
+		Timestamp:          time.Now(),
+	}
+
+	if err := t.publisher.PublishBudgetEvent(event); err != nil {
+		t.logger.Error("failed to publish budget event", zap.Error(err), zap.String("budget_id", budget.ID))
+		// Rearma o limiar para que a próxima alteração tente publicar de novo
+		if _, err := t.repo.SetAlert(ctx, budget.ID, month, crossedThreshold, false); err != nil {
+			t.logger.Error("failed to reset budget alert", zap.Error(err), zap.String("budget_id", budget.ID))
+		}
+		return
+	}
+
+	metrics.BudgetAlertsTotal.WithLabelValues(strconv.Itoa(crossedThreshold)).Inc()
+}
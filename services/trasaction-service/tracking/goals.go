@@ -0,0 +1,126 @@
+package tracking
+
+import (
+	"context"
+	"time"
+
+	"transaction-service/messaging"
+	"transaction-service/metrics"
+	"transaction-service/models"
+	"transaction-service/repository"
+
+	"go.uber.org/zap"
+)
+
+// GoalTracker recalcula as metas ligadas à conta ou à categoria de cada
+// transação alterada e publica goal.achieved quando o alvo é alcançado
+type GoalTracker struct {
+	repo      *repository.GoalRepository
+	users     *repository.UserRepository
+	publisher *messaging.EventPublisher
+	logger    *zap.Logger
+}
+
+func NewGoalTracker(
+	repo *repository.GoalRepository,
+	users *repository.UserRepository,
+	publisher *messaging.EventPublisher,
+	logger *zap.Logger,
+) *GoalTracker {
+	return &GoalTracker{
+		repo:      repo,
+		users:     users,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// TransactionChanged reavalia as metas pendentes afetadas pela alteração
+func (t *GoalTracker) TransactionChanged(ctx context.Context, change Change) {
+	accountIDs := []string{}
+	categories := []string{}
+	for _, transaction := range []*models.Transaction{change.Before, change.After} {
+		if transaction == nil {
+			continue
+		}
+		accountIDs = append(accountIDs, transaction.AccountID)
+		if transaction.Type != models.TransactionTypeTransfer {
+			for _, line := range transaction.Lines() {
+				categories = append(categories, line.Category)
+			}
+		}
+	}
+
+	goals, err := t.repo.FindPendingByLinks(ctx, change.UserID, accountIDs, categories)
+	if err != nil {
+		t.logger.Error("failed to find goals", zap.Error(err), zap.String("user_id", change.UserID))
+		return
+	}
+
+	for _, goal := range goals {
+		t.Evaluate(ctx, goal, change.UserEmail)
+	}
+}
+
+// Evaluate publica goal.achieved se a meta pendente alcançou o alvo. A marcação
+// em achieved_at garante um único evento, mesmo com avaliações concorrentes.
+func (t *GoalTracker) Evaluate(ctx context.Context, goal *models.Goal, userEmail string) {
+	if goal.AchievedAt != nil {
+		return
+	}
+
+	progress, err := t.repo.Progress(ctx, goal, time.Now())
+	if err != nil {
+		t.logger.Error("failed to evaluate goal", zap.Error(err), zap.String("goal_id", goal.ID))
+		return
+	}
+
+	if progress.Saved < goal.TargetAmount {
+		return
+	}
+
+	// A meta só é marcada se houver para quem avisar; senão o único goal.achieved
+	// seria descartado pelo notification-service e a meta não seria mais avaliada
+	userEmail = recipient(ctx, t.users, goal.UserID, userEmail)
+	if userEmail == "" {
+		t.logger.Warn("goal achievement deferred: no recipient", zap.String("goal_id", goal.ID))
+		return
+	}
+
+	marked, err := t.repo.MarkAchieved(ctx, goal.ID)
+	if err != nil {
+		t.logger.Error("failed to mark goal achieved", zap.Error(err), zap.String("goal_id", goal.ID))
+		return
+	}
+	if !marked {
+		return
+	}
+
+	event := messaging.GoalEvent{
+		EventType:         messaging.GoalAchieved,
+		GoalID:            goal.ID,
+		UserID:            goal.UserID,
+		UserEmail:         userEmail,
+		GoalName:          goal.Name,
+		Currency:          goal.Currency,
+		TargetAmount:      goal.TargetAmount.String(),
+		TargetAmountCents: goal.TargetAmount.Cents(),
+		SavedAmount:       progress.Saved.String(),
+		SavedAmountCents:  progress.Saved.Cents(),
+		Deadline:          goal.Deadline,
+		Timestamp:         time.Now(),
+	}
+
+	if err := t.publisher.PublishGoalEvent(event); err != nil {
+		t.logger.Error("failed to publish goal event", zap.Error(err), zap.String("goal_id", goal.ID))
+		// Sem o evento a meta volta a ficar pendente e será reavaliada
+		if err := t.repo.ClearAchieved(ctx, goal.ID); err != nil {
+			t.logger.Error("failed to reset goal", zap.Error(err), zap.String("goal_id", goal.ID))
+		}
+		return
+	}
+
+	now := time.Now()
+	goal.AchievedAt = &now
+	metrics.GoalsAchievedTotal.Inc()
+}
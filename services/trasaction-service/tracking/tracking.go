@@ -0,0 +1,49 @@
+// Package tracking reage a alterações de transações para manter orçamentos
+// (e outros acompanhamentos do usuário) em dia.
+package tracking
+
+import (
+	"context"
+
+	"transaction-service/models"
+	"transaction-service/repository"
+)
+
+// Change descreve uma alteração de transação. Before é nil na criação e
+// After é nil na exclusão.
+type Change struct {
+	UserID    string
+	UserEmail string // vazio quando a alteração não vem de uma requisição autenticada (ver recipient)
+	Before    *models.Transaction
+	After     *models.Transaction
+}
+
+// Observer é notificado depois que uma alteração de transação foi gravada.
+// Falhas são tratadas (logadas) pelo próprio observador e não desfazem a alteração.
+type Observer interface {
+	TransactionChanged(ctx context.Context, change Change)
+}
+
+// Observers repassa cada alteração a todos os observadores, em ordem
+type Observers []Observer
+
+func (o Observers) TransactionChanged(ctx context.Context, change Change) {
+	for _, observer := range o {
+		observer.TransactionChanged(ctx, change)
+	}
+}
+
+// recipient devolve o email para as notificações do usuário. Alterações feitas
+// pelo worker de recorrências ou por importações não trazem o email da requisição,
+// que então é buscado pelo user_id; vazio se não for possível descobri-lo.
+func recipient(ctx context.Context, users *repository.UserRepository, userID, email string) string {
+	if email != "" {
+		return email
+	}
+
+	email, err := users.FindEmail(ctx, userID)
+	if err != nil {
+		return ""
+	}
+	return email
+}